@@ -0,0 +1,88 @@
+// Package apikey generates project API keys and verifies them against stored hashes.
+//
+// Only a salted SHA-256 hash of each key is stored, together with its first
+// PrefixLength characters. The prefix is not secret: it identifies the key in
+// listings and narrows the lookup to a handful of candidates, whose hashes are
+// then compared in constant time. A fast hash is sufficient because generated
+// keys carry 160 bits of randomness; there is nothing to brute-force.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// KeyPrefix starts every generated key, so leaked keys are easy to recognise.
+	KeyPrefix = "jazz_"
+
+	// PrefixLength is how many leading characters of a key are stored in plaintext.
+	// The rule also applies to keys created before hashing (e.g. "jazz_<uuid>").
+	PrefixLength = 12
+
+	secretBytes = 20
+	saltBytes   = 16
+)
+
+// Key is the stored form of an API key.
+type Key struct {
+	Prefix string
+	Salt   []byte
+	Hash   []byte
+}
+
+// Generate creates a new random API key. The plaintext is shown to the user once
+// and must not be stored; persist the returned Key instead.
+func Generate() (string, Key, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", Key{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	plaintext := KeyPrefix + hex.EncodeToString(secret)
+	key, err := New(plaintext)
+	if err != nil {
+		return "", Key{}, err
+	}
+	return plaintext, key, nil
+}
+
+// New hashes plaintext with a fresh random salt.
+func New(plaintext string) (Key, error) {
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return Key{}, fmt.Errorf("failed to generate API key salt: %w", err)
+	}
+
+	return Key{
+		Prefix: Prefix(plaintext),
+		Salt:   salt,
+		Hash:   Hash(plaintext, salt),
+	}, nil
+}
+
+// Prefix returns the lookup prefix of plaintext: its first PrefixLength characters,
+// or all of it if shorter.
+func Prefix(plaintext string) string {
+	if len(plaintext) <= PrefixLength {
+		return plaintext
+	}
+	return plaintext[:PrefixLength]
+}
+
+// Hash returns SHA-256(salt || plaintext). The 006_hash_api_keys migration computes
+// the same value in SQL for keys that were stored in plaintext.
+func Hash(plaintext string, salt []byte) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(plaintext))
+	return h.Sum(nil)
+}
+
+// Verify reports whether plaintext is the key k was created from, in constant time.
+func (k Key) Verify(plaintext string) bool {
+	return subtle.ConstantTimeCompare(Hash(plaintext, k.Salt), k.Hash) == 1
+}
@@ -0,0 +1,55 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	plaintext, key, err := Generate()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(plaintext, KeyPrefix))
+	assert.Len(t, plaintext, len(KeyPrefix)+2*secretBytes)
+	assert.Equal(t, plaintext[:PrefixLength], key.Prefix)
+	assert.Len(t, key.Salt, saltBytes)
+	assert.NotContains(t, string(key.Hash), plaintext)
+
+	assert.True(t, key.Verify(plaintext))
+	assert.False(t, key.Verify(plaintext+"x"))
+	assert.False(t, key.Verify(key.Prefix))
+	assert.False(t, key.Verify(""))
+
+	other, otherKey, err := Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, plaintext, other)
+	assert.False(t, otherKey.Verify(plaintext))
+}
+
+func TestNew_SaltsEachKey(t *testing.T) {
+	a, err := New("test_key_12345")
+	require.NoError(t, err)
+	b, err := New("test_key_12345")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a.Hash, b.Hash, "the same key hashes differently with different salts")
+	assert.True(t, a.Verify("test_key_12345"))
+	assert.True(t, b.Verify("test_key_12345"))
+}
+
+func TestPrefix(t *testing.T) {
+	assert.Equal(t, "jazz_a0eebc9", Prefix("jazz_a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"))
+	assert.Equal(t, "short", Prefix("short"))
+	assert.Equal(t, "", Prefix(""))
+}
+
+func TestHash_MatchesMigration(t *testing.T) {
+	// 006_hash_api_keys computes sha256(salt || convert_to(api_key, 'UTF8'))
+	salt := []byte{1, 2, 3}
+	expected := sha256.Sum256(append([]byte{1, 2, 3}, "jazz_key"...))
+	assert.Equal(t, expected[:], Hash("jazz_key", salt))
+}
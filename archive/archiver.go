@@ -0,0 +1,329 @@
+// Package archive writes expired logs to compressed files before the retention
+// worker deletes them, and restores those files back into the logs table.
+//
+// Each project has a directory holding one gzip NDJSON file per archived batch of a
+// UTC day, named "<day>.<part>.ndjson.gz", plus a manifest.json listing them with row
+// counts, timestamp bounds and SHA-256 checksums. A day normally has a single part;
+// logs ingested late for an already archived day are added as further parts.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"jazz/database"
+	"jazz/models"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// FormatNDJSONGzip is the only archive format: one JSON log entry per line, gzip-compressed.
+	FormatNDJSONGzip = "ndjson.gz"
+
+	manifestName = "manifest.json"
+	dayLayout    = "2006-01-02"
+
+	// restoreBatchSize is how many entries are inserted per RestoreLogs call.
+	restoreBatchSize = 1000
+)
+
+// Manifest lists a project's archive files, oldest first.
+type Manifest struct {
+	ProjectID uuid.UUID   `json:"project_id"`
+	Files     []FileEntry `json:"files"`
+}
+
+// FileEntry describes one archive file. Name is relative to the project's directory.
+type FileEntry struct {
+	Name         string    `json:"name"`
+	Day          string    `json:"day"`
+	Format       string    `json:"format"`
+	Rows         int64     `json:"rows"`
+	Bytes        int64     `json:"bytes"`
+	SHA256       string    `json:"sha256"`
+	MinTimestamp time.Time `json:"min_timestamp"`
+	MaxTimestamp time.Time `json:"max_timestamp"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Archiver copies logs from the database to a Sink.
+// Not safe for concurrent use on the same project - the manifest is rewritten on
+// every archive. The retention worker serializes runs across instances.
+type Archiver struct {
+	db   *database.DB
+	sink Sink
+}
+
+// New creates an Archiver writing to sink.
+func New(db *database.DB, sink Sink) *Archiver {
+	return &Archiver{db: db, sink: sink}
+}
+
+// Archive writes every log in r to a new file for r.Start's UTC day and records it
+// in the project's manifest. r must not span more than one day.
+// Nothing is written when r is empty. Returns the number of rows archived;
+// once it returns without error the rows are safe to delete.
+func (a *Archiver) Archive(ctx context.Context, r database.LogRange) (int64, error) {
+	manifest, err := a.LoadManifest(ctx, r.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+
+	day := r.Start.UTC().Format(dayLayout)
+	entry := FileEntry{
+		Name:   fmt.Sprintf("%s.%d.%s", day, manifest.parts(day)+1, FormatNDJSONGzip),
+		Day:    day,
+		Format: FormatNDJSONGzip,
+	}
+
+	var w *archiveWriter
+	rows, err := a.db.ExportLogRange(ctx, r, func(logEntry models.LogEntry) error {
+		if w == nil {
+			file, err := a.sink.Create(ctx, path.Join(r.ProjectID.String(), entry.Name))
+			if err != nil {
+				return err
+			}
+			w = newArchiveWriter(file)
+			entry.MinTimestamp = logEntry.Timestamp
+		}
+		entry.MaxTimestamp = logEntry.Timestamp
+		return w.write(logEntry)
+	})
+	if err != nil {
+		if w != nil {
+			_ = w.file.Abort()
+		}
+		return 0, fmt.Errorf("failed to archive %s for project %s: %w", day, r.ProjectID, err)
+	}
+	if rows == 0 {
+		return 0, nil
+	}
+
+	if err := w.close(); err != nil {
+		return 0, fmt.Errorf("failed to archive %s for project %s: %w", day, r.ProjectID, err)
+	}
+
+	entry.Rows = rows
+	entry.Bytes = w.bytes
+	entry.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	entry.CreatedAt = time.Now().UTC()
+
+	manifest.Files = append(manifest.Files, entry)
+	if err := a.saveManifest(ctx, manifest); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// LoadManifest reads a project's manifest. A project without archives
+// gets an empty manifest rather than an error.
+func (a *Archiver) LoadManifest(ctx context.Context, projectID uuid.UUID) (*Manifest, error) {
+	manifest := &Manifest{ProjectID: projectID, Files: []FileEntry{}}
+
+	rc, err := a.sink.Open(ctx, path.Join(projectID.String(), manifestName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return manifest, nil
+		}
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	if err := json.NewDecoder(rc).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Restore inserts a project's archived logs with from <= timestamp < to into the
+// logs table under project into (usually projectID itself). Entries already stored
+// are skipped. Each file's checksum is verified before any of it is inserted.
+//
+// Restored logs older than into's retention are purged (and archived again) by the
+// next retention run, so restore into a project without retention for long investigations.
+// Returns the number of rows inserted.
+func (a *Archiver) Restore(ctx context.Context, projectID, into uuid.UUID, from, to time.Time) (int64, error) {
+	manifest, err := a.LoadManifest(ctx, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	var restored int64
+	for _, file := range manifest.Files {
+		if file.MaxTimestamp.Before(from) || !file.MinTimestamp.Before(to) {
+			continue
+		}
+
+		name := path.Join(projectID.String(), file.Name)
+		if err := a.verify(ctx, name, file.SHA256); err != nil {
+			return restored, err
+		}
+
+		n, err := a.restoreFile(ctx, name, into, from, to)
+		restored += n
+		if err != nil {
+			return restored, err
+		}
+	}
+
+	return restored, nil
+}
+
+func (a *Archiver) restoreFile(ctx context.Context, name string, into uuid.UUID, from, to time.Time) (int64, error) {
+	rc, err := a.sink.Open(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	var restored int64
+	batch := make([]models.LogEntry, 0, restoreBatchSize)
+	flush := func() error {
+		n, err := a.db.RestoreLogs(ctx, batch)
+		restored += n
+		batch = batch[:0]
+		return err
+	}
+
+	decoder := json.NewDecoder(gz)
+	for {
+		var entry models.LogEntry
+		if err := decoder.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return restored, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if entry.Timestamp.Before(from) || !entry.Timestamp.Before(to) {
+			continue
+		}
+
+		entry.ProjectID = into
+		batch = append(batch, entry)
+		if len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return restored, err
+	}
+
+	return restored, nil
+}
+
+// verify checks name's SHA-256 against the manifest.
+func (a *Archiver) verify(ctx context.Context, name, checksum string) error {
+	rc, err := a.sink.Open(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != checksum {
+		return fmt.Errorf("checksum mismatch for %s", name)
+	}
+
+	return nil
+}
+
+func (a *Archiver) saveManifest(ctx context.Context, manifest *Manifest) error {
+	file, err := a.sink.Create(ctx, path.Join(manifest.ProjectID.String(), manifestName))
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		_ = file.Abort()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return file.Commit()
+}
+
+// parts returns how many files the manifest already has for day.
+func (m *Manifest) parts(day string) int {
+	n := 0
+	for _, file := range m.Files {
+		if file.Day == day {
+			n++
+		}
+	}
+	return n
+}
+
+// archiveWriter gzips NDJSON into a File while counting and hashing the compressed bytes.
+type archiveWriter struct {
+	file    File
+	buf     *bufio.Writer
+	gz      *gzip.Writer
+	encoder *json.Encoder
+	hash    hash.Hash
+	bytes   int64
+}
+
+func newArchiveWriter(file File) *archiveWriter {
+	w := &archiveWriter{file: file, hash: sha256.New()}
+	w.buf = bufio.NewWriterSize(io.MultiWriter(file, w.hash, byteCounter{&w.bytes}), 32*1024)
+	w.gz = gzip.NewWriter(w.buf)
+	w.encoder = json.NewEncoder(w.gz)
+	return w
+}
+
+func (w *archiveWriter) write(entry models.LogEntry) error {
+	return w.encoder.Encode(entry)
+}
+
+// close finishes the gzip stream and commits the file.
+func (w *archiveWriter) close() error {
+	if err := w.gz.Close(); err != nil {
+		_ = w.file.Abort()
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		_ = w.file.Abort()
+		return err
+	}
+	return w.file.Commit()
+}
+
+type byteCounter struct {
+	n *int64
+}
+
+func (c byteCounter) Write(p []byte) (int, error) {
+	*c.n += int64(len(p))
+	return len(p), nil
+}
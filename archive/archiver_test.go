@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveWriter_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	sink := NewDirSink(t.TempDir())
+
+	file, err := sink.Create(ctx, "p/2024-11-22.1.ndjson.gz")
+	require.NoError(t, err)
+
+	projectID := uuid.New()
+	entries := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: projectID, Level: "info", Message: "first", Timestamp: time.Date(2024, 11, 22, 1, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), ProjectID: projectID, Level: "error", Message: "second", Source: "api", Timestamp: time.Date(2024, 11, 22, 2, 0, 0, 0, time.UTC)},
+	}
+
+	w := newArchiveWriter(file)
+	for _, entry := range entries {
+		require.NoError(t, w.write(entry))
+	}
+	require.NoError(t, w.close())
+
+	rc, err := sink.Open(ctx, "p/2024-11-22.1.ndjson.gz")
+	require.NoError(t, err)
+	defer func() { _ = rc.Close() }()
+	raw, err := io.ReadAll(rc)
+	require.NoError(t, err)
+
+	// Size and checksum cover the compressed file as stored
+	sum := sha256.Sum256(raw)
+	assert.Equal(t, hex.EncodeToString(sum[:]), hex.EncodeToString(w.hash.Sum(nil)))
+	assert.Equal(t, int64(len(raw)), w.bytes)
+
+	rc2, err := sink.Open(ctx, "p/2024-11-22.1.ndjson.gz")
+	require.NoError(t, err)
+	defer func() { _ = rc2.Close() }()
+	gz, err := gzip.NewReader(rc2)
+	require.NoError(t, err)
+
+	decoder := json.NewDecoder(gz)
+	var decoded []models.LogEntry
+	for decoder.More() {
+		var entry models.LogEntry
+		require.NoError(t, decoder.Decode(&entry))
+		decoded = append(decoded, entry)
+	}
+	assert.Equal(t, entries, decoded)
+}
+
+func TestArchiver_LoadManifest(t *testing.T) {
+	ctx := context.Background()
+	archiver := New(nil, NewDirSink(t.TempDir()))
+	projectID := uuid.New()
+
+	// Missing manifest is empty, not an error
+	manifest, err := archiver.LoadManifest(ctx, projectID)
+	require.NoError(t, err)
+	assert.Equal(t, projectID, manifest.ProjectID)
+	assert.Empty(t, manifest.Files)
+
+	manifest.Files = append(manifest.Files,
+		FileEntry{Name: "2024-11-22.1.ndjson.gz", Day: "2024-11-22", Rows: 10},
+		FileEntry{Name: "2024-11-22.2.ndjson.gz", Day: "2024-11-22", Rows: 1},
+		FileEntry{Name: "2024-11-23.1.ndjson.gz", Day: "2024-11-23", Rows: 5},
+	)
+	require.NoError(t, archiver.saveManifest(ctx, manifest))
+
+	loaded, err := archiver.LoadManifest(ctx, projectID)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, loaded)
+	assert.Equal(t, 2, loaded.parts("2024-11-22"))
+	assert.Equal(t, 0, loaded.parts("2024-11-24"))
+}
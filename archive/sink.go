@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sink stores archive files under slash-separated names such as
+// "<project_id>/2024-11-22.1.ndjson.gz". Implement it to archive somewhere
+// other than the local filesystem (e.g. object storage).
+type Sink interface {
+	// Create starts writing name. The file must not become visible to Open
+	// until Commit succeeds, so readers never see a partial archive.
+	Create(ctx context.Context, name string) (File, error)
+
+	// Open reads name. Returns an error wrapping fs.ErrNotExist if it doesn't exist.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// File is an archive file being written to a Sink.
+// Exactly one of Commit or Abort must be called.
+type File interface {
+	io.Writer
+
+	// Commit publishes the file, replacing any previous file with the same name.
+	Commit() error
+
+	// Abort discards everything written.
+	Abort() error
+}
+
+// DirSink stores archives in a local directory.
+// Files are written to a temporary file and renamed into place on Commit.
+type DirSink struct {
+	root string
+}
+
+// NewDirSink creates a DirSink rooted at root. The directory is created on first write.
+func NewDirSink(root string) *DirSink {
+	return &DirSink{root: root}
+}
+
+// Create implements Sink.
+func (s *DirSink) Create(_ context.Context, name string) (File, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file: %w", err)
+	}
+
+	return &dirFile{File: tmp, path: path}, nil
+}
+
+// Open implements Sink.
+func (s *DirSink) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// path maps name into the root, rejecting names that would escape it.
+func (s *DirSink) path(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid archive name %q", name)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+type dirFile struct {
+	*os.File
+	path string
+}
+
+func (f *dirFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		_ = f.Abort()
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to close archive file: %w", err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to publish archive file: %w", err)
+	}
+	return nil
+}
+
+func (f *dirFile) Abort() error {
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
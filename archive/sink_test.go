@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirSink_CommitPublishes(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	sink := NewDirSink(root)
+
+	file, err := sink.Create(ctx, "project/2024-11-22.1.ndjson.gz")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	// Not visible until committed
+	_, err = sink.Open(ctx, "project/2024-11-22.1.ndjson.gz")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, file.Commit())
+
+	rc, err := sink.Open(ctx, "project/2024-11-22.1.ndjson.gz")
+	require.NoError(t, err)
+	defer func() { _ = rc.Close() }()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	entries, err := os.ReadDir(filepath.Join(root, "project"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file should be renamed, not copied")
+}
+
+func TestDirSink_AbortDiscards(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	sink := NewDirSink(root)
+
+	file, err := sink.Create(ctx, "project/manifest.json")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("partial"))
+	require.NoError(t, err)
+	require.NoError(t, file.Abort())
+
+	_, err = sink.Open(ctx, "project/manifest.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	entries, err := os.ReadDir(filepath.Join(root, "project"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDirSink_RejectsEscapingNames(t *testing.T) {
+	sink := NewDirSink(t.TempDir())
+
+	for _, name := range []string{"../outside", "/etc/passwd", "project/../../outside"} {
+		_, err := sink.Create(context.Background(), name)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,116 @@
+// Package main provides a tool for inspecting and restoring Jazz log archives.
+//
+// Usage:
+//
+//	jazz-archive list -dir ./archive -project PROJECT_ID
+//	jazz-archive restore -dir ./archive -project PROJECT_ID [-into PROJECT_ID] [-from 2024-11-01] [-to 2024-11-08]
+//
+// restore loads archived logs with timestamps in [from, to) back into the logs table.
+// Dates are UTC days; -to is exclusive and both default to the whole archive.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"jazz/archive"
+	"jazz/database"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	dir := flags.String("dir", os.Getenv("ARCHIVE_DIR"), "archive directory (default $ARCHIVE_DIR)")
+	projectFlag := flags.String("project", "", "project ID whose archive to read")
+	intoFlag := flags.String("into", "", "project ID to restore into (default -project)")
+	fromFlag := flags.String("from", "", "first UTC day to restore (YYYY-MM-DD)")
+	toFlag := flags.String("to", "", "UTC day to stop before (YYYY-MM-DD)")
+	_ = flags.Parse(os.Args[2:])
+
+	if *dir == "" {
+		log.Fatal("-dir or ARCHIVE_DIR is required")
+	}
+	projectID, err := uuid.Parse(*projectFlag)
+	if err != nil {
+		log.Fatal("-project must be a project ID")
+	}
+
+	ctx := context.Background()
+	sink := archive.NewDirSink(*dir)
+
+	switch os.Args[1] {
+	case "list":
+		list(ctx, archive.New(nil, sink), projectID)
+
+	case "restore":
+		into := projectID
+		if *intoFlag != "" {
+			if into, err = uuid.Parse(*intoFlag); err != nil {
+				log.Fatal("-into must be a project ID")
+			}
+		}
+		from := parseDay(*fromFlag, time.Time{})
+		to := parseDay(*toFlag, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+
+		databaseURL := os.Getenv("DATABASE_URL")
+		if databaseURL == "" {
+			log.Fatal("DATABASE_URL not set")
+		}
+		db, err := database.Connect(ctx, databaseURL)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer db.Close()
+
+		restored, err := archive.New(db, sink).Restore(ctx, projectID, into, from, to)
+		if err != nil {
+			log.Fatalf("Restore failed after %d rows: %v", restored, err)
+		}
+		fmt.Printf("Restored %d logs into project %s\n", restored, into)
+
+	default:
+		usage()
+	}
+}
+
+func list(ctx context.Context, archiver *archive.Archiver, projectID uuid.UUID) {
+	manifest, err := archiver.LoadManifest(ctx, projectID)
+	if err != nil {
+		log.Fatal("Failed to read manifest:", err)
+	}
+
+	var rows int64
+	for _, file := range manifest.Files {
+		fmt.Printf("%s  %8d rows  %10d bytes  %s - %s\n", file.Name, file.Rows, file.Bytes,
+			file.MinTimestamp.Format(time.RFC3339), file.MaxTimestamp.Format(time.RFC3339))
+		rows += file.Rows
+	}
+	fmt.Printf("\n%d files, %d rows\n", len(manifest.Files), rows)
+}
+
+func parseDay(s string, fallback time.Time) time.Time {
+	if s == "" {
+		return fallback
+	}
+	day, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		log.Fatalf("Invalid day %q (expected YYYY-MM-DD)", s)
+	}
+	return day
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jazz-archive list|restore -dir DIR -project ID [-into ID] [-from YYYY-MM-DD] [-to YYYY-MM-DD]")
+	os.Exit(2)
+}
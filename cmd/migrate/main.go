@@ -1,63 +1,150 @@
 // Package main provides a database migration tool for Jazz.
-// It reads SQL migration files from database/migrations/ and applies them in order.
+// It applies and rolls back the versioned SQL migrations embedded from
+// database/migrations/, tracking applied versions in the schema_migrations table.
+// -dir reads migrations from a directory instead, e.g. while writing a new one.
+//
+// Usage:
+//
+//	jazz-migrate [-dir path] up              apply every pending migration (default)
+//	jazz-migrate [-dir path] down [n]        roll back the last n migrations (default 1)
+//	jazz-migrate [-dir path] to <version>    migrate up or down to version (0 rolls back everything)
+//	jazz-migrate [-dir path] status          list migrations and when they were applied
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"jazz/database"
 	"log"
 	"os"
-	"path/filepath"
-	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
 )
 
 func main() {
 	_ = godotenv.Load()
 
+	dir := flag.String("dir", "", "read NNN_name.sql migrations from this directory instead of the embedded ones")
+	flag.Usage = usage
+	flag.Parse()
+
 	databaseURL := os.Getenv("DATABASE_URL")
 	if databaseURL == "" {
 		log.Fatal("DATABASE_URL not set")
 	}
 
-	conn, err := pgx.Connect(context.Background(), databaseURL)
+	migrationsFS := database.MigrationsFS()
+	if *dir != "" {
+		migrationsFS = os.DirFS(*dir)
+	}
+	migrations, err := database.LoadMigrations(migrationsFS)
 	if err != nil {
-		log.Fatal("Failed to connect:", err)
+		log.Fatal(err)
 	}
 
-	defer func() { _ = conn.Close(context.Background()) }()
+	ctx := context.Background()
+
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	migrationsDir := "./database/migrations"
-	files, err := os.ReadDir(migrationsDir)
+	db, err := database.Connect(connectCtx, databaseURL)
 	if err != nil {
-		log.Fatal("Failed to read migrations:", err)
+		log.Fatal("Failed to connect:", err)
 	}
+	defer db.Close()
 
-	var sqlFiles []string
-	for _, file := range files {
-		if filepath.Ext(file.Name()) == ".sql" {
-			sqlFiles = append(sqlFiles, file.Name())
-		}
+	migrator := database.NewMigrator(db, migrations)
+
+	command := flag.Arg(0)
+	if command == "" {
+		command = "up"
 	}
-	sort.Strings(sqlFiles)
 
-	for _, file := range sqlFiles {
-		log.Printf("Running migration: %s", file)
+	switch command {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", count)
 
-		content, err := os.ReadFile(filepath.Join(migrationsDir, file))
+	case "down":
+		steps := 1
+		if arg := flag.Arg(1); arg != "" {
+			if steps, err = strconv.Atoi(arg); err != nil || steps <= 0 {
+				log.Fatalf("Invalid step count %q", arg)
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
 		if err != nil {
-			log.Fatal("Failed to read file:", err)
+			log.Fatal(err)
 		}
+		fmt.Printf("Rolled back %d migration(s)\n", count)
 
-		_, err = conn.Exec(context.Background(), string(content))
+	case "to":
+		version, err := strconv.Atoi(flag.Arg(1))
 		if err != nil {
-			log.Fatalf("Failed to execute %s: %v", file, err)
+			log.Fatalf("Invalid version %q", flag.Arg(1))
 		}
+		count, err := migrator.To(ctx, version)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Migrated to version %d (%d change(s))\n", version, count)
 
-		log.Printf("✓ %s", file)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printStatus(statuses)
+
+	default:
+		usage()
+		os.Exit(2)
 	}
+}
+
+func printStatus(statuses []database.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED\tNOTE")
+
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+
+		var note string
+		switch {
+		case status.Modified:
+			note = "modified since applied"
+		case status.Up == "":
+			note = "no migration file"
+		case status.Down == "":
+			note = "irreversible"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status.Migration, applied, note)
+	}
+
+	_ = w.Flush()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s [-dir path] <command>
+
+Commands:
+  up              apply every pending migration (default)
+  down [n]        roll back the last n migrations (default 1)
+  to <version>    migrate up or down to version (0 rolls back everything)
+  status          list migrations and when they were applied
 
-	fmt.Println("\nAll migrations completed!")
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
 }
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/apikey"
+	"jazz/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrAdminUserNotFound is returned when an admin user ID doesn't exist.
+	// Safe to expose to clients.
+	ErrAdminUserNotFound = errors.New("admin user not found")
+
+	// ErrAdminUserExists is returned when creating an admin user with a name that is taken.
+	// Safe to expose to clients.
+	ErrAdminUserExists = errors.New("admin user already exists")
+
+	// ErrInvalidAdminToken is returned when a request's admin token is unknown.
+	// Safe to expose to clients.
+	ErrInvalidAdminToken = errors.New("invalid admin token")
+)
+
+// adminUserColumns are the columns scanAdminUser reads, in order.
+const adminUserColumns = "id, name, token_prefix, created_at, last_used_at"
+
+// CreateAdminUser creates an admin user with a generated token (see package apikey).
+// Only the token's prefix and salted hash are stored: the returned user's Token is
+// the only time the plaintext is available.
+// Returns ErrAdminUserExists if the name is taken.
+func (db *DB) CreateAdminUser(ctx context.Context, name string) (*models.AdminUser, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO admin_users (name, token_prefix, token_salt, token_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + adminUserColumns
+
+	user, err := scanAdminUser(db.Pool.QueryRow(ctx, query, name, secret.Prefix, secret.Salt, secret.Hash))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAdminUserExists
+		}
+		return nil, fmt.Errorf("failed to create admin user: %w", err)
+	}
+	user.Token = plaintext
+
+	log.Printf("Created admin user: %s (ID: %s)", user.Name, user.ID)
+	return user, nil
+}
+
+// ListAdminUsers returns every admin user, oldest first.
+func (db *DB) ListAdminUsers(ctx context.Context) ([]models.AdminUser, error) {
+	query := `
+		SELECT ` + adminUserColumns + `
+		FROM admin_users
+		ORDER BY created_at, id
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.AdminUser{}
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating admin users: %w", err)
+	}
+
+	return users, nil
+}
+
+// DeleteAdminUser removes an admin user; their token stops working immediately.
+// Returns ErrAdminUserNotFound if ID doesn't exist.
+func (db *DB) DeleteAdminUser(ctx context.Context, userID uuid.UUID) error {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM admin_users WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete admin user: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAdminUserNotFound
+	}
+
+	log.Printf("Deleted admin user: %s", userID)
+	return nil
+}
+
+// AuthenticateAdmin validates an admin token and returns its user.
+// Like AuthenticateAPIKey, candidates are found by prefix and verified in constant time,
+// and last_used_at is written at most once per APIKeyLastUsedInterval.
+// Returns ErrInvalidAdminToken if no user matches (safe to expose to client).
+func (db *DB) AuthenticateAdmin(ctx context.Context, token string) (*models.AdminUser, error) {
+	query := `
+		SELECT ` + adminUserColumns + `, token_salt, token_hash
+		FROM admin_users
+		WHERE token_prefix = $1
+	`
+
+	rows, err := db.Pool.Query(ctx, query, apikey.Prefix(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+	defer rows.Close()
+
+	var found *models.AdminUser
+	for rows.Next() {
+		var secret apikey.Key
+		user, err := scanAdminUser(rows, &secret.Salt, &secret.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin user: %w", err)
+		}
+		if secret.Verify(token) {
+			found = user
+			break
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+	if found == nil {
+		return nil, ErrInvalidAdminToken
+	}
+
+	var lastUsed time.Time
+	err = db.Pool.QueryRow(ctx, `
+		UPDATE admin_users SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(secs => $2))
+		RETURNING last_used_at
+	`, found.ID, APIKeyLastUsedInterval.Seconds()).Scan(&lastUsed)
+	switch {
+	case err == nil:
+		lastUsed = lastUsed.UTC()
+		found.LastUsedAt = &lastUsed
+	case !errors.Is(err, pgx.ErrNoRows):
+		log.Printf("Failed to record use of admin user %s: %v", found.ID, err)
+	}
+
+	return found, nil
+}
+
+// scanAdminUser scans adminUserColumns followed by any extra columns into extra.
+func scanAdminUser(row rowScanner, extra ...interface{}) (*models.AdminUser, error) {
+	var user models.AdminUser
+	dest := append([]interface{}{
+		&user.ID,
+		&user.Name,
+		&user.TokenPrefix,
+		&user.CreatedAt,
+		&user.LastUsedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	user.CreatedAt = user.CreatedAt.UTC()
+	if user.LastUsedAt != nil {
+		*user.LastUsedAt = user.LastUsedAt.UTC()
+	}
+	return &user, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/apikey"
+	"jazz/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultAPIKeyName names the key every project is created with.
+const DefaultAPIKeyName = "default"
+
+// APIKeyLastUsedInterval is how stale a key's last_used_at may get before
+// authenticating with it writes a new one, so busy keys don't cost a write per request.
+const APIKeyLastUsedInterval = time.Minute
+
+var (
+	// ErrAPIKeyNotFound is returned when a key ID doesn't exist in the project.
+	// Safe to expose to clients.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	// ErrAPIKeyInactive is returned when rotating a key that is revoked or expired.
+	// Safe to expose to clients.
+	ErrAPIKeyInactive = errors.New("API key is revoked or expired")
+
+	// ErrInvalidAPIKey is returned when a request's key is unknown, revoked or expired.
+	// Safe to expose to clients.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+)
+
+// apiKeyColumns are the columns scanAPIKey reads, in order.
+const apiKeyColumns = "id, project_id, name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at"
+
+// AuthenticateAPIKey validates an API key and returns it, with the project it belongs to.
+// Used by authentication middleware to verify requests.
+// Candidates are found by the key's public prefix and verified against their salted
+// hash in constant time; the plaintext key is never stored.
+// Returns ErrInvalidAPIKey if no active key matches (safe to expose to client).
+// Returns error with technical details if database fails (log server-side only).
+func (db *DB) AuthenticateAPIKey(ctx context.Context, apiKey string) (*models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `, salt, hash
+		FROM api_keys
+		WHERE prefix = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND project_id IN (SELECT id FROM projects WHERE deleted_at IS NULL)
+	`
+
+	rows, err := db.Pool.Query(ctx, query, apikey.Prefix(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	defer rows.Close()
+
+	// Prefixes are short, so a few keys may share one
+	var found *models.APIKey
+	for rows.Next() {
+		var secret apikey.Key
+		key, err := scanAPIKey(rows, &secret.Salt, &secret.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		if secret.Verify(apiKey) {
+			found = key
+			break
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if found == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Bookkeeping only: a failure here shouldn't reject the request
+	var lastUsed time.Time
+	err = db.Pool.QueryRow(ctx, `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(secs => $2))
+		RETURNING last_used_at
+	`, found.ID, APIKeyLastUsedInterval.Seconds()).Scan(&lastUsed)
+	switch {
+	case err == nil:
+		lastUsed = lastUsed.UTC()
+		found.LastUsedAt = &lastUsed
+	case !errors.Is(err, pgx.ErrNoRows):
+		log.Printf("Failed to record use of API key %s: %v", found.ID, err)
+	}
+
+	return found, nil
+}
+
+// CreateAPIKey issues a new API key for a project (see package apikey).
+// Only the key's prefix and salted hash are stored: the returned key's Key is
+// the only time the plaintext is available. Without scopes the key gets
+// models.DefaultScopes; without ExpiresAt it never expires.
+// Returns ErrProjectNotFound if the project doesn't exist.
+func (db *DB) CreateAPIKey(ctx context.Context, projectID uuid.UUID, req models.CreateAPIKeyRequest) (*models.APIKey, error) {
+	key, err := insertAPIKey(ctx, db.Pool, projectID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	log.Printf("Created API key %q (ID: %s) for project %s", key.Name, key.ID, projectID)
+	return key, nil
+}
+
+// ListAPIKeys returns all of a project's keys, including revoked and expired ones,
+// newest first. Returns ErrProjectNotFound if the project doesn't exist.
+func (db *DB) ListAPIKeys(ctx context.Context, projectID uuid.UUID) ([]models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE project_id = $1
+		ORDER BY created_at DESC, id
+	`
+
+	rows, err := db.Pool.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	if len(keys) == 0 {
+		if _, err := db.GetProject(ctx, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key from authenticating immediately.
+// Revoking a revoked key keeps its original revoked_at.
+// Returns ErrProjectNotFound or ErrAPIKeyNotFound if either doesn't exist.
+func (db *DB) RevokeAPIKey(ctx context.Context, projectID, keyID uuid.UUID) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND project_id = $2
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(db.Pool.QueryRow(ctx, query, keyID, projectID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, db.apiKeyNotFound(ctx, projectID)
+		}
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	log.Printf("Revoked API key %s of project %s", keyID, projectID)
+	return key, nil
+}
+
+// RotateAPIKey issues a replacement for an active key, with the same name and scopes,
+// and makes the old key expire after gracePeriod (or sooner, if it already expires
+// before then), so clients can switch over without downtime.
+// Returns the new key, with Key set, and the old one.
+// Returns ErrProjectNotFound or ErrAPIKeyNotFound if either doesn't exist, and
+// ErrAPIKeyInactive if the old key is already revoked or expired.
+func (db *DB) RotateAPIKey(ctx context.Context, projectID, keyID uuid.UUID, gracePeriod time.Duration) (*models.APIKey, *models.APIKey, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Locks the key so concurrent rotations can't both replace it
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE id = $1 AND project_id = $2
+		FOR UPDATE
+	`
+	old, err := scanAPIKey(tx.QueryRow(ctx, query, keyID, projectID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, db.apiKeyNotFound(ctx, projectID)
+		}
+		return nil, nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if !old.Active(time.Now()) {
+		return nil, nil, ErrAPIKeyInactive
+	}
+
+	// LEAST ignores NULL, so keys without an expiry get one
+	query = `
+		UPDATE api_keys
+		SET expires_at = LEAST(expires_at, NOW() + make_interval(secs => $2))
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns
+	old, err = scanAPIKey(tx.QueryRow(ctx, query, keyID, gracePeriod.Seconds()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to expire API key: %w", err)
+	}
+
+	key, err := insertAPIKey(ctx, tx, projectID, old.Name, old.Scopes, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	log.Printf("Rotated API key %s of project %s (replacement: %s, old key expires %s)",
+		old.ID, projectID, key.ID, old.ExpiresAt.Format(time.RFC3339))
+	return key, old, nil
+}
+
+// apiKeyNotFound tells a missing project from a missing key after a lookup by both.
+func (db *DB) apiKeyNotFound(ctx context.Context, projectID uuid.UUID) error {
+	if _, err := db.GetProject(ctx, projectID); err != nil {
+		return err
+	}
+	return ErrAPIKeyNotFound
+}
+
+// queryRower is satisfied by both the pool and transactions.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertAPIKey generates a key and stores its prefix and salted hash.
+// Empty scopes means models.DefaultScopes. The returned key has Key set to the plaintext.
+func insertAPIKey(ctx context.Context, q queryRower, projectID uuid.UUID, name string, scopes []models.Scope, expiresAt *time.Time) (*models.APIKey, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+
+	query := `
+		INSERT INTO api_keys (project_id, name, prefix, scopes, salt, hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + apiKeyColumns
+
+	row := q.QueryRow(ctx, query, projectID, name, secret.Prefix, scopeStrings(models.NormalizeScopes(scopes)),
+		secret.Salt, secret.Hash, expiresAt)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	key.Key = plaintext
+
+	return key, nil
+}
+
+// scanAPIKey scans apiKeyColumns followed by any extra columns into extra.
+func scanAPIKey(row rowScanner, extra ...interface{}) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes []string
+	dest := append([]interface{}{
+		&key.ID,
+		&key.ProjectID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	key.Scopes = make([]models.Scope, len(scopes))
+	for i, scope := range scopes {
+		key.Scopes[i] = models.Scope(scope)
+	}
+	key.CreatedAt = key.CreatedAt.UTC()
+	for _, t := range []*time.Time{key.LastUsedAt, key.ExpiresAt, key.RevokedAt} {
+		if t != nil {
+			*t = t.UTC()
+		}
+	}
+	return &key, nil
+}
+
+// scopeStrings converts scopes for the TEXT[] column.
+func scopeStrings(scopes []models.Scope) []string {
+	strs := make([]string, len(scopes))
+	for i, scope := range scopes {
+		strs[i] = string(scope)
+	}
+	return strs
+}
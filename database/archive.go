@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// LogRange selects a project's logs with Start <= timestamp < End.
+// Rows stored at or after CreatedBefore are excluded, so an archive and the purge
+// that follows it see the same rows even while ingestion continues.
+// Rows without created_at always match.
+type LogRange struct {
+	ProjectID     uuid.UUID
+	Start         time.Time
+	End           time.Time
+	CreatedBefore time.Time
+}
+
+func (r LogRange) whereClause() (string, []interface{}) {
+	where := fmt.Sprintf(`
+		WHERE %s = $1 AND %s >= $2 AND %s < $3
+		AND (created_at < $4 OR created_at IS NULL)
+	`, columnProjectID, columnTimestamp, columnTimestamp)
+	return where, []interface{}{r.ProjectID, r.Start, r.End, r.CreatedBefore}
+}
+
+// ExportLogRange streams every log in r to fn, oldest first, through a server-side cursor.
+// Returns the number of rows passed to fn.
+func (db *DB) ExportLogRange(ctx context.Context, r LogRange, fn func(models.LogEntry) error) (int64, error) {
+	where, args := r.whereClause()
+	orderBy := OrderByClause([]SortField{{Column: columnTimestamp}})
+	return db.streamLogEntries(ctx, where, args, orderBy, fn)
+}
+
+// PurgeLogRange deletes up to batchSize logs in r.
+// Call repeatedly until it returns fewer than batchSize rows.
+// Returns the number of rows deleted.
+func (db *DB) PurgeLogRange(ctx context.Context, r LogRange, batchSize int) (int64, error) {
+	where, args := r.whereClause()
+	query := fmt.Sprintf(`
+		DELETE FROM logs
+		WHERE (%s, %s) IN (
+			SELECT %s, %s FROM logs
+			%s
+			LIMIT $5
+		)
+	`, columnID, columnTimestamp, columnID, columnTimestamp, where)
+
+	result, err := db.Pool.Exec(ctx, query, append(args, batchSize)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge logs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// NextLogTime returns the earliest timestamp of a project's logs with
+// from <= timestamp < before, or nil if there are none.
+func (db *DB) NextLogTime(ctx context.Context, projectID uuid.UUID, from, before time.Time) (*time.Time, error) {
+	query := fmt.Sprintf(`
+		SELECT MIN(%s) FROM logs
+		WHERE %s = $1 AND %s >= $2 AND %s < $3
+	`, columnTimestamp, columnProjectID, columnTimestamp, columnTimestamp)
+
+	var next *time.Time
+	if err := db.Pool.QueryRow(ctx, query, projectID, from, before).Scan(&next); err != nil {
+		return nil, fmt.Errorf("failed to find next log: %w", err)
+	}
+	if next != nil {
+		t := next.UTC()
+		next = &t
+	}
+
+	return next, nil
+}
+
+// RestoreLogs inserts previously archived logs in a single batch.
+// Entries that are already stored (same ID and timestamp) are skipped, so
+// restoring the same archive twice is harmless.
+// Returns the number of rows inserted, or ErrProjectNotFound if the target project doesn't exist.
+func (db *DB) RestoreLogs(ctx context.Context, logs []models.LogEntry) (int64, error) {
+	if len(logs) == 0 {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO logs (%s, %s, %s, %s, %s, %s)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (%s, %s) DO NOTHING
+	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
+		columnID, columnTimestamp)
+
+	batch := &pgx.Batch{}
+	for _, entry := range logs {
+		batch.Queue(query, entry.ID, entry.ProjectID, entry.Level,
+			entry.Message, entry.Source, entry.Timestamp.UTC())
+	}
+
+	results := db.Pool.SendBatch(ctx, batch)
+	defer func() {
+		_ = results.Close()
+	}()
+
+	var inserted int64
+	for i := range logs {
+		tag, err := results.Exec()
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return inserted, ErrProjectNotFound
+			}
+			return inserted, &BatchInsertError{FailedIndex: i, TotalLogs: len(logs), Err: err}
+		}
+		inserted += tag.RowsAffected()
+	}
+
+	return inserted, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
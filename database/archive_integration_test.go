@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogRange_ExportAndPurge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	day := time.Date(2024, 11, 22, 0, 0, 0, 0, time.UTC)
+	inDay := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Late", Timestamp: day.Add(20 * time.Hour)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Early", Timestamp: day.Add(time.Hour)},
+	}
+	nextDay := models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Next day", Timestamp: day.AddDate(0, 0, 1)}
+	require.NoError(t, db.InsertLogsBatch(ctx, append(inDay, nextDay)))
+
+	next, err := db.NextLogTime(ctx, project.ID, time.Time{}, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.True(t, next.Equal(day.Add(time.Hour)))
+
+	r := LogRange{
+		ProjectID:     project.ID,
+		Start:         day,
+		End:           day.AddDate(0, 0, 1),
+		CreatedBefore: time.Now().Add(time.Minute),
+	}
+
+	var exported []string
+	count, err := db.ExportLogRange(ctx, r, func(entry models.LogEntry) error {
+		exported = append(exported, entry.Message)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, []string{"Early", "Late"}, exported, "oldest first")
+
+	// Rows stored after CreatedBefore are left alone
+	stale := r
+	stale.CreatedBefore = time.Now().Add(-time.Hour)
+	deleted, err := db.PurgeLogRange(ctx, stale, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = db.PurgeLogRange(ctx, r, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	deleted, err = db.PurgeLogRange(ctx, r, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	next, err = db.NextLogTime(ctx, project.ID, time.Time{}, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.True(t, next.Equal(nextDay.Timestamp), "the following day is untouched")
+
+	next, err = db.NextLogTime(ctx, project.ID, time.Time{}, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestRestoreLogs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	entries := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "One", Timestamp: time.Now().Add(-time.Hour)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Two", Source: "api", Timestamp: time.Now()},
+	}
+
+	restored, err := db.RestoreLogs(ctx, entries)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), restored)
+
+	// Restoring the same archive again is a no-op
+	restored, err = db.RestoreLogs(ctx, entries)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), restored)
+
+	got, err := db.GetLog(ctx, project.ID, entries[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "api", got.Source)
+
+	entries[0].ID = uuid.New()
+	entries[0].ProjectID = uuid.New()
+	_, err = db.RestoreLogs(ctx, entries[:1])
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// auditColumns are the columns scanAuditEvent reads, in order.
+const auditColumns = `id, occurred_at, actor_type, actor_id, actor_name, action, target_type,
+	target_id, project_id, request_id, source_ip, before, after`
+
+// AuditFilter is the validated, backend-neutral form of AuditQueryParams.
+// Nil and empty fields match every event; Start and End are inclusive bounds in UTC.
+type AuditFilter struct {
+	ProjectID *uuid.UUID
+	ActorID   *uuid.UUID
+	Action    string
+	Start     *time.Time
+	End       *time.Time
+}
+
+// ResolveAuditQueryParams validates params as ListAuditEvents does, resolving
+// relative times against now. Returns *QueryError for invalid input.
+func ResolveAuditQueryParams(params models.AuditQueryParams, now time.Time) (AuditFilter, error) {
+	filter := AuditFilter{Action: params.Action}
+
+	if params.ProjectID != "" {
+		projectID, err := uuid.Parse(params.ProjectID)
+		if err != nil {
+			return AuditFilter{}, &QueryError{Err: fmt.Errorf("invalid project_id: %w", err)}
+		}
+		filter.ProjectID = &projectID
+	}
+	if params.ActorID != "" {
+		actorID, err := uuid.Parse(params.ActorID)
+		if err != nil {
+			return AuditFilter{}, &QueryError{Err: fmt.Errorf("invalid actor_id: %w", err)}
+		}
+		filter.ActorID = &actorID
+	}
+
+	parser := NewTimeParser(now, time.UTC)
+	if params.StartTime != "" {
+		start, err := parser.Parse(params.StartTime)
+		if err != nil {
+			return AuditFilter{}, &QueryError{Err: fmt.Errorf("invalid start_time: %w", err)}
+		}
+		filter.Start = &start
+	}
+	if params.EndTime != "" {
+		end, err := parser.Parse(params.EndTime)
+		if err != nil {
+			return AuditFilter{}, &QueryError{Err: fmt.Errorf("invalid end_time: %w", err)}
+		}
+		filter.End = &end
+	}
+	if filter.Start != nil && filter.End != nil && filter.Start.After(*filter.End) {
+		return AuditFilter{}, &QueryError{Err: fmt.Errorf("invalid time range: start_time is after end_time")}
+	}
+
+	return filter, nil
+}
+
+// Match reports whether event passes the filter.
+func (f AuditFilter) Match(event models.AuditEvent) bool {
+	switch {
+	case f.ProjectID != nil && (event.ProjectID == nil || *event.ProjectID != *f.ProjectID):
+		return false
+	case f.ActorID != nil && (event.ActorID == nil || *event.ActorID != *f.ActorID):
+		return false
+	case f.Action != "" && event.Action != f.Action:
+		return false
+	case f.Start != nil && event.OccurredAt.Before(*f.Start):
+		return false
+	case f.End != nil && event.OccurredAt.After(*f.End):
+		return false
+	}
+	return true
+}
+
+// RecordAuditEvent appends an event to the audit trail, setting its ID and OccurredAt.
+// The table rejects updates and deletes (migration 014), so recorded events are final.
+func (db *DB) RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (
+			actor_type, actor_id, actor_name, action, target_type, target_id,
+			project_id, request_id, source_ip, before, after
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, occurred_at
+	`
+
+	err := db.Pool.QueryRow(ctx, query,
+		event.ActorType, event.ActorID, event.ActorName, event.Action, event.TargetType, event.TargetID,
+		event.ProjectID, event.RequestID, event.SourceIP, jsonOrNil(event.Before), jsonOrNil(event.After),
+	).Scan(&event.ID, &event.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	event.OccurredAt = event.OccurredAt.UTC()
+	return nil
+}
+
+// ListAuditEvents returns a page of audit events, newest first, and the number of
+// events matching params.
+//
+// Filters applied:
+//   - ProjectID, ActorID, Action: exact match
+//   - StartTime/EndTime: inclusive range over occurred_at (RFC3339, epoch, or "now-24h" style)
+//   - Limit: max results (default 50, max 1000)
+//   - Offset: pagination offset (default 0)
+//
+// Returns *QueryError for invalid filters and an empty slice (not nil) if nothing matches.
+func (db *DB) ListAuditEvents(ctx context.Context, params models.AuditQueryParams) ([]models.AuditEvent, int, error) {
+	filter, err := ResolveAuditQueryParams(params, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	limit, offset := Page(params.Limit, params.Offset)
+
+	qb := NewQueryBuilder()
+	if filter.ProjectID != nil {
+		qb.AddCondition("project_id", *filter.ProjectID)
+	}
+	if filter.ActorID != nil {
+		qb.AddCondition("actor_id", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		qb.AddCondition("action", filter.Action)
+	}
+	qb.AddBounds("occurred_at", filter.Start, filter.End)
+
+	query := fmt.Sprintf(`
+		SELECT %s, COUNT(*) OVER() AS total_count
+		FROM audit_events
+		%s
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, auditColumns, qb.WhereClause(), qb.NextArgNum(), qb.NextArgNum()+1)
+
+	rows, err := db.Pool.Query(ctx, query, append(qb.Args(), limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	var total int
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after []byte
+		err := rows.Scan(
+			&event.ID, &event.OccurredAt, &event.ActorType, &event.ActorID, &event.ActorName,
+			&event.Action, &event.TargetType, &event.TargetID, &event.ProjectID,
+			&event.RequestID, &event.SourceIP, &before, &after, &total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		event.OccurredAt = event.OccurredAt.UTC()
+		event.Before, event.After = before, after
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// jsonOrNil returns raw as a JSONB parameter, or nil (SQL NULL) if it is empty.
+func jsonOrNil(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
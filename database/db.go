@@ -6,6 +6,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"jazz/storage"
 	"log"
 	"time"
 
@@ -19,6 +20,15 @@ type DB struct {
 	Pool *pgxpool.Pool
 }
 
+// DB implements every storage interface, including the optional ones.
+var (
+	_ storage.Store          = (*DB)(nil)
+	_ storage.ContextStore   = (*DB)(nil)
+	_ storage.FacetStore     = (*DB)(nil)
+	_ storage.ExportStore    = (*DB)(nil)
+	_ storage.RetentionStore = (*DB)(nil)
+)
+
 // Connect establishes a connection pool to PostgreSQL with production-ready settings.
 // Connection pool is configured with:
 //   - MaxConns: 25 (prevent overwhelming database)
@@ -59,3 +69,30 @@ func (db *DB) Close() {
 	db.Pool.Close()
 	log.Println("Database connection closed")
 }
+
+// TryAdvisoryLock runs fn while holding the session-level advisory lock key.
+// Returns false without calling fn if another session (e.g. another API instance)
+// holds the lock, so background jobs run on one instance at a time.
+// The lock is held on a dedicated pool connection; fn may use the pool freely.
+func (db *DB) TryAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	defer func() {
+		// Unlock even if ctx was cancelled, so the pooled connection doesn't keep the lock
+		_, _ = conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, key)
+	}()
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"jazz/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// exportFetchSize is how many rows each FETCH pulls from the export cursor.
+// Bounds memory use regardless of how many rows match.
+const exportFetchSize = 1000
+
+// ExportLogs streams every log matching params to fn, ordered like QueryLogs (params.Sort).
+// Unlike QueryLogs there is no limit: Limit and Offset are ignored.
+// If params.Search is provided, only full-text search matches are exported.
+//
+// Rows are read through a server-side cursor in a read-only transaction,
+// fetching 1000 rows at a time, so the result set is never held in memory.
+// Stops and returns fn's error if fn fails (e.g. the client disconnected).
+// Returns the number of rows passed to fn.
+func (db *DB) ExportLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams, fn func(models.LogEntry) error) (int64, error) {
+	start := time.Now()
+	var exported int64
+	defer func() {
+		log.Printf("ExportLogs: duration=%v project=%s rows=%d filters=[level=%s source=%s search=%s]",
+			time.Since(start), projectID, exported, params.Level, params.Source, params.Search)
+	}()
+
+	// Exports don't compute ts_rank, so search exports sort like plain queries
+	sortFields, err := parseSort(params.Sort, logSortColumns, defaultLogSort)
+	if err != nil {
+		return 0, err
+	}
+
+	filter, err := db.withSearchLanguage(ctx, projectID, filterFromQueryParams(params))
+	if err != nil {
+		return 0, err
+	}
+
+	qb := NewQueryBuilder()
+	if err := filter.apply(qb, projectID); err != nil {
+		return 0, err
+	}
+
+	exported, err = db.streamLogEntries(ctx, qb.WhereClause(), qb.Args(), OrderByClause(sortFields), fn)
+	return exported, err
+}
+
+// streamLogEntries passes every log matching whereClause to fn in orderBy order.
+// Rows are read through a server-side cursor in a read-only transaction,
+// exportFetchSize at a time. Returns the number of rows passed to fn.
+func (db *DB) streamLogEntries(ctx context.Context, whereClause string, args []interface{}, orderBy string, fn func(models.LogEntry) error) (int64, error) {
+	var exported int64
+
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin export transaction: %w", err)
+	}
+	defer func() {
+		// Read-only, so rollback is also the normal way to end it
+		_ = tx.Rollback(context.Background())
+	}()
+
+	// SAFETY: All user input is parameterized. whereClause only contains safe SQL.
+	declare := fmt.Sprintf(`
+		DECLARE export_cursor NO SCROLL CURSOR FOR
+		SELECT %s, %s, %s, %s, %s, %s
+		FROM logs
+		%s
+		%s
+	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
+		whereClause, orderBy)
+
+	if _, err := tx.Exec(ctx, declare, args...); err != nil {
+		return 0, fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return exported, fmt.Errorf("failed to fetch logs: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			entry, err := scanLogEntry(rows)
+			if err != nil {
+				rows.Close()
+				return exported, fmt.Errorf("failed to scan log: %w", err)
+			}
+			fetched++
+			if err := fn(*entry); err != nil {
+				rows.Close()
+				return exported, err
+			}
+			exported++
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return exported, fmt.Errorf("error iterating logs: %w", err)
+		}
+		if fetched < exportFetchSize {
+			return exported, nil
+		}
+	}
+}
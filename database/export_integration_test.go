@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportLogs_StreamsAllRows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	// More than one cursor fetch and more than maxLimit
+	total := exportFetchSize*2 + 500
+	base := time.Now().Add(-time.Hour)
+	logs := make([]models.LogEntry, total)
+	for i := range logs {
+		level := "info"
+		if i%5 == 0 {
+			level = "error"
+		}
+		logs[i] = models.LogEntry{
+			ID:        uuid.New(),
+			ProjectID: project.ID,
+			Level:     level,
+			Message:   "Export me",
+			Timestamp: base.Add(time.Duration(i) * time.Millisecond),
+		}
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	var exported []models.LogEntry
+	count, err := db.ExportLogs(ctx, project.ID, models.QueryParams{Limit: 10}, func(entry models.LogEntry) error {
+		exported = append(exported, entry)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(total), count)
+	require.Len(t, exported, total)
+
+	for i := 1; i < len(exported); i++ {
+		assert.False(t, exported[i].Timestamp.After(exported[i-1].Timestamp), "export must be newest first")
+	}
+
+	count, err = db.ExportLogs(ctx, project.ID, models.QueryParams{Level: "error"}, func(models.LogEntry) error {
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(total/5), count)
+}
+
+func TestExportLogs_StopsOnCallbackError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Now()
+	logs := make([]models.LogEntry, 10)
+	for i := range logs {
+		logs[i] = models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Log", Timestamp: now}
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	errStop := errors.New("client went away")
+	count, err := db.ExportLogs(ctx, project.ID, models.QueryParams{}, func(models.LogEntry) error {
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestExportLogs_InvalidFilter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	ctx := context.Background()
+
+	_, err := db.ExportLogs(ctx, uuid.New(), models.QueryParams{StartTime: "not-a-date"}, func(models.LogEntry) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
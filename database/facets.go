@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"jazz/models"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultFacetSize = 10
+	maxFacetSize     = 100
+
+	facetLevel  = "level"
+	facetSource = "source"
+	facetTotal  = "total"
+)
+
+// FacetLogs returns the most frequent level and source values among logs matching params.
+// If params.Search is provided, facets are computed over the full-text search matches.
+// Limit and Offset are ignored - facets always describe the whole result set.
+// Size caps the number of values per facet (default 10, max 100).
+//
+// Both facets and the total match count are computed in a single query.
+func (db *DB) FacetLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams, size int) (*models.FacetsResponse, error) {
+	return db.facetLogs(ctx, projectID, filterFromQueryParams(params), size)
+}
+
+// FacetSearch returns the most frequent level and source values among full-text search matches.
+// Accepts the same request as SearchLogs; Limit and Offset are ignored.
+// Returns error if the search query is invalid or database fails.
+func (db *DB) FacetSearch(ctx context.Context, projectID uuid.UUID, req models.SearchRequest, size int) (*models.FacetsResponse, error) {
+	if req.Query == "" {
+		return nil, &QueryError{Err: fmt.Errorf("invalid search query: query is required")}
+	}
+	return db.facetLogs(ctx, projectID, filterFromSearchRequest(req), size)
+}
+
+// FacetSize applies the default (10) and maximum (100) number of values per facet.
+func FacetSize(size int) int {
+	return validateLimit(size, defaultFacetSize, maxFacetSize)
+}
+
+func (db *DB) facetLogs(ctx context.Context, projectID uuid.UUID, filter logFilter, size int) (*models.FacetsResponse, error) {
+	start := time.Now()
+	defer func() {
+		log.Printf("FacetLogs: duration=%v project=%s filters=[level=%s source=%s search=%s]",
+			time.Since(start), projectID, filter.Level, filter.Source, filter.Search)
+	}()
+
+	size = FacetSize(size)
+
+	filter, err := db.withSearchLanguage(ctx, projectID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := NewQueryBuilder()
+	if err := filter.apply(qb, projectID); err != nil {
+		return nil, err
+	}
+
+	// SAFETY: All user input is parameterized. whereClause only contains safe SQL.
+	// Rows are tagged with the facet they belong to; the 'total' row carries the match count.
+	query := fmt.Sprintf(`
+		WITH filtered AS (
+			SELECT %[1]s, COALESCE(%[2]s, '') AS %[2]s
+			FROM logs
+			%[3]s
+		),
+		level_facets AS (
+			SELECT '%[5]s' AS field, %[1]s AS value, COUNT(*) AS value_count
+			FROM filtered
+			GROUP BY %[1]s
+			ORDER BY value_count DESC, value
+			LIMIT $%[4]d
+		),
+		source_facets AS (
+			SELECT '%[6]s' AS field, %[2]s AS value, COUNT(*) AS value_count
+			FROM filtered
+			GROUP BY %[2]s
+			ORDER BY value_count DESC, value
+			LIMIT $%[4]d
+		)
+		SELECT field, value, value_count FROM level_facets
+		UNION ALL
+		SELECT field, value, value_count FROM source_facets
+		UNION ALL
+		SELECT '%[7]s', '', COUNT(*) FROM filtered
+	`, columnLevel, columnSource, qb.WhereClause(), qb.NextArgNum(), facetLevel, facetSource, facetTotal)
+
+	args := append(qb.Args(), size)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facets: %w", err)
+	}
+	defer rows.Close()
+
+	response := &models.FacetsResponse{
+		Facets: map[string][]models.FacetValue{
+			facetLevel:  {},
+			facetSource: {},
+		},
+	}
+
+	for rows.Next() {
+		var field string
+		var value models.FacetValue
+		if err := rows.Scan(&field, &value.Value, &value.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
+		}
+		if field == facetTotal {
+			response.Total = value.Count
+			continue
+		}
+		response.Facets[field] = append(response.Facets[field], value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facets: %w", err)
+	}
+
+	// UNION ALL does not guarantee row order, so re-apply the per-facet ordering
+	for _, values := range response.Facets {
+		sort.SliceStable(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+			return values[i].Value < values[j].Value
+		})
+	}
+
+	return response, nil
+}
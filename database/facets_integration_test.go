@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFacetLogs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Now()
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database connection failed", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database timeout", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Render failed", Source: "frontend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "User logged in", Source: "auth", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "No source", Timestamp: now},
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	response, err := db.FacetLogs(ctx, project.ID, models.QueryParams{}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), response.Total)
+	assert.Equal(t, []models.FacetValue{
+		{Value: "error", Count: 3},
+		{Value: "info", Count: 2},
+	}, response.Facets["level"])
+	assert.Equal(t, []models.FacetValue{
+		{Value: "backend", Count: 2},
+		{Value: "", Count: 1},
+		{Value: "auth", Count: 1},
+		{Value: "frontend", Count: 1},
+	}, response.Facets["source"])
+}
+
+func TestFacetLogs_FiltersAndSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Now()
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database connection failed", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database timeout", Source: "worker", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "warning", Message: "Database slow query", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "User logged in", Source: "auth", Timestamp: now},
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	response, err := db.FacetLogs(ctx, project.ID, models.QueryParams{Level: "error"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), response.Total)
+	assert.Len(t, response.Facets["level"], 1)
+	assert.Len(t, response.Facets["source"], 2)
+
+	response, err = db.FacetSearch(ctx, project.ID, models.SearchRequest{Query: "database"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), response.Total)
+	assert.Equal(t, []models.FacetValue{{Value: "error", Count: 2}}, response.Facets["level"])
+	assert.Equal(t, []models.FacetValue{{Value: "backend", Count: 2}}, response.Facets["source"])
+}
+
+func TestFacetLogs_ProjectIsolation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project1, err := db.CreateProject(ctx, "Project 1")
+	require.NoError(t, err)
+	project2, err := db.CreateProject(ctx, "Project 2")
+	require.NoError(t, err)
+
+	err = db.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project1.ID, Level: "error", Message: "P1 Log", Timestamp: time.Now()},
+	})
+	require.NoError(t, err)
+
+	response, err := db.FacetLogs(ctx, project2.ID, models.QueryParams{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), response.Total)
+	assert.Empty(t, response.Facets["level"])
+	assert.Empty(t, response.Facets["source"])
+}
+
+func TestFacetSearch_InvalidQuery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	ctx := context.Background()
+
+	_, err := db.FacetSearch(ctx, uuid.New(), models.SearchRequest{Query: "ab"}, 10)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid search query")
+}
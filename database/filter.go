@@ -0,0 +1,117 @@
+package database
+
+import (
+	"fmt"
+	"jazz/models"
+	"strings"
+	"time"
+)
+
+// LogFilter is the validated, backend-neutral form of the filters in QueryParams
+// and SearchRequest. Storage implementations that can't use the SQL built by
+// logFilter.apply resolve requests into a LogFilter, so every backend accepts
+// and rejects exactly the same input as PostgreSQL.
+type LogFilter struct {
+	Level  string
+	Source string
+
+	// Start and End are inclusive bounds in UTC; nil means unbounded.
+	Start *time.Time
+	End   *time.Time
+
+	// Terms are the lowercased search words (all must match); empty without a search.
+	Terms []string
+}
+
+// ResolveQueryParams validates params' filters as QueryLogs does, resolving
+// relative times against now. Returns *QueryError for invalid input.
+func ResolveQueryParams(params models.QueryParams, now time.Time) (LogFilter, error) {
+	return filterFromQueryParams(params).resolve(now)
+}
+
+// ResolveSearchRequest validates req's filters as SearchLogs does, resolving
+// relative times against now. Returns *QueryError if req.Query is empty or invalid.
+func ResolveSearchRequest(req models.SearchRequest, now time.Time) (LogFilter, error) {
+	if req.Query == "" {
+		return LogFilter{}, &QueryError{Err: fmt.Errorf("invalid search query: query is required")}
+	}
+	return filterFromSearchRequest(req).resolve(now)
+}
+
+// LogSort validates a QueryParams sort expression (default timestamp descending).
+// Returns *QueryError for unknown columns or directions.
+func LogSort(expr string) ([]SortField, error) {
+	return parseSort(expr, logSortColumns, defaultLogSort)
+}
+
+// SearchSort validates a SearchRequest sort expression (default rank, then timestamp, descending).
+// Returns *QueryError for unknown columns or directions.
+func SearchSort(expr string) ([]SortField, error) {
+	return parseSort(expr, searchSortColumns, defaultSearchSort)
+}
+
+// Page applies the default (50) and maximum (1000) limit and clamps negative offsets to 0.
+func Page(limit, offset int) (int, int) {
+	return validateLimit(limit, defaultLimit, maxLimit), validateOffset(offset)
+}
+
+func (f logFilter) resolve(now time.Time) (LogFilter, error) {
+	resolved := LogFilter{Level: f.Level, Source: f.Source}
+
+	if f.Search != "" {
+		tsQuery, err := NewSearchQueryParser().Parse(f.Search)
+		if err != nil {
+			return LogFilter{}, &QueryError{Err: fmt.Errorf("invalid search query: %w", err)}
+		}
+		resolved.Terms = strings.Split(tsQuery, " & ")
+	}
+
+	loc, err := LoadTimezone(f.TZ)
+	if err != nil {
+		return LogFilter{}, &QueryError{Err: fmt.Errorf("invalid tz: %w", err)}
+	}
+	parser := NewTimeParser(now, loc)
+
+	if f.StartTime != "" {
+		start, err := parser.Parse(f.StartTime)
+		if err != nil {
+			return LogFilter{}, &QueryError{Err: fmt.Errorf("invalid start_time: %w", err)}
+		}
+		resolved.Start = &start
+	}
+	if f.EndTime != "" {
+		end, err := parser.Parse(f.EndTime)
+		if err != nil {
+			return LogFilter{}, &QueryError{Err: fmt.Errorf("invalid end_time: %w", err)}
+		}
+		resolved.End = &end
+	}
+
+	return resolved, nil
+}
+
+// Match reports whether entry passes the level, source and time filters and contains
+// every search term (case-insensitive substring match, without stemming).
+func (f LogFilter) Match(entry models.LogEntry) bool {
+	if f.Level != "" && entry.Level != f.Level {
+		return false
+	}
+	if f.Source != "" && entry.Source != f.Source {
+		return false
+	}
+	if f.Start != nil && entry.Timestamp.Before(*f.Start) {
+		return false
+	}
+	if f.End != nil && entry.Timestamp.After(*f.End) {
+		return false
+	}
+
+	message := strings.ToLower(entry.Message)
+	for _, term := range f.Terms {
+		if !strings.Contains(message, term) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"errors"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveQueryParams(t *testing.T) {
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	filter, err := ResolveQueryParams(models.QueryParams{
+		Level:     "error",
+		StartTime: "now-15m",
+		EndTime:   "2024-11-22T13:00:00",
+		TZ:        "Europe/Berlin",
+		Search:    "Database TIMEOUT",
+	}, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "error", filter.Level)
+	assert.Equal(t, now.Add(-15*time.Minute), *filter.Start)
+	assert.Equal(t, time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC), *filter.End)
+	assert.Equal(t, []string{"database", "timeout"}, filter.Terms)
+
+	for _, params := range []models.QueryParams{
+		{StartTime: "yesterday"},
+		{EndTime: "not-a-date"},
+		{TZ: "Mars/Olympus"},
+		{Search: "a"},
+	} {
+		_, err := ResolveQueryParams(params, now)
+		var queryErr *QueryError
+		assert.True(t, errors.As(err, &queryErr), "%+v should be a QueryError", params)
+	}
+}
+
+func TestResolveSearchRequest_RequiresQuery(t *testing.T) {
+	_, err := ResolveSearchRequest(models.SearchRequest{}, time.Now())
+	var queryErr *QueryError
+	assert.True(t, errors.As(err, &queryErr))
+}
+
+func TestLogFilter_Match(t *testing.T) {
+	start := time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	filter := LogFilter{Level: "error", Source: "api", Start: &start, End: &end, Terms: []string{"database", "timeout"}}
+
+	entry := models.LogEntry{Level: "error", Source: "api", Message: "Database connection TIMEOUT", Timestamp: start}
+
+	assert.True(t, filter.Match(entry))
+	assert.True(t, filter.Match(withTimestamp(entry, end)), "end is inclusive")
+
+	assert.False(t, filter.Match(withTimestamp(entry, start.Add(-time.Second))))
+	assert.False(t, filter.Match(withTimestamp(entry, end.Add(time.Second))))
+
+	other := entry
+	other.Message = "database connection refused"
+	assert.False(t, filter.Match(other))
+
+	other = entry
+	other.Source = "worker"
+	assert.False(t, filter.Match(other))
+
+	assert.True(t, LogFilter{}.Match(entry))
+}
+
+func withTimestamp(entry models.LogEntry, ts time.Time) models.LogEntry {
+	entry.Timestamp = ts
+	return entry
+}
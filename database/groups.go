@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrProjectGroupExists is returned when granting a group a project already grants.
+	// Safe to expose to clients.
+	ErrProjectGroupExists = errors.New("group already has access to this project")
+
+	// ErrProjectGroupNotFound is returned when revoking a group a project doesn't grant.
+	// Safe to expose to clients.
+	ErrProjectGroupNotFound = errors.New("group not found")
+)
+
+// GrantProjectGroup lets members of an identity provider group read a project's logs.
+// Returns ErrProjectNotFound or ErrProjectGroupExists.
+func (db *DB) GrantProjectGroup(ctx context.Context, projectID uuid.UUID, group string) (*models.ProjectGroup, error) {
+	query := `
+		INSERT INTO project_groups (project_id, group_name)
+		VALUES ($1, $2)
+		RETURNING project_id, group_name, created_at
+	`
+
+	var grant models.ProjectGroup
+	err := db.Pool.QueryRow(ctx, query, projectID, group).Scan(&grant.ProjectID, &grant.Group, &grant.CreatedAt)
+	if err != nil {
+		switch {
+		case isForeignKeyViolation(err):
+			return nil, ErrProjectNotFound
+		case isUniqueViolation(err):
+			return nil, ErrProjectGroupExists
+		}
+		return nil, fmt.Errorf("failed to grant group: %w", err)
+	}
+
+	grant.CreatedAt = grant.CreatedAt.UTC()
+	return &grant, nil
+}
+
+// ListProjectGroups returns the groups that may read a project's logs, in name order.
+// Returns ErrProjectNotFound if the project doesn't exist.
+func (db *DB) ListProjectGroups(ctx context.Context, projectID uuid.UUID) ([]models.ProjectGroup, error) {
+	query := `
+		SELECT p.id, g.group_name, g.created_at
+		FROM projects p
+		LEFT JOIN project_groups g ON g.project_id = p.id
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+		ORDER BY g.group_name
+	`
+
+	rows, err := db.Pool.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+
+	found := false
+	groups := []models.ProjectGroup{}
+	for rows.Next() {
+		found = true
+
+		// The LEFT JOIN yields one row of NULLs for a project without groups
+		var (
+			grant     models.ProjectGroup
+			group     *string
+			createdAt *time.Time
+		)
+		if err := rows.Scan(&grant.ProjectID, &group, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		if group == nil {
+			continue
+		}
+		grant.Group = *group
+		grant.CreatedAt = createdAt.UTC()
+		groups = append(groups, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating groups: %w", err)
+	}
+
+	if !found {
+		return nil, ErrProjectNotFound
+	}
+	return groups, nil
+}
+
+// RevokeProjectGroup removes a group's access to a project.
+// Returns ErrProjectGroupNotFound if the project doesn't grant the group.
+func (db *DB) RevokeProjectGroup(ctx context.Context, projectID uuid.UUID, group string) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM project_groups WHERE project_id = $1 AND group_name = $2`, projectID, group)
+	if err != nil {
+		return fmt.Errorf("failed to revoke group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProjectGroupNotFound
+	}
+	return nil
+}
+
+// HasProjectGroup reports whether any of groups may read a project's logs.
+func (db *DB) HasProjectGroup(ctx context.Context, projectID uuid.UUID, groups []string) (bool, error) {
+	if len(groups) == 0 {
+		return false, nil
+	}
+
+	var ok bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM project_groups g
+			JOIN projects p ON p.id = g.project_id
+			WHERE g.project_id = $1 AND g.group_name = ANY($2) AND p.deleted_at IS NULL
+		)
+	`
+	if err := db.Pool.QueryRow(ctx, query, projectID, groups).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check groups: %w", err)
+	}
+	return ok, nil
+}
@@ -2,9 +2,11 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"jazz/models"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,8 +16,23 @@ import (
 const (
 	defaultLimit = 50
 	maxLimit     = 1000
+
+	defaultContextSize = 10
+	maxContextSize     = 100
+)
+
+var (
+	// logSortColumns are the columns QueryLogs and ExportLogs accept in params.Sort
+	logSortColumns    = []string{columnTimestamp, columnLevel, columnSource}
+	defaultLogSort    = []SortField{{Column: columnTimestamp, Descending: true}}
+	searchSortColumns = []string{columnRank, columnTimestamp, columnLevel, columnSource}
+	defaultSearchSort = []SortField{{Column: columnRank, Descending: true}, {Column: columnTimestamp, Descending: true}}
 )
 
+// ErrLogNotFound is returned when a log entry does not exist in the requested project.
+// Safe to expose to clients.
+var ErrLogNotFound = errors.New("log not found")
+
 // BatchInsertError indicates which log failed during batch insert.
 // Contains the index of the failed log and the total batch size for debugging.
 type BatchInsertError struct {
@@ -28,11 +45,27 @@ func (e *BatchInsertError) Error() string {
 	return fmt.Sprintf("failed to insert log at index %d/%d: %v", e.FailedIndex, e.TotalLogs, e.Err)
 }
 
+// QueryError indicates a read was rejected because the caller's parameters are invalid
+// (malformed time range, unknown time zone, unusable search query).
+// The message is safe to expose to clients; handlers map it to 400 Bad Request.
+type QueryError struct {
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
 // InsertLogsBatch inserts multiple log entries atomically using pgx batching.
 // All logs are inserted in a single network round-trip for performance.
 // If any log fails, returns BatchInsertError indicating which log failed.
 // Empty slice is a no-op and returns nil.
 // All logs must belong to the same project (not enforced, caller's responsibility).
+// Timestamps may carry any offset; they are stored as instants (TIMESTAMPTZ).
 func (db *DB) InsertLogsBatch(ctx context.Context, logs []models.LogEntry) error {
 	if len(logs) == 0 {
 		return nil
@@ -51,7 +84,7 @@ func (db *DB) InsertLogsBatch(ctx context.Context, logs []models.LogEntry) error
 	batch := &pgx.Batch{}
 	for _, logEntry := range logs {
 		batch.Queue(query, logEntry.ID, logEntry.ProjectID, logEntry.Level,
-			logEntry.Message, logEntry.Source, logEntry.Timestamp)
+			logEntry.Message, logEntry.Source, logEntry.Timestamp.UTC())
 	}
 
 	results := db.Pool.SendBatch(ctx, batch)
@@ -76,12 +109,14 @@ func (db *DB) InsertLogsBatch(ctx context.Context, logs []models.LogEntry) error
 // QueryLogs retrieves logs for a project with optional filtering and pagination.
 // If params.Search is provided, delegates to SearchLogs for full-text search.
 // Uses COUNT(*) OVER() window function to get total count in single query.
-// Returns logs ordered by timestamp DESC (newest first), total count, and any error.
+// Returns logs ordered by params.Sort (default timestamp DESC, newest first), total count, and any error.
 //
 // Filters applied:
 //   - Level: exact match (e.g., "error", "info")
 //   - Source: exact match (e.g., "backend", "frontend")
-//   - StartTime/EndTime: inclusive timestamp range (RFC3339 format)
+//   - StartTime/EndTime: inclusive timestamp range (RFC3339, epoch, or "now-15m" style; see TimeParser)
+//   - TZ: time zone for relative times and timestamps without an offset (default UTC)
+//   - Sort: "timestamp", "level" and/or "source", e.g. "timestamp:asc" (id breaks ties)
 //   - Limit: max results (default 50, max 1000)
 //   - Offset: pagination offset (default 0)
 //
@@ -101,6 +136,8 @@ func (db *DB) QueryLogs(ctx context.Context, projectID uuid.UUID, params models.
 			Source:    params.Source,
 			StartTime: params.StartTime,
 			EndTime:   params.EndTime,
+			TZ:        params.TZ,
+			Sort:      params.Sort,
 			Limit:     params.Limit,
 			Offset:    params.Offset,
 		}
@@ -111,17 +148,14 @@ func (db *DB) QueryLogs(ctx context.Context, projectID uuid.UUID, params models.
 	limit := validateLimit(params.Limit, defaultLimit, maxLimit)
 	offset := validateOffset(params.Offset)
 
+	sortFields, err := parseSort(params.Sort, logSortColumns, defaultLogSort)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Build query
 	qb := NewQueryBuilder()
-	qb.AddCondition(columnProjectID, projectID)
-
-	if params.Level != "" {
-		qb.AddCondition(columnLevel, params.Level)
-	}
-	if params.Source != "" {
-		qb.AddCondition(columnSource, params.Source)
-	}
-	if err := qb.AddTimeRange(columnTimestamp, params.StartTime, params.EndTime); err != nil {
+	if err := filterFromQueryParams(params).apply(qb, projectID); err != nil {
 		return nil, 0, err
 	}
 
@@ -131,10 +165,10 @@ func (db *DB) QueryLogs(ctx context.Context, projectID uuid.UUID, params models.
 			COUNT(*) OVER() as total_count
 		FROM logs
 		%s
-		ORDER BY %s DESC
+		%s
 		LIMIT $%d OFFSET $%d
 	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
-		qb.WhereClause(), columnTimestamp, qb.NextArgNum(), qb.NextArgNum()+1)
+		qb.WhereClause(), OrderByClause(sortFields), qb.NextArgNum(), qb.NextArgNum()+1)
 
 	args := append(qb.Args(), limit, offset)
 
@@ -147,8 +181,236 @@ func (db *DB) QueryLogs(ctx context.Context, projectID uuid.UUID, params models.
 	return scanLogs(rows, false)
 }
 
+// GetLog retrieves a single log entry by ID.
+// Only entries belonging to projectID are visible, so one project cannot read another's logs.
+// Returns ErrLogNotFound if the entry doesn't exist in the project.
+func (db *DB) GetLog(ctx context.Context, projectID, logID uuid.UUID) (*models.LogEntry, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, %s, %s, %s, %s, %s
+		FROM logs
+		WHERE %s = $1 AND %s = $2
+	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
+		columnProjectID, columnID)
+
+	entry, err := scanLogEntry(db.Pool.QueryRow(ctx, query, projectID, logID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLogNotFound
+		}
+		return nil, fmt.Errorf("failed to get log: %w", err)
+	}
+
+	return entry, nil
+}
+
+// ContextSize resolves a LogContextParams count: 10 if unset, otherwise n clamped to
+// 0..100. An explicit zero returns no entries on that side.
+func ContextSize(n *int) int {
+	if n == nil {
+		return defaultContextSize
+	}
+	return max(0, min(*n, maxContextSize))
+}
+
+// GetLogContext retrieves a log entry together with the entries logged just before and after it.
+// Neighbours come from the same project (and, if params.SameSource is set, the same source)
+// and are ordered by (timestamp, id), so entries sharing a timestamp have a stable order.
+// Before and After each default to 10 entries (max 100, see ContextSize) and are returned
+// oldest first.
+//
+// The target and both neighbour queries are sent in a single batch (one round trip).
+// Returns ErrLogNotFound if the target entry doesn't exist in the project.
+func (db *DB) GetLogContext(ctx context.Context, projectID, logID uuid.UUID, params models.LogContextParams) (*models.LogContextResponse, error) {
+	before := ContextSize(params.Before)
+	after := ContextSize(params.After)
+
+	start := time.Now()
+	defer func() {
+		log.Printf("GetLogContext: duration=%v project=%s log=%s before=%d after=%d same_source=%t",
+			time.Since(start), projectID, logID, before, after, params.SameSource)
+	}()
+
+	sourceCondition := ""
+	if params.SameSource {
+		sourceCondition = fmt.Sprintf("AND l.%s IS NOT DISTINCT FROM t.%s", columnSource, columnSource)
+	}
+
+	targetQuery := fmt.Sprintf(`
+		SELECT %s, %s, %s, %s, %s, %s
+		FROM logs
+		WHERE %s = $1 AND %s = $2
+	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
+		columnProjectID, columnID)
+
+	// $1 = project, $2 = target log, $3 = neighbour count.
+	// comparison is "<" or ">" and direction is the matching ORDER BY direction.
+	neighbourQuery := func(comparison, direction string) string {
+		return fmt.Sprintf(`
+			WITH t AS (
+				SELECT %[1]s, %[2]s, %[3]s
+				FROM logs
+				WHERE %[4]s = $1 AND %[1]s = $2
+			)
+			SELECT l.%[1]s, l.%[4]s, l.%[5]s, l.%[6]s, l.%[3]s, l.%[2]s
+			FROM logs l, t
+			WHERE l.%[4]s = $1
+				AND (l.%[2]s, l.%[1]s) %[7]s (t.%[2]s, t.%[1]s)
+				%[8]s
+			ORDER BY l.%[2]s %[9]s, l.%[1]s %[9]s
+			LIMIT $3
+		`, columnID, columnTimestamp, columnSource, columnProjectID, columnLevel, columnMessage,
+			comparison, sourceCondition, direction)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(targetQuery, projectID, logID)
+	batch.Queue(neighbourQuery("<", "DESC"), projectID, logID, before)
+	batch.Queue(neighbourQuery(">", "ASC"), projectID, logID, after)
+
+	results := db.Pool.SendBatch(ctx, batch)
+	defer func() {
+		_ = results.Close()
+	}()
+
+	target, err := scanLogEntry(results.QueryRow())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLogNotFound
+		}
+		return nil, fmt.Errorf("failed to get log: %w", err)
+	}
+
+	beforeLogs, err := queryLogEntries(results)
+	if err != nil {
+		return nil, err
+	}
+	// Fetched newest first so LIMIT keeps the closest entries; return oldest first
+	for i, j := 0, len(beforeLogs)-1; i < j; i, j = i+1, j-1 {
+		beforeLogs[i], beforeLogs[j] = beforeLogs[j], beforeLogs[i]
+	}
+
+	afterLogs, err := queryLogEntries(results)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LogContextResponse{
+		Log:    *target,
+		Before: beforeLogs,
+		After:  afterLogs,
+	}, nil
+}
+
+// logFilter holds the filters shared by every log read path.
+// QueryLogs, SearchLogs and FacetLogs all build their WHERE clause from it,
+// so a given set of filters always selects the same rows.
+type logFilter struct {
+	Level     string
+	Source    string
+	StartTime string
+	EndTime   string
+	Search    string
+	TZ        string
+
+	// Language is the project's search language (see searchLanguage); empty means english.
+	Language string
+}
+
+func filterFromQueryParams(params models.QueryParams) logFilter {
+	return logFilter{
+		Level:     params.Level,
+		Source:    params.Source,
+		StartTime: params.StartTime,
+		EndTime:   params.EndTime,
+		Search:    params.Search,
+		TZ:        params.TZ,
+	}
+}
+
+func filterFromSearchRequest(req models.SearchRequest) logFilter {
+	return logFilter{
+		Level:     req.Level,
+		Source:    req.Source,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Search:    req.Query,
+		TZ:        req.TZ,
+	}
+}
+
+// apply adds the filter conditions to qb, scoped to projectID.
+// project_id is always $1 and, when Search is set, the parsed tsquery is always $2
+// (SearchLogs relies on this to reference the tsquery in ts_rank).
+// Relative times and timestamps without an offset are resolved in the TZ time zone.
+// Returns *QueryError if the search query, time zone or time range is invalid.
+func (f logFilter) apply(qb *QueryBuilder, projectID uuid.UUID) error {
+	qb.AddCondition(columnProjectID, projectID)
+
+	if f.Search != "" {
+		tsQuery, err := NewSearchQueryParser().Parse(f.Search)
+		if err != nil {
+			return &QueryError{Err: fmt.Errorf("invalid search query: %w", err)}
+		}
+		qb.AddFullTextSearchIn(f.language(), tsQuery)
+	}
+	if f.Level != "" {
+		qb.AddCondition(columnLevel, f.Level)
+	}
+	if f.Source != "" {
+		qb.AddCondition(columnSource, f.Source)
+	}
+
+	loc, err := LoadTimezone(f.TZ)
+	if err != nil {
+		return &QueryError{Err: fmt.Errorf("invalid tz: %w", err)}
+	}
+	if err := qb.AddTimeRangeWith(columnTimestamp, f.StartTime, f.EndTime, NewTimeParser(time.Now(), loc)); err != nil {
+		return &QueryError{Err: err}
+	}
+
+	return nil
+}
+
+// language returns the text search configuration for f's search.
+func (f logFilter) language() string {
+	if f.Language == "" {
+		return models.DefaultSearchLanguage
+	}
+	return f.Language
+}
+
+// withSearchLanguage sets f.Language to projectID's search language if f searches.
+// Unknown projects keep the default, since they have no logs to match anyway.
+func (db *DB) withSearchLanguage(ctx context.Context, projectID uuid.UUID, f logFilter) (logFilter, error) {
+	if f.Search == "" {
+		return f, nil
+	}
+
+	var language *string
+	err := db.Pool.QueryRow(ctx,
+		`SELECT settings->>'search_language' FROM projects WHERE id = $1`, projectID).Scan(&language)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return f, fmt.Errorf("failed to get search language: %w", err)
+	}
+
+	// The language is written into the query, so only ever use a known configuration
+	if language != nil && slices.Contains(models.SearchLanguages, *language) {
+		f.Language = *language
+	}
+	return f, nil
+}
+
 // Helper functions
 
+// parseSort is ParseSort with errors reported as *QueryError.
+func parseSort(expr string, allowed []string, defaultSort []SortField) ([]SortField, error) {
+	fields, err := ParseSort(expr, allowed, defaultSort)
+	if err != nil {
+		return nil, &QueryError{Err: fmt.Errorf("invalid sort: %w", err)}
+	}
+	return fields, nil
+}
+
 func scanLog(row rowScanner, includeRank bool) (*models.LogEntry, int64, error) {
 	var log models.LogEntry
 	var total int64
@@ -173,9 +435,52 @@ func scanLog(row rowScanner, includeRank bool) (*models.LogEntry, int64, error)
 		}
 	}
 
+	// pgx returns TIMESTAMPTZ in the server's local zone; API responses are always UTC
+	log.Timestamp = log.Timestamp.UTC()
+
 	return &log, total, nil
 }
 
+func scanLogEntry(row rowScanner) (*models.LogEntry, error) {
+	var log models.LogEntry
+	err := row.Scan(
+		&log.ID, &log.ProjectID, &log.Level, &log.Message,
+		&log.Source, &log.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.Timestamp = log.Timestamp.UTC()
+	return &log, nil
+}
+
+func scanLogEntries(rows rowsScanner) ([]models.LogEntry, error) {
+	logs := []models.LogEntry{}
+	for rows.Next() {
+		log, err := scanLogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+		logs = append(logs, *log)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+func queryLogEntries(results pgx.BatchResults) ([]models.LogEntry, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLogEntries(rows)
+}
+
 func scanLogs(rows rowsScanner, includeRank bool) ([]models.LogEntry, int64, error) {
 	logs := []models.LogEntry{}
 	var total int64
@@ -2,7 +2,9 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"jazz/models"
+	"sort"
 	"testing"
 	"time"
 
@@ -216,3 +218,287 @@ func TestQueryLogs_ProjectIsolation(t *testing.T) {
 	assert.Equal(t, int64(1), total)
 	assert.Equal(t, "P2 Log", results[0].Message)
 }
+
+func TestGetLog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project1, err := db.CreateProject(ctx, "Project 1")
+	require.NoError(t, err)
+	project2, err := db.CreateProject(ctx, "Project 2")
+	require.NoError(t, err)
+
+	entry := models.LogEntry{
+		ID: uuid.New(), ProjectID: project1.ID, Level: "error", Message: "Target", Source: "backend", Timestamp: time.Now(),
+	}
+	err = db.InsertLogsBatch(ctx, []models.LogEntry{entry})
+	require.NoError(t, err)
+
+	retrieved, err := db.GetLog(ctx, project1.ID, entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entry.ID, retrieved.ID)
+	assert.Equal(t, "Target", retrieved.Message)
+
+	_, err = db.GetLog(ctx, project2.ID, entry.ID)
+	assert.ErrorIs(t, err, ErrLogNotFound)
+
+	_, err = db.GetLog(ctx, project1.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrLogNotFound)
+}
+
+func TestGetLogContext(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	logs := make([]models.LogEntry, 9)
+	for i := range logs {
+		source := "backend"
+		if i%2 == 1 {
+			source = "worker"
+		}
+		logs[i] = models.LogEntry{
+			ID:        uuid.New(),
+			ProjectID: project.ID,
+			Level:     "info",
+			Message:   fmt.Sprintf("Log %d", i),
+			Source:    source,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		}
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	target := logs[4]
+
+	t.Run("before and after", func(t *testing.T) {
+		response, err := db.GetLogContext(ctx, project.ID, target.ID, models.LogContextParams{Before: intPtr(2), After: intPtr(3)})
+		require.NoError(t, err)
+		assert.Equal(t, target.ID, response.Log.ID)
+		assert.Equal(t, []string{"Log 2", "Log 3"}, messages(response.Before))
+		assert.Equal(t, []string{"Log 5", "Log 6", "Log 7"}, messages(response.After))
+	})
+
+	t.Run("same source", func(t *testing.T) {
+		response, err := db.GetLogContext(ctx, project.ID, target.ID, models.LogContextParams{Before: intPtr(10), After: intPtr(10), SameSource: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Log 0", "Log 2"}, messages(response.Before))
+		assert.Equal(t, []string{"Log 6", "Log 8"}, messages(response.After))
+	})
+
+	t.Run("one side only", func(t *testing.T) {
+		response, err := db.GetLogContext(ctx, project.ID, target.ID, models.LogContextParams{Before: intPtr(0), After: intPtr(5)})
+		require.NoError(t, err)
+		assert.Empty(t, response.Before)
+		assert.Equal(t, []string{"Log 5", "Log 6", "Log 7", "Log 8"}, messages(response.After))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := db.GetLogContext(ctx, project.ID, uuid.New(), models.LogContextParams{})
+		assert.ErrorIs(t, err, ErrLogNotFound)
+	})
+}
+
+func TestGetLogContext_SameTimestamp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Second)
+	logs := make([]models.LogEntry, 5)
+	for i := range logs {
+		logs[i] = models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Same time", Timestamp: now}
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID.String() < logs[j].ID.String() })
+
+	response, err := db.GetLogContext(ctx, project.ID, logs[2].ID, models.LogContextParams{})
+	require.NoError(t, err)
+	require.Len(t, response.Before, 2)
+	require.Len(t, response.After, 2)
+	assert.Equal(t, logs[0].ID, response.Before[0].ID)
+	assert.Equal(t, logs[1].ID, response.Before[1].ID)
+	assert.Equal(t, logs[3].ID, response.After[0].ID)
+	assert.Equal(t, logs[4].ID, response.After[1].ID)
+}
+
+func messages(logs []models.LogEntry) []string {
+	result := make([]string, len(logs))
+	for i, entry := range logs {
+		result[i] = entry.Message
+	}
+	return result
+}
+
+func TestInsertLogsBatch_MixedOffsets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	// The same instant, and one 30 minutes later, written with different offsets
+	instant := time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC)
+	india := time.FixedZone("IST", 5*3600+1800)
+	pacific := time.FixedZone("PST", -8*3600)
+
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "UTC", Timestamp: instant},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "IST", Timestamp: instant.In(india)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "PST later", Timestamp: instant.Add(30 * time.Minute).In(pacific)},
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	for _, entry := range logs {
+		retrieved, err := db.GetLog(ctx, project.ID, entry.ID)
+		require.NoError(t, err)
+		assert.True(t, entry.Timestamp.Equal(retrieved.Timestamp),
+			"%s: expected %v, got %v", entry.Message, entry.Timestamp, retrieved.Timestamp)
+		assert.Equal(t, time.UTC, retrieved.Timestamp.Location())
+	}
+
+	// A range expressed with an offset selects by instant, not wall-clock time
+	results, total, err := db.QueryLogs(ctx, project.ID, models.QueryParams{
+		StartTime: "2024-11-22T15:30:00+05:30",
+		EndTime:   "2024-11-22T15:30:00+05:30",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.ElementsMatch(t, []string{"UTC", "IST"}, messages(results))
+
+	results, _, err = db.QueryLogs(ctx, project.ID, models.QueryParams{
+		StartTime: "2024-11-22T02:15:00-08:00",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PST later"}, messages(results))
+
+	// Local times without an offset are resolved in tz
+	results, _, err = db.QueryLogs(ctx, project.ID, models.QueryParams{
+		StartTime: "2024-11-22T15:45:00",
+		TZ:        "Asia/Kolkata",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PST later"}, messages(results))
+}
+
+func TestInsertLogsBatch_SessionTimeZoneIndependent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	instant := time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC)
+	entry := models.LogEntry{
+		ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Offset",
+		Timestamp: instant.In(time.FixedZone("IST", 5*3600+1800)),
+	}
+
+	// Write through a session with a non-UTC time zone
+	conn, err := db.Pool.Acquire(ctx)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "SET TIME ZONE 'America/Los_Angeles'")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx,
+		"INSERT INTO logs (id, project_id, level, message, source, timestamp) VALUES ($1, $2, $3, $4, $5, $6)",
+		entry.ID, entry.ProjectID, entry.Level, entry.Message, entry.Source, entry.Timestamp)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "RESET TIME ZONE")
+	require.NoError(t, err)
+	conn.Release()
+
+	retrieved, err := db.GetLog(ctx, project.ID, entry.ID)
+	require.NoError(t, err)
+	assert.True(t, instant.Equal(retrieved.Timestamp), "expected %v, got %v", instant, retrieved.Timestamp)
+}
+
+func TestQueryLogs_Sort(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour)
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "warning", Message: "Log 0", Timestamp: base},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Log 1", Timestamp: base.Add(time.Second)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Log 2", Timestamp: base.Add(2 * time.Second)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Log 3", Timestamp: base.Add(3 * time.Second)},
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		sort     string
+		expected []string
+	}{
+		{name: "default newest first", sort: "", expected: []string{"Log 3", "Log 2", "Log 1", "Log 0"}},
+		{name: "chronological", sort: "timestamp:asc", expected: []string{"Log 0", "Log 1", "Log 2", "Log 3"}},
+		{name: "secondary key", sort: "level:asc,timestamp:desc", expected: []string{"Log 3", "Log 1", "Log 2", "Log 0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, _, err := db.QueryLogs(ctx, project.ID, models.QueryParams{Sort: tt.sort, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, messages(results))
+		})
+	}
+
+	_, _, err = db.QueryLogs(ctx, project.ID, models.QueryParams{Sort: "rank:desc"})
+	var queryErr *QueryError
+	assert.ErrorAs(t, err, &queryErr, "rank is only valid for search")
+}
+
+func intPtr(n int) *int {
+	return &n
+}
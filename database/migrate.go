@@ -0,0 +1,384 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID serializes migration runs across concurrent deploys.
+const migrationLockID = 0x6a617a6d // "jazm"
+
+// ErrMigrationModified is returned when an applied migration's file no longer matches
+// the checksum recorded when it ran. Restore the original file and add a new migration instead.
+var ErrMigrationModified = errors.New("applied migration has been modified")
+
+// Migration is a versioned schema change, loaded from NNN_name.sql with an optional
+// NNN_name.down.sql that reverts it.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string // empty if the migration can't be rolled back
+	Checksum string // hex SHA-256 of Up
+}
+
+// String returns the migration's file name without extension, e.g. "003_partition_logs".
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus is a migration and whether it has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	Modified  bool // applied with a different checksum
+}
+
+// LoadMigrations reads *.sql files from the root of fsys, ordered by version.
+// Returns an error for names without a numeric version prefix, duplicate versions
+// and down migrations without a matching up migration.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	downs := map[int]string{}
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || path.Ext(filename) != ".sql" {
+			continue
+		}
+
+		base, isDown := strings.CutSuffix(strings.TrimSuffix(filename, ".sql"), ".down")
+		versionText, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if !ok || err != nil || version <= 0 || name == "" {
+			return nil, fmt.Errorf("invalid migration file name %q: want NNN_name.sql", filename)
+		}
+
+		content, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+
+		if isDown {
+			downs[version] = string(content)
+			continue
+		}
+		if existing, ok := byVersion[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, existing, base)
+		}
+		sum := sha256.Sum256(content)
+		byVersion[version] = &Migration{
+			Version:  version,
+			Name:     name,
+			Up:       string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		}
+	}
+
+	for version, down := range downs {
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("down migration for version %d has no up migration", version)
+		}
+		migration.Down = down
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and rolls back migrations, recording them in schema_migrations.
+//
+// Each migration runs in its own transaction together with its schema_migrations
+// row, so a failed migration leaves no trace. Runs hold an advisory lock: concurrent
+// deploys wait for each other and then find nothing left to do.
+type Migrator struct {
+	db         *DB
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator for migrations, as returned by LoadMigrations.
+func NewMigrator(db *DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// appliedMigration is a schema_migrations row.
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Status lists every known migration and whether it has been applied.
+// Applied versions without a migration file are included with an empty Up.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int]appliedMigration) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if row, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &row.appliedAt
+				status.Modified = row.checksum != migration.Checksum
+				delete(applied, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for version, row := range applied {
+			statuses = append(statuses, MigrationStatus{
+				Migration: Migration{Version: version, Name: row.name, Checksum: row.checksum},
+				AppliedAt: &row.appliedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int {
+		return a.Version - b.Version
+	})
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns how many ran.
+// Fails with ErrMigrationModified, before changing anything, if an applied migration was edited.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if len(m.migrations) == 0 {
+		return 0, nil
+	}
+	// Versions applied by a newer build are left alone
+	return m.migrate(ctx, m.migrations[len(m.migrations)-1].Version, false)
+}
+
+// Down rolls back the most recently applied steps migrations (by version) and
+// returns how many were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be positive")
+	}
+
+	count := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int]appliedMigration) error {
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && count < steps; i-- {
+			if err := m.rollback(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// To migrates up or down so that exactly the migrations up to and including
+// version are applied. Version 0 rolls back everything. Returns how many
+// migrations were applied or rolled back.
+func (m *Migrator) To(ctx context.Context, version int) (int, error) {
+	if version < 0 {
+		return 0, fmt.Errorf("version must not be negative")
+	}
+	if version > 0 && !slices.ContainsFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	}) {
+		return 0, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.migrate(ctx, version, true)
+}
+
+// migrate applies pending migrations up to version and, with rollback, rolls back
+// applied ones above it.
+func (m *Migrator) migrate(ctx context.Context, version int, rollback bool) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int]appliedMigration) error {
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; rollback && i >= 0 && versions[i] > version; i-- {
+			if err := m.rollback(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// withLock runs fn on a dedicated connection holding the migration lock, with the
+// applied migrations loaded. Creates schema_migrations on first use.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn, applied map[int]appliedMigration) error) error {
+	conn, err := m.db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Blocks until any concurrent run finishes
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = row
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating applied migrations: %w", err)
+	}
+
+	return fn(conn, applied)
+}
+
+// verify fails with ErrMigrationModified if any applied migration's checksum differs from its file.
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	for _, migration := range m.migrations {
+		if row, ok := applied[migration.Version]; ok && row.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %s", ErrMigrationModified, migration)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	start := time.Now()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %s: %w", migration, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, migration.Up); err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", migration, err)
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+		migration.Version, migration.Name, migration.Checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", migration, err)
+	}
+
+	log.Printf("Applied migration %s (%v)", migration, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func (m *Migrator) rollback(ctx context.Context, conn *pgxpool.Conn, version int) error {
+	index := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	})
+	if index < 0 {
+		return fmt.Errorf("cannot roll back migration %d: no migration file", version)
+	}
+	migration := m.migrations[index]
+	if strings.TrimSpace(migration.Down) == "" {
+		return fmt.Errorf("cannot roll back migration %s: no down migration", migration)
+	}
+
+	start := time.Now()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin rollback of %s: %w", migration, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, migration.Down); err != nil {
+		return fmt.Errorf("failed to roll back migration %s: %w", migration, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return fmt.Errorf("failed to record rollback of %s: %w", migration, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit rollback of %s: %w", migration, err)
+	}
+
+	log.Printf("Rolled back migration %s (%v)", migration, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// appliedVersions returns the applied versions in ascending order.
+func appliedVersions(applied map[int]appliedMigration) []int {
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMigrations are run in a scratch schema (see scratchDB).
+// 002 is deliberately not idempotent.
+func testMigrations(t *testing.T) []Migration {
+	t.Helper()
+
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"001_widgets.sql":          {Data: []byte("CREATE TABLE widgets (id INT PRIMARY KEY);")},
+		"001_widgets.down.sql":     {Data: []byte("DROP TABLE widgets;")},
+		"002_widget_name.sql":      {Data: []byte("ALTER TABLE widgets ADD COLUMN name TEXT; CREATE INDEX widgets_name ON widgets(name);")},
+		"002_widget_name.down.sql": {Data: []byte("DROP INDEX widgets_name; ALTER TABLE widgets DROP COLUMN name;")},
+		"003_gadgets.sql":          {Data: []byte("CREATE TABLE gadgets (id INT);")},
+		"003_gadgets.down.sql":     {Data: []byte("DROP TABLE gadgets;")},
+	})
+	require.NoError(t, err)
+	return migrations
+}
+
+// scratchDB returns a connection pool whose search_path is a fresh, empty schema,
+// dropped when the test ends, so migrations never touch the shared test schema.
+func scratchDB(t *testing.T) *DB {
+	t.Helper()
+
+	ctx := context.Background()
+	db := GetTestDB()
+
+	_, err := db.Pool.Exec(ctx, "DROP SCHEMA IF EXISTS migrate_scratch CASCADE; CREATE SCHEMA migrate_scratch")
+	require.NoError(t, err)
+
+	config := db.Pool.Config().Copy()
+	config.ConnConfig.RuntimeParams["search_path"] = "migrate_scratch"
+	config.MinConns = 0
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		pool.Close()
+		_, _ = db.Pool.Exec(ctx, "DROP SCHEMA IF EXISTS migrate_scratch CASCADE")
+	})
+	return &DB{Pool: pool}
+}
+
+func tableExists(t *testing.T, db *DB, name string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.Pool.QueryRow(context.Background(), "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists)
+	require.NoError(t, err)
+	return exists
+}
+
+func appliedCount(statuses []MigrationStatus) int {
+	count := 0
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMigrator_UpDownTo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := scratchDB(t)
+
+	ctx := context.Background()
+	migrator := NewMigrator(db, testMigrations(t))
+
+	count, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.True(t, tableExists(t, db, "gadgets"))
+
+	// Re-running skips applied migrations, so 002's bare CREATE INDEX doesn't fail
+	count, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	count, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.False(t, tableExists(t, db, "gadgets"))
+
+	count, err = migrator.To(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.Nil(t, statuses[2].AppliedAt)
+
+	count, err = migrator.To(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = migrator.To(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.False(t, tableExists(t, db, "widgets"))
+
+	_, err = migrator.To(ctx, 42)
+	assert.Error(t, err)
+}
+
+func TestMigrator_DetectsModifiedMigration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := scratchDB(t)
+
+	ctx := context.Background()
+	migrations := testMigrations(t)
+
+	_, err := NewMigrator(db, migrations[:2]).Up(ctx)
+	require.NoError(t, err)
+
+	edited := testMigrations(t)
+	edited[0].Checksum = "edited"
+
+	_, err = NewMigrator(db, edited).Up(ctx)
+	assert.ErrorIs(t, err, ErrMigrationModified)
+	assert.False(t, tableExists(t, db, "gadgets"), "nothing runs after a checksum mismatch")
+
+	statuses, err := NewMigrator(db, edited).Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Modified)
+	assert.False(t, statuses[1].Modified)
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := scratchDB(t)
+
+	ctx := context.Background()
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"001_broken.sql": {Data: []byte("CREATE TABLE broken (id INT); SELECT * FROM missing_table;")},
+	})
+	require.NoError(t, err)
+	migrator := NewMigrator(db, migrations)
+
+	_, err = migrator.Up(ctx)
+	require.Error(t, err)
+	assert.False(t, tableExists(t, db, "broken"), "the migration's transaction is rolled back")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, appliedCount(statuses))
+}
+
+func TestMigrator_ConcurrentUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := scratchDB(t)
+
+	ctx := context.Background()
+	migrations := testMigrations(t)
+
+	var wg sync.WaitGroup
+	counts := make([]int, 4)
+	errs := make([]error, 4)
+	for i := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counts[i], errs[i] = NewMigrator(db, migrations).Up(ctx)
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range counts {
+		require.NoError(t, errs[i])
+		total += counts[i]
+	}
+	assert.Equal(t, 3, total, "each migration is applied exactly once")
+}
+
+func TestMigrator_EmbeddedMigrationsRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := scratchDB(t)
+	ctx := context.Background()
+	migrations, err := EmbeddedMigrations()
+	require.NoError(t, err)
+
+	migrator := NewMigrator(db, migrations)
+
+	count, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), count)
+
+	count, err = migrator.To(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), count)
+
+	// And back up again from empty
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+}
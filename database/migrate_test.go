@@ -0,0 +1,63 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_column.sql":      {Data: []byte("ALTER TABLE things ADD COLUMN size INT;")},
+		"002_add_column.down.sql": {Data: []byte("ALTER TABLE things DROP COLUMN size;")},
+		"001_create.sql":          {Data: []byte("CREATE TABLE things (id INT);")},
+		"README.md":               {Data: []byte("not a migration")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create", migrations[0].Name)
+	assert.Equal(t, "001_create", migrations[0].String())
+	assert.Empty(t, migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.Equal(t, "ALTER TABLE things DROP COLUMN size;", migrations[1].Down)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{name: "no version", fsys: fstest.MapFS{"create.sql": {}}},
+		{name: "no name", fsys: fstest.MapFS{"001.sql": {}}},
+		{name: "zero version", fsys: fstest.MapFS{"000_create.sql": {}}},
+		{name: "duplicate version", fsys: fstest.MapFS{"001_a.sql": {}, "1_b.sql": {}}},
+		{name: "orphan down", fsys: fstest.MapFS{"001_a.sql": {}, "002_b.down.sql": {}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := EmbeddedMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "migration versions should be contiguous")
+		assert.NotEmpty(t, migration.Down, "%s has no down migration", migration)
+	}
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// migrationFiles is the schema, compiled into every binary that imports database.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// MigrationsFS returns the migration files built into the binary.
+func MigrationsFS() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		// Unreachable: the directory is embedded at compile time
+		panic(fmt.Sprintf("embedded migrations: %v", err))
+	}
+	return sub
+}
+
+// EmbeddedMigrations loads the migrations built into the binary.
+func EmbeddedMigrations() ([]Migration, error) {
+	return LoadMigrations(MigrationsFS())
+}
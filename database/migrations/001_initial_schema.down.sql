@@ -0,0 +1,3 @@
+-- Drops every log and project
+DROP TABLE IF EXISTS logs;
+DROP TABLE IF EXISTS projects;
@@ -7,7 +7,7 @@ CREATE TABLE IF NOT EXISTS projects (
     updated_at TIMESTAMP DEFAULT NOW()
 );
 
-CREATE INDEX idx_projects_api_key ON projects(api_key);
+CREATE INDEX IF NOT EXISTS idx_projects_api_key ON projects(api_key);
 
 -- Logs table with project_id
 CREATE TABLE IF NOT EXISTS logs (
@@ -20,10 +20,10 @@ CREATE TABLE IF NOT EXISTS logs (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
-CREATE INDEX idx_logs_timestamp ON logs(timestamp DESC);
-CREATE INDEX idx_logs_level ON logs(level);
-CREATE INDEX idx_logs_source ON logs(source);
-CREATE INDEX idx_logs_project_id ON logs(project_id);
+CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_logs_level ON logs(level);
+CREATE INDEX IF NOT EXISTS idx_logs_source ON logs(source);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id ON logs(project_id);
 
 -- Test project for development
 INSERT INTO projects (id, name, api_key) 
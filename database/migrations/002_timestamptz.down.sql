@@ -0,0 +1,24 @@
+-- Convert the columns back to TIMESTAMP holding UTC wall-clock time.
+-- Requires 003 to be rolled back first: a partition key's type can't be changed.
+DO $$
+DECLARE
+    col RECORD;
+BEGIN
+    FOR col IN
+        SELECT table_name, column_name
+        FROM information_schema.columns
+        WHERE table_schema = current_schema()
+          AND data_type = 'timestamp with time zone'
+          AND (table_name::text, column_name::text) IN (
+              ('projects', 'created_at'),
+              ('projects', 'updated_at'),
+              ('logs', 'timestamp'),
+              ('logs', 'created_at')
+          )
+    LOOP
+        EXECUTE format(
+            'ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMP USING %I AT TIME ZONE ''UTC''',
+            col.table_name, col.column_name, col.column_name
+        );
+    END LOOP;
+END $$;
@@ -0,0 +1,26 @@
+-- Store timestamps as TIMESTAMPTZ so entries ingested with an offset (e.g. +05:30)
+-- keep their instant instead of their wall-clock time.
+-- Existing values are interpreted as UTC, which is how the API wrote them.
+-- Each column is converted only while it is still TIMESTAMP, so re-running is safe.
+DO $$
+DECLARE
+    col RECORD;
+BEGIN
+    FOR col IN
+        SELECT table_name, column_name
+        FROM information_schema.columns
+        WHERE table_schema = current_schema()
+          AND data_type = 'timestamp without time zone'
+          AND (table_name::text, column_name::text) IN (
+              ('projects', 'created_at'),
+              ('projects', 'updated_at'),
+              ('logs', 'timestamp'),
+              ('logs', 'created_at')
+          )
+    LOOP
+        EXECUTE format(
+            'ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE ''UTC''',
+            col.table_name, col.column_name, col.column_name
+        );
+    END LOOP;
+END $$;
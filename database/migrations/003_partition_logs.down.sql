@@ -0,0 +1,34 @@
+-- Move logs back into a plain table. Only attached partitions are copied;
+-- partitions detached by the partition manager are left as standalone tables.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_class
+        WHERE oid = to_regclass('logs') AND relkind = 'p'
+    ) THEN
+        ALTER TABLE logs RENAME TO logs_partitioned;
+        ALTER TABLE logs_partitioned RENAME CONSTRAINT logs_pkey TO logs_partitioned_pkey;
+
+        CREATE TABLE logs (
+            id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+            project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+            level VARCHAR(20) NOT NULL,
+            message TEXT NOT NULL,
+            source VARCHAR(100),
+            timestamp TIMESTAMPTZ NOT NULL,
+            created_at TIMESTAMPTZ DEFAULT NOW()
+        );
+
+        INSERT INTO logs (id, project_id, level, message, source, timestamp, created_at)
+        SELECT id, project_id, level, message, source, timestamp, created_at
+        FROM logs_partitioned;
+
+        -- Drops the attached partitions and the indexes that 001 recreates below
+        DROP TABLE logs_partitioned;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_logs_level ON logs(level);
+CREATE INDEX IF NOT EXISTS idx_logs_source ON logs(source);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id ON logs(project_id);
@@ -0,0 +1,42 @@
+-- Convert logs to a table range-partitioned by timestamp.
+-- Existing rows move to the default partition; the API's partition manager
+-- pre-creates daily/weekly partitions for new data and detaches expired ones.
+-- Guarded so it only runs while logs is still a plain table.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_class
+        WHERE oid = to_regclass('logs') AND relkind = 'r'
+    ) THEN
+        ALTER TABLE logs RENAME TO logs_unpartitioned;
+        ALTER TABLE logs_unpartitioned RENAME CONSTRAINT logs_pkey TO logs_unpartitioned_pkey;
+
+        -- The primary key must include the partition key
+        CREATE TABLE logs (
+            id UUID NOT NULL DEFAULT gen_random_uuid(),
+            project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+            level VARCHAR(20) NOT NULL,
+            message TEXT NOT NULL,
+            source VARCHAR(100),
+            timestamp TIMESTAMPTZ NOT NULL,
+            created_at TIMESTAMPTZ DEFAULT NOW(),
+            PRIMARY KEY (id, timestamp)
+        ) PARTITION BY RANGE (timestamp);
+
+        CREATE TABLE logs_default PARTITION OF logs DEFAULT;
+
+        INSERT INTO logs (id, project_id, level, message, source, timestamp, created_at)
+        SELECT id, project_id, level, message, source, timestamp, created_at
+        FROM logs_unpartitioned;
+
+        DROP TABLE logs_unpartitioned;
+    END IF;
+END $$;
+
+-- Indexes on the parent cascade to every partition, including future ones
+CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_logs_level ON logs(level);
+CREATE INDEX IF NOT EXISTS idx_logs_source ON logs(source);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id ON logs(project_id);
+CREATE INDEX IF NOT EXISTS idx_logs_project_timestamp ON logs(project_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_logs_message_search ON logs USING GIN (to_tsvector('english', message));
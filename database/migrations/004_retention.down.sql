@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS retention_runs;
+ALTER TABLE projects DROP COLUMN IF EXISTS retention_days;
@@ -0,0 +1,16 @@
+-- Per-project log retention (NULL keeps logs forever)
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS retention_days INTEGER
+    CHECK (retention_days > 0);
+
+-- History of purge runs, shown by GET /projects/:id/retention
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    cutoff TIMESTAMPTZ NOT NULL,
+    deleted_rows BIGINT NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_runs_project_started ON retention_runs(project_id, started_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE retention_runs DROP COLUMN IF EXISTS archived_rows;
@@ -0,0 +1,2 @@
+-- Rows copied to the archive by a retention run before deletion
+ALTER TABLE retention_runs ADD COLUMN IF NOT EXISTS archived_rows BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+-- Hashed keys can't be turned back into plaintext: every project gets a new random
+-- key, which the old code returns from GET /projects/:id. All existing keys stop working.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key VARCHAR(64);
+UPDATE projects SET api_key = 'jazz_' || gen_random_uuid() WHERE api_key IS NULL;
+ALTER TABLE projects ALTER COLUMN api_key SET NOT NULL;
+ALTER TABLE projects ADD CONSTRAINT projects_api_key_key UNIQUE (api_key);
+CREATE INDEX IF NOT EXISTS idx_projects_api_key ON projects(api_key);
+
+DROP INDEX IF EXISTS idx_projects_api_key_prefix;
+ALTER TABLE projects DROP COLUMN IF EXISTS api_key_prefix;
+ALTER TABLE projects DROP COLUMN IF EXISTS api_key_salt;
+ALTER TABLE projects DROP COLUMN IF EXISTS api_key_hash;
@@ -0,0 +1,32 @@
+-- Store API keys as a salted SHA-256 hash plus a short public prefix used for lookup
+-- (see package apikey). Existing plaintext keys are hashed in place and keep working;
+-- the plaintext column is then dropped.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_prefix VARCHAR(12);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_salt BYTEA;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_hash BYTEA;
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'projects' AND column_name = 'api_key'
+    ) THEN
+        -- 16 random bytes per key
+        UPDATE projects SET api_key_salt = uuid_send(gen_random_uuid())
+        WHERE api_key_hash IS NULL;
+
+        UPDATE projects SET
+            api_key_prefix = left(api_key, 12),
+            api_key_hash = sha256(api_key_salt || convert_to(api_key, 'UTF8'))
+        WHERE api_key_hash IS NULL;
+
+        ALTER TABLE projects DROP COLUMN api_key;
+    END IF;
+END $$;
+
+ALTER TABLE projects ALTER COLUMN api_key_prefix SET NOT NULL;
+ALTER TABLE projects ALTER COLUMN api_key_salt SET NOT NULL;
+ALTER TABLE projects ALTER COLUMN api_key_hash SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_projects_api_key;
+CREATE INDEX IF NOT EXISTS idx_projects_api_key_prefix ON projects(api_key_prefix);
@@ -0,0 +1,31 @@
+-- Each project keeps its newest active key (or its newest key, if none is active);
+-- every other key stops working. A project without keys gets an unusable random hash.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_prefix VARCHAR(12);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_salt BYTEA;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS api_key_hash BYTEA;
+
+UPDATE projects p SET
+    api_key_prefix = k.prefix,
+    api_key_salt = k.salt,
+    api_key_hash = k.hash
+FROM (
+    SELECT DISTINCT ON (project_id) project_id, prefix, salt, hash
+    FROM api_keys
+    ORDER BY project_id,
+        (revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())) DESC,
+        created_at DESC
+) k
+WHERE k.project_id = p.id;
+
+UPDATE projects SET
+    api_key_prefix = 'jazz_',
+    api_key_salt = uuid_send(gen_random_uuid()),
+    api_key_hash = sha256(uuid_send(gen_random_uuid()))
+WHERE api_key_hash IS NULL;
+
+ALTER TABLE projects ALTER COLUMN api_key_prefix SET NOT NULL;
+ALTER TABLE projects ALTER COLUMN api_key_salt SET NOT NULL;
+ALTER TABLE projects ALTER COLUMN api_key_hash SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_projects_api_key_prefix ON projects(api_key_prefix);
+
+DROP TABLE IF EXISTS api_keys;
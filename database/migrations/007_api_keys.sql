@@ -0,0 +1,35 @@
+-- Projects can have any number of named API keys, so a key can be rotated or revoked
+-- without downtime for every service sharing it. Each project's existing key becomes
+-- its "default" key.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(12) NOT NULL,
+    salt BYTEA NOT NULL,
+    hash BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_project_id ON api_keys(project_id);
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'projects' AND column_name = 'api_key_hash'
+    ) THEN
+        INSERT INTO api_keys (project_id, name, prefix, salt, hash, created_at)
+        SELECT id, 'default', api_key_prefix, api_key_salt, api_key_hash, created_at
+        FROM projects;
+
+        DROP INDEX IF EXISTS idx_projects_api_key_prefix;
+        ALTER TABLE projects DROP COLUMN api_key_prefix;
+        ALTER TABLE projects DROP COLUMN api_key_salt;
+        ALTER TABLE projects DROP COLUMN api_key_hash;
+    END IF;
+END $$;
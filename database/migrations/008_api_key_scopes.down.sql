@@ -0,0 +1,2 @@
+-- Every key gets full access again.
+ALTER TABLE api_keys DROP COLUMN IF EXISTS scopes;
@@ -0,0 +1,11 @@
+-- API keys are limited to scopes: logs:write (ingest), logs:read (query, search, export,
+-- tail) and project:admin (everything). Existing keys had full access, so they keep it.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL
+    DEFAULT '{logs:write,logs:read,project:admin}';
+ALTER TABLE api_keys ALTER COLUMN scopes DROP DEFAULT;
+
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_scopes_check;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_scopes_check CHECK (
+    cardinality(scopes) > 0
+    AND scopes <@ ARRAY['logs:write', 'logs:read', 'project:admin']::TEXT[]
+);
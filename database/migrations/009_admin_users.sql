@@ -0,0 +1,13 @@
+-- Admin users manage every project. Their tokens are stored like API keys: a salted
+-- SHA-256 hash plus a short public prefix used for lookup.
+CREATE TABLE IF NOT EXISTS admin_users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    token_prefix VARCHAR(12) NOT NULL,
+    token_salt BYTEA NOT NULL,
+    token_hash BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_users_token_prefix ON admin_users(token_prefix);
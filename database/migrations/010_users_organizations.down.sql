@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_projects_org_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS org_id;
+
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
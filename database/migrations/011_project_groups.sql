@@ -0,0 +1,7 @@
+-- Identity provider groups whose members may read a project's logs (OIDC sign-in)
+CREATE TABLE IF NOT EXISTS project_groups (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    group_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, group_name)
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS projects_set_updated_at ON projects;
+DROP FUNCTION IF EXISTS set_updated_at();
+ALTER TABLE projects DROP COLUMN IF EXISTS settings;
@@ -0,0 +1,5 @@
+-- Projects waiting for the reaper would come back to life, so delete them for good
+DELETE FROM projects WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_projects_deleted_at;
+ALTER TABLE projects DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,5 @@
+-- Deleted projects are hidden until the reaper removes them once the restore window
+-- has passed; NULL means the project is live
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects(deleted_at) WHERE deleted_at IS NOT NULL;
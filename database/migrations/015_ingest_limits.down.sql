@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS take_ingest_tokens(UUID, VARCHAR, DOUBLE PRECISION, DOUBLE PRECISION);
+DROP TABLE IF EXISTS ingest_usage;
+DROP TABLE IF EXISTS ingest_rate_buckets;
@@ -0,0 +1,47 @@
+-- Counters behind per-project ingestion limits (see middleware.RateLimit), shared by
+-- every API instance
+
+-- Token buckets for requests_per_second and entries_per_second
+CREATE TABLE IF NOT EXISTS ingest_rate_buckets (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    bucket VARCHAR(20) NOT NULL,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (project_id, bucket)
+);
+
+-- Entries and request body bytes ingested per UTC day, for the daily quotas
+CREATE TABLE IF NOT EXISTS ingest_usage (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    entries BIGINT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day)
+);
+
+-- Refills a bucket by the database clock and spends p_cost tokens if it isn't empty,
+-- holding the row lock throughout (see database.TokenBucket). A new bucket starts full.
+-- Returns 0 if admitted, otherwise the seconds until the bucket has tokens again.
+CREATE OR REPLACE FUNCTION take_ingest_tokens(
+    p_project_id UUID, p_bucket VARCHAR, p_rate DOUBLE PRECISION, p_cost DOUBLE PRECISION
+) RETURNS DOUBLE PRECISION AS $$
+DECLARE
+    v_now TIMESTAMPTZ := clock_timestamp();
+    v_tokens DOUBLE PRECISION;
+BEGIN
+    INSERT INTO ingest_rate_buckets AS b (project_id, bucket, tokens, updated_at)
+    VALUES (p_project_id, p_bucket, p_rate, v_now)
+    ON CONFLICT (project_id, bucket) DO UPDATE
+    SET tokens = LEAST(p_rate, b.tokens + p_rate * GREATEST(EXTRACT(EPOCH FROM v_now - b.updated_at), 0)),
+        updated_at = v_now
+    RETURNING tokens INTO v_tokens;
+
+    IF v_tokens <= 0 THEN
+        RETURN GREATEST(-v_tokens / p_rate, 0.001);
+    END IF;
+
+    UPDATE ingest_rate_buckets SET tokens = v_tokens - p_cost
+    WHERE project_id = p_project_id AND bucket = p_bucket;
+    RETURN 0;
+END;
+$$ LANGUAGE plpgsql;
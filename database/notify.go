@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"jazz/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Notify sends each payload on a PostgreSQL NOTIFY channel.
+// All payloads are sent in a single batch (one round trip).
+// Payloads must be under 8000 bytes - PostgreSQL rejects larger notifications.
+// Empty slice is a no-op and returns nil.
+func (db *DB) Notify(ctx context.Context, channel string, payloads []string) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, payload := range payloads {
+		batch.Queue(`SELECT pg_notify($1, $2)`, channel, payload)
+	}
+
+	results := db.Pool.SendBatch(ctx, batch)
+	defer func() {
+		_ = results.Close()
+	}()
+
+	for range payloads {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("failed to notify %s: %w", channel, err)
+		}
+	}
+
+	return nil
+}
+
+// Listen subscribes to a PostgreSQL NOTIFY channel and calls handle for every notification.
+// Takes a dedicated connection out of the pool for the lifetime of the call.
+// Blocks until ctx is cancelled (returns ctx.Err()) or the connection fails.
+// handle runs on the listening goroutine - slow handlers delay later notifications.
+func (db *DB) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	poolConn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire listen connection: %w", err)
+	}
+
+	// The connection keeps its LISTEN registration, so never return it to the pool
+	conn := poolConn.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed waiting for notification: %w", err)
+		}
+		handle(notification.Payload)
+	}
+}
+
+// GetLogsByIDs retrieves the log entries of a project with the given IDs.
+// IDs that don't exist (or belong to another project) are silently skipped.
+// Returns logs ordered by (timestamp, id), oldest first.
+func (db *DB) GetLogsByIDs(ctx context.Context, projectID uuid.UUID, ids []uuid.UUID) ([]models.LogEntry, error) {
+	if len(ids) == 0 {
+		return []models.LogEntry{}, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s, %s, %s, %s, %s
+		FROM logs
+		WHERE %s = $1 AND %s = ANY($2)
+		ORDER BY %s, %s
+	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
+		columnProjectID, columnID, columnTimestamp, columnID)
+
+	rows, err := db.Pool.Query(ctx, query, projectID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLogEntries(rows)
+}
@@ -0,0 +1,369 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/models"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrOrganizationNotFound is returned when an organization ID doesn't exist.
+	// Safe to expose to clients.
+	ErrOrganizationNotFound = errors.New("organization not found")
+
+	// ErrMemberNotFound is returned when a user isn't a member of an organization.
+	// Safe to expose to clients.
+	ErrMemberNotFound = errors.New("member not found")
+
+	// ErrMemberExists is returned when adding a user who is already a member.
+	// Safe to expose to clients.
+	ErrMemberExists = errors.New("user is already a member")
+
+	// ErrLastOwner is returned when a change would leave an organization without an owner.
+	// Safe to expose to clients.
+	ErrLastOwner = errors.New("organization must keep at least one owner")
+)
+
+// membershipColumns are the columns scanMembership reads, in order, from memberships m
+// joined to users u.
+const membershipColumns = "m.org_id, m.user_id, u.email, u.name, m.role, m.created_at"
+
+// CreateOrganization creates an organization with ownerID as its first owner.
+// Returns ErrUserNotFound if the owner doesn't exist.
+func (db *DB) CreateOrganization(ctx context.Context, name string, ownerID uuid.UUID) (*models.Organization, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	org := models.Organization{Name: name, Role: models.RoleOwner}
+	err = tx.QueryRow(ctx, `INSERT INTO organizations (name) VALUES ($1) RETURNING id, created_at`, name).
+		Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	org.CreatedAt = org.CreatedAt.UTC()
+
+	_, err = tx.Exec(ctx, `INSERT INTO memberships (org_id, user_id, role) VALUES ($1, $2, $3)`,
+		org.ID, ownerID, models.RoleOwner)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	log.Printf("Created organization: %s (ID: %s, owner: %s)", org.Name, org.ID, ownerID)
+	return &org, nil
+}
+
+// ListOrganizations returns the organizations userID belongs to, with their role, oldest first.
+func (db *DB) ListOrganizations(ctx context.Context, userID uuid.UUID) ([]models.Organization, error) {
+	query := `
+		SELECT o.id, o.name, m.role, o.created_at
+		FROM organizations o
+		JOIN memberships m ON m.org_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.created_at, o.id
+	`
+
+	rows, err := db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		var org models.Organization
+		if err := rows.Scan(&org.ID, &org.Name, &org.Role, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		org.CreatedAt = org.CreatedAt.UTC()
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// GetMembership returns userID's membership of orgID.
+// Returns ErrMemberNotFound if there is none, including when the organization doesn't exist.
+func (db *DB) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*models.Membership, error) {
+	query := `
+		SELECT ` + membershipColumns + `
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1 AND m.user_id = $2
+	`
+
+	member, err := scanMembership(db.Pool.QueryRow(ctx, query, orgID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return member, nil
+}
+
+// ListMembers returns an organization's members, oldest first.
+// Returns ErrOrganizationNotFound if the organization doesn't exist.
+func (db *DB) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.Membership, error) {
+	query := `
+		SELECT ` + membershipColumns + `
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1
+		ORDER BY m.created_at, u.email
+	`
+
+	rows, err := db.Pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.Membership{}
+	for rows.Next() {
+		member, err := scanMembership(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, *member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating members: %w", err)
+	}
+
+	// Organizations always have an owner, so no members means no organization
+	if len(members) == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+	return members, nil
+}
+
+// AddMember gives the user with email a role in orgID.
+// Returns ErrOrganizationNotFound, ErrUserNotFound, or ErrMemberExists if they already belong to it.
+func (db *DB) AddMember(ctx context.Context, orgID uuid.UUID, email string, role models.Role) (*models.Membership, error) {
+	query := `
+		WITH m AS (
+			INSERT INTO memberships (org_id, user_id, role)
+			SELECT $1::uuid, id, $3::varchar FROM users WHERE email = $2
+			RETURNING org_id, user_id, role, created_at
+		)
+		SELECT ` + membershipColumns + `
+		FROM m
+		JOIN users u ON u.id = m.user_id
+	`
+
+	member, err := scanMembership(db.Pool.QueryRow(ctx, query, orgID, models.NormalizeEmail(email), role))
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrUserNotFound
+		case isForeignKeyViolation(err):
+			return nil, ErrOrganizationNotFound
+		case isUniqueViolation(err):
+			return nil, ErrMemberExists
+		}
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	log.Printf("Added %s to organization %s as %s", member.Email, orgID, role)
+	return member, nil
+}
+
+// UpdateMemberRole changes a member's role.
+// Returns ErrMemberNotFound, or ErrLastOwner if it would leave the organization without an owner.
+func (db *DB) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.Role) (*models.Membership, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if role != models.RoleOwner {
+		if err := checkNotLastOwner(ctx, tx, orgID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		WITH m AS (
+			UPDATE memberships SET role = $3
+			WHERE org_id = $1 AND user_id = $2
+			RETURNING org_id, user_id, role, created_at
+		)
+		SELECT ` + membershipColumns + `
+		FROM m
+		JOIN users u ON u.id = m.user_id
+	`
+
+	member, err := scanMembership(tx.QueryRow(ctx, query, orgID, userID, role))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	log.Printf("Changed role of %s in organization %s to %s", member.Email, orgID, role)
+	return member, nil
+}
+
+// RemoveMember removes a user from an organization.
+// Returns ErrMemberNotFound, or ErrLastOwner for the organization's only owner.
+func (db *DB) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := checkNotLastOwner(ctx, tx, orgID, userID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM memberships WHERE org_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrMemberNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	log.Printf("Removed user %s from organization %s", userID, orgID)
+	return nil
+}
+
+// CreateOrganizationProject creates a project owned by orgID, with a "default" key
+// like CreateProject. Returns ErrOrganizationNotFound if the organization doesn't exist.
+func (db *DB) CreateOrganizationProject(ctx context.Context, orgID uuid.UUID, name string) (*models.Project, error) {
+	project, err := db.createProject(ctx, name, &orgID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+// ListOrganizationProjects returns an organization's projects, newest first.
+func (db *DB) ListOrganizationProjects(ctx context.Context, orgID uuid.UUID) ([]models.Project, error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE org_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := db.Pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProjects(rows)
+}
+
+// ProjectRole returns userID's role in the organization that owns projectID.
+// Returns ErrProjectNotFound, or ErrMemberNotFound if the user isn't a member or the
+// project belongs to no organization.
+func (db *DB) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (models.Role, error) {
+	query := `
+		SELECT m.role
+		FROM projects p
+		LEFT JOIN memberships m ON m.org_id = p.org_id AND m.user_id = $2
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+	`
+
+	var role *models.Role
+	if err := db.Pool.QueryRow(ctx, query, projectID, userID).Scan(&role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrProjectNotFound
+		}
+		return "", fmt.Errorf("failed to get project role: %w", err)
+	}
+	if role == nil {
+		return "", ErrMemberNotFound
+	}
+	return *role, nil
+}
+
+// checkNotLastOwner returns ErrLastOwner if userID is orgID's only owner.
+// It locks the organization's owners until tx ends, so concurrent demotions can't
+// both pass the check.
+func checkNotLastOwner(ctx context.Context, tx pgx.Tx, orgID, userID uuid.UUID) error {
+	rows, err := tx.Query(ctx, `
+		SELECT user_id FROM memberships
+		WHERE org_id = $1 AND role = $2
+		FOR UPDATE
+	`, orgID, models.RoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to get owners: %w", err)
+	}
+	defer rows.Close()
+
+	isOwner, owners := false, 0
+	for rows.Next() {
+		var ownerID uuid.UUID
+		if err := rows.Scan(&ownerID); err != nil {
+			return fmt.Errorf("failed to scan owner: %w", err)
+		}
+		owners++
+		isOwner = isOwner || ownerID == userID
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get owners: %w", err)
+	}
+
+	if isOwner && owners == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func scanMembership(row rowScanner) (*models.Membership, error) {
+	var member models.Membership
+	err := row.Scan(
+		&member.OrgID,
+		&member.UserID,
+		&member.Email,
+		&member.Name,
+		&member.Role,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	member.CreatedAt = member.CreatedAt.UTC()
+	return &member, nil
+}
@@ -0,0 +1,431 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PartitionInterval is the time span covered by one logs partition.
+type PartitionInterval string
+
+const (
+	PartitionDaily  PartitionInterval = "daily"
+	PartitionWeekly PartitionInterval = "weekly"
+)
+
+const (
+	// defaultLogPartition receives rows outside every managed partition
+	// (data migrated from the unpartitioned table, or far past/future timestamps).
+	defaultLogPartition = "logs_default"
+
+	// partitionLockID serializes partition DDL across API instances.
+	partitionLockID = 0x6a617a7a // "jazz"
+
+	partitionNameDateLayout = "20060102"
+)
+
+// Partition is a managed range partition of the logs table covering [Start, End).
+type Partition struct {
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Interval PartitionInterval
+}
+
+// PartitionConfig controls how the PartitionManager maintains the logs table.
+type PartitionConfig struct {
+	// Interval is the span of each new partition (daily or weekly).
+	Interval PartitionInterval
+
+	// Premake is how many partitions after the current one are created ahead of time.
+	Premake int
+
+	// DetachAfter detaches partitions whose range ended longer ago than this.
+	// Zero keeps every partition attached.
+	DetachAfter time.Duration
+
+	// DropDetached drops partitions after detaching them instead of leaving
+	// them behind as standalone tables.
+	DropDetached bool
+
+	// CheckInterval is how often Run performs maintenance.
+	CheckInterval time.Duration
+}
+
+// DefaultPartitionConfig returns daily partitions created a week ahead, checked hourly,
+// with nothing ever detached.
+func DefaultPartitionConfig() PartitionConfig {
+	return PartitionConfig{
+		Interval:      PartitionDaily,
+		Premake:       7,
+		CheckInterval: time.Hour,
+	}
+}
+
+// PartitionManager pre-creates future partitions of the logs table and detaches
+// expired ones. Requires the table to be partitioned (migration 003).
+// Safe to run on several API instances at once - DDL is serialized with an advisory lock.
+type PartitionManager struct {
+	db     *DB
+	config PartitionConfig
+}
+
+// NewPartitionManager validates config and creates a PartitionManager.
+// Returns error for an unknown interval or a negative Premake/DetachAfter.
+func NewPartitionManager(db *DB, config PartitionConfig) (*PartitionManager, error) {
+	if config.Interval != PartitionDaily && config.Interval != PartitionWeekly {
+		return nil, fmt.Errorf("invalid partition interval %q (must be daily or weekly)", config.Interval)
+	}
+	if config.Premake < 0 {
+		return nil, fmt.Errorf("partition premake must not be negative")
+	}
+	if config.DetachAfter < 0 {
+		return nil, fmt.Errorf("partition detach age must not be negative")
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = time.Hour
+	}
+
+	return &PartitionManager{db: db, config: config}, nil
+}
+
+// Run performs maintenance immediately and then every CheckInterval until ctx is cancelled.
+// Returns straight away if the logs table is not partitioned.
+// Failures are logged and retried on the next tick.
+func (m *PartitionManager) Run(ctx context.Context) {
+	partitioned, err := m.db.LogsPartitioned(ctx)
+	if err != nil {
+		log.Printf("partitions: failed to inspect logs table: %v", err)
+		return
+	}
+	if !partitioned {
+		log.Println("partitions: logs table is not partitioned, partition manager disabled")
+		return
+	}
+
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Maintain(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("partitions: maintenance failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain creates missing partitions and detaches expired ones as of now.
+func (m *PartitionManager) Maintain(ctx context.Context, now time.Time) error {
+	created, err := m.EnsurePartitions(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, name := range created {
+		log.Printf("partitions: created %s", name)
+	}
+
+	detached, err := m.DetachExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, name := range detached {
+		log.Printf("partitions: detached %s", name)
+	}
+
+	return nil
+}
+
+// EnsurePartitions creates the partition containing now plus Premake following ones.
+// Ranges already covered by an existing partition (possibly of a different interval)
+// are skipped. Rows in the default partition that fall into a new range are moved into it.
+// Returns the names of the partitions created.
+func (m *PartitionManager) EnsurePartitions(ctx context.Context, now time.Time) ([]string, error) {
+	existing, err := m.db.ListLogPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	start := partitionStart(now, m.config.Interval)
+	for i := 0; i <= m.config.Premake; i++ {
+		p := newPartition(start, m.config.Interval)
+		start = p.End
+
+		if overlapsAny(p, existing) {
+			continue
+		}
+
+		ok, err := m.db.createLogPartition(ctx, p)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, p.Name)
+		}
+	}
+
+	return created, nil
+}
+
+// DetachExpired detaches (and with DropDetached, drops) partitions whose range ended
+// more than DetachAfter before now. No-op when DetachAfter is zero.
+// The default partition is never detached.
+// Returns the names of the partitions detached.
+func (m *PartitionManager) DetachExpired(ctx context.Context, now time.Time) ([]string, error) {
+	if m.config.DetachAfter == 0 {
+		return nil, nil
+	}
+
+	partitions, err := m.db.ListLogPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := now.Add(-m.config.DetachAfter)
+	var detached []string
+	for _, p := range partitions {
+		if p.End.After(cutoff) {
+			continue
+		}
+		if err := m.db.detachLogPartition(ctx, p.Name, m.config.DropDetached); err != nil {
+			return detached, err
+		}
+		detached = append(detached, p.Name)
+	}
+
+	return detached, nil
+}
+
+// LogsPartitioned reports whether the logs table uses native partitioning.
+func (db *DB) LogsPartitioned(ctx context.Context) (bool, error) {
+	var partitioned bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(
+			(SELECT relkind = 'p' FROM pg_class WHERE oid = to_regclass('logs')),
+			false
+		)
+	`).Scan(&partitioned)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect logs table: %w", err)
+	}
+	return partitioned, nil
+}
+
+// ListLogPartitions returns the managed partitions attached to logs, oldest first.
+// Partitions not named by the manager (including the default partition) are omitted.
+func (db *DB) ListLogPartitions(ctx context.Context) ([]Partition, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass('logs')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log partitions: %w", err)
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan log partitions: %w", err)
+	}
+
+	var partitions []Partition
+	for _, name := range names {
+		if p, ok := parsePartitionName(name); ok {
+			partitions = append(partitions, p)
+		}
+	}
+
+	slices.SortFunc(partitions, func(a, b Partition) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return partitions, nil
+}
+
+// createLogPartition creates p unless another instance did so first.
+// CREATE TABLE ... PARTITION OF fails while the default partition holds rows in the
+// new range, so those rows are moved across in the same transaction.
+func (db *DB) createLogPartition(ctx context.Context, p Partition) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, partitionLockID); err != nil {
+		return false, fmt.Errorf("failed to lock partitions: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, p.Name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check partition %s: %w", p.Name, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	var hasDefault, needsMove bool
+	if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, defaultLogPartition).Scan(&hasDefault); err != nil {
+		return false, fmt.Errorf("failed to check default partition: %w", err)
+	}
+	if hasDefault {
+		err := tx.QueryRow(ctx, fmt.Sprintf(`
+			SELECT EXISTS (SELECT 1 FROM %s WHERE %s >= $1 AND %s < $2)
+		`, defaultLogPartition, columnTimestamp, columnTimestamp), p.Start, p.End).Scan(&needsMove)
+		if err != nil {
+			return false, fmt.Errorf("failed to check default partition: %w", err)
+		}
+	}
+
+	// SAFETY: names and bounds are generated from time values, never user input
+	name := pgx.Identifier{p.Name}.Sanitize()
+	create := fmt.Sprintf(`CREATE TABLE %s PARTITION OF logs FOR VALUES FROM ('%s') TO ('%s')`,
+		name, p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339))
+
+	if !needsMove {
+		if _, err := tx.Exec(ctx, create); err != nil {
+			return false, fmt.Errorf("failed to create partition %s: %w", p.Name, err)
+		}
+		return true, tx.Commit(ctx)
+	}
+
+	columns := strings.Join([]string{
+		columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp, "created_at",
+	}, ", ")
+	bounds := []interface{}{p.Start, p.End}
+	statements := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{sql: fmt.Sprintf(`ALTER TABLE logs DETACH PARTITION %s`, defaultLogPartition)},
+		{sql: create},
+		{sql: fmt.Sprintf(`INSERT INTO logs (%s) SELECT %s FROM %s WHERE %s >= $1 AND %s < $2`,
+			columns, columns, defaultLogPartition, columnTimestamp, columnTimestamp), args: bounds},
+		{sql: fmt.Sprintf(`DELETE FROM %s WHERE %s >= $1 AND %s < $2`,
+			defaultLogPartition, columnTimestamp, columnTimestamp), args: bounds},
+		{sql: fmt.Sprintf(`ALTER TABLE logs ATTACH PARTITION %s DEFAULT`, defaultLogPartition)},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt.sql, stmt.args...); err != nil {
+			return false, fmt.Errorf("failed to create partition %s: %w", p.Name, err)
+		}
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+func (db *DB) detachLogPartition(ctx context.Context, name string, drop bool) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, partitionLockID); err != nil {
+		return fmt.Errorf("failed to lock partitions: %w", err)
+	}
+
+	// Another instance may have detached it already
+	var attached bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_inherits
+			WHERE inhparent = to_regclass('logs') AND inhrelid = to_regclass($1)
+		)
+	`, name).Scan(&attached)
+	if err != nil {
+		return fmt.Errorf("failed to check partition %s: %w", name, err)
+	}
+	if !attached {
+		return nil
+	}
+
+	ident := pgx.Identifier{name}.Sanitize()
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE logs DETACH PARTITION %s`, ident)); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", name, err)
+	}
+	if drop {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DROP TABLE %s`, ident)); err != nil {
+			return fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// partitionStart returns the UTC start of the interval containing t.
+// Weekly partitions start on Monday.
+func partitionStart(t time.Time, interval PartitionInterval) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if interval == PartitionWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// newPartition describes the partition of interval starting at start.
+// Daily partitions are named logs_pYYYYMMDD and weekly ones logs_wYYYYMMDD.
+func newPartition(start time.Time, interval PartitionInterval) Partition {
+	if interval == PartitionWeekly {
+		return Partition{
+			Name:     "logs_w" + start.Format(partitionNameDateLayout),
+			Start:    start,
+			End:      start.AddDate(0, 0, 7),
+			Interval: PartitionWeekly,
+		}
+	}
+	return Partition{
+		Name:     "logs_p" + start.Format(partitionNameDateLayout),
+		Start:    start,
+		End:      start.AddDate(0, 0, 1),
+		Interval: PartitionDaily,
+	}
+}
+
+// parsePartitionName reverses newPartition. Returns false for names the
+// manager did not generate.
+func parsePartitionName(name string) (Partition, bool) {
+	var interval PartitionInterval
+	switch {
+	case strings.HasPrefix(name, "logs_p"):
+		interval = PartitionDaily
+	case strings.HasPrefix(name, "logs_w"):
+		interval = PartitionWeekly
+	default:
+		return Partition{}, false
+	}
+
+	start, err := time.Parse(partitionNameDateLayout, name[len("logs_p"):])
+	if err != nil || (interval == PartitionWeekly && start.Weekday() != time.Monday) {
+		return Partition{}, false
+	}
+
+	return newPartition(start, interval), true
+}
+
+func overlapsAny(p Partition, partitions []Partition) bool {
+	for _, other := range partitions {
+		if p.Start.Before(other.End) && other.Start.Before(p.End) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"jazz/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dropLogPartitions removes managed partitions (attached or detached) so tests
+// start with only the default partition.
+func dropLogPartitions(t *testing.T, db *DB) {
+	t.Helper()
+
+	ctx := context.Background()
+	rows, err := db.Pool.Query(ctx, `
+		SELECT relname FROM pg_class
+		WHERE relkind = 'r' AND relname ~ '^logs_[pw][0-9]{8}$'
+	`)
+	require.NoError(t, err)
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+
+	for _, name := range names {
+		_, err := db.Pool.Exec(ctx, "DROP TABLE "+pgx.Identifier{name}.Sanitize())
+		require.NoError(t, err)
+	}
+}
+
+func TestPartitionManager_EnsurePartitions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+	dropLogPartitions(t, db)
+	t.Cleanup(func() { dropLogPartitions(t, db) })
+
+	ctx := context.Background()
+
+	partitioned, err := db.LogsPartitioned(ctx)
+	require.NoError(t, err)
+	require.True(t, partitioned)
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	// Lands in the default partition until its day's partition exists
+	now := time.Date(2030, 3, 14, 12, 0, 0, 0, time.UTC)
+	entry := models.LogEntry{
+		ID:        uuid.New(),
+		ProjectID: project.ID,
+		Level:     "info",
+		Message:   "Before partitioning",
+		Timestamp: now,
+	}
+	require.NoError(t, db.InsertLogsBatch(ctx, []models.LogEntry{entry}))
+
+	manager, err := NewPartitionManager(db, PartitionConfig{Interval: PartitionDaily, Premake: 2})
+	require.NoError(t, err)
+
+	created, err := manager.EnsurePartitions(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs_p20300314", "logs_p20300315", "logs_p20300316"}, created)
+
+	// Idempotent
+	created, err = manager.EnsurePartitions(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+
+	var partition string
+	err = db.Pool.QueryRow(ctx, `SELECT tableoid::regclass::text FROM logs WHERE id = $1`, entry.ID).Scan(&partition)
+	require.NoError(t, err)
+	assert.Equal(t, "logs_p20300314", partition, "row should move out of the default partition")
+
+	// A weekly manager skips ranges the daily partitions already cover
+	weekly, err := NewPartitionManager(db, PartitionConfig{Interval: PartitionWeekly, Premake: 1})
+	require.NoError(t, err)
+	created, err = weekly.EnsurePartitions(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs_w20300318"}, created)
+}
+
+func TestPartitionManager_DetachExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+	dropLogPartitions(t, db)
+	t.Cleanup(func() { dropLogPartitions(t, db) })
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	start := time.Date(2030, 3, 14, 0, 0, 0, 0, time.UTC)
+	manager, err := NewPartitionManager(db, PartitionConfig{
+		Interval:    PartitionDaily,
+		Premake:     2,
+		DetachAfter: 48 * time.Hour,
+	})
+	require.NoError(t, err)
+	_, err = manager.EnsurePartitions(ctx, start)
+	require.NoError(t, err)
+
+	old := models.LogEntry{
+		ID:        uuid.New(),
+		ProjectID: project.ID,
+		Level:     "info",
+		Message:   "Old",
+		Timestamp: start.Add(time.Hour),
+	}
+	require.NoError(t, db.InsertLogsBatch(ctx, []models.LogEntry{old}))
+
+	// 03-14 ended at 03-15 00:00, more than 48h before 03-17 06:00; 03-15 ended less than 48h before
+	detached, err := manager.DetachExpired(ctx, time.Date(2030, 3, 17, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs_p20300314"}, detached)
+
+	partitions, err := db.ListLogPartitions(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, p := range partitions {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"logs_p20300315", "logs_p20300316"}, names)
+
+	// Detached rows leave the logs table but stay in the standalone table
+	_, err = db.GetLog(ctx, project.ID, old.ID)
+	assert.ErrorIs(t, err, ErrLogNotFound)
+
+	var count int
+	err = db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM logs_p20300314`).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestQueryLogs_PartitionPruning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+	dropLogPartitions(t, db)
+	t.Cleanup(func() { dropLogPartitions(t, db) })
+
+	ctx := context.Background()
+
+	manager, err := NewPartitionManager(db, PartitionConfig{Interval: PartitionDaily, Premake: 2})
+	require.NoError(t, err)
+	_, err = manager.EnsurePartitions(ctx, time.Date(2030, 3, 14, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	// Same filter construction QueryLogs uses
+	qb := NewQueryBuilder()
+	err = filterFromQueryParams(models.QueryParams{
+		StartTime: "2030-03-15T01:00:00Z",
+		EndTime:   "2030-03-15T23:00:00Z",
+	}).apply(qb, uuid.New())
+	require.NoError(t, err)
+
+	rows, err := db.Pool.Query(ctx, "EXPLAIN SELECT id FROM logs "+qb.WhereClause(), qb.Args()...)
+	require.NoError(t, err)
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+	plan := strings.Join(lines, "\n")
+
+	assert.Contains(t, plan, "logs_p20300315")
+	assert.NotContains(t, plan, "logs_p20300314")
+	assert.NotContains(t, plan, "logs_p20300316")
+	assert.NotContains(t, plan, "logs_default")
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionStart(t *testing.T) {
+	// Thursday 2024-11-21 23:30 UTC, already Friday in Tokyo
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	at := time.Date(2024, 11, 22, 8, 30, 0, 0, tokyo)
+
+	assert.Equal(t, time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC), partitionStart(at, PartitionDaily))
+	assert.Equal(t, time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC), partitionStart(at, PartitionWeekly))
+
+	monday := time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, partitionStart(monday, PartitionWeekly))
+}
+
+func TestNewPartition(t *testing.T) {
+	start := time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC)
+
+	daily := newPartition(start, PartitionDaily)
+	assert.Equal(t, "logs_p20241118", daily.Name)
+	assert.Equal(t, start.AddDate(0, 0, 1), daily.End)
+
+	weekly := newPartition(start, PartitionWeekly)
+	assert.Equal(t, "logs_w20241118", weekly.Name)
+	assert.Equal(t, start.AddDate(0, 0, 7), weekly.End)
+}
+
+func TestParsePartitionName(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		wantOK bool
+		want   Partition
+	}{
+		{
+			name:   "daily",
+			input:  "logs_p20241122",
+			wantOK: true,
+			want:   newPartition(time.Date(2024, 11, 22, 0, 0, 0, 0, time.UTC), PartitionDaily),
+		},
+		{
+			name:   "weekly",
+			input:  "logs_w20241118",
+			wantOK: true,
+			want:   newPartition(time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC), PartitionWeekly),
+		},
+		{name: "default partition", input: "logs_default"},
+		{name: "weekly not on monday", input: "logs_w20241122"},
+		{name: "invalid date", input: "logs_p20241332"},
+		{name: "other table", input: "projects"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := parsePartitionName(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.want, p)
+			}
+		})
+	}
+}
+
+func TestOverlapsAny(t *testing.T) {
+	week := newPartition(time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC), PartitionWeekly)
+
+	inside := newPartition(time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC), PartitionDaily)
+	adjacent := newPartition(week.End, PartitionDaily)
+
+	assert.True(t, overlapsAny(inside, []Partition{week}))
+	assert.False(t, overlapsAny(adjacent, []Partition{week}))
+	assert.False(t, overlapsAny(inside, nil))
+}
+
+func TestNewPartitionManager_Validation(t *testing.T) {
+	_, err := NewPartitionManager(nil, PartitionConfig{Interval: "hourly"})
+	assert.Error(t, err)
+
+	_, err = NewPartitionManager(nil, PartitionConfig{Interval: PartitionDaily, Premake: -1})
+	assert.Error(t, err)
+
+	m, err := NewPartitionManager(nil, PartitionConfig{Interval: PartitionWeekly})
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, m.config.CheckInterval)
+}
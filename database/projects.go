@@ -2,91 +2,193 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"jazz/models"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
-// GetProjectByAPIKey validates an API key and returns the associated project.
-// Used by authentication middleware to verify requests.
-// Returns error with "invalid API key" message if key not found (safe to expose to client).
-// Returns error with technical details if database fails (log server-side only).
-func (db *DB) GetProjectByAPIKey(ctx context.Context, apiKey string) (*models.Project, error) {
-	query := `
-		SELECT id, name, api_key, created_at, updated_at
-		FROM projects
-		WHERE api_key = $1
-	`
+var (
+	// ErrProjectNotFound is returned when a project ID doesn't exist or the project
+	// was deleted. Safe to expose to clients.
+	ErrProjectNotFound = errors.New("project not found")
 
-	project, err := scanProject(db.Pool.QueryRow(ctx, query, apiKey))
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("invalid API key")
-		}
-		return nil, fmt.Errorf("failed to get project: %w", err)
-	}
+	// ErrProjectNotDeleted is returned when restoring a project that isn't deleted.
+	// Safe to expose to clients.
+	ErrProjectNotDeleted = errors.New("project is not deleted")
+)
 
-	return project, nil
-}
+// Columns ListProjects sorts by
+const (
+	columnName      = "name"
+	columnCreatedAt = "created_at"
+)
 
-// CreateProject creates a new project with a generated API key.
-// API key format: "jazz_" + UUID v4 (e.g., "jazz_a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11").
-// API key is returned in plaintext only once - store it securely.
+// The stats ListProjects aggregates from logs
+const (
+	columnLogCount     = "log_count"
+	columnLastLogAt    = "last_log_at"
+	columnStorageBytes = "storage_bytes"
+)
+
+var (
+	// projectSortColumns are the columns ListProjects accepts in params.Sort
+	projectSortColumns = []string{columnName, columnCreatedAt}
+	defaultProjectSort = []SortField{{Column: columnCreatedAt, Descending: true}}
+)
+
+// projectColumns are the columns scanProject reads, in order.
+const projectColumns = "id, name, org_id, retention_days, settings, created_at, updated_at"
+
+// CreateProject creates a new project together with its first API key, named
+// "default", which has every scope (see CreateAPIKey). The returned project's APIKey is the only time the
+// plaintext key is available - store it securely.
 // Returns the created project with all fields populated, including timestamps.
 func (db *DB) CreateProject(ctx context.Context, name string) (*models.Project, error) {
-	apiKey := generateAPIKey()
+	return db.createProject(ctx, name, nil)
+}
+
+// createProject creates a project, owned by orgID if it isn't nil, with its "default" key.
+func (db *DB) createProject(ctx context.Context, name string, orgID *uuid.UUID) (*models.Project, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	query := `
-		INSERT INTO projects (name, api_key)
+		INSERT INTO projects (name, org_id)
 		VALUES ($1, $2)
-		RETURNING id, name, api_key, created_at, updated_at
-	`
+		RETURNING ` + projectColumns
+
+	project, err := scanProject(tx.QueryRow(ctx, query, name, orgID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
 
-	project, err := scanProject(db.Pool.QueryRow(ctx, query, name, apiKey))
+	key, err := insertAPIKey(ctx, tx, project.ID, DefaultAPIKeyName, models.AllScopes, nil)
 	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
+	project.APIKey = key.Key
 
 	log.Printf("Created project: %s (ID: %s)", project.Name, project.ID)
 	return project, nil
 }
 
-// ListProjects returns all projects ordered by creation date (newest first).
-// No pagination - suitable for small number of projects (<1000).
-// Returns empty slice (not nil) if no projects exist.
-func (db *DB) ListProjects(ctx context.Context) ([]models.Project, error) {
-	query := `
-		SELECT id, name, api_key, created_at, updated_at
-		FROM projects
-		ORDER BY created_at DESC
-	`
+// ListProjects returns a page of projects, leaving out deleted ones, and the number
+// of projects matching the filter. Each project's Stats are aggregated from its logs.
+//
+// Params:
+//   - Search: case-insensitive substring of the project name
+//   - Sort: see ProjectSort (default created_at DESC, newest first; id breaks ties)
+//   - Limit: max results (default 50, max 1000)
+//   - Offset: pagination offset (default 0)
+//
+// Stats are only aggregated for the page's projects, after paginating, so the listing
+// stays cheap however many projects exist; that is also why stats can't be sorted by.
+// Returns empty slice (not nil) if no projects match.
+func (db *DB) ListProjects(ctx context.Context, params models.ProjectListParams) ([]models.Project, int, error) {
+	sortFields, err := ProjectSort(params.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	limit, offset := Page(params.Limit, params.Offset)
+	orderBy := OrderByClause(sortFields)
+
+	// $1 = name search ('' matches every project), $2 = limit, $3 = offset
+	query := fmt.Sprintf(`
+		SELECT
+			p.id, p.name, p.org_id, p.retention_days, p.settings, p.created_at, p.updated_at,
+			s.%s, s.%s, s.%s, p.total_count
+		FROM (
+			SELECT %s, COUNT(*) OVER() AS total_count
+			FROM projects
+			WHERE deleted_at IS NULL AND strpos(lower(name), lower($1)) > 0
+			%s
+			LIMIT $2 OFFSET $3
+		) p
+		CROSS JOIN LATERAL (
+			SELECT
+				COUNT(*) AS %s,
+				MAX(l.%s) AS %s,
+				COALESCE(SUM(pg_column_size(l.*)), 0) AS %s
+			FROM logs l
+			WHERE l.%s = p.id
+		) s
+		%s
+	`, columnLogCount, columnLastLogAt, columnStorageBytes, projectColumns, orderBy,
+		columnLogCount, columnTimestamp, columnLastLogAt, columnStorageBytes, columnProjectID,
+		orderBy)
 
-	rows, err := db.Pool.Query(ctx, query)
+	rows, err := db.Pool.Query(ctx, query, params.Search, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list projects: %w", err)
+		return nil, 0, fmt.Errorf("failed to list projects: %w", err)
 	}
 	defer rows.Close()
 
-	return scanProjects(rows)
+	projects := []models.Project{}
+	var total int
+	for rows.Next() {
+		var (
+			project models.Project
+			stats   models.ProjectStats
+		)
+		err := rows.Scan(
+			&project.ID, &project.Name, &project.OrgID, &project.RetentionDays, &project.Settings,
+			&project.CreatedAt, &project.UpdatedAt,
+			&stats.LogCount, &stats.LastLogAt, &stats.StorageBytes, &total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan project: %w", err)
+		}
+		project.CreatedAt = project.CreatedAt.UTC()
+		project.UpdatedAt = project.UpdatedAt.UTC()
+		if stats.LastLogAt != nil {
+			last := stats.LastLogAt.UTC()
+			stats.LastLogAt = &last
+		}
+		project.Stats = &stats
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating projects: %w", err)
+	}
+
+	return projects, total, nil
+}
+
+// ProjectSort validates a ProjectListParams sort expression over "name" and
+// "created_at" (default created_at descending).
+// Returns *QueryError for unknown columns or directions.
+func ProjectSort(expr string) ([]SortField, error) {
+	return parseSort(expr, projectSortColumns, defaultProjectSort)
 }
 
 // GetProject retrieves a single project by ID.
-// Returns error with "project not found" if ID doesn't exist.
+// Returns ErrProjectNotFound if ID doesn't exist or the project was deleted.
 // Used for project detail views and validation.
 func (db *DB) GetProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error) {
 	query := `
-		SELECT id, name, api_key, created_at, updated_at
+		SELECT ` + projectColumns + `
 		FROM projects
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	project, err := scanProject(db.Pool.QueryRow(ctx, query, projectID))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("project not found")
+			return nil, ErrProjectNotFound
 		}
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
@@ -94,12 +196,39 @@ func (db *DB) GetProject(ctx context.Context, projectID uuid.UUID) (*models.Proj
 	return project, nil
 }
 
-// DeleteProject removes a project and all its logs (CASCADE).
-// This is a destructive operation that cannot be undone.
-// Returns error with "project not found" if ID doesn't exist.
+// UpdateProject changes the fields req sets: the name, the retention policy (nil
+// keeps logs forever) and the settings document, which is replaced as a whole.
+// updated_at is maintained by the projects_set_updated_at trigger.
+// Returns ErrProjectNotFound if ID doesn't exist or the project was deleted.
+func (db *DB) UpdateProject(ctx context.Context, projectID uuid.UUID, req models.UpdateProjectRequest) (*models.Project, error) {
+	query := `
+		UPDATE projects
+		SET name = COALESCE($2, name),
+			retention_days = CASE WHEN $3 THEN $4 ELSE retention_days END,
+			settings = COALESCE($5, settings)
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + projectColumns
+
+	project, err := scanProject(db.Pool.QueryRow(ctx, query,
+		projectID, req.Name, req.SetRetention, req.RetentionDays, req.Settings))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	log.Printf("Updated project: %s (ID: %s)", project.Name, project.ID)
+	return project, nil
+}
+
+// DeleteProject marks a project deleted. It disappears from every read and its keys
+// stop authenticating, but nothing is removed: RestoreProject brings it back until the
+// reaper purges it (see ListDeletedProjects).
+// Returns ErrProjectNotFound if ID doesn't exist or the project is already deleted.
 // Logs the deletion for audit trail.
 func (db *DB) DeleteProject(ctx context.Context, projectID uuid.UUID) error {
-	query := `DELETE FROM projects WHERE id = $1`
+	query := `UPDATE projects SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
 
 	result, err := db.Pool.Exec(ctx, query, projectID)
 	if err != nil {
@@ -107,19 +236,129 @@ func (db *DB) DeleteProject(ctx context.Context, projectID uuid.UUID) error {
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("project not found")
+		return ErrProjectNotFound
 	}
 
 	log.Printf("Deleted project: %s", projectID)
 	return nil
 }
 
-// Helper functions
+// RestoreProject undeletes a project deleted at or after deletedSince.
+// Returns ErrProjectNotDeleted if the project is live, or ErrProjectNotFound if ID
+// doesn't exist or it was deleted before deletedSince.
+func (db *DB) RestoreProject(ctx context.Context, projectID uuid.UUID, deletedSince time.Time) (*models.Project, error) {
+	query := `
+		UPDATE projects
+		SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at >= $2
+		RETURNING ` + projectColumns
+
+	project, err := scanProject(db.Pool.QueryRow(ctx, query, projectID, deletedSince))
+	if err == nil {
+		log.Printf("Restored project: %s (ID: %s)", project.Name, project.ID)
+		return project, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to restore project: %w", err)
+	}
+
+	var live bool
+	err = db.Pool.QueryRow(ctx, `SELECT deleted_at IS NULL FROM projects WHERE id = $1`, projectID).Scan(&live)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrProjectNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to restore project: %w", err)
+	case live:
+		return nil, ErrProjectNotDeleted
+	}
+	return nil, ErrProjectNotFound
+}
+
+// GetDeletedProject returns a deleted project as it was when deleted.
+// Returns ErrProjectNotDeleted for a live project and ErrProjectNotFound if ID doesn't exist.
+func (db *DB) GetDeletedProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	project, err := scanProject(db.Pool.QueryRow(ctx, query, projectID))
+	if err == nil {
+		return project, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get deleted project: %w", err)
+	}
+
+	if _, err := db.GetProject(ctx, projectID); err == nil {
+		return nil, ErrProjectNotDeleted
+	}
+	return nil, ErrProjectNotFound
+}
+
+// ListDeletedProjects returns the projects deleted before deletedBefore, oldest deletion first.
+func (db *DB) ListDeletedProjects(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id FROM projects
+		WHERE deleted_at < $1
+		ORDER BY deleted_at
+	`, deletedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted projects: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan deleted projects: %w", err)
+	}
+	return ids, nil
+}
+
+// PurgeDeletedProjectLogs deletes up to batchSize of the logs of a project deleted
+// before deletedBefore. Call repeatedly until it returns fewer than batchSize rows,
+// like PurgeExpiredLogs. Returns the number of rows deleted.
+func (db *DB) PurgeDeletedProjectLogs(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time, batchSize int) (int64, error) {
+	// The project check stops a purge racing a restore from deleting a live project's logs
+	query := fmt.Sprintf(`
+		DELETE FROM logs
+		WHERE (%s, %s) IN (
+			SELECT %s, %s FROM logs
+			WHERE %s = $1
+			  AND EXISTS (SELECT 1 FROM projects WHERE id = $1 AND deleted_at < $2)
+			LIMIT $3
+		)
+	`, columnID, columnTimestamp, columnID, columnTimestamp, columnProjectID)
+
+	result, err := db.Pool.Exec(ctx, query, projectID, deletedBefore, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted project logs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// PurgeDeletedProject permanently removes a project deleted before deletedBefore,
+// cascading to its API keys, groups, retention runs and any logs still left.
+// Returns ErrProjectNotFound if there's no such project.
+func (db *DB) PurgeDeletedProject(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time) error {
+	result, err := db.Pool.Exec(ctx,
+		`DELETE FROM projects WHERE id = $1 AND deleted_at < $2`, projectID, deletedBefore)
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted project: %w", err)
+	}
 
-func generateAPIKey() string {
-	return fmt.Sprintf("jazz_%s", uuid.New().String())
+	if result.RowsAffected() == 0 {
+		return ErrProjectNotFound
+	}
+
+	log.Printf("Purged deleted project: %s", projectID)
+	return nil
 }
 
+// Helper functions
+
 type rowScanner interface {
 	Scan(dest ...interface{}) error
 }
@@ -129,13 +368,17 @@ func scanProject(row rowScanner) (*models.Project, error) {
 	err := row.Scan(
 		&project.ID,
 		&project.Name,
-		&project.APIKey,
+		&project.OrgID,
+		&project.RetentionDays,
+		&project.Settings,
 		&project.CreatedAt,
 		&project.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	project.CreatedAt = project.CreatedAt.UTC()
+	project.UpdatedAt = project.UpdatedAt.UTC()
 	return &project, nil
 }
 
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"jazz/models"
 	"testing"
 
 	"github.com/google/uuid"
@@ -29,7 +30,7 @@ func TestCreateProject(t *testing.T) {
 	assert.False(t, project.UpdatedAt.IsZero())
 }
 
-func TestGetProjectByAPIKey(t *testing.T) {
+func TestAuthenticateAPIKey(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
@@ -42,15 +43,17 @@ func TestGetProjectByAPIKey(t *testing.T) {
 	created, err := db.CreateProject(ctx, "Test Project")
 	require.NoError(t, err)
 
-	retrieved, err := db.GetProjectByAPIKey(ctx, created.APIKey)
+	key, err := db.AuthenticateAPIKey(ctx, created.APIKey)
 	require.NoError(t, err)
 
-	assert.Equal(t, created.ID, retrieved.ID)
-	assert.Equal(t, created.Name, retrieved.Name)
-	assert.Equal(t, created.APIKey, retrieved.APIKey)
+	assert.Equal(t, created.ID, key.ProjectID)
+	assert.Equal(t, DefaultAPIKeyName, key.Name)
+	assert.Equal(t, created.APIKey[:len(key.Prefix)], key.Prefix)
+	assert.Empty(t, key.Key, "only the hash is stored")
+	require.NotNil(t, key.LastUsedAt)
 }
 
-func TestGetProjectByAPIKey_Invalid(t *testing.T) {
+func TestAuthenticateAPIKey_Invalid(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
@@ -60,8 +63,8 @@ func TestGetProjectByAPIKey_Invalid(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := db.GetProjectByAPIKey(ctx, "invalid_key")
-	assert.Error(t, err)
+	_, err := db.AuthenticateAPIKey(ctx, "invalid_key")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
 	assert.Contains(t, err.Error(), "invalid API key")
 }
 
@@ -75,9 +78,10 @@ func TestListProjects(t *testing.T) {
 
 	ctx := context.Background()
 
-	projects, err := db.ListProjects(ctx)
+	projects, total, err := db.ListProjects(ctx, models.ProjectListParams{})
 	require.NoError(t, err)
 	assert.Empty(t, projects)
+	assert.Zero(t, total)
 
 	_, err = db.CreateProject(ctx, "Project 1")
 	require.NoError(t, err)
@@ -86,9 +90,10 @@ func TestListProjects(t *testing.T) {
 	_, err = db.CreateProject(ctx, "Project 3")
 	require.NoError(t, err)
 
-	projects, err = db.ListProjects(ctx)
+	projects, total, err = db.ListProjects(ctx, models.ProjectListParams{})
 	require.NoError(t, err)
 	assert.Len(t, projects, 3)
+	assert.Equal(t, 3, total)
 }
 
 func TestGetProject(t *testing.T) {
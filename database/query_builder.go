@@ -2,6 +2,8 @@ package database
 
 import (
 	"fmt"
+	"jazz/models"
+	"slices"
 	"strings"
 	"time"
 )
@@ -13,8 +15,17 @@ const (
 	columnMessage   = "message"
 	columnSource    = "source"
 	columnTimestamp = "timestamp"
+
+	// columnRank is the ts_rank alias selected by SearchLogs
+	columnRank = "rank"
 )
 
+// SortField is a single validated ORDER BY key.
+type SortField struct {
+	Column     string
+	Descending bool
+}
+
 // QueryBuilder helps build WHERE clauses safely
 type QueryBuilder struct {
 	conditions []string
@@ -43,7 +54,8 @@ func (qb *QueryBuilder) AddCondition(column string, value interface{}) {
 }
 
 // AddTimeRange adds timestamp range conditions (start and/or end).
-// Times must be in RFC3339 format (e.g., "2024-11-22T10:30:00Z").
+// Accepts every format TimeParser supports, evaluated in UTC relative to the current time
+// (e.g., "2024-11-22T10:30:00Z", "1732271400", "now-15m").
 // Both parameters are optional - empty string skips that bound.
 // Returns error if time format is invalid.
 //
@@ -52,8 +64,15 @@ func (qb *QueryBuilder) AddCondition(column string, value interface{}) {
 //	AddTimeRange("timestamp", "2024-11-01T00:00:00Z", "2024-11-22T23:59:59Z")
 //	→ "timestamp >= $1 AND timestamp <= $2"
 func (qb *QueryBuilder) AddTimeRange(column, start, end string) error {
+	return qb.AddTimeRangeWith(column, start, end, NewTimeParser(time.Now(), time.UTC))
+}
+
+// AddTimeRangeWith is AddTimeRange with an explicit TimeParser,
+// used when relative times and local timestamps must be resolved in a request's time zone.
+// Both bounds are resolved against the same "now", so "now-1h" to "now" spans exactly one hour.
+func (qb *QueryBuilder) AddTimeRangeWith(column, start, end string, parser *TimeParser) error {
 	if start != "" {
-		startTime, err := parseRFC3339(start)
+		startTime, err := parser.Parse(start)
 		if err != nil {
 			return fmt.Errorf("invalid start_time: %w", err)
 		}
@@ -63,7 +82,7 @@ func (qb *QueryBuilder) AddTimeRange(column, start, end string) error {
 	}
 
 	if end != "" {
-		endTime, err := parseRFC3339(end)
+		endTime, err := parser.Parse(end)
 		if err != nil {
 			return fmt.Errorf("invalid end_time: %w", err)
 		}
@@ -75,13 +94,40 @@ func (qb *QueryBuilder) AddTimeRange(column, start, end string) error {
 	return nil
 }
 
+// AddBounds adds inclusive range conditions on column for whichever of start and end
+// is set.
+//
+// Example:
+//
+//	AddBounds("occurred_at", &start, nil)
+//	→ "occurred_at >= $1"
+func (qb *QueryBuilder) AddBounds(column string, start, end *time.Time) {
+	if start != nil {
+		qb.conditions = append(qb.conditions, fmt.Sprintf("%s >= $%d", column, qb.argCount))
+		qb.args = append(qb.args, *start)
+		qb.argCount++
+	}
+	if end != nil {
+		qb.conditions = append(qb.conditions, fmt.Sprintf("%s <= $%d", column, qb.argCount))
+		qb.args = append(qb.args, *end)
+		qb.argCount++
+	}
+}
+
 // AddFullTextSearch adds PostgreSQL full-text search condition.
 // Uses to_tsvector and to_tsquery for GIN index optimization.
 // searchQuery must already be in tsquery format (e.g., "hello & world").
 // Column is assumed to be 'message' - this is hardcoded for Jazz's use case.
 func (qb *QueryBuilder) AddFullTextSearch(searchQuery string) {
+	qb.AddFullTextSearchIn(models.DefaultSearchLanguage, searchQuery)
+}
+
+// AddFullTextSearchIn is AddFullTextSearch with another text search configuration.
+// language is written into the SQL, so it must be one of models.SearchLanguages.
+// Only english matches the GIN index; other languages scan the project's rows.
+func (qb *QueryBuilder) AddFullTextSearchIn(language, searchQuery string) {
 	qb.conditions = append(qb.conditions,
-		fmt.Sprintf("to_tsvector('english', %s) @@ to_tsquery('english', $%d)", columnMessage, qb.argCount))
+		fmt.Sprintf("to_tsvector('%[1]s', %[2]s) @@ to_tsquery('%[1]s', $%[3]d)", language, columnMessage, qb.argCount))
 	qb.args = append(qb.args, searchQuery)
 	qb.argCount++
 }
@@ -111,8 +157,79 @@ func (qb *QueryBuilder) NextArgNum() int {
 	return qb.argCount
 }
 
+// ParseSort validates a sort expression against a whitelist of columns.
+// Format: comma-separated "column" or "column:asc|desc" (default asc),
+// e.g. "timestamp:asc" or "level,timestamp:desc".
+// Empty expression returns defaultSort.
+// Returns error for unknown columns or directions and for repeated columns.
+//
+// Only whitelisted column names ever reach the SQL, so the result is safe to interpolate.
+func ParseSort(expr string, allowed []string, defaultSort []SortField) ([]SortField, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return defaultSort, nil
+	}
+
+	fields := []SortField{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(expr, ",") {
+		column, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		column = strings.ToLower(strings.TrimSpace(column))
+
+		if !slices.Contains(allowed, column) {
+			return nil, fmt.Errorf("cannot sort by %q (allowed: %s)", column, strings.Join(allowed, ", "))
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("sort column %q repeated", column)
+		}
+		seen[column] = true
+
+		field := SortField{Column: column}
+		switch strings.ToLower(strings.TrimSpace(direction)) {
+		case "", "asc":
+		case "desc":
+			field.Descending = true
+		default:
+			return nil, fmt.Errorf("invalid sort direction %q for %s (use asc or desc)", direction, column)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// OrderByClause renders fields as an ORDER BY clause with id as the final tiebreaker,
+// so rows with equal sort keys keep a stable order across pages.
+// The tiebreaker follows the direction of the first field.
+//
+// Example:
+//
+//	OrderByClause([]SortField{{Column: "timestamp", Descending: true}})
+//	→ "ORDER BY timestamp DESC, id DESC"
+func OrderByClause(fields []SortField) string {
+	terms := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		if field.Column == columnID {
+			continue
+		}
+		terms = append(terms, field.Column+" "+sortDirection(field.Descending))
+	}
+
+	tiebreaker := len(fields) > 0 && fields[0].Descending
+	terms = append(terms, columnID+" "+sortDirection(tiebreaker))
+
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
 // Helper functions
 
+func sortDirection(descending bool) string {
+	if descending {
+		return "DESC"
+	}
+	return "ASC"
+}
+
 func parseRFC3339(s string) (time.Time, error) {
 	return time.Parse(time.RFC3339, s)
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -65,6 +66,13 @@ func TestQueryBuilder_AddTimeRange(t *testing.T) {
 			wantConditions: 0,
 			wantErr:        false,
 		},
+		{
+			name:           "relative and epoch",
+			startTime:      "now-15m",
+			endTime:        "1732271400",
+			wantConditions: 2,
+			wantErr:        false,
+		},
 		{
 			name:      "invalid start time",
 			startTime: "not-a-date",
@@ -94,6 +102,23 @@ func TestQueryBuilder_AddTimeRange(t *testing.T) {
 	}
 }
 
+func TestQueryBuilder_AddTimeRangeWith(t *testing.T) {
+	// 23:35 on 2024-11-20 in Tokyo
+	now := time.Date(2024, 11, 20, 14, 35, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	qb := NewQueryBuilder()
+	err = qb.AddTimeRangeWith("timestamp", "now-1d/d", "2024-11-20T12:00:00", NewTimeParser(now, tokyo))
+	require.NoError(t, err)
+
+	assert.Equal(t, "WHERE timestamp >= $1 AND timestamp <= $2", qb.WhereClause())
+	assert.Equal(t, []interface{}{
+		time.Date(2024, 11, 18, 15, 0, 0, 0, time.UTC),
+		time.Date(2024, 11, 20, 3, 0, 0, 0, time.UTC),
+	}, qb.Args())
+}
+
 func TestQueryBuilder_AddFullTextSearch(t *testing.T) {
 	qb := NewQueryBuilder()
 
@@ -104,6 +129,15 @@ func TestQueryBuilder_AddFullTextSearch(t *testing.T) {
 	assert.Equal(t, []interface{}{"database & error"}, qb.Args())
 }
 
+func TestQueryBuilder_AddFullTextSearchIn(t *testing.T) {
+	qb := NewQueryBuilder()
+
+	qb.AddFullTextSearchIn("german", "datenbank")
+
+	assert.Equal(t, "WHERE to_tsvector('german', message) @@ to_tsquery('german', $1)", qb.WhereClause())
+	assert.Equal(t, []interface{}{"datenbank"}, qb.Args())
+}
+
 func TestQueryBuilder_WhereClause_Empty(t *testing.T) {
 	qb := NewQueryBuilder()
 
@@ -129,3 +163,106 @@ func TestQueryBuilder_ComplexQuery(t *testing.T) {
 	assert.Contains(t, whereClause, "to_tsvector")
 	assert.Len(t, qb.Args(), 5)
 }
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"timestamp", "level", "source"}
+	defaultSort := []SortField{{Column: "timestamp", Descending: true}}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []SortField
+		wantErr  bool
+	}{
+		{
+			name:     "empty uses default",
+			input:    "",
+			expected: defaultSort,
+		},
+		{
+			name:     "ascending",
+			input:    "timestamp:asc",
+			expected: []SortField{{Column: "timestamp"}},
+		},
+		{
+			name:     "direction defaults to ascending",
+			input:    "level",
+			expected: []SortField{{Column: "level"}},
+		},
+		{
+			name:     "multiple keys with whitespace and case",
+			input:    " Level:DESC , timestamp:asc ",
+			expected: []SortField{{Column: "level", Descending: true}, {Column: "timestamp"}},
+		},
+		{name: "unknown column", input: "message:asc", wantErr: true},
+		{name: "sql injection attempt", input: "timestamp; DROP TABLE logs", wantErr: true},
+		{name: "invalid direction", input: "timestamp:up", wantErr: true},
+		{name: "repeated column", input: "level,level:desc", wantErr: true},
+		{name: "empty key", input: "timestamp,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseSort(tt.input, allowed, defaultSort)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestOrderByClause(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []SortField
+		expected string
+	}{
+		{
+			name:     "descending tiebreaker follows first key",
+			fields:   []SortField{{Column: "timestamp", Descending: true}},
+			expected: "ORDER BY timestamp DESC, id DESC",
+		},
+		{
+			name:     "ascending",
+			fields:   []SortField{{Column: "timestamp"}},
+			expected: "ORDER BY timestamp ASC, id ASC",
+		},
+		{
+			name:     "multiple keys",
+			fields:   []SortField{{Column: "rank", Descending: true}, {Column: "timestamp"}},
+			expected: "ORDER BY rank DESC, timestamp ASC, id DESC",
+		},
+		{
+			name:     "no keys",
+			fields:   nil,
+			expected: "ORDER BY id ASC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, OrderByClause(tt.fields))
+		})
+	}
+}
+
+func TestProjectSort(t *testing.T) {
+	fields, err := ProjectSort("")
+	require.NoError(t, err)
+	assert.Equal(t, "ORDER BY created_at DESC, id DESC", OrderByClause(fields))
+
+	fields, err = ProjectSort("name,created_at:desc")
+	require.NoError(t, err)
+	assert.Equal(t, "ORDER BY name ASC, created_at DESC, id ASC", OrderByClause(fields))
+
+	// Stats are aggregated after paginating, so they can't be sorted by
+	for _, expr := range []string{"api_key", "log_count", "last_log_at:desc", "storage_bytes"} {
+		_, err = ProjectSort(expr)
+		var queryErr *QueryError
+		assert.ErrorAs(t, err, &queryErr, expr)
+	}
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// minRefillWait is the shortest wait TakeTokens reports for an empty bucket, so that
+// zero always means admitted.
+const minRefillWait = time.Millisecond
+
+// TokenBucket is a rate limit bucket as the stores without PostgreSQL keep it.
+// Take implements the algorithm that take_ingest_tokens (migration 015) runs in
+// PostgreSQL; the zero value is a full bucket.
+type TokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take refills the bucket for the time since UpdatedAt at rate tokens per second, up to
+// one second's worth, then spends cost if the bucket isn't empty. Spending may leave it
+// in debt, so a batch bigger than the bucket still gets through and later requests wait
+// it out. Returns zero if admitted, otherwise how long until the bucket has tokens again.
+func (b *TokenBucket) Take(now time.Time, rate, cost float64) time.Duration {
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = rate
+	} else if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = min(rate, b.Tokens+rate*elapsed.Seconds())
+	}
+	b.UpdatedAt = now
+
+	if b.Tokens <= 0 {
+		return max(time.Duration(-b.Tokens/rate*float64(time.Second)), minRefillWait)
+	}
+	b.Tokens -= cost
+	return 0
+}
+
+// TakeTokens implements storage.RateLimitStore with take_ingest_tokens, which updates
+// the bucket under a row lock by the database clock, so instances share each bucket
+// however their clocks drift.
+func (db *DB) TakeTokens(ctx context.Context, projectID uuid.UUID, bucket string, rate, cost float64) (time.Duration, error) {
+	var wait float64
+	err := db.Pool.QueryRow(ctx, "SELECT take_ingest_tokens($1, $2, $3, $4)",
+		projectID, bucket, rate, cost).Scan(&wait)
+	if err != nil {
+		return 0, fmt.Errorf("failed to take rate limit tokens: %w", err)
+	}
+
+	return time.Duration(wait * float64(time.Second)), nil
+}
+
+// ChargeIngestQuota implements storage.RateLimitStore. The check and the increment
+// are a single upsert, so concurrent requests can't overrun the quota together.
+func (db *DB) ChargeIngestQuota(ctx context.Context, projectID uuid.UUID, day time.Time, usage, quota models.IngestUsage) (bool, error) {
+	// A new day's row is inserted without the quota check
+	if !usage.Within(quota) {
+		return false, nil
+	}
+
+	query := `
+		INSERT INTO ingest_usage AS u (project_id, day, entries, bytes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, day) DO UPDATE
+		SET entries = u.entries + EXCLUDED.entries, bytes = u.bytes + EXCLUDED.bytes
+		WHERE ($5 = 0 OR u.entries + EXCLUDED.entries <= $5)
+		  AND ($6 = 0 OR u.bytes + EXCLUDED.bytes <= $6)
+	`
+
+	result, err := db.Pool.Exec(ctx, query,
+		projectID, UTCDay(day), usage.Entries, usage.Bytes, quota.Entries, quota.Bytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to charge ingest quota: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// PurgeIngestUsage implements storage.RateLimitStore.
+func (db *DB) PurgeIngestUsage(ctx context.Context, day time.Time) (int64, error) {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM ingest_usage WHERE day < $1`, UTCDay(day))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge ingest usage: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// UTCDay returns midnight UTC of the day containing t.
+func UTCDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2024, 11, 20, 9, 30, 0, 0, time.UTC)
+	var b TokenBucket
+
+	// New buckets start full: 5 requests pass at once, the 6th waits for a token
+	for i := 0; i < 5; i++ {
+		assert.Zero(t, b.Take(start, 5, 1), "request %d", i)
+	}
+	assert.Equal(t, minRefillWait, b.Take(start, 5, 1))
+
+	// Tokens refill at the rate, capped at one second's worth
+	assert.Zero(t, b.Take(start.Add(200*time.Millisecond), 5, 1))
+	assert.Equal(t, minRefillWait, b.Take(start.Add(200*time.Millisecond), 5, 1))
+	b.Take(start.Add(time.Hour), 5, 0)
+	assert.InDelta(t, 5, b.Tokens, 1e-9)
+
+	// A batch bigger than the bucket passes and leaves debt to wait out
+	now := start.Add(time.Hour)
+	assert.Zero(t, b.Take(now, 5, 20))
+	assert.Equal(t, 3*time.Second, b.Take(now, 5, 1))
+	assert.Zero(t, b.Take(now.Add(3100*time.Millisecond), 5, 1))
+
+	// Clocks going backwards don't refill
+	b = TokenBucket{Tokens: -1, UpdatedAt: now}
+	assert.Equal(t, 200*time.Millisecond, b.Take(now.Add(-time.Hour), 5, 1))
+}
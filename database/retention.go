@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// RetentionPolicy is a project's retention setting as seen by the purge worker.
+type RetentionPolicy struct {
+	ProjectID     uuid.UUID
+	RetentionDays int
+}
+
+// RetentionCutoff returns the time before which logs are expired under a policy of days.
+func RetentionCutoff(now time.Time, days int) time.Time {
+	return now.UTC().AddDate(0, 0, -days)
+}
+
+// SetProjectRetention sets how many days of logs a project keeps.
+// nil disables retention (logs are kept forever).
+// Returns ErrProjectNotFound if ID doesn't exist.
+func (db *DB) SetProjectRetention(ctx context.Context, projectID uuid.UUID, days *int) (*models.Project, error) {
+	query := `
+		UPDATE projects
+		SET retention_days = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + projectColumns + `
+	`
+
+	project, err := scanProject(db.Pool.QueryRow(ctx, query, projectID, days))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to set retention: %w", err)
+	}
+
+	return project, nil
+}
+
+// ListRetentionPolicies returns every project that has a retention policy.
+func (db *DB) ListRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, retention_days
+		FROM projects
+		WHERE retention_days IS NOT NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+
+	policies, err := pgx.CollectRows(rows, pgx.RowToStructByPos[RetentionPolicy])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan retention policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// PurgeExpiredLogs deletes up to batchSize of a project's logs older than cutoff.
+// Call repeatedly until it returns fewer than batchSize rows - small batches keep
+// each transaction short instead of locking millions of rows at once.
+// Returns the number of rows deleted.
+func (db *DB) PurgeExpiredLogs(ctx context.Context, projectID uuid.UUID, cutoff time.Time, batchSize int) (int64, error) {
+	// (id, timestamp) is the primary key, so the subquery's rows are matched by index
+	query := fmt.Sprintf(`
+		DELETE FROM logs
+		WHERE (%s, %s) IN (
+			SELECT %s, %s FROM logs
+			WHERE %s = $1 AND %s < $2
+			LIMIT $3
+		)
+	`, columnID, columnTimestamp, columnID, columnTimestamp, columnProjectID, columnTimestamp)
+
+	result, err := db.Pool.Exec(ctx, query, projectID, cutoff, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge logs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DropExpiredLogPartitions drops whole partitions of the logs table once every row in
+// them is past every project's retention. Much cheaper than row deletes, but only
+// possible when all projects have a policy - a single project without one keeps
+// every partition. Returns the names of the dropped partitions.
+func (db *DB) DropExpiredLogPartitions(ctx context.Context, now time.Time) ([]string, error) {
+	var unlimited, projects int64
+	var longest *int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE retention_days IS NULL), COUNT(*), MAX(retention_days)
+		FROM projects
+	`).Scan(&unlimited, &projects, &longest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retention policies: %w", err)
+	}
+	if unlimited > 0 || projects == 0 || longest == nil {
+		return nil, nil
+	}
+
+	partitions, err := db.ListLogPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := RetentionCutoff(now, *longest)
+	var dropped []string
+	for _, p := range partitions {
+		if p.End.After(cutoff) {
+			continue
+		}
+		if err := db.detachLogPartition(ctx, p.Name, true); err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, p.Name)
+	}
+
+	return dropped, nil
+}
+
+// StartRetentionRun records the start of a purge and returns the run's ID.
+func (db *DB) StartRetentionRun(ctx context.Context, projectID uuid.UUID, cutoff time.Time) (uuid.UUID, error) {
+	var runID uuid.UUID
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO retention_runs (project_id, cutoff)
+		VALUES ($1, $2)
+		RETURNING id
+	`, projectID, cutoff).Scan(&runID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to start retention run: %w", err)
+	}
+
+	return runID, nil
+}
+
+// FinishRetentionRun records the outcome of a purge started with StartRetentionRun.
+// runErr is stored as the run's error message when non-nil.
+func (db *DB) FinishRetentionRun(ctx context.Context, runID uuid.UUID, deleted, archived int64, runErr error) error {
+	var message *string
+	if runErr != nil {
+		msg := runErr.Error()
+		message = &msg
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE retention_runs
+		SET finished_at = NOW(), deleted_rows = $2, archived_rows = $3, error = $4
+		WHERE id = $1
+	`, runID, deleted, archived, message)
+	if err != nil {
+		return fmt.Errorf("failed to finish retention run: %w", err)
+	}
+
+	return nil
+}
+
+// PruneRetentionRuns deletes run history started before before.
+// Returns the number of runs deleted.
+func (db *DB) PruneRetentionRuns(ctx context.Context, before time.Time) (int64, error) {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM retention_runs WHERE started_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune retention runs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetRetentionStatus reports a project's retention policy, its oldest log, how many
+// logs are expired as of now, and the most recent purge run.
+// Returns ErrProjectNotFound if ID doesn't exist.
+func (db *DB) GetRetentionStatus(ctx context.Context, projectID uuid.UUID, now time.Time) (*models.RetentionStatus, error) {
+	status := models.RetentionStatus{ProjectID: projectID}
+
+	var retentionDays *int
+	if err := db.Pool.QueryRow(ctx, `SELECT retention_days FROM projects WHERE id = $1 AND deleted_at IS NULL`, projectID).Scan(&retentionDays); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get retention status: %w", err)
+	}
+	status.RetentionDays = retentionDays
+	if retentionDays != nil {
+		cutoff := RetentionCutoff(now, *retentionDays)
+		status.Cutoff = &cutoff
+	}
+
+	// COUNT with a NULL cutoff matches nothing
+	query := fmt.Sprintf(`
+		SELECT
+			(SELECT MIN(%s) FROM logs WHERE %s = $1),
+			(SELECT COUNT(*) FROM logs WHERE %s = $1 AND %s < $2::timestamptz),
+			r.id, r.started_at, r.finished_at, r.cutoff, r.deleted_rows, r.archived_rows, r.error
+		FROM (SELECT 1) AS one
+		LEFT JOIN LATERAL (
+			SELECT * FROM retention_runs
+			WHERE project_id = $1
+			ORDER BY started_at DESC
+			LIMIT 1
+		) r ON true
+	`, columnTimestamp, columnProjectID, columnProjectID, columnTimestamp)
+
+	var (
+		runID        *uuid.UUID
+		startedAt    *time.Time
+		finishedAt   *time.Time
+		cutoff       *time.Time
+		deletedRows  *int64
+		archivedRows *int64
+		runError     *string
+	)
+	err := db.Pool.QueryRow(ctx, query, projectID, status.Cutoff).Scan(
+		&status.OldestLog, &status.ExpiredLogs,
+		&runID, &startedAt, &finishedAt, &cutoff, &deletedRows, &archivedRows, &runError,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention status: %w", err)
+	}
+
+	if status.OldestLog != nil {
+		oldest := status.OldestLog.UTC()
+		status.OldestLog = &oldest
+	}
+
+	if runID != nil {
+		run := &models.RetentionRun{
+			ID:           *runID,
+			ProjectID:    projectID,
+			StartedAt:    startedAt.UTC(),
+			Cutoff:       cutoff.UTC(),
+			DeletedRows:  *deletedRows,
+			ArchivedRows: *archivedRows,
+		}
+		if finishedAt != nil {
+			finished := finishedAt.UTC()
+			run.FinishedAt = &finished
+		}
+		if runError != nil {
+			run.Error = *runError
+		}
+		status.LastRun = run
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetProjectRetention(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	assert.Nil(t, project.RetentionDays)
+
+	days := 30
+	updated, err := db.SetProjectRetention(ctx, project.ID, &days)
+	require.NoError(t, err)
+	require.NotNil(t, updated.RetentionDays)
+	assert.Equal(t, 30, *updated.RetentionDays)
+
+	policies, err := db.ListRetentionPolicies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []RetentionPolicy{{ProjectID: project.ID, RetentionDays: 30}}, policies)
+
+	updated, err = db.SetProjectRetention(ctx, project.ID, nil)
+	require.NoError(t, err)
+	assert.Nil(t, updated.RetentionDays)
+
+	_, err = db.SetProjectRetention(ctx, uuid.New(), &days)
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+}
+
+func TestPurgeExpiredLogs_Batches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	other, err := db.CreateProject(ctx, "Other Project")
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	cutoff := RetentionCutoff(now, 7)
+
+	var logs []models.LogEntry
+	for i := 0; i < 25; i++ {
+		logs = append(logs, models.LogEntry{
+			ID:        uuid.New(),
+			ProjectID: project.ID,
+			Level:     "info",
+			Message:   "Expired",
+			Timestamp: cutoff.Add(-time.Duration(i+1) * time.Hour),
+		})
+	}
+	fresh := models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Fresh", Timestamp: now}
+	otherOld := models.LogEntry{ID: uuid.New(), ProjectID: other.ID, Level: "info", Message: "Other", Timestamp: cutoff.Add(-time.Hour)}
+	logs = append(logs, fresh, otherOld)
+	require.NoError(t, db.InsertLogsBatch(ctx, logs))
+
+	var batches []int64
+	for {
+		n, err := db.PurgeExpiredLogs(ctx, project.ID, cutoff, 10)
+		require.NoError(t, err)
+		batches = append(batches, n)
+		if n < 10 {
+			break
+		}
+	}
+	assert.Equal(t, []int64{10, 10, 5}, batches)
+
+	_, err = db.GetLog(ctx, project.ID, fresh.ID)
+	assert.NoError(t, err, "logs newer than the cutoff are kept")
+	_, err = db.GetLog(ctx, other.ID, otherOld.ID)
+	assert.NoError(t, err, "other projects are untouched")
+}
+
+func TestGetRetentionStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+
+	// No policy and no logs
+	status, err := db.GetRetentionStatus(ctx, project.ID, now)
+	require.NoError(t, err)
+	assert.Nil(t, status.RetentionDays)
+	assert.Nil(t, status.Cutoff)
+	assert.Nil(t, status.OldestLog)
+	assert.Nil(t, status.LastRun)
+
+	days := 1
+	_, err = db.SetProjectRetention(ctx, project.ID, &days)
+	require.NoError(t, err)
+
+	oldest := now.Add(-72 * time.Hour).Truncate(time.Microsecond)
+	err = db.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Old", Timestamp: oldest},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Older than a day", Timestamp: now.Add(-48 * time.Hour)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "New", Timestamp: now},
+	})
+	require.NoError(t, err)
+
+	runID, err := db.StartRetentionRun(ctx, project.ID, RetentionCutoff(now, days))
+	require.NoError(t, err)
+	require.NoError(t, db.FinishRetentionRun(ctx, runID, 0, 0, errors.New("boom")))
+
+	status, err = db.GetRetentionStatus(ctx, project.ID, now)
+	require.NoError(t, err)
+	require.NotNil(t, status.Cutoff)
+	assert.Equal(t, RetentionCutoff(now, 1), *status.Cutoff)
+	require.NotNil(t, status.OldestLog)
+	assert.True(t, oldest.Equal(*status.OldestLog))
+	assert.Equal(t, int64(2), status.ExpiredLogs)
+	require.NotNil(t, status.LastRun)
+	assert.Equal(t, runID, status.LastRun.ID)
+	assert.NotNil(t, status.LastRun.FinishedAt)
+	assert.Equal(t, "boom", status.LastRun.Error)
+
+	_, err = db.GetRetentionStatus(ctx, uuid.New(), now)
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+}
+
+func TestDropExpiredLogPartitions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+	dropLogPartitions(t, db)
+	t.Cleanup(func() { dropLogPartitions(t, db) })
+
+	ctx := context.Background()
+
+	manager, err := NewPartitionManager(db, PartitionConfig{Interval: PartitionDaily, Premake: 2})
+	require.NoError(t, err)
+	_, err = manager.EnsurePartitions(ctx, time.Date(2030, 3, 14, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	short, err := db.CreateProject(ctx, "Short Retention")
+	require.NoError(t, err)
+	long, err := db.CreateProject(ctx, "Long Retention")
+	require.NoError(t, err)
+	one, two := 1, 2
+	_, err = db.SetProjectRetention(ctx, short.ID, &one)
+	require.NoError(t, err)
+
+	now := time.Date(2030, 3, 17, 0, 0, 0, 0, time.UTC)
+
+	// A project without a policy keeps every partition
+	dropped, err := db.DropExpiredLogPartitions(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, dropped)
+
+	// The longest policy (2 days, cutoff 03-15) decides
+	_, err = db.SetProjectRetention(ctx, long.ID, &two)
+	require.NoError(t, err)
+	dropped, err = db.DropExpiredLogPartitions(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs_p20300314"}, dropped)
+}
@@ -0,0 +1,165 @@
+package database_test
+
+import (
+	"context"
+	"jazz/archive"
+	"jazz/database"
+	"jazz/models"
+	"jazz/retention"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs the retention worker against partitioned PostgreSQL logs. It lives in an
+// external test package because retention imports database.
+func TestRetentionWorker_DropsPartitionsOnlyWhenEveryProjectHasPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := database.GetTestDB()
+	database.CleanupTestDB(t, db)
+	dropPartitions(t, db)
+	t.Cleanup(func() { dropPartitions(t, db) })
+
+	ctx := context.Background()
+
+	manager, err := database.NewPartitionManager(db, database.PartitionConfig{Interval: database.PartitionDaily, Premake: 2})
+	require.NoError(t, err)
+	_, err = manager.EnsurePartitions(ctx, time.Date(2030, 3, 14, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	limited, err := db.CreateProject(ctx, "Limited")
+	require.NoError(t, err)
+	unlimited, err := db.CreateProject(ctx, "Unlimited")
+	require.NoError(t, err)
+	one := 1
+	_, err = db.SetProjectRetention(ctx, limited.ID, &one)
+	require.NoError(t, err)
+
+	old := time.Date(2030, 3, 14, 6, 0, 0, 0, time.UTC)
+	require.NoError(t, db.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: limited.ID, Level: "info", Message: "Expired", Timestamp: old},
+		{ID: uuid.New(), ProjectID: unlimited.ID, Level: "info", Message: "Kept forever", Timestamp: old},
+	}))
+
+	now := time.Date(2030, 3, 17, 0, 0, 0, 0, time.UTC)
+	worker := retention.NewWorker(db, retention.Config{BatchSize: 10})
+
+	// The project without a policy keeps every partition; the other's rows are deleted
+	require.NoError(t, worker.RunOnce(ctx, now))
+	assert.Contains(t, partitionNames(t, db), "logs_p20300314")
+	_, total, err := db.QueryLogs(ctx, unlimited.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	_, total, err = db.QueryLogs(ctx, limited.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// Once it has one, expired partitions are dropped along with their rows
+	_, err = db.SetProjectRetention(ctx, unlimited.ID, &one)
+	require.NoError(t, err)
+	require.NoError(t, worker.RunOnce(ctx, now))
+	assert.NotContains(t, partitionNames(t, db), "logs_p20300314")
+	_, total, err = db.QueryLogs(ctx, unlimited.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestRetentionWorker_ArchivesBeforeDeleting(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := database.GetTestDB()
+	database.CleanupTestDB(t, db)
+	dropPartitions(t, db)
+	t.Cleanup(func() { dropPartitions(t, db) })
+
+	ctx := context.Background()
+
+	manager, err := database.NewPartitionManager(db, database.PartitionConfig{Interval: database.PartitionDaily, Premake: 3})
+	require.NoError(t, err)
+	_, err = manager.EnsurePartitions(ctx, time.Date(2030, 3, 8, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	project, err := db.CreateProject(ctx, "Archived")
+	require.NoError(t, err)
+	seven := 7
+	_, err = db.SetProjectRetention(ctx, project.ID, &seven)
+	require.NoError(t, err)
+
+	// A 7 day policy expires everything before 03-10 12:00, but only whole days go
+	day := time.Date(2030, 3, 8, 0, 0, 0, 0, time.UTC)
+	expired := []time.Time{day.Add(time.Hour), day.Add(23 * time.Hour), day.AddDate(0, 0, 1).Add(5 * time.Hour)}
+	partial := day.AddDate(0, 0, 2).Add(time.Hour)
+	var logs []models.LogEntry
+	for _, ts := range append(expired, partial) {
+		logs = append(logs, models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Entry", Timestamp: ts})
+	}
+	require.NoError(t, db.InsertLogsBatch(ctx, logs))
+
+	archiver := archive.New(db, archive.NewDirSink(t.TempDir()))
+	worker := retention.NewWorker(db, retention.Config{BatchSize: 10})
+	worker.SetArchiver(archiver)
+
+	now := time.Date(2030, 3, 17, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, worker.RunOnce(ctx, now))
+
+	// Each archived day covers exactly the rows deleted from it
+	manifest, err := archiver.LoadManifest(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 2)
+	assert.Equal(t, "2030-03-08", manifest.Files[0].Day)
+	assert.Equal(t, int64(2), manifest.Files[0].Rows)
+	assert.True(t, expired[0].Equal(manifest.Files[0].MinTimestamp))
+	assert.True(t, expired[1].Equal(manifest.Files[0].MaxTimestamp))
+	assert.Equal(t, "2030-03-09", manifest.Files[1].Day)
+	assert.Equal(t, int64(1), manifest.Files[1].Rows)
+	assert.True(t, expired[2].Equal(manifest.Files[1].MinTimestamp))
+	assert.True(t, expired[2].Equal(manifest.Files[1].MaxTimestamp))
+
+	remaining, _, err := db.QueryLogs(ctx, project.ID, models.QueryParams{})
+	require.NoError(t, err)
+	require.Len(t, remaining, 1, "the partially expired day waits")
+	assert.True(t, partial.Equal(remaining[0].Timestamp))
+
+	// Every project has a policy, but partitions would skip the archive
+	assert.Contains(t, partitionNames(t, db), "logs_p20300308")
+}
+
+func partitionNames(t *testing.T, db *database.DB) []string {
+	t.Helper()
+
+	partitions, err := db.ListLogPartitions(context.Background())
+	require.NoError(t, err)
+	names := make([]string, len(partitions))
+	for i, p := range partitions {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// dropPartitions drops every daily and weekly logs partition, leaving logs_default.
+func dropPartitions(t *testing.T, db *database.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+	rows, err := db.Pool.Query(ctx, `
+		SELECT relname FROM pg_class
+		WHERE relkind = 'r' AND relname ~ '^logs_[pw][0-9]{8}$'
+	`)
+	require.NoError(t, err)
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+
+	for _, name := range names {
+		_, err := db.Pool.Exec(ctx, "DROP TABLE "+pgx.Identifier{name}.Sanitize())
+		require.NoError(t, err)
+	}
+}
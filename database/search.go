@@ -101,7 +101,8 @@ func (p *SearchQueryParser) filterValidWords(words []string) []string {
 }
 
 // SearchLogs performs full-text search on log messages using PostgreSQL GIN indexes.
-// Results are ranked by relevance (ts_rank) and timestamp (DESC).
+// Results are ordered by relevance (ts_rank) and timestamp (DESC) unless req.Sort
+// selects otherwise (e.g. "timestamp:asc"; allowed: rank, timestamp, level, source).
 // Only searches within the specified project for data isolation.
 //
 // Search query is parsed and sanitized before execution to prevent injection.
@@ -121,29 +122,28 @@ func (db *DB) SearchLogs(ctx context.Context, projectID uuid.UUID, req models.Se
 			projectID, req.Query, time.Since(start).Milliseconds())
 	}()
 
-	// Parse search query
-	parser := NewSearchQueryParser()
-	tsQuery, err := parser.Parse(req.Query)
-	if err != nil {
-		return nil, 0, fmt.Errorf("invalid search query: %w", err)
+	// An empty query would otherwise fall through to an unranked listing
+	if req.Query == "" {
+		return nil, 0, &QueryError{Err: fmt.Errorf("invalid search query: query is required")}
 	}
 
 	// Validate pagination
 	limit := validateLimit(req.Limit, defaultLimit, maxLimit)
 	offset := validateOffset(req.Offset)
 
-	// Build query
-	qb := NewQueryBuilder()
-	qb.AddCondition(columnProjectID, projectID)
-	qb.AddFullTextSearch(tsQuery)
-
-	if req.Level != "" {
-		qb.AddCondition(columnLevel, req.Level)
+	sortFields, err := parseSort(req.Sort, searchSortColumns, defaultSearchSort)
+	if err != nil {
+		return nil, 0, err
 	}
-	if req.Source != "" {
-		qb.AddCondition(columnSource, req.Source)
+
+	filter, err := db.withSearchLanguage(ctx, projectID, filterFromSearchRequest(req))
+	if err != nil {
+		return nil, 0, err
 	}
-	if err := qb.AddTimeRange(columnTimestamp, req.StartTime, req.EndTime); err != nil {
+
+	// Build query (parses and sanitizes req.Query into $2)
+	qb := NewQueryBuilder()
+	if err := filter.apply(qb, projectID); err != nil {
 		return nil, 0, err
 	}
 
@@ -151,14 +151,14 @@ func (db *DB) SearchLogs(ctx context.Context, projectID uuid.UUID, req models.Se
 	query := fmt.Sprintf(`
 		SELECT 
 			%s, %s, %s, %s, %s, %s,
-			ts_rank(to_tsvector('english', %s), to_tsquery('english', $2)) as rank,
+			ts_rank(to_tsvector('%s', %s), to_tsquery('%s', $2)) as %s,
 			COUNT(*) OVER() as total_count
 		FROM logs
 		%s
-		ORDER BY rank DESC, %s DESC
+		%s
 		LIMIT $%d OFFSET $%d
 	`, columnID, columnProjectID, columnLevel, columnMessage, columnSource, columnTimestamp,
-		columnMessage, qb.WhereClause(), columnTimestamp, qb.NextArgNum(), qb.NextArgNum()+1)
+		filter.language(), columnMessage, filter.language(), columnRank, qb.WhereClause(), OrderByClause(sortFields), qb.NextArgNum(), qb.NextArgNum()+1)
 
 	args := append(qb.Args(), limit, offset)
 
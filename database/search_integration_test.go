@@ -142,3 +142,80 @@ func TestSearchLogs_Ranking(t *testing.T) {
 	// First result should have higher rank (more occurrences)
 	assert.Greater(t, *results[0].Rank, *results[1].Rank)
 }
+
+func TestSearchLogs_SortByTime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour)
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database database database down", Timestamp: base.Add(2 * time.Second)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database slow", Timestamp: base},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database retry", Timestamp: base.Add(time.Second)},
+	}
+	err = db.InsertLogsBatch(ctx, logs)
+	require.NoError(t, err)
+
+	results, _, err := db.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database", Sort: "timestamp:asc", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"database slow", "database retry", "database database database down"}, messages(results))
+
+	results, _, err = db.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "database database database down", results[0].Message, "default sort is by rank")
+
+	_, _, err = db.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database", Sort: "message"})
+	var queryErr *QueryError
+	assert.ErrorAs(t, err, &queryErr)
+}
+
+func TestSearchLogs_ProjectLanguage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db := GetTestDB()
+	CleanupTestDB(t, db)
+
+	ctx := context.Background()
+
+	project, err := db.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	err = db.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Lost connections to replica", Timestamp: time.Now()},
+	})
+	require.NoError(t, err)
+
+	// english stems "connections" to "connection"
+	_, total, err := db.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "connection"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// simple only lowercases, so the exact word is needed
+	_, err = db.UpdateProject(ctx, project.ID, models.UpdateProjectRequest{
+		Settings: &models.ProjectSettings{SearchLanguage: "simple"},
+	})
+	require.NoError(t, err)
+
+	_, total, err = db.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "connection"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	_, total, err = db.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "connections"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	facets, err := db.FacetSearch(ctx, project.ID, models.SearchRequest{Query: "connection"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), facets.Total)
+}
@@ -0,0 +1,22 @@
+package database_test
+
+import (
+	"jazz/database"
+	"jazz/storage"
+	"jazz/storage/storagetest"
+	"testing"
+)
+
+// Runs the backend-neutral storage suite against PostgreSQL. It lives in an
+// external test package because storagetest imports database.
+func TestStorageConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		db := database.GetTestDB()
+		database.CleanupTestDB(t, db)
+		return db
+	})
+}
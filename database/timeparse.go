@@ -0,0 +1,201 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts accepted for timestamps without an explicit offset.
+// These are interpreted in the parser's time zone (the request's tz parameter).
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds.
+// 1e11 seconds is the year 5138, while 1e11 milliseconds is March 1973.
+const epochMillisThreshold = 100_000_000_000
+
+// TimeParser resolves the time expressions accepted by start_time and end_time.
+// Relative expressions and timestamps without an offset are evaluated in loc.
+//
+// Supported formats:
+//   - RFC3339: "2024-11-22T10:30:00Z", "2024-11-22T10:30:00.123-08:00"
+//   - Local time (uses loc): "2024-11-22T10:30:00", "2024-11-22 10:30:00", "2024-11-22"
+//   - Unix epoch: "1732271400" (seconds) or "1732271400000" (milliseconds)
+//   - Date math: "now", "now-15m", "now+1h", "now-1d/d", "now/w"
+//
+// Date math units are s, m, h, d, w, M (months) and y. "/unit" rounds down to the
+// start of that unit in loc (weeks start on Monday), e.g. "now-1d/d" is midnight yesterday.
+type TimeParser struct {
+	now time.Time
+	loc *time.Location
+}
+
+// NewTimeParser creates a TimeParser that evaluates "now" as now, in loc.
+// A nil loc means UTC.
+func NewTimeParser(now time.Time, loc *time.Location) *TimeParser {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &TimeParser{now: now, loc: loc}
+}
+
+// LoadTimezone resolves an IANA time zone name (e.g. "Europe/Berlin").
+// Empty string means UTC.
+// Returns error if the name is unknown.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Parse resolves expr to an absolute time, returned in UTC.
+// Returns error if expr matches none of the supported formats.
+func (p *TimeParser) Parse(expr string) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("empty time expression")
+	}
+
+	if strings.HasPrefix(expr, "now") {
+		return p.parseDateMath(expr)
+	}
+
+	if isDigits(expr) {
+		return parseEpoch(expr)
+	}
+
+	if t, err := parseRFC3339(expr); err == nil {
+		return t.UTC(), nil
+	}
+
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, expr, p.loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized time %q (use RFC3339, epoch seconds/millis, or now-15m style)", expr)
+}
+
+func (p *TimeParser) parseDateMath(expr string) (time.Time, error) {
+	t := p.now.In(p.loc)
+	rest := expr[len("now"):]
+
+	for rest != "" {
+		op := rest[0]
+		rest = rest[1:]
+
+		switch op {
+		case '+', '-':
+			i := 0
+			for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+				i++
+			}
+			if i == 0 || i == len(rest) {
+				return time.Time{}, fmt.Errorf("invalid date math %q: expected amount and unit after %q", expr, op)
+			}
+			amount, err := strconv.Atoi(rest[:i])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid date math %q: %w", expr, err)
+			}
+			if op == '-' {
+				amount = -amount
+			}
+			t, err = addUnit(t, amount, rest[i])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid date math %q: %w", expr, err)
+			}
+			rest = rest[i+1:]
+		case '/':
+			if rest == "" {
+				return time.Time{}, fmt.Errorf("invalid date math %q: expected unit after /", expr)
+			}
+			var err error
+			t, err = roundDown(t, rest[0])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid date math %q: %w", expr, err)
+			}
+			rest = rest[1:]
+		default:
+			return time.Time{}, fmt.Errorf("invalid date math %q: unexpected %q", expr, op)
+		}
+	}
+
+	return t.UTC(), nil
+}
+
+func addUnit(t time.Time, amount int, unit byte) (time.Time, error) {
+	switch unit {
+	case 's':
+		return t.Add(time.Duration(amount) * time.Second), nil
+	case 'm':
+		return t.Add(time.Duration(amount) * time.Minute), nil
+	case 'h':
+		return t.Add(time.Duration(amount) * time.Hour), nil
+	case 'd':
+		return t.AddDate(0, 0, amount), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*amount), nil
+	case 'M':
+		return t.AddDate(0, amount, 0), nil
+	case 'y':
+		return t.AddDate(amount, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+func roundDown(t time.Time, unit byte) (time.Time, error) {
+	y, mo, d := t.Date()
+	loc := t.Location()
+
+	switch unit {
+	case 's':
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+	case 'm':
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, loc), nil
+	case 'h':
+		return time.Date(y, mo, d, t.Hour(), 0, 0, 0, loc), nil
+	case 'd':
+		return time.Date(y, mo, d, 0, 0, 0, 0, loc), nil
+	case 'w':
+		// Weekday counts from Sunday; shift so Monday starts the week
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, mo, d-daysSinceMonday, 0, 0, 0, 0, loc), nil
+	case 'M':
+		return time.Date(y, mo, 1, 0, 0, 0, 0, loc), nil
+	case 'y':
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+func parseEpoch(s string) (time.Time, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid epoch %q: %w", s, err)
+	}
+	if n >= epochMillisThreshold {
+		return time.UnixMilli(n).UTC(), nil
+	}
+	return time.Unix(n, 0).UTC(), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeParser_Parse(t *testing.T) {
+	// Wednesday 2024-11-20 14:35:27.5 UTC
+	now := time.Date(2024, 11, 20, 14, 35, 27, 500_000_000, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		input    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "RFC3339 UTC",
+			input:    "2024-11-22T10:30:00Z",
+			expected: time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC3339 with offset is not affected by tz",
+			loc:      berlin,
+			input:    "2024-11-22T10:30:00+05:30",
+			expected: time.Date(2024, 11, 22, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "local time defaults to UTC",
+			input:    "2024-11-22T10:30:00",
+			expected: time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "local time in tz",
+			loc:      berlin,
+			input:    "2024-11-22T10:30:00",
+			expected: time.Date(2024, 11, 22, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "local time with space and fraction",
+			input:    "2024-11-22 10:30:00.250",
+			expected: time.Date(2024, 11, 22, 10, 30, 0, 250_000_000, time.UTC),
+		},
+		{
+			name:     "date only in tz",
+			loc:      berlin,
+			input:    "2024-11-22",
+			expected: time.Date(2024, 11, 21, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "epoch seconds",
+			input:    "1732271400",
+			expected: time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "epoch millis",
+			input:    "1732271400123",
+			expected: time.Date(2024, 11, 22, 10, 30, 0, 123_000_000, time.UTC),
+		},
+		{
+			name:     "now",
+			input:    "now",
+			expected: now,
+		},
+		{
+			name:     "now minus minutes",
+			input:    "now-15m",
+			expected: now.Add(-15 * time.Minute),
+		},
+		{
+			name:     "now plus hours",
+			input:    "now+2h",
+			expected: now.Add(2 * time.Hour),
+		},
+		{
+			name:     "yesterday rounded to day",
+			input:    "now-1d/d",
+			expected: time.Date(2024, 11, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "rounding uses tz",
+			loc:      berlin,
+			input:    "now/d",
+			expected: time.Date(2024, 11, 19, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "start of week is Monday",
+			input:    "now/w",
+			expected: time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "months and chained ops",
+			input:    "now-1M/M+1h",
+			expected: time.Date(2024, 10, 1, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "start of year",
+			input:    "now/y",
+			expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "unknown unit", input: "now-5x", wantErr: true},
+		{name: "missing unit", input: "now-5", wantErr: true},
+		{name: "missing amount", input: "now-m", wantErr: true},
+		{name: "dangling rounding", input: "now/", wantErr: true},
+		{name: "garbage after now", input: "now*2", wantErr: true},
+		{name: "not a date", input: "not-a-date", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTimeParser(now, tt.loc).Parse(tt.input)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(result), "expected %v, got %v", tt.expected, result)
+			assert.Equal(t, time.UTC, result.Location())
+		})
+	}
+}
+
+func TestLoadTimezone(t *testing.T) {
+	loc, err := LoadTimezone("")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = LoadTimezone("America/New_York")
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", loc.String())
+
+	_, err = LoadTimezone("Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
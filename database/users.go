@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/apikey"
+	"jazz/models"
+	"jazz/password"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrUserExists is returned when registering an email that already has an account.
+	// Safe to expose to clients.
+	ErrUserExists = errors.New("user already exists")
+
+	// ErrUserNotFound is returned when no user has a given email.
+	// Safe to expose to clients.
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrInvalidCredentials is returned when an email and password don't match a user.
+	// It doesn't say which was wrong. Safe to expose to clients.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrInvalidSession is returned when a session token is unknown or expired.
+	// Safe to expose to clients.
+	ErrInvalidSession = errors.New("invalid session")
+)
+
+// userColumns are the columns scanUser reads, in order.
+const userColumns = "id, email, name, created_at"
+
+// sessionUserColumns are userColumns qualified for queries joining sessions to users.
+const sessionUserColumns = "users.id, users.email, users.name, users.created_at"
+
+// CreateUser creates a user with a bcrypt-hashed password.
+// Returns ErrUserExists if the email is taken.
+func (db *DB) CreateUser(ctx context.Context, email, name, plaintext string) (*models.User, error) {
+	hash, err := password.Hash(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO users (email, name, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING ` + userColumns
+
+	user, err := scanUser(db.Pool.QueryRow(ctx, query, models.NormalizeEmail(email), name, hash))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUserExists
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	log.Printf("Created user: %s (ID: %s)", user.Email, user.ID)
+	return user, nil
+}
+
+// AuthenticateUser checks an email and password.
+// Unknown emails cost a bcrypt comparison too, so response times don't reveal which emails
+// have accounts. Returns ErrInvalidCredentials if either is wrong (safe to expose to client).
+func (db *DB) AuthenticateUser(ctx context.Context, email, plaintext string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `, password_hash
+		FROM users
+		WHERE email = $1
+	`
+
+	var hash []byte
+	user, err := scanUser(db.Pool.QueryRow(ctx, query, models.NormalizeEmail(email)), &hash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !password.Verify(hash, plaintext) {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// GetUserByEmail returns the user with email, or ErrUserNotFound.
+func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+
+	user, err := scanUser(db.Pool.QueryRow(ctx, query, models.NormalizeEmail(email)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateSession signs a user in for ttl, generating a token like an API key
+// (see package apikey). The user's expired sessions are deleted at the same time.
+func (db *DB) CreateSession(ctx context.Context, userID uuid.UUID, ttl time.Duration) (*models.Session, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM sessions WHERE user_id = $1 AND expires_at <= NOW()`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	query := `
+		WITH session AS (
+			INSERT INTO sessions (user_id, token_prefix, token_salt, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5))
+			RETURNING id, user_id, created_at, expires_at
+		)
+		SELECT session.id, session.created_at, session.expires_at, ` + sessionUserColumns + `
+		FROM session
+		JOIN users ON users.id = session.user_id
+	`
+
+	session, err := scanSession(db.Pool.QueryRow(ctx, query, userID, secret.Prefix, secret.Salt, secret.Hash, ttl.Seconds()))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	session.Token = plaintext
+
+	return session, nil
+}
+
+// AuthenticateSession returns the unexpired session a token belongs to, with its user.
+// Returns ErrInvalidSession if there is none (safe to expose to client).
+func (db *DB) AuthenticateSession(ctx context.Context, token string) (*models.Session, error) {
+	query := `
+		SELECT sessions.id, sessions.created_at, sessions.expires_at, ` + sessionUserColumns + `,
+		       sessions.token_salt, sessions.token_hash
+		FROM sessions
+		JOIN users ON users.id = sessions.user_id
+		WHERE sessions.token_prefix = $1 AND sessions.expires_at > NOW()
+	`
+
+	rows, err := db.Pool.Query(ctx, query, apikey.Prefix(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var secret apikey.Key
+		session, err := scanSession(rows, &secret.Salt, &secret.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if secret.Verify(token) {
+			return session, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return nil, ErrInvalidSession
+}
+
+// DeleteSession signs a session out. Deleting a missing session is not an error.
+func (db *DB) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM sessions WHERE id = $1`, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// scanUser scans userColumns followed by any extra columns into extra.
+func scanUser(row rowScanner, extra ...interface{}) (*models.User, error) {
+	var user models.User
+	dest := append([]interface{}{&user.ID, &user.Email, &user.Name, &user.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	user.CreatedAt = user.CreatedAt.UTC()
+	return &user, nil
+}
+
+// scanSession scans a session's id, created_at and expires_at, its user's userColumns,
+// then any extra columns into extra.
+func scanSession(row rowScanner, extra ...interface{}) (*models.Session, error) {
+	var session models.Session
+	dest := append([]interface{}{
+		&session.ID,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+		&session.User.ID,
+		&session.User.Email,
+		&session.User.Name,
+		&session.User.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	session.CreatedAt = session.CreatedAt.UTC()
+	session.ExpiresAt = session.ExpiresAt.UTC()
+	session.User.CreatedAt = session.User.CreatedAt.UTC()
+	return &session, nil
+}
@@ -66,6 +66,16 @@ func TestValidateLimit(t *testing.T) {
 	}
 }
 
+func TestContextSize(t *testing.T) {
+	n := func(n int) *int { return &n }
+
+	assert.Equal(t, 10, ContextSize(nil), "default when unset")
+	assert.Equal(t, 0, ContextSize(n(0)), "explicit zero")
+	assert.Equal(t, 5, ContextSize(n(5)))
+	assert.Equal(t, 100, ContextSize(n(500)), "cap at max")
+	assert.Equal(t, 0, ContextSize(n(-3)))
+}
+
 func TestValidateOffset(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateAdminUser creates an admin user and returns it with a generated token.
+// The token is shown only once - caller must store it securely.
+// Requires admin credentials (middleware.AdminRequired).
+//
+// Request body:
+//
+//	{"name": "alice"}
+//
+// Returns 201 Created, 400 for validation errors, 409 if the name is taken,
+// 500 for database errors.
+func CreateAdminUser(store storage.AdminStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		var req models.CreateAdminUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		user, err := store.CreateAdminUser(ctx, req.Name)
+		if err != nil {
+			if errors.Is(err, database.ErrAdminUserExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("CreateAdminUser error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create admin user"})
+			return
+		}
+
+		recorded := *user
+		recorded.Token = ""
+		recordAudit(c, models.AuditAdminUserCreate, models.AuditTargetAdminUser, user.ID.String(), nil,
+			nil, recorded)
+		c.JSON(http.StatusCreated, user)
+	}
+}
+
+// ListAdminUsers returns every admin user, oldest first, without their tokens.
+// Requires admin credentials (middleware.AdminRequired).
+func ListAdminUsers(store storage.AdminStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		users, err := store.ListAdminUsers(ctx)
+		if err != nil {
+			log.Printf("ListAdminUsers error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list admin users"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.AdminUsersResponse{
+			AdminUsers: users,
+			Total:      len(users),
+		})
+	}
+}
+
+// DeleteAdminUser removes an admin user; their token stops working immediately.
+// The bootstrap ADMIN_TOKEN isn't an admin user and can only be changed in the environment.
+// Requires admin credentials (middleware.AdminRequired).
+// Returns 404 if the user doesn't exist, 500 for database errors.
+func DeleteAdminUser(store storage.AdminStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid admin user ID"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		if err := store.DeleteAdminUser(ctx, userID); err != nil {
+			if errors.Is(err, database.ErrAdminUserNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("DeleteAdminUser error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete admin user"})
+			return
+		}
+
+		recordAudit(c, models.AuditAdminUserDelete, models.AuditTargetAdminUser, userID.String(), nil,
+			nil, nil)
+		c.JSON(http.StatusOK, gin.H{"message": "admin user deleted"})
+	}
+}
+
+// requireAdmin reports whether the request was authenticated by middleware.AdminRequired,
+// responding 401 Unauthorized if not. Handlers check it themselves so that a route
+// registered without the middleware fails closed.
+func requireAdmin(c *gin.Context) bool {
+	if _, ok := c.Get("admin"); ok {
+		return true
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "admin credentials required"})
+	return false
+}
+
+// requireProjectAdmin reports whether the request may manage projectID: it was
+// authenticated as an admin, by one of the project's keys with the project:admin scope, or
+// by a user whose role in the project's organization grants it
+// (middleware.ProjectAdminRequired). Otherwise it responds 401 or 403.
+func requireProjectAdmin(c *gin.Context, projectID uuid.UUID) bool {
+	if _, ok := c.Get("admin"); ok {
+		return true
+	}
+
+	if value, ok := c.Get("role"); ok {
+		if c.MustGet("project_id").(uuid.UUID) != projectID || !value.(models.Role).HasScope(models.ScopeProjectAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this project"})
+			return false
+		}
+		return true
+	}
+
+	value, ok := c.Get("api_key")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin credentials required"})
+		return false
+	}
+	key := value.(*models.APIKey)
+	if key.ProjectID != projectID || !key.HasScope(models.ScopeProjectAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this project"})
+		return false
+	}
+	return true
+}
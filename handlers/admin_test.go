@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminUserLifecycle(t *testing.T) {
+	r := newTestRouter(memory.New())
+
+	// The bootstrap token creates the first admin user
+	w := doRequest(t, r, http.MethodPost, "/admin/users", testAdminToken, models.CreateAdminUserRequest{Name: "alice"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	alice := decode[models.AdminUser](t, w)
+	require.NotEmpty(t, alice.Token)
+
+	w = doRequest(t, r, http.MethodPost, "/admin/users", alice.Token, models.CreateAdminUserRequest{Name: "alice"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, "/admin/users", alice.Token, models.CreateAdminUserRequest{Name: "x"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Admin users manage projects like the bootstrap token
+	w = doRequest(t, r, http.MethodPost, "/projects", alice.Token, models.CreateProjectRequest{Name: "My Application"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	project := decode[models.Project](t, w)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/"+project.ID.String()+"/keys", alice.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/admin/users", alice.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list := decode[models.AdminUsersResponse](t, w)
+	require.Equal(t, 1, list.Total)
+	assert.Empty(t, list.AdminUsers[0].Token)
+	assert.Equal(t, alice.TokenPrefix, list.AdminUsers[0].TokenPrefix)
+
+	w = doRequest(t, r, http.MethodDelete, "/admin/users/"+alice.ID.String(), testAdminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/projects", alice.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, "/admin/users/"+alice.ID.String(), testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, "/admin/users/not-a-uuid", testAdminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestProjectRoutes_RequireAuth(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	projectPath := "/projects/" + project.ID.String()
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/projects"},
+		{http.MethodGet, "/projects"},
+		{http.MethodGet, projectPath},
+		{http.MethodDelete, projectPath},
+		{http.MethodPost, projectPath + "/keys"},
+		{http.MethodGet, projectPath + "/keys"},
+		{http.MethodGet, "/admin/users"},
+	}
+
+	for _, route := range routes {
+		for _, token := range []string{"", "wrong-admin-token"} {
+			w := doRequest(t, r, route.method, route.path, token, nil)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s with token %q", route.method, route.path, token)
+		}
+	}
+
+	// Project keys, even with project:admin, can't reach routes spanning every project
+	for _, path := range []string{"/projects", "/admin/users"} {
+		w := doRequest(t, r, http.MethodGet, path, project.APIKey, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+	}
+
+	_, err := store.GetProject(t.Context(), project.ID)
+	assert.NoError(t, err, "project survives unauthenticated requests")
+}
+
+func TestProjectRoutes_ProjectAdminKey(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	other := newTestProject(t, store)
+	projectPath := "/projects/" + project.ID.String()
+
+	w := doRequest(t, r, http.MethodPost, projectPath+"/keys", testAdminToken, models.CreateAPIKeyRequest{
+		Name:   "ops",
+		Scopes: []models.Scope{models.ScopeProjectAdmin},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	admin := decode[models.APIKey](t, w)
+
+	w = doRequest(t, r, http.MethodPost, projectPath+"/keys", testAdminToken, models.CreateAPIKeyRequest{Name: "service"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	service := decode[models.APIKey](t, w)
+
+	w = doRequest(t, r, http.MethodGet, projectPath+"/keys", admin.Key, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, projectPath, admin.Key, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, projectPath+"/keys", service.Key, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, string(models.ScopeProjectAdmin), decode[map[string]string](t, w)["required_scope"])
+
+	w = doRequest(t, r, http.MethodGet, "/projects/"+other.ID.String(), admin.Key, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, "/projects/"+other.ID.String(), project.APIKey, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/not-a-uuid", admin.Key, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestProjectHandlers_RefuseUnauthenticated(t *testing.T) {
+	store := memory.New()
+	project := newTestProject(t, store)
+
+	// Registered without the middleware, as if a route were wired by mistake
+	r := gin.New()
+	r.GET("/projects", ListProjects(store))
+	r.DELETE("/projects/:id", DeleteProject(store, testRestoreWindow))
+	r.POST("/admin/users", CreateAdminUser(store))
+
+	w := doRequest(t, r, http.MethodGet, "/projects", testAdminToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, "/projects/"+project.ID.String(), testAdminToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, "/admin/users", testAdminToken, models.CreateAdminUserRequest{Name: "mallory"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// An API key in the context only authorizes its own project
+	r = gin.New()
+	r.DELETE("/projects/:id", func(c *gin.Context) {
+		c.Set("api_key", &models.APIKey{ProjectID: uuid.New(), Scopes: models.AllScopes})
+	}, DeleteProject(store, testRestoreWindow))
+	w = doRequest(t, r, http.MethodDelete, "/projects/"+project.ID.String(), "", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	_, err := store.GetProject(t.Context(), project.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultGracePeriod is how long a rotated key keeps working when the request doesn't say.
+	DefaultGracePeriod = 24 * time.Hour
+
+	// MaxGracePeriod caps how long a rotated key can keep working.
+	MaxGracePeriod = 30 * 24 * time.Hour
+)
+
+// CreateAPIKey issues a new API key for a project.
+// The key is shown only once - caller must store it securely.
+// Like every key route, requires admin credentials, a project:admin key of the
+// same project or an organization admin's session (middleware.ProjectAdminRequired).
+//
+// Request body:
+//
+//	{"name": "browser-reporter", "scopes": ["logs:write"], "expires_at": "2025-01-01T00:00:00Z"}
+//
+// scopes defaults to ["logs:write", "logs:read"]; expires_at is optional.
+// Returns 201 Created with the key, 400 for validation errors,
+// 404 if project doesn't exist, 500 for database errors.
+func CreateAPIKey(store storage.APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		var req models.CreateAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Scopes != nil && len(req.Scopes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scopes must not be empty"})
+			return
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, err := store.CreateAPIKey(ctx, projectID, req)
+		if err != nil {
+			respondAPIKeyError(c, "CreateAPIKey", err)
+			return
+		}
+
+		recordAudit(c, models.AuditAPIKeyCreate, models.AuditTargetAPIKey, key.ID.String(), &projectID,
+			nil, auditAPIKey(key))
+		c.JSON(http.StatusCreated, key)
+	}
+}
+
+// ListAPIKeys returns a project's API keys, newest first, including revoked and expired
+// ones. Only key prefixes are returned, never the keys themselves.
+// Returns 404 if project doesn't exist, 500 for database errors.
+func ListAPIKeys(store storage.APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		keys, err := store.ListAPIKeys(ctx, projectID)
+		if err != nil {
+			respondAPIKeyError(c, "ListAPIKeys", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIKeysResponse{
+			Keys:  keys,
+			Total: len(keys),
+		})
+	}
+}
+
+// RevokeAPIKey stops an API key from authenticating immediately.
+// Revoked keys stay listed with revoked_at set; revoking one again is a no-op.
+// Returns the revoked key, 404 if project or key doesn't exist, 500 for database errors.
+func RevokeAPIKey(store storage.APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, keyID, ok := parseAPIKeyPath(c)
+		if !ok || !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, err := store.RevokeAPIKey(ctx, projectID, keyID)
+		if err != nil {
+			respondAPIKeyError(c, "RevokeAPIKey", err)
+			return
+		}
+
+		recordAudit(c, models.AuditAPIKeyRevoke, models.AuditTargetAPIKey, keyID.String(), &projectID,
+			nil, auditAPIKey(key))
+		c.JSON(http.StatusOK, key)
+	}
+}
+
+// RotateAPIKey replaces an API key without downtime: it issues a new key with the
+// same name and scopes and lets the old one keep working for a grace period, so
+// clients can switch over before it expires.
+//
+// Request body (optional):
+//
+//	{"grace_period": "1h"}
+//
+// Response:
+//
+//	{
+//	  "key": {"id": "...", "name": "billing-service", "key": "jazz_...", ...},
+//	  "previous": {"id": "...", "name": "billing-service", "expires_at": "...", ...}
+//	}
+//
+// Returns 201 Created, 400 for an invalid grace period, 404 if project or key doesn't
+// exist, 409 if the key is already revoked or expired, 500 for database errors.
+func RotateAPIKey(store storage.APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, keyID, ok := parseAPIKeyPath(c)
+		if !ok || !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		// The body is optional
+		var req models.RotateAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		gracePeriod, err := parseGracePeriod(req.GracePeriod)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, previous, err := store.RotateAPIKey(ctx, projectID, keyID, gracePeriod)
+		if err != nil {
+			respondAPIKeyError(c, "RotateAPIKey", err)
+			return
+		}
+
+		// The event's target is the rotated key; after holds it with its new expiry
+		// alongside the key that replaces it
+		recordAudit(c, models.AuditAPIKeyRotate, models.AuditTargetAPIKey, keyID.String(), &projectID,
+			nil, models.RotateAPIKeyResponse{Key: auditAPIKey(key), Previous: auditAPIKey(previous)})
+		c.JSON(http.StatusCreated, models.RotateAPIKeyResponse{
+			Key:      *key,
+			Previous: *previous,
+		})
+	}
+}
+
+// parseAPIKeyPath parses the :id and :key_id path parameters, responding 400 if either is invalid.
+func parseAPIKeyPath(c *gin.Context) (projectID, keyID uuid.UUID, ok bool) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	keyID, err = uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return projectID, keyID, true
+}
+
+// parseGracePeriod parses a Go duration between 0 and MaxGracePeriod.
+// Empty means DefaultGracePeriod.
+func parseGracePeriod(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultGracePeriod, nil
+	}
+
+	gracePeriod, err := time.ParseDuration(s)
+	if err != nil || gracePeriod < 0 || gracePeriod > MaxGracePeriod {
+		return 0, fmt.Errorf("grace_period must be a duration such as \"24h\", at most %dh", int(MaxGracePeriod.Hours()))
+	}
+	return gracePeriod, nil
+}
+
+// respondAPIKeyError maps API key store errors to status codes.
+func respondAPIKeyError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, database.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+	case errors.Is(err, database.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	case errors.Is(err, database.ErrAPIKeyInactive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("%s error: %v", op, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to manage API keys"})
+	}
+}
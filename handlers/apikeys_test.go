@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	keysPath := "/projects/" + project.ID.String() + "/keys"
+
+	w := doRequest(t, r, http.MethodPost, keysPath, testAdminToken, models.CreateAPIKeyRequest{Name: "billing-service"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	key := decode[models.APIKey](t, w)
+	assert.Equal(t, "billing-service", key.Name)
+	require.NotEmpty(t, key.Key)
+
+	// Both the project's first key and the new one authenticate
+	for _, apiKey := range []string{project.APIKey, key.Key} {
+		w = doRequest(t, r, http.MethodGet, "/logs", apiKey, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w = doRequest(t, r, http.MethodGet, keysPath, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list := decode[models.APIKeysResponse](t, w)
+	assert.Equal(t, 2, list.Total)
+	assert.NotContains(t, w.Body.String(), key.Key, "keys are only returned on creation")
+
+	// Rotation keeps the old key working for the grace period
+	w = doRequest(t, r, http.MethodPost, keysPath+"/"+key.ID.String()+"/rotate", testAdminToken, models.RotateAPIKeyRequest{GracePeriod: "1h"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	rotated := decode[models.RotateAPIKeyResponse](t, w)
+	assert.Equal(t, "billing-service", rotated.Key.Name)
+	require.NotNil(t, rotated.Previous.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *rotated.Previous.ExpiresAt, time.Minute)
+
+	for _, apiKey := range []string{key.Key, rotated.Key.Key} {
+		w = doRequest(t, r, http.MethodGet, "/logs", apiKey, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Revocation takes effect immediately
+	w = doRequest(t, r, http.MethodDelete, keysPath+"/"+key.ID.String(), testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, decode[models.APIKey](t, w).RevokedAt)
+
+	w = doRequest(t, r, http.MethodGet, "/logs", key.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, keysPath+"/"+key.ID.String()+"/rotate", testAdminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRotateAPIKey_DefaultGracePeriod(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	keysPath := "/projects/" + project.ID.String() + "/keys"
+
+	w := doRequest(t, r, http.MethodGet, keysPath, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	keyID := decode[models.APIKeysResponse](t, w).Keys[0].ID
+
+	// No body
+	w = doRequest(t, r, http.MethodPost, keysPath+"/"+keyID.String()+"/rotate", testAdminToken, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	rotated := decode[models.RotateAPIKeyResponse](t, w)
+	require.NotNil(t, rotated.Previous.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(DefaultGracePeriod), *rotated.Previous.ExpiresAt, time.Minute)
+}
+
+func TestAPIKeys_Validation(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	keysPath := "/projects/" + project.ID.String() + "/keys"
+	past := time.Now().Add(-time.Hour)
+
+	w := doRequest(t, r, http.MethodPost, keysPath, testAdminToken, models.CreateAPIKeyRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "name is required")
+
+	w = doRequest(t, r, http.MethodPost, keysPath, testAdminToken, models.CreateAPIKeyRequest{Name: "old", ExpiresAt: &past})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/not-a-uuid/keys", testAdminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/"+uuid.New().String()+"/keys", testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, keysPath+"/not-a-uuid", testAdminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, keysPath+"/"+uuid.New().String(), testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, keysPath, testAdminToken, nil)
+	keyID := decode[models.APIKeysResponse](t, w).Keys[0].ID
+	for _, gracePeriod := range []string{"soon", "-1h", "721h"} {
+		w = doRequest(t, r, http.MethodPost, keysPath+"/"+keyID.String()+"/rotate", testAdminToken, models.RotateAPIKeyRequest{GracePeriod: gracePeriod})
+		assert.Equal(t, http.StatusBadRequest, w.Code, gracePeriod)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	keysPath := "/projects/" + project.ID.String() + "/keys"
+
+	w := doRequest(t, r, http.MethodPost, keysPath, testAdminToken, models.CreateAPIKeyRequest{
+		Name:   "browser-reporter",
+		Scopes: []models.Scope{models.ScopeLogsWrite},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	ingest := decode[models.APIKey](t, w)
+	assert.Equal(t, []models.Scope{models.ScopeLogsWrite}, ingest.Scopes)
+
+	w = doRequest(t, r, http.MethodPost, "/logs", ingest.Key, []models.LogEntry{{Level: "error", Message: "TypeError"}})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// The ingest-only key can't read logs back
+	w = doRequest(t, r, http.MethodGet, "/logs", ingest.Key, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "logs:read")
+
+	w = doRequest(t, r, http.MethodPost, "/search", ingest.Key, models.SearchRequest{Query: "TypeError"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Keys default to reading and writing logs
+	w = doRequest(t, r, http.MethodPost, keysPath, testAdminToken, models.CreateAPIKeyRequest{Name: "service"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, models.DefaultScopes, decode[models.APIKey](t, w).Scopes)
+
+	for _, body := range []string{`{"name": "bad", "scopes": []}`, `{"name": "bad", "scopes": ["logs:delete"]}`} {
+		w = doRequest(t, r, http.MethodPost, keysPath, testAdminToken, json.RawMessage(body))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListAuditEvents returns the audit trail of administrative actions, newest first.
+// Requires admin credentials (middleware.AdminRequired).
+//
+// Query parameters:
+//   - project_id: events concerning a project, its keys and groups
+//   - actor_id: events by an admin user, user or API key
+//   - action: e.g. "project.delete" or "api_key.rotate"
+//   - start_time, end_time: inclusive range (RFC3339, epoch, or "now-24h" style)
+//   - limit: max results (default 50, max 1000)
+//   - offset: pagination offset
+//
+// Returns 400 for invalid filters, 500 for database errors.
+func ListAuditEvents(store storage.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		var params models.AuditQueryParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if params.Limit <= 0 {
+			params.Limit = defaultLimit
+		}
+		if params.Limit > maxLimit {
+			params.Limit = maxLimit
+		}
+		if params.Offset < 0 {
+			params.Offset = defaultOffset
+		}
+
+		ctx := c.Request.Context()
+		events, total, err := store.ListAuditEvents(ctx, params)
+		if err != nil {
+			respondReadError(c, err, "failed to list audit events")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.AuditEventsResponse{
+			Events:  events,
+			Total:   total,
+			Limit:   params.Limit,
+			Offset:  params.Offset,
+			HasMore: params.Offset+params.Limit < total,
+		})
+	}
+}
+
+// recordAudit attaches an audit event for a change the handler made to the request;
+// middleware.Audit records it with the actor, request ID and source IP once the
+// handler returns. before and after are stored as JSON, nil meaning none, so callers
+// pass copies without secrets (see auditProject, auditAPIKey).
+func recordAudit(c *gin.Context, action, targetType, targetID string, projectID *uuid.UUID, before, after any) {
+	c.Set("audit_event", &models.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		ProjectID:  projectID,
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+	})
+}
+
+func auditJSON(value any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("audit: failed to encode %T: %v", value, err)
+		return nil
+	}
+	return data
+}
+
+// auditProject returns project as it is recorded in audit events: without the
+// plaintext API key of a new project or listing stats.
+func auditProject(project *models.Project) models.Project {
+	recorded := *project
+	recorded.APIKey = ""
+	recorded.Stats = nil
+	return recorded
+}
+
+// auditAPIKey returns key as it is recorded in audit events: without the plaintext key.
+func auditAPIKey(key *models.APIKey) models.APIKey {
+	recorded := *key
+	recorded.Key = ""
+	return recorded
+}
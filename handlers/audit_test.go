@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"jazz/middleware"
+	"jazz/models"
+	"jazz/storage/memory"
+	"jazz/storage/sqlite"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTrail(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+
+	w := doRequest(t, r, http.MethodPost, "/projects", testAdminToken, models.CreateProjectRequest{Name: "My Application"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	project := decode[models.Project](t, w)
+	requestID := w.Header().Get(middleware.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+	projectPath := "/projects/" + project.ID.String()
+
+	// project:admin keys are actors too
+	w = doRequest(t, r, http.MethodPatch, projectPath, project.APIKey, map[string]interface{}{"name": "Renamed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodPost, projectPath+"/keys", testAdminToken, models.CreateAPIKeyRequest{Name: "billing"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	key := decode[models.APIKey](t, w)
+
+	w = doRequest(t, r, http.MethodPost, projectPath+"/keys/"+key.ID.String()+"/rotate", testAdminToken, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	rotated := decode[models.RotateAPIKeyResponse](t, w)
+
+	w = doRequest(t, r, http.MethodDelete, projectPath, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Reads and failed changes aren't recorded
+	doRequest(t, r, http.MethodGet, "/projects", testAdminToken, nil)
+	w = doRequest(t, r, http.MethodDelete, projectPath, testAdminToken, nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// Restores are recorded, reading the project isn't
+	w = doRequest(t, r, http.MethodPost, projectPath+"/restore", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest(t, r, http.MethodGet, projectPath, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(t, r, http.MethodPost, projectPath+"/restore", testAdminToken, nil)
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/audit", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	trail := decode[models.AuditEventsResponse](t, w)
+	require.Equal(t, 6, trail.Total)
+	actions := make([]string, 0, len(trail.Events))
+	for _, event := range trail.Events {
+		actions = append(actions, event.Action)
+	}
+	assert.Equal(t, []string{
+		models.AuditProjectRestore, models.AuditProjectDelete, models.AuditAPIKeyRotate, models.AuditAPIKeyCreate,
+		models.AuditProjectUpdate, models.AuditProjectCreate,
+	}, actions)
+
+	create := trail.Events[5]
+	assert.Equal(t, models.AuditActorAdmin, create.ActorType)
+	assert.Equal(t, models.BootstrapAdminName, create.ActorName)
+	assert.Equal(t, requestID, create.RequestID)
+	assert.NotEmpty(t, create.SourceIP)
+	assert.Equal(t, "null", string(create.Before))
+	assert.NotContains(t, string(create.After), project.APIKey, "secrets are never recorded")
+
+	update := trail.Events[4]
+	assert.Equal(t, models.AuditActorAPIKey, update.ActorType)
+	require.NotNil(t, update.ActorID)
+	var before, after models.Project
+	require.NoError(t, json.Unmarshal(update.Before, &before))
+	require.NoError(t, json.Unmarshal(update.After, &after))
+	assert.Equal(t, "My Application", before.Name)
+	assert.Equal(t, "Renamed", after.Name)
+
+	rotate := trail.Events[2]
+	assert.Equal(t, key.ID.String(), rotate.TargetID)
+	assert.NotContains(t, string(rotate.After), rotated.Key.Key)
+	assert.Equal(t, "null", string(trail.Events[1].After))
+
+	restore := trail.Events[0]
+	assert.Equal(t, project.ID.String(), restore.TargetID)
+	require.NoError(t, json.Unmarshal(restore.Before, &before))
+	require.NoError(t, json.Unmarshal(restore.After, &after))
+	assert.Equal(t, "Renamed", before.Name)
+	assert.Equal(t, project.ID, after.ID)
+
+	w = doRequest(t, r, http.MethodGet, "/audit?action=api_key.create&project_id="+project.ID.String(), testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, decode[models.AuditEventsResponse](t, w).Total)
+
+	w = doRequest(t, r, http.MethodGet, "/audit?limit=2", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	page := decode[models.AuditEventsResponse](t, w)
+	assert.Len(t, page.Events, 2)
+	assert.True(t, page.HasMore)
+
+	w = doRequest(t, r, http.MethodGet, "/audit?project_id=nope", testAdminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The trail is for admins only
+	w = doRequest(t, r, http.MethodGet, "/audit", key.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuditTrail_Retention(t *testing.T) {
+	// The in-memory store has no retention, so this runs against SQLite
+	store, err := sqlite.Open(context.Background(), ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	r := newTestRouter(store)
+
+	w := doRequest(t, r, http.MethodPost, "/projects", testAdminToken, models.CreateProjectRequest{Name: "My Application"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	project := decode[models.Project](t, w)
+	retentionPath := "/projects/" + project.ID.String() + "/retention"
+
+	w = doRequest(t, r, http.MethodPut, retentionPath, testAdminToken, map[string]interface{}{"retention_days": 30})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest(t, r, http.MethodPut, retentionPath, testAdminToken, map[string]interface{}{"retention_days": nil})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodGet, "/audit?action="+models.AuditProjectRetention, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	trail := decode[models.AuditEventsResponse](t, w)
+	require.Len(t, trail.Events, 2)
+
+	// Both sides are the project as stored, not the request body
+	var before, after models.Project
+	set := trail.Events[1]
+	require.NoError(t, json.Unmarshal(set.Before, &before))
+	require.NoError(t, json.Unmarshal(set.After, &after))
+	assert.Equal(t, project.ID, before.ID)
+	assert.Equal(t, "My Application", after.Name)
+	assert.Nil(t, before.RetentionDays)
+	require.NotNil(t, after.RetentionDays)
+	assert.Equal(t, 30, *after.RetentionDays)
+
+	cleared := trail.Events[0]
+	require.NoError(t, json.Unmarshal(cleared.Before, &before))
+	require.NoError(t, json.Unmarshal(cleared.After, &after))
+	require.NotNil(t, before.RetentionDays)
+	assert.Equal(t, 30, *before.RetentionDays)
+	assert.Nil(t, after.RetentionDays)
+
+	// Unknown projects aren't recorded
+	w = doRequest(t, r, http.MethodPut, "/projects/"+uuid.NewString()+"/retention", testAdminToken, map[string]interface{}{"retention_days": 7})
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	w = doRequest(t, r, http.MethodGet, "/audit?action="+models.AuditProjectRetention, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, decode[models.AuditEventsResponse](t, w).Total)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+	exportCompressGzip = "gzip"
+
+	// exportFlushEvery controls how often buffered output is pushed to the client
+	exportFlushEvery = 1000
+)
+
+var exportCSVHeader = []string{"id", "project_id", "level", "message", "source", "timestamp"}
+
+// ExportLogs streams every log matching the query filters as a file download.
+// Rows are read from a database cursor and written as they arrive, so exports
+// are not capped at maxLimit and are never buffered in memory.
+//
+// Query parameters:
+//   - level, source, start_time, end_time, tz, search, sort: same as GET /logs
+//   - format: "ndjson" (default, one JSON log per line) or "csv"
+//   - compress: "gzip" to download a gzip-compressed file
+//
+// Returns 400 for invalid parameters or filters and 500 if the export fails before any data is sent.
+// Failures after streaming starts can't change the status code; the response is cut short.
+func ExportLogs(store storage.ExportStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, exists := c.Get("project_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var params models.ExportParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if params.Format == "" {
+			params.Format = exportFormatNDJSON
+		}
+
+		// Headers are sent with the first row, so early failures still get a proper status
+		var writer *exportWriter
+		begin := func() {
+			writer = newExportWriter(c, projectID.(uuid.UUID), params)
+		}
+
+		ctx := c.Request.Context()
+		count, err := store.ExportLogs(ctx, projectID.(uuid.UUID), params.QueryParams, func(entry models.LogEntry) error {
+			if writer == nil {
+				begin()
+			}
+			return writer.write(entry)
+		})
+		if err != nil {
+			if writer == nil {
+				respondReadError(c, err, "failed to export logs")
+				return
+			}
+			log.Printf("export aborted after %d logs: %v", count, err)
+			return
+		}
+
+		if writer == nil {
+			begin()
+		}
+		if err := writer.close(); err != nil {
+			log.Printf("failed to finish export: %v", err)
+		}
+	}
+}
+
+// exportWriter encodes logs in the requested format, optionally gzip-compressed,
+// flushing to the client every exportFlushEvery rows.
+type exportWriter struct {
+	c       *gin.Context
+	format  string
+	buf     *bufio.Writer
+	gz      *gzip.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+	written int
+}
+
+func newExportWriter(c *gin.Context, projectID uuid.UUID, params models.ExportParams) *exportWriter {
+	filename := fmt.Sprintf("logs-%s-%s.%s", projectID, time.Now().UTC().Format("20060102T150405Z"), params.Format)
+	contentType := "application/x-ndjson"
+	if params.Format == exportFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+
+	w := &exportWriter{c: c, format: params.Format}
+
+	var out io.Writer = c.Writer
+	if params.Compress == exportCompressGzip {
+		filename += ".gz"
+		contentType = "application/gzip"
+		w.gz = gzip.NewWriter(c.Writer)
+		out = w.gz
+	}
+	w.buf = bufio.NewWriterSize(out, 32*1024)
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	if w.format == exportFormatCSV {
+		w.csv = csv.NewWriter(w.buf)
+		_ = w.csv.Write(exportCSVHeader)
+	} else {
+		w.json = json.NewEncoder(w.buf)
+	}
+
+	return w
+}
+
+func (w *exportWriter) write(entry models.LogEntry) error {
+	var err error
+	if w.csv != nil {
+		err = w.csv.Write([]string{
+			entry.ID.String(),
+			entry.ProjectID.String(),
+			entry.Level,
+			entry.Message,
+			entry.Source,
+			entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		})
+	} else {
+		err = w.json.Encode(entry)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode log: %w", err)
+	}
+
+	w.written++
+	if w.written%exportFlushEvery == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+func (w *exportWriter) close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			return err
+		}
+		w.c.Writer.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"jazz/models"
+	"jazz/storage"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetLogFacets returns the most frequent level and source values for the authenticated project.
+// Accepts the same filters as GET /logs, so triage views can show what dominates a result set.
+// Limit and offset are ignored; facets always describe every matching log.
+//
+// Query parameters:
+//   - level, source, start_time, end_time, tz, search: same as GET /logs
+//   - size: max distinct values per facet (default 10, max 100)
+//
+// Response:
+//
+//	{
+//	  "facets": {
+//	    "level":  [{"value": "error", "count": 120}, ...],
+//	    "source": [{"value": "backend", "count": 98}, ...]
+//	  },
+//	  "total": 154,
+//	  "query_time_ms": 12
+//	}
+//
+// Returns 400 for invalid filters, 500 for database errors.
+func GetLogFacets(store storage.FacetStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, exists := c.Get("project_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var params models.FacetParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		start := time.Now()
+		ctx := c.Request.Context()
+		response, err := store.FacetLogs(ctx, projectID.(uuid.UUID), params.QueryParams, params.Size)
+		if err != nil {
+			respondReadError(c, err, "failed to retrieve facets")
+			return
+		}
+		queryTimeMs := time.Since(start).Milliseconds()
+		response.QueryTimeMs = &queryTimeMs
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// SearchLogFacets returns the most frequent level and source values among full-text search matches.
+// Accepts the same body as POST /search plus an optional "size" (default 10, max 100).
+// Returns 400 for invalid requests, 500 for database errors.
+func SearchLogFacets(store storage.FacetStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, exists := c.Get("project_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		var req models.SearchFacetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		start := time.Now()
+		ctx := c.Request.Context()
+		response, err := store.FacetSearch(ctx, projectID.(uuid.UUID), req.SearchRequest, req.Size)
+		if err != nil {
+			respondReadError(c, err, "search facets failed")
+			return
+		}
+		queryTimeMs := time.Since(start).Milliseconds()
+		response.QueryTimeMs = &queryTimeMs
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GrantProjectGroup lets members of an identity provider group read a project's logs
+// when they sign in with the provider (middleware.OIDCAuth).
+// Requires admin credentials, a project:admin key or an organization admin's session
+// (middleware.ProjectAdminRequired).
+//
+// Request body:
+//
+//	{"group": "sre"}
+//
+// Returns 201 Created, 400 for validation errors, 404 if project doesn't exist,
+// 409 if the group already has access, 500 for database errors.
+func GrantProjectGroup(store storage.ProjectGroupStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		var req models.GrantProjectGroupRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		grant, err := store.GrantProjectGroup(ctx, projectID, req.Group)
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrProjectNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, database.ErrProjectGroupExists):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("GrantProjectGroup error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant group"})
+			}
+			return
+		}
+
+		recordAudit(c, models.AuditGroupGrant, models.AuditTargetProjectGroup, grant.Group, &projectID,
+			nil, grant)
+		c.JSON(http.StatusCreated, grant)
+	}
+}
+
+// ListProjectGroups returns the groups that may read a project's logs, in name order.
+// Requires admin credentials, a project:admin key or an organization admin's session
+// (middleware.ProjectAdminRequired). Returns 404 if project doesn't exist.
+func ListProjectGroups(store storage.ProjectGroupStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		groups, err := store.ListProjectGroups(ctx, projectID)
+		if err != nil {
+			if errors.Is(err, database.ErrProjectNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("ListProjectGroups error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list groups"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ProjectGroupsResponse{
+			Groups: groups,
+			Total:  len(groups),
+		})
+	}
+}
+
+// RevokeProjectGroup removes a group's access; its members' tokens stop working for the
+// project immediately. Requires admin credentials, a project:admin key or an organization
+// admin's session (middleware.ProjectAdminRequired).
+// Returns 404 if the project doesn't grant the group, 500 for database errors.
+func RevokeProjectGroup(store storage.ProjectGroupStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		group := c.Param("group")
+		ctx := c.Request.Context()
+		if err := store.RevokeProjectGroup(ctx, projectID, group); err != nil {
+			if errors.Is(err, database.ErrProjectGroupNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("RevokeProjectGroup error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke group"})
+			return
+		}
+
+		recordAudit(c, models.AuditGroupRevoke, models.AuditTargetProjectGroup, group, &projectID,
+			models.GrantProjectGroupRequest{Group: group}, nil)
+		c.JSON(http.StatusOK, gin.H{"message": "group revoked"})
+	}
+}
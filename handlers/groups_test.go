@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectGroups(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	groupsPath := "/projects/" + project.ID.String() + "/groups"
+
+	w := doRequest(t, r, http.MethodPost, groupsPath, testAdminToken, models.GrantProjectGroupRequest{Group: "sre"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "sre", decode[models.ProjectGroup](t, w).Group)
+
+	w = doRequest(t, r, http.MethodPost, groupsPath, project.APIKey, models.GrantProjectGroupRequest{Group: "eng"})
+	require.Equal(t, http.StatusCreated, w.Code, "project:admin keys manage their project's groups")
+
+	w = doRequest(t, r, http.MethodPost, groupsPath, testAdminToken, models.GrantProjectGroupRequest{Group: "sre"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest(t, r, http.MethodPost, groupsPath, testAdminToken, models.GrantProjectGroupRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, groupsPath, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list := decode[models.ProjectGroupsResponse](t, w)
+	require.Equal(t, 2, list.Total)
+	assert.Equal(t, "eng", list.Groups[0].Group)
+
+	w = doRequest(t, r, http.MethodDelete, groupsPath+"/sre", testAdminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(t, r, http.MethodDelete, groupsPath+"/sre", testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, groupsPath, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"jazz/middleware"
+	"jazz/models"
+	"jazz/storage"
+	"jazz/storage/memory"
+	"jazz/tail"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testAdminToken is newTestRouter's bootstrap ADMIN_TOKEN.
+const testAdminToken = "test-admin-token-0123456789"
+
+// testRestoreWindow is how long newTestRouter lets deleted projects be restored.
+const testRestoreWindow = 24 * time.Hour
+
+// newTestRouter wires the routes like main.go, registering those of the optional
+// interfaces (storage.RetentionStore, ...) only when store implements them.
+func newTestRouter(store storage.Store) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Audit(store))
+
+	r.GET("/health", HealthCheck)
+
+	admin := r.Group("")
+	admin.Use(middleware.AdminRequired(store, testAdminToken))
+	admin.POST("/projects", CreateProject(store))
+	admin.GET("/projects", ListProjects(store))
+	admin.POST("/admin/users", CreateAdminUser(store))
+	admin.GET("/admin/users", ListAdminUsers(store))
+	admin.DELETE("/admin/users/:id", DeleteAdminUser(store))
+	admin.GET("/audit", ListAuditEvents(store))
+
+	project := r.Group("/projects/:id")
+	project.Use(middleware.ProjectAdminRequired(store, testAdminToken))
+	project.GET("", GetProject(store))
+	project.PATCH("", UpdateProject(store))
+	project.DELETE("", DeleteProject(store, testRestoreWindow))
+	project.POST("/restore", RestoreProject(store, testRestoreWindow))
+	project.POST("/keys", CreateAPIKey(store))
+	project.GET("/keys", ListAPIKeys(store))
+	project.DELETE("/keys/:key_id", RevokeAPIKey(store))
+	project.POST("/keys/:key_id/rotate", RotateAPIKey(store))
+	project.POST("/groups", GrantProjectGroup(store))
+	project.GET("/groups", ListProjectGroups(store))
+	project.DELETE("/groups/:group", RevokeProjectGroup(store))
+	if retentionStore, ok := store.(storage.RetentionStore); ok {
+		project.GET("/retention", GetRetentionStatus(retentionStore))
+		project.PUT("/retention", UpdateRetention(store, retentionStore))
+	}
+
+	r.POST("/auth/register", Register(store))
+	r.POST("/auth/login", Login(store))
+
+	account := r.Group("")
+	account.Use(middleware.SessionRequired(store))
+	account.POST("/auth/logout", Logout(store))
+	account.GET("/auth/me", Me(store))
+	account.POST("/orgs", CreateOrganization(store))
+	account.GET("/orgs", ListOrganizations(store))
+	account.GET("/orgs/:org_id/members", ListMembers(store))
+	account.POST("/orgs/:org_id/members", AddMember(store))
+	account.PATCH("/orgs/:org_id/members/:user_id", UpdateMember(store))
+	account.DELETE("/orgs/:org_id/members/:user_id", RemoveMember(store))
+	account.POST("/orgs/:org_id/projects", CreateOrganizationProject(store))
+	account.GET("/orgs/:org_id/projects", ListOrganizationProjects(store))
+
+	writer := r.Group("")
+	writer.Use(middleware.AuthRequired(store, models.ScopeLogsWrite), middleware.RateLimit(store))
+	writer.POST("/logs", IngestLogs(store, tail.NewHub(tail.DefaultBufferSize)))
+
+	reader := r.Group("")
+	reader.Use(middleware.AuthRequired(store, models.ScopeLogsRead))
+	reader.GET("/logs", GetLogs(store))
+	reader.GET("/logs/:id", GetLog(store))
+	reader.POST("/search", SearchLogs(store))
+	if facetStore, ok := store.(storage.FacetStore); ok {
+		reader.GET("/logs/facets", GetLogFacets(facetStore))
+		reader.POST("/search/facets", SearchLogFacets(facetStore))
+	}
+	if exportStore, ok := store.(storage.ExportStore); ok {
+		reader.GET("/logs/export", ExportLogs(exportStore))
+	}
+	if contextStore, ok := store.(storage.ContextStore); ok {
+		reader.GET("/logs/:id/context", GetLogContext(contextStore))
+	}
+
+	return r
+}
+
+// newTestProject creates a project directly in the store.
+func newTestProject(t *testing.T, store *memory.Store) *models.Project {
+	t.Helper()
+
+	project, err := store.CreateProject(context.Background(), "Test Project")
+	require.NoError(t, err)
+	return project
+}
+
+// doRequest sends body (JSON-encoded unless nil) with apiKey as bearer token (if set).
+func doRequest(t *testing.T, r http.Handler, method, path, apiKey string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
@@ -1,13 +1,18 @@
 // Package handlers implements HTTP request handlers for the Jazz API.
-// All handlers follow the Gin framework pattern and require database access.
+// All handlers follow the Gin framework pattern and take the storage interfaces they need.
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"jazz/database"
 	"jazz/models"
+	"jazz/storage"
+	"jazz/tail"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,7 +36,9 @@ func HealthCheck(c *gin.Context) {
 
 // IngestLogs accepts a batch of log entries and stores them in the database.
 // Requires valid API key authentication (project_id in context).
-// Validates batch size (1-1000 logs) and generates UUIDs/timestamps if missing.
+// Validates batch size (1-1000 logs, or the project's max_batch_size) and each entry
+// against the project's allowed_levels and max_message_length settings, and generates
+// UUIDs/timestamps if missing.
 //
 // Request body:
 //
@@ -41,9 +48,11 @@ func HealthCheck(c *gin.Context) {
 //	  ]
 //	}
 //
-// Returns 201 Created on success, 400 for validation errors, 500 for database errors.
+// Returns 201 Created on success, 400 for validation errors, 500 for database errors
+// (429 from middleware.RateLimit for projects over their ingestion limits).
 // All logs in batch are inserted atomically - partial failures are not allowed.
-func IngestLogs(db *database.DB) gin.HandlerFunc {
+// Stored logs are then published to hub for live tail subscribers.
+func IngestLogs(store storage.Store, hub *tail.Hub) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get project from auth middleware
 		projectID, exists := c.Get("project_id")
@@ -58,10 +67,16 @@ func IngestLogs(db *database.DB) gin.HandlerFunc {
 			return
 		}
 
-		if len(logs) < minBatchSize || len(logs) > maxBatchSize {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("batch size must be between %d and %d", minBatchSize, maxBatchSize),
-			})
+		ctx := c.Request.Context()
+		project, err := requestProject(c, store, projectID.(uuid.UUID))
+		if err != nil {
+			log.Printf("GetProject error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store logs"})
+			return
+		}
+
+		if err := checkBatch(logs, project.Settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
@@ -74,8 +89,7 @@ func IngestLogs(db *database.DB) gin.HandlerFunc {
 			}
 		}
 
-		ctx := c.Request.Context()
-		if err := db.InsertLogsBatch(ctx, logs); err != nil {
+		if err := store.InsertLogsBatch(ctx, logs); err != nil {
 			log.Printf("failed to insert logs: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "failed to store logs",
@@ -83,6 +97,8 @@ func IngestLogs(db *database.DB) gin.HandlerFunc {
 			return
 		}
 
+		hub.Publish(ctx, logs)
+
 		log.Printf("ingested %d logs for project %s", len(logs), projectID)
 		c.JSON(http.StatusCreated, gin.H{
 			"message": "logs stored",
@@ -91,6 +107,37 @@ func IngestLogs(db *database.DB) gin.HandlerFunc {
 	}
 }
 
+// requestProject returns the project middleware.RateLimit loaded for the request, or
+// loads it from store.
+func requestProject(c *gin.Context, store storage.ProjectStore, projectID uuid.UUID) (*models.Project, error) {
+	if value, ok := c.Get("project"); ok {
+		return value.(*models.Project), nil
+	}
+	return store.GetProject(c.Request.Context(), projectID)
+}
+
+// checkBatch validates a batch's size and entries against a project's settings.
+func checkBatch(logs []models.LogEntry, settings models.ProjectSettings) error {
+	limit := maxBatchSize
+	if settings.MaxBatchSize > 0 {
+		limit = min(settings.MaxBatchSize, maxBatchSize)
+	}
+	if len(logs) < minBatchSize || len(logs) > limit {
+		return fmt.Errorf("batch size must be between %d and %d", minBatchSize, limit)
+	}
+
+	for i, entry := range logs {
+		if len(settings.AllowedLevels) > 0 && !slices.Contains(settings.AllowedLevels, entry.Level) {
+			return fmt.Errorf("logs[%d]: level %q is not allowed (allowed: %s)",
+				i, entry.Level, strings.Join(settings.AllowedLevels, ", "))
+		}
+		if settings.MaxMessageLength > 0 && len(entry.Message) > settings.MaxMessageLength {
+			return fmt.Errorf("logs[%d]: message is longer than %d bytes", i, settings.MaxMessageLength)
+		}
+	}
+	return nil
+}
+
 // GetLogs retrieves logs for the authenticated project with optional filtering.
 // Supports filtering by level, source, time range, pagination, and full-text search.
 // If 'search' parameter is provided, performs full-text search instead of basic query.
@@ -98,14 +145,17 @@ func IngestLogs(db *database.DB) gin.HandlerFunc {
 // Query parameters:
 //   - level: filter by log level (exact match)
 //   - source: filter by source (exact match)
-//   - start_time: RFC3339 timestamp (inclusive)
-//   - end_time: RFC3339 timestamp (inclusive)
+//   - start_time: inclusive lower bound (RFC3339, epoch seconds/millis, or "now-15m" style)
+//   - end_time: inclusive upper bound (same formats as start_time)
+//   - tz: IANA time zone for relative times and timestamps without an offset (default UTC)
+//   - sort: e.g. "timestamp:asc" or "level,timestamp:desc" (default "timestamp:desc")
 //   - limit: max results (default 50, max 1000)
 //   - offset: pagination offset
 //   - search: full-text search query (triggers SearchLogs)
 //
 // Response includes logs array, total count, and has_more flag for pagination.
-func GetLogs(db *database.DB) gin.HandlerFunc {
+// Returns 400 for invalid filters (bad time range, time zone or search query), 500 for database errors.
+func GetLogs(store storage.LogStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		projectID, exists := c.Get("project_id")
 		if !exists {
@@ -130,12 +180,9 @@ func GetLogs(db *database.DB) gin.HandlerFunc {
 		}
 
 		ctx := c.Request.Context()
-		logs, total, err := db.QueryLogs(ctx, projectID.(uuid.UUID), params)
+		logs, total, err := store.QueryLogs(ctx, projectID.(uuid.UUID), params)
 		if err != nil {
-			log.Printf("failed to query logs: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "failed to retrieve logs",
-			})
+			respondReadError(c, err, "failed to retrieve logs")
 			return
 		}
 
@@ -151,6 +198,84 @@ func GetLogs(db *database.DB) gin.HandlerFunc {
 	}
 }
 
+// GetLog retrieves a single log entry by ID for the authenticated project.
+// Returns 400 for a malformed ID, 404 if the entry doesn't exist in the project, 500 for database errors.
+func GetLog(store storage.LogStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, exists := c.Get("project_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		logID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log ID"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		entry, err := store.GetLog(ctx, projectID.(uuid.UUID), logID)
+		if err != nil {
+			if errors.Is(err, database.ErrLogNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "log not found"})
+				return
+			}
+			log.Printf("failed to get log: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve log"})
+			return
+		}
+
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// GetLogContext retrieves a log entry with the entries logged just before and after it.
+// Useful after finding an error via search to see what the service was doing around it.
+// Neighbours are ordered by (timestamp, id) and returned oldest first.
+//
+// Query parameters:
+//   - before: entries before the target (default 10, max 100; 0 for none)
+//   - after: entries after the target (default 10, max 100; 0 for none)
+//   - same_source: only include entries with the target's source (default false)
+//
+// Returns 400 for a malformed ID, 404 if the entry doesn't exist in the project, 500 for database errors.
+func GetLogContext(store storage.ContextStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, exists := c.Get("project_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		logID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log ID"})
+			return
+		}
+
+		var params models.LogContextParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		response, err := store.GetLogContext(ctx, projectID.(uuid.UUID), logID, params)
+		if err != nil {
+			if errors.Is(err, database.ErrLogNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "log not found"})
+				return
+			}
+			log.Printf("failed to get log context: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve log context"})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
 // SearchLogs performs full-text search on log messages for the authenticated project.
 // Uses PostgreSQL GIN indexes for fast search across large datasets.
 // Results include relevance ranking and query execution time.
@@ -163,13 +288,15 @@ func GetLogs(db *database.DB) gin.HandlerFunc {
 //	  "source": "backend",        // optional
 //	  "start_time": "...",        // optional
 //	  "end_time": "...",          // optional
+//	  "tz": "Europe/Berlin",      // optional
+//	  "sort": "timestamp:asc",    // optional, default "rank:desc,timestamp:desc"
 //	  "limit": 50,                // optional
 //	  "offset": 0                 // optional
 //	}
 //
 // Response includes logs with rank field, total count, and query_time_ms.
-// Returns 400 for invalid queries (too short, etc.), 500 for database errors.
-func SearchLogs(db *database.DB) gin.HandlerFunc {
+// Returns 400 for invalid queries (too short, bad time range, etc.), 500 for database errors.
+func SearchLogs(store storage.LogStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		projectID, exists := c.Get("project_id")
 		if !exists {
@@ -196,12 +323,9 @@ func SearchLogs(db *database.DB) gin.HandlerFunc {
 
 		start := time.Now()
 		ctx := c.Request.Context()
-		logs, total, err := db.SearchLogs(ctx, projectID.(uuid.UUID), req)
+		logs, total, err := store.SearchLogs(ctx, projectID.(uuid.UUID), req)
 		if err != nil {
-			log.Printf("search error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "search failed",
-			})
+			respondReadError(c, err, "search failed")
 			return
 		}
 		queryTimeMs := time.Since(start).Milliseconds()
@@ -218,3 +342,16 @@ func SearchLogs(db *database.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, response)
 	}
 }
+
+// respondReadError responds 400 with the validation message if err is a *database.QueryError
+// (invalid filters are the client's fault), otherwise logs err and responds 500 with message.
+func respondReadError(c *gin.Context, err error, message string) {
+	var queryErr *database.QueryError
+	if errors.As(err, &queryErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": queryErr.Error()})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestAndGetLogs(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+
+	w := doRequest(t, r, http.MethodPost, "/logs", project.APIKey, []models.LogEntry{
+		{Level: "info", Message: "Server started", Source: "api"},
+		{Level: "error", Message: "Database timeout", Source: "api"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, float64(2), decode[map[string]interface{}](t, w)["count"])
+
+	w = doRequest(t, r, http.MethodGet, "/logs?level=error", project.APIKey, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := decode[models.LogsResponse](t, w)
+	assert.Equal(t, int64(1), response.Total)
+	assert.Equal(t, 50, response.Limit)
+	assert.False(t, response.HasMore)
+	require.Len(t, response.Logs, 1)
+	assert.Equal(t, project.ID, response.Logs[0].ProjectID)
+	assert.False(t, response.Logs[0].Timestamp.IsZero(), "missing timestamps are filled in")
+
+	w = doRequest(t, r, http.MethodGet, "/logs/"+response.Logs[0].ID.String(), project.APIKey, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Database timeout", decode[models.LogEntry](t, w).Message)
+}
+
+func TestIngestLogs_Validation(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{name: "empty batch", body: []models.LogEntry{}},
+		{name: "missing message", body: []map[string]string{{"level": "info"}}},
+		{name: "not an array", body: map[string]string{"level": "info"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(t, r, http.MethodPost, "/logs", project.APIKey, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	tooMany := make([]models.LogEntry, maxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = models.LogEntry{Level: "info", Message: "x"}
+	}
+	w := doRequest(t, r, http.MethodPost, "/logs", project.APIKey, tooMany)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIngestLogs_ProjectSettings(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+
+	_, err := store.UpdateProject(context.Background(), project.ID, models.UpdateProjectRequest{
+		Settings: &models.ProjectSettings{
+			AllowedLevels:    []string{"info", "error"},
+			MaxBatchSize:     2,
+			MaxMessageLength: 10,
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		logs       []models.LogEntry
+		wantStatus int
+	}{
+		{name: "within limits", logs: []models.LogEntry{{Level: "info", Message: "ok"}, {Level: "error", Message: "failed"}}, wantStatus: http.StatusCreated},
+		{name: "level not allowed", logs: []models.LogEntry{{Level: "debug", Message: "ok"}}, wantStatus: http.StatusBadRequest},
+		{name: "message too long", logs: []models.LogEntry{{Level: "info", Message: "way too long"}}, wantStatus: http.StatusBadRequest},
+		{name: "batch too large", logs: []models.LogEntry{{Level: "info", Message: "1"}, {Level: "info", Message: "2"}, {Level: "info", Message: "3"}}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(t, r, http.MethodPost, "/logs", project.APIKey, tt.logs)
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestGetLogs_InvalidFiltersAreBadRequests(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+
+	for _, query := range []string{
+		"start_time=yesterday",
+		"tz=Mars/Olympus",
+		"sort=message:asc",
+		"search=a",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := doRequest(t, r, http.MethodGet, "/logs?"+query, project.APIKey, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.NotEmpty(t, decode[map[string]string](t, w)["error"])
+		})
+	}
+}
+
+func TestGetLog_NotFound(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	other := newTestProject(t, store)
+
+	w := doRequest(t, r, http.MethodPost, "/logs", other.APIKey, []models.LogEntry{{Level: "info", Message: "secret"}})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doRequest(t, r, http.MethodGet, "/logs", other.APIKey, nil)
+	otherLog := decode[models.LogsResponse](t, w).Logs[0]
+
+	// Another project's log is indistinguishable from a missing one
+	w = doRequest(t, r, http.MethodGet, "/logs/"+otherLog.ID.String(), project.APIKey, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/logs/"+uuid.New().String(), project.APIKey, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/logs/not-a-uuid", project.APIKey, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// contextStore records the params GetLogContext was called with.
+type contextStore struct {
+	params models.LogContextParams
+}
+
+func (s *contextStore) GetLogContext(ctx context.Context, projectID, logID uuid.UUID, params models.LogContextParams) (*models.LogContextResponse, error) {
+	s.params = params
+	return &models.LogContextResponse{}, nil
+}
+
+func TestGetLogContext_Params(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &contextStore{}
+	r := gin.New()
+	r.GET("/logs/:id/context", func(c *gin.Context) {
+		c.Set("project_id", uuid.New())
+	}, GetLogContext(store))
+	path := "/logs/" + uuid.NewString() + "/context"
+
+	// An explicit zero asks for one side only; unset counts use the default
+	w := doRequest(t, r, http.MethodGet, path+"?before=0&after=5", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotNil(t, store.params.Before)
+	assert.Equal(t, 0, *store.params.Before)
+	require.NotNil(t, store.params.After)
+	assert.Equal(t, 5, *store.params.After)
+
+	w = doRequest(t, r, http.MethodGet, path, "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, store.params.Before)
+	assert.Nil(t, store.params.After)
+
+	w = doRequest(t, r, http.MethodGet, path+"?before=-1", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSearchLogs(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+
+	now := time.Now()
+	w := doRequest(t, r, http.MethodPost, "/logs", project.APIKey, []models.LogEntry{
+		{Level: "error", Message: "Database connection timeout", Timestamp: now.Add(-time.Minute)},
+		{Level: "info", Message: "Cache warmed", Timestamp: now},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, "/search", project.APIKey, models.SearchRequest{Query: "database timeout"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := decode[models.LogsResponse](t, w)
+	assert.Equal(t, int64(1), response.Total)
+	require.Len(t, response.Logs, 1)
+	assert.NotNil(t, response.Logs[0].Rank)
+	assert.NotNil(t, response.QueryTimeMs)
+
+	w = doRequest(t, r, http.MethodPost, "/search", project.APIKey, models.SearchRequest{Query: "a b c"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "invalid search query"))
+}
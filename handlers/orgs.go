@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateOrganization creates an organization owned by the signed-in user.
+// Requires a session (middleware.SessionRequired).
+//
+// Request body:
+//
+//	{"name": "Acme"}
+//
+// Returns 201 Created, 400 for validation errors, 500 for database errors.
+func CreateOrganization(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, ok := currentSession(c)
+		if !ok {
+			return
+		}
+
+		var req models.CreateOrganizationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		org, err := store.CreateOrganization(ctx, req.Name, session.User.ID)
+		if err != nil {
+			log.Printf("CreateOrganization error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create organization"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, org)
+	}
+}
+
+// ListOrganizations returns the signed-in user's organizations, oldest first, with their
+// role in each. Requires a session (middleware.SessionRequired).
+func ListOrganizations(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, ok := currentSession(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		orgs, err := store.ListOrganizations(ctx, session.User.ID)
+		if err != nil {
+			log.Printf("ListOrganizations error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list organizations"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.OrganizationsResponse{
+			Organizations: orgs,
+			Total:         len(orgs),
+		})
+	}
+}
+
+// ListMembers returns an organization's members, oldest first. Any member may list them.
+// Requires a session (middleware.SessionRequired).
+// Returns 404 if the organization doesn't exist or the user isn't a member.
+func ListMembers(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := requireMember(c, store, models.RoleViewer)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		members, err := store.ListMembers(ctx, actor.OrgID)
+		if err != nil {
+			log.Printf("ListMembers error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list members"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.MembersResponse{
+			Members: members,
+			Total:   len(members),
+		})
+	}
+}
+
+// AddMember adds a registered user to an organization with a role.
+// Requires an admin or owner session; only owners may add owners.
+//
+// Request body:
+//
+//	{"email": "bob@example.com", "role": "member"}
+//
+// Returns 201 Created with the membership, 400 for validation errors, 403 for a role that
+// can't add members, 404 if the organization or user doesn't exist, 409 if the user is
+// already a member, 500 for database errors.
+func AddMember(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := requireMember(c, store, models.RoleAdmin)
+		if !ok {
+			return
+		}
+
+		var req models.AddMemberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireRoleManager(c, actor, req.Role) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		member, err := store.AddMember(ctx, actor.OrgID, req.Email, req.Role)
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrUserNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, database.ErrMemberExists):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("AddMember error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add member"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, member)
+	}
+}
+
+// UpdateMember changes a member's role.
+// Requires an admin or owner session; only owners may change an owner's role or make
+// someone an owner.
+//
+// Request body:
+//
+//	{"role": "admin"}
+//
+// Returns the updated membership, 400 for validation errors, 403 for a role that can't
+// make the change, 404 if the member doesn't exist, 409 when demoting the last owner,
+// 500 for database errors.
+func UpdateMember(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := requireMember(c, store, models.RoleAdmin)
+		if !ok {
+			return
+		}
+		target, ok := memberParam(c, store, actor.OrgID)
+		if !ok {
+			return
+		}
+
+		var req models.UpdateMemberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireRoleManager(c, actor, target.Role) || !requireRoleManager(c, actor, req.Role) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		member, err := store.UpdateMemberRole(ctx, actor.OrgID, target.UserID, req.Role)
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrMemberNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, database.ErrLastOwner):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("UpdateMemberRole error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update member"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, member)
+	}
+}
+
+// RemoveMember removes a member from an organization. Members may always remove
+// themselves; removing someone else requires an admin or owner session, and only owners
+// may remove owners.
+// Returns 403 for a role that can't remove the member, 404 if the member doesn't exist,
+// 409 when removing the last owner, 500 for database errors.
+func RemoveMember(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := requireMember(c, store, models.RoleViewer)
+		if !ok {
+			return
+		}
+		target, ok := memberParam(c, store, actor.OrgID)
+		if !ok {
+			return
+		}
+
+		if target.UserID != actor.UserID {
+			if !actor.Role.AtLeast(models.RoleAdmin) {
+				respondRoleRequired(c, models.RoleAdmin)
+				return
+			}
+			if !requireRoleManager(c, actor, target.Role) {
+				return
+			}
+		}
+
+		ctx := c.Request.Context()
+		if err := store.RemoveMember(ctx, actor.OrgID, target.UserID); err != nil {
+			switch {
+			case errors.Is(err, database.ErrMemberNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, database.ErrLastOwner):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("RemoveMember error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove member"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+	}
+}
+
+// CreateOrganizationProject creates a project owned by an organization and returns it with
+// its default API key, like CreateProject. Requires a member, admin or owner session.
+//
+// Request body:
+//
+//	{"name": "My Application"}
+//
+// Returns 201 Created, 400 for validation errors, 403 for viewers, 404 if the
+// organization doesn't exist, 500 for database errors.
+func CreateOrganizationProject(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := requireMember(c, store, models.RoleMember)
+		if !ok {
+			return
+		}
+
+		var req models.CreateProjectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		project, err := store.CreateOrganizationProject(ctx, actor.OrgID, req.Name)
+		if err != nil {
+			if errors.Is(err, database.ErrOrganizationNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("CreateOrganizationProject error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create project"})
+			return
+		}
+
+		recordAudit(c, models.AuditProjectCreate, models.AuditTargetProject, project.ID.String(), &project.ID,
+			nil, auditProject(project))
+		c.JSON(http.StatusCreated, project)
+	}
+}
+
+// ListOrganizationProjects returns an organization's projects, newest first.
+// Any member may list them. Requires a session (middleware.SessionRequired).
+func ListOrganizationProjects(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := requireMember(c, store, models.RoleViewer)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		projects, err := store.ListOrganizationProjects(ctx, actor.OrgID)
+		if err != nil {
+			log.Printf("ListOrganizationProjects error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list projects"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ProjectsResponse{
+			Projects: projects,
+			Total:    len(projects),
+		})
+	}
+}
+
+// requireMember returns the signed-in user's membership of the :org_id organization if
+// their role is at least min. Otherwise it responds 401 without a session, 400 for an
+// invalid ID, 404 if they aren't a member (hiding whether the organization exists) or
+// 403 naming the required role, and returns false.
+func requireMember(c *gin.Context, store storage.OrganizationStore, min models.Role) (*models.Membership, bool) {
+	session, ok := currentSession(c)
+	if !ok {
+		return nil, false
+	}
+
+	orgID, err := uuid.Parse(c.Param("org_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	member, err := store.GetMembership(ctx, orgID, session.User.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrMemberNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": database.ErrOrganizationNotFound.Error()})
+			return nil, false
+		}
+		log.Printf("GetMembership error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get membership"})
+		return nil, false
+	}
+
+	if !member.Role.AtLeast(min) {
+		respondRoleRequired(c, min)
+		return nil, false
+	}
+	return member, true
+}
+
+// memberParam returns the :user_id member of orgID, responding 400 or 404 if there is none.
+func memberParam(c *gin.Context, store storage.OrganizationStore, orgID uuid.UUID) (*models.Membership, bool) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	member, err := store.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrMemberNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		log.Printf("GetMembership error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get membership"})
+		return nil, false
+	}
+	return member, true
+}
+
+// requireRoleManager reports whether actor may grant, change or remove role:
+// only owners manage owners. Otherwise it responds 403.
+func requireRoleManager(c *gin.Context, actor *models.Membership, role models.Role) bool {
+	if role == models.RoleOwner && actor.Role != models.RoleOwner {
+		respondRoleRequired(c, models.RoleOwner)
+		return false
+	}
+	return true
+}
+
+func respondRoleRequired(c *gin.Context, role models.Role) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":         "your role in this organization doesn't allow this",
+		"required_role": role,
+	})
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"jazz/middleware"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUser registers a user through the API and logs them in, returning their session.
+func newTestUser(t *testing.T, r *gin.Engine, email string) *models.Session {
+	t.Helper()
+
+	w := doRequest(t, r, http.MethodPost, "/auth/register", "", models.RegisterRequest{
+		Email:    email,
+		Name:     strings.Split(email, "@")[0],
+		Password: "correct horse",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodPost, "/auth/login", "", models.LoginRequest{Email: email, Password: "correct horse"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	session := decode[models.Session](t, w)
+	return &session
+}
+
+// doProjectRequest is doRequest for log routes with a session, naming the project in X-Project-ID.
+func doProjectRequest(t *testing.T, r http.Handler, method, path, token, projectID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(`[{"level": "info", "message": "hello"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if projectID != "" {
+		req.Header.Set(middleware.ProjectHeader, projectID)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	r := newTestRouter(memory.New())
+
+	session := newTestUser(t, r, "alice@example.com")
+	assert.NotEmpty(t, session.Token)
+	assert.Equal(t, "alice@example.com", session.User.Email)
+
+	w := doRequest(t, r, http.MethodPost, "/auth/register", "", models.RegisterRequest{
+		Email: "ALICE@example.com", Name: "Alice", Password: "another password",
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, "/auth/register", "", models.RegisterRequest{
+		Email: "bob@example.com", Name: "Bob", Password: "short",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 72 characters, but more than 72 bytes
+	w = doRequest(t, r, http.MethodPost, "/auth/register", "", models.RegisterRequest{
+		Email: "bob@example.com", Name: "Bob", Password: strings.Repeat("é", 72),
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, "/auth/login", "", models.LoginRequest{Email: "alice@example.com", Password: "wrong password"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/auth/me", session.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	me := decode[models.MeResponse](t, w)
+	assert.Equal(t, session.User.ID, me.User.ID)
+	assert.Empty(t, me.Organizations)
+
+	w = doRequest(t, r, http.MethodPost, "/auth/logout", session.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/auth/me", session.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	r := newTestRouter(memory.New())
+	alice := newTestUser(t, r, "alice@example.com")
+	bob := newTestUser(t, r, "bob@example.com")
+	carol := newTestUser(t, r, "carol@example.com")
+
+	w := doRequest(t, r, http.MethodPost, "/orgs", alice.Token, models.CreateOrganizationRequest{Name: "Acme"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	org := decode[models.Organization](t, w)
+	membersPath := "/orgs/" + org.ID.String() + "/members"
+
+	// Non-members can't tell the organization exists
+	w = doRequest(t, r, http.MethodGet, membersPath, bob.Token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, membersPath, alice.Token, models.AddMemberRequest{Email: "bob@example.com", Role: models.RoleAdmin})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodPost, membersPath, alice.Token, models.AddMemberRequest{Email: "bob@example.com", Role: models.RoleAdmin})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest(t, r, http.MethodPost, membersPath, alice.Token, models.AddMemberRequest{Email: "nobody@example.com", Role: models.RoleViewer})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(t, r, http.MethodPost, membersPath, alice.Token, models.AddMemberRequest{Email: "carol@example.com", Role: "superuser"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Admins manage members, but only owners manage owners
+	w = doRequest(t, r, http.MethodPost, membersPath, bob.Token, models.AddMemberRequest{Email: "carol@example.com", Role: models.RoleOwner})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, string(models.RoleOwner), decode[map[string]string](t, w)["required_role"])
+
+	w = doRequest(t, r, http.MethodPost, membersPath, bob.Token, models.AddMemberRequest{Email: "carol@example.com", Role: models.RoleViewer})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodPatch, membersPath+"/"+alice.User.ID.String(), bob.Token, models.UpdateMemberRequest{Role: models.RoleViewer})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Viewers can list but not manage
+	w = doRequest(t, r, http.MethodGet, membersPath, carol.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, decode[models.MembersResponse](t, w).Total)
+
+	w = doRequest(t, r, http.MethodDelete, membersPath+"/"+bob.User.ID.String(), carol.Token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// The last owner can't step down
+	w = doRequest(t, r, http.MethodPatch, membersPath+"/"+alice.User.ID.String(), alice.Token, models.UpdateMemberRequest{Role: models.RoleAdmin})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest(t, r, http.MethodDelete, membersPath+"/"+alice.User.ID.String(), alice.Token, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, r, http.MethodPatch, membersPath+"/"+carol.User.ID.String(), bob.Token, models.UpdateMemberRequest{Role: models.RoleMember})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, models.RoleMember, decode[models.Membership](t, w).Role)
+
+	// Anyone can leave
+	w = doRequest(t, r, http.MethodDelete, membersPath+"/"+carol.User.ID.String(), carol.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(t, r, http.MethodGet, "/orgs", carol.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, decode[models.OrganizationsResponse](t, w).Total)
+
+	w = doRequest(t, r, http.MethodDelete, membersPath+"/"+carol.User.ID.String(), alice.Token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(t, r, http.MethodGet, "/orgs/not-a-uuid/members", alice.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOrganizationProjects_RoleAccess(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	owner := newTestUser(t, r, "owner@example.com")
+	viewer := newTestUser(t, r, "viewer@example.com")
+	outsider := newTestUser(t, r, "outsider@example.com")
+
+	w := doRequest(t, r, http.MethodPost, "/orgs", owner.Token, models.CreateOrganizationRequest{Name: "Acme"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	org := decode[models.Organization](t, w)
+	orgPath := "/orgs/" + org.ID.String()
+
+	w = doRequest(t, r, http.MethodPost, orgPath+"/members", owner.Token, models.AddMemberRequest{Email: "viewer@example.com", Role: models.RoleViewer})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodPost, orgPath+"/projects", owner.Token, models.CreateProjectRequest{Name: "My Application"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	project := decode[models.Project](t, w)
+	require.NotNil(t, project.OrgID)
+	assert.Equal(t, org.ID, *project.OrgID)
+	projectID := project.ID.String()
+
+	w = doRequest(t, r, http.MethodPost, orgPath+"/projects", viewer.Token, models.CreateProjectRequest{Name: "Not Allowed"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, orgPath+"/projects", viewer.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, decode[models.ProjectsResponse](t, w).Total)
+
+	// Logs: owners write, viewers only read, outsiders neither
+	w = doProjectRequest(t, r, http.MethodPost, "/logs", owner.Token, projectID)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doProjectRequest(t, r, http.MethodPost, "/logs", viewer.Token, projectID)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, string(models.ScopeLogsWrite), decode[map[string]string](t, w)["required_scope"])
+
+	w = doProjectRequest(t, r, http.MethodGet, "/logs", viewer.Token, projectID)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, decode[models.LogsResponse](t, w).Logs, 1)
+
+	w = doProjectRequest(t, r, http.MethodGet, "/logs", outsider.Token, projectID)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doProjectRequest(t, r, http.MethodGet, "/logs", viewer.Token, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Projects without an organization are only reachable with keys and admin credentials
+	unowned := newTestProject(t, store)
+	w = doProjectRequest(t, r, http.MethodGet, "/logs", owner.Token, unowned.ID.String())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Project management needs an admin role
+	w = doRequest(t, r, http.MethodGet, "/projects/"+projectID+"/keys", owner.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doRequest(t, r, http.MethodGet, "/projects/"+projectID+"/keys", viewer.Token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doRequest(t, r, http.MethodDelete, "/projects/"+projectID, viewer.Token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Sessions never pass for admin credentials
+	w = doRequest(t, r, http.MethodGet, "/projects", owner.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodDelete, "/projects/"+projectID, owner.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
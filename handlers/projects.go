@@ -1,18 +1,23 @@
 package handlers
 
 import (
+	"errors"
 	"jazz/database"
 	"jazz/models"
+	"jazz/storage"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// CreateProject creates a new project and returns it with a generated API key.
+// CreateProject creates a new project and returns it with a generated API key,
+// the project's "default" key (see CreateAPIKey for more).
 // API key is shown only once - caller must store it securely.
 // Project name must be 3-255 characters (validated by binding).
+// Requires admin credentials (middleware.AdminRequired).
 //
 // Request body:
 //
@@ -28,9 +33,14 @@ import (
 //	  "updated_at": "..."
 //	}
 //
-// Returns 201 Created on success, 400 for validation errors, 500 for database errors.
-func CreateProject(db *database.DB) gin.HandlerFunc {
+// Returns 201 Created on success, 400 for validation errors, 401 without admin
+// credentials, 500 for database errors.
+func CreateProject(store storage.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
 		var req models.CreateProjectRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			log.Printf("Bind error: %v", err)
@@ -41,7 +51,7 @@ func CreateProject(db *database.DB) gin.HandlerFunc {
 		log.Printf("Creating project: %s", req.Name)
 
 		ctx := c.Request.Context()
-		project, err := db.CreateProject(ctx, req.Name)
+		project, err := store.CreateProject(ctx, req.Name)
 		if err != nil {
 			log.Printf("CreateProject database error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -52,33 +62,67 @@ func CreateProject(db *database.DB) gin.HandlerFunc {
 		}
 
 		log.Printf("Project created: %s", project.ID)
+		recordAudit(c, models.AuditProjectCreate, models.AuditTargetProject, project.ID.String(), &project.ID,
+			nil, auditProject(project))
 		c.JSON(http.StatusCreated, project)
 	}
 }
 
-// ListProjects returns all projects ordered by creation date (newest first).
-// No authentication required (will be added in Phase 4 with user accounts).
-// Response includes projects array and total count.
-func ListProjects(db *database.DB) gin.HandlerFunc {
+// ListProjects returns a page of projects with their log stats.
+// Requires admin credentials (middleware.AdminRequired).
+//
+// Query parameters:
+//   - search: case-insensitive substring of the project name
+//   - sort: "name" and/or "created_at", e.g. "name:asc" (default "created_at:desc")
+//   - limit: max results (default 50, max 1000)
+//   - offset: pagination offset
+//
+// Response includes projects array, total count, and has_more flag for pagination.
+// Returns 400 for an invalid sort, 500 for database errors.
+func ListProjects(store storage.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		var params models.ProjectListParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if params.Limit <= 0 {
+			params.Limit = defaultLimit
+		}
+		if params.Limit > maxLimit {
+			params.Limit = maxLimit
+		}
+		if params.Offset < 0 {
+			params.Offset = defaultOffset
+		}
+
 		ctx := c.Request.Context()
-		projects, err := db.ListProjects(ctx)
+		projects, total, err := store.ListProjects(ctx, params)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list projects"})
+			respondReadError(c, err, "failed to list projects")
 			return
 		}
 
 		c.JSON(http.StatusOK, models.ProjectsResponse{
 			Projects: projects,
-			Total:    len(projects),
+			Total:    total,
+			Limit:    params.Limit,
+			Offset:   params.Offset,
+			HasMore:  params.Offset+params.Limit < total,
 		})
 	}
 }
 
 // GetProject retrieves a single project by ID.
-// No authentication required (will be added in Phase 4 with user accounts).
+// Requires admin credentials, a project:admin key or an organization admin's session
+// (middleware.ProjectAdminRequired).
 // Returns 404 if project doesn't exist, 500 for database errors.
-func GetProject(db *database.DB) gin.HandlerFunc {
+func GetProject(store storage.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		projectIDStr := c.Param("id")
 		projectID, err := uuid.Parse(projectIDStr)
@@ -86,9 +130,12 @@ func GetProject(db *database.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
 			return
 		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
 
 		ctx := c.Request.Context()
-		project, err := db.GetProject(ctx, projectID)
+		project, err := store.GetProject(ctx, projectID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
 			return
@@ -98,8 +145,94 @@ func GetProject(db *database.DB) gin.HandlerFunc {
 	}
 }
 
-// DeleteProject removes a project and all its logs (CASCADE).
-func DeleteProject(db *database.DB) gin.HandlerFunc {
+// respondProjectError responds 404 for database.ErrProjectNotFound, otherwise logs err
+// as op's and responds 500 with message.
+func respondProjectError(c *gin.Context, op string, err error, message string) {
+	if errors.Is(err, database.ErrProjectNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+	log.Printf("%s error: %v", op, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// UpdateProject renames a project and changes its retention policy and settings.
+// Omitted fields are left as they are; settings replaces the whole settings document.
+// Requires admin credentials, a project:admin key or an organization admin's session
+// (middleware.ProjectAdminRequired).
+//
+// Request body:
+//
+//	{
+//	  "name": "Checkout",
+//	  "retention_days": 30,
+//	  "settings": {
+//	    "allowed_levels": ["debug", "info", "warn", "error"],
+//	    "search_language": "german",
+//	    "max_batch_size": 500,
+//	    "max_message_length": 8192
+//	  }
+//	}
+//
+// Returns the updated project, 400 for validation errors or an empty body, 404 if
+// project doesn't exist, 500 for database errors.
+func UpdateProject(store storage.ProjectStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		var req models.UpdateProjectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Settings != nil {
+			if err := req.Settings.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.Empty() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		before, err := store.GetProject(ctx, projectID)
+		if err != nil {
+			respondProjectError(c, "UpdateProject", err, "failed to update project")
+			return
+		}
+		project, err := store.UpdateProject(ctx, projectID, req)
+		if err != nil {
+			respondProjectError(c, "UpdateProject", err, "failed to update project")
+			return
+		}
+
+		recordAudit(c, models.AuditProjectUpdate, models.AuditTargetProject, projectID.String(), &projectID,
+			auditProject(before), auditProject(project))
+		c.JSON(http.StatusOK, project)
+	}
+}
+
+// DeleteProject deletes a project. Its keys stop working and its logs are hidden
+// straight away, but nothing is destroyed until restoreWindow has passed: until then
+// RestoreProject brings everything back. Afterwards the reaper purges it for good.
+// Requires admin credentials, a project:admin key or an organization admin's session
+// (middleware.ProjectAdminRequired).
+//
+// Response:
+//
+//	{"message": "project deleted", "restore_until": "2024-11-29T12:00:00Z"}
+//
+// Returns 404 if project doesn't exist or is already deleted, 500 for database errors.
+func DeleteProject(store storage.ProjectStore, restoreWindow time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		projectIDStr := c.Param("id")
 		projectID, err := uuid.Parse(projectIDStr)
@@ -107,13 +240,66 @@ func DeleteProject(db *database.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
 			return
 		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
 
 		ctx := c.Request.Context()
-		if err := db.DeleteProject(ctx, projectID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete project"})
+		before, err := store.GetProject(ctx, projectID)
+		if err == nil {
+			err = store.DeleteProject(ctx, projectID)
+		}
+		if err != nil {
+			respondProjectError(c, "DeleteProject", err, "failed to delete project")
+			return
+		}
+
+		recordAudit(c, models.AuditProjectDelete, models.AuditTargetProject, projectID.String(), &projectID,
+			auditProject(before), nil)
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "project deleted",
+			"restore_until": time.Now().UTC().Add(restoreWindow),
+		})
+	}
+}
+
+// RestoreProject undeletes a project deleted less than restoreWindow ago, with its
+// keys, logs and settings. Requires admin credentials (middleware.ProjectAdminRequired):
+// a deleted project's keys and its organization's sessions no longer reach it.
+// Returns the restored project, 404 if project doesn't exist or the restore window has
+// passed, 409 if the project isn't deleted, 500 for database errors.
+func RestoreProject(store storage.ProjectStore, restoreWindow time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		before, err := store.GetDeletedProject(ctx, projectID)
+		var project *models.Project
+		if err == nil {
+			project, err = store.RestoreProject(ctx, projectID, time.Now().Add(-restoreWindow))
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrProjectNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			case errors.Is(err, database.ErrProjectNotDeleted):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("RestoreProject error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore project"})
+			}
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "project deleted"})
+		recordAudit(c, models.AuditProjectRestore, models.AuditTargetProject, projectID.String(), &projectID,
+			auditProject(before), auditProject(project))
+		c.JSON(http.StatusOK, project)
 	}
 }
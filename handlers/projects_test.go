@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectLifecycle(t *testing.T) {
+	r := newTestRouter(memory.New())
+
+	w := doRequest(t, r, http.MethodPost, "/projects", testAdminToken, models.CreateProjectRequest{Name: "My Application"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	project := decode[models.Project](t, w)
+	assert.Equal(t, "My Application", project.Name)
+	assert.NotEmpty(t, project.APIKey)
+
+	w = doRequest(t, r, http.MethodGet, "/projects", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list := decode[models.ProjectsResponse](t, w)
+	assert.Equal(t, 1, list.Total)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/"+project.ID.String(), testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, project.ID, decode[models.Project](t, w).ID)
+
+	w = doRequest(t, r, http.MethodDelete, "/projects/"+project.ID.String(), testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/"+project.ID.String(), testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The deleted project's key no longer works
+	w = doRequest(t, r, http.MethodGet, "/logs", project.APIKey, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestListProjects(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+
+	var checkout *models.Project
+	for _, name := range []string{"Billing", "Checkout", "Search"} {
+		w := doRequest(t, r, http.MethodPost, "/projects", testAdminToken, models.CreateProjectRequest{Name: name})
+		require.Equal(t, http.StatusCreated, w.Code)
+		if name == "Checkout" {
+			project := decode[models.Project](t, w)
+			checkout = &project
+		}
+	}
+	w := doRequest(t, r, http.MethodPost, "/logs", checkout.APIKey, []models.LogEntry{{Level: "info", Message: "cart created"}})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, r, http.MethodGet, "/projects?sort=name:desc&limit=1&offset=1", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	list := decode[models.ProjectsResponse](t, w)
+	assert.Equal(t, 3, list.Total)
+	assert.Equal(t, 1, list.Limit)
+	assert.True(t, list.HasMore)
+	require.Len(t, list.Projects, 1)
+	assert.Equal(t, checkout.ID, list.Projects[0].ID)
+	require.NotNil(t, list.Projects[0].Stats)
+	assert.Equal(t, int64(1), list.Projects[0].Stats.LogCount)
+	assert.NotNil(t, list.Projects[0].Stats.LastLogAt)
+
+	w = doRequest(t, r, http.MethodGet, "/projects?search=CHECK", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list = decode[models.ProjectsResponse](t, w)
+	assert.Equal(t, 1, list.Total)
+	assert.False(t, list.HasMore)
+
+	w = doRequest(t, r, http.MethodGet, "/projects?sort=api_key", testAdminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRestoreProject(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	path := "/projects/" + project.ID.String()
+
+	w := doRequest(t, r, http.MethodPost, path+"/restore", testAdminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "project isn't deleted")
+
+	w = doRequest(t, r, http.MethodDelete, path, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	deleted := decode[struct {
+		RestoreUntil time.Time `json:"restore_until"`
+	}](t, w)
+	assert.WithinDuration(t, time.Now().Add(testRestoreWindow), deleted.RestoreUntil, time.Minute)
+
+	// The project's own key can't undo the delete
+	w = doRequest(t, r, http.MethodPost, path+"/restore", project.APIKey, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, path+"/restore", testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, project.ID, decode[models.Project](t, w).ID)
+
+	// Keys and logs come back with the project
+	w = doRequest(t, r, http.MethodGet, "/logs", project.APIKey, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, r, http.MethodPost, "/projects/"+uuid.New().String()+"/restore", testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateProject_Validation(t *testing.T) {
+	r := newTestRouter(memory.New())
+
+	for _, name := range []string{"", "ab"} {
+		w := doRequest(t, r, http.MethodPost, "/projects", testAdminToken, models.CreateProjectRequest{Name: name})
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+}
+
+func TestGetProject_InvalidID(t *testing.T) {
+	r := newTestRouter(memory.New())
+
+	w := doRequest(t, r, http.MethodGet, "/projects/not-a-uuid", testAdminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest(t, r, http.MethodGet, "/projects/"+uuid.New().String(), testAdminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateProject(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+	path := "/projects/" + project.ID.String()
+
+	w := doRequest(t, r, http.MethodPatch, path, testAdminToken, map[string]interface{}{
+		"name":           "Checkout",
+		"retention_days": 30,
+		"settings": map[string]interface{}{
+			"allowed_levels":  []string{"info", "error"},
+			"search_language": "german",
+			"max_batch_size":  100,
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := decode[models.Project](t, w)
+	assert.Equal(t, "Checkout", updated.Name)
+	require.NotNil(t, updated.RetentionDays)
+	assert.Equal(t, 30, *updated.RetentionDays)
+	assert.Equal(t, []string{"info", "error"}, updated.Settings.AllowedLevels)
+	assert.Equal(t, "german", updated.Settings.SearchLanguage)
+	assert.Equal(t, 100, updated.Settings.MaxBatchSize)
+	assert.True(t, updated.UpdatedAt.After(project.UpdatedAt))
+
+	// Omitted fields are kept; null disables retention
+	w = doRequest(t, r, http.MethodPatch, path, testAdminToken, map[string]interface{}{"retention_days": nil})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated = decode[models.Project](t, w)
+	assert.Equal(t, "Checkout", updated.Name)
+	assert.Nil(t, updated.RetentionDays)
+	assert.Equal(t, "german", updated.Settings.SearchLanguage)
+
+	// Settings are replaced as a whole
+	w = doRequest(t, r, http.MethodPatch, path, testAdminToken, map[string]interface{}{"settings": map[string]interface{}{}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, models.ProjectSettings{}, decode[models.Project](t, w).Settings)
+
+	w = doRequest(t, r, http.MethodGet, path, project.APIKey, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Checkout", decode[models.Project](t, w).Name, "project:admin keys may read the update")
+
+	w = doRequest(t, r, http.MethodPatch, "/projects/"+uuid.New().String(), testAdminToken, map[string]string{"name": "Missing"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateProject_Validation(t *testing.T) {
+	store := memory.New()
+	r := newTestRouter(store)
+	project := newTestProject(t, store)
+
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{name: "empty", body: map[string]interface{}{}},
+		{name: "short name", body: map[string]interface{}{"name": "ab"}},
+		{name: "retention too long", body: map[string]interface{}{"retention_days": 5000}},
+		{name: "unknown language", body: map[string]interface{}{"settings": map[string]interface{}{"search_language": "klingon"}}},
+		{name: "batch too large", body: map[string]interface{}{"settings": map[string]interface{}{"max_batch_size": 5000}}},
+		{name: "empty level", body: map[string]interface{}{"settings": map[string]interface{}{"allowed_levels": []string{""}}}},
+		{name: "level too long", body: map[string]interface{}{"settings": map[string]interface{}{"allowed_levels": []string{strings.Repeat("x", 21)}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(t, r, http.MethodPatch, "/projects/"+project.ID.String(), testAdminToken, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetRetentionStatus reports a project's retention policy and purge progress.
+//
+// Response:
+//
+//	{
+//	  "project_id": "...",
+//	  "retention_days": 30,
+//	  "cutoff": "2024-10-23T12:00:00Z",
+//	  "oldest_log": "2024-10-23T12:04:11Z",
+//	  "expired_logs": 0,
+//	  "last_run": {"started_at": "...", "finished_at": "...", "deleted_rows": 18233, ...}
+//	}
+//
+// Requires admin credentials, a project:admin key or an organization admin's session
+// (middleware.ProjectAdminRequired).
+// Returns 404 if project doesn't exist, 500 for database errors.
+func GetRetentionStatus(store storage.RetentionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		ctx := c.Request.Context()
+		status, err := store.GetRetentionStatus(ctx, projectID, time.Now())
+		if err != nil {
+			if errors.Is(err, database.ErrProjectNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+				return
+			}
+			log.Printf("GetRetentionStatus error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get retention status"})
+			return
+		}
+
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// UpdateRetention sets how many days of logs a project keeps.
+// Expired logs are deleted by the retention worker on its next pass.
+//
+// Request body:
+//
+//	{"retention_days": 30}
+//
+// null disables retention. Requires admin credentials, a project:admin key or an
+// organization admin's session (middleware.ProjectAdminRequired). Returns the updated project, 400 for validation errors,
+// 404 if project doesn't exist, 500 for database errors.
+func UpdateRetention(projects storage.ProjectStore, store storage.RetentionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		if !requireProjectAdmin(c, projectID) {
+			return
+		}
+
+		var req models.UpdateRetentionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		before, err := projects.GetProject(ctx, projectID)
+		if err != nil {
+			respondProjectError(c, "UpdateRetention", err, "failed to update retention")
+			return
+		}
+		project, err := store.SetProjectRetention(ctx, projectID, req.RetentionDays)
+		if err != nil {
+			if errors.Is(err, database.ErrProjectNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+				return
+			}
+			log.Printf("UpdateRetention error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update retention"})
+			return
+		}
+
+		recordAudit(c, models.AuditProjectRetention, models.AuditTargetProject, projectID.String(), &projectID,
+			auditProject(before), auditProject(project))
+		c.JSON(http.StatusOK, project)
+	}
+}
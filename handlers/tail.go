@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"io"
+	"jazz/models"
+	"jazz/tail"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	tailHeartbeatInterval = 15 * time.Second
+	tailWriteTimeout      = 10 * time.Second
+)
+
+// Clients authenticate with a bearer API key rather than cookies,
+// so cross-origin WebSocket connections carry no ambient credentials.
+var tailUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// tailMessage is the WebSocket frame format for GET /logs/tail/ws.
+type tailMessage struct {
+	Type    string           `json:"type"`
+	Log     *models.LogEntry `json:"log,omitempty"`
+	Dropped uint64           `json:"dropped,omitempty"`
+}
+
+// TailLogs streams newly ingested logs for the authenticated project as Server-Sent Events.
+// Accepts the same filters as GET /logs (limit and offset are ignored); search terms are
+// matched case-insensitively against the message rather than with full-text ranking.
+//
+// Events:
+//   - "log": a matching entry (JSON)
+//   - "dropped": {"dropped": N} - total entries skipped because this client fell behind
+//
+// A comment line is sent every 15s to keep proxies from closing idle connections.
+// Returns 400 for invalid filters.
+func TailLogs(hub *tail.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := subscribeTail(c, hub)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(tailHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		var reportedDrops uint64
+		reportDrops := func() {
+			if dropped := sub.Dropped(); dropped != reportedDrops {
+				reportedDrops = dropped
+				c.SSEvent("dropped", gin.H{"dropped": dropped})
+			}
+		}
+
+		ctx := c.Request.Context()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case entry := <-sub.C():
+				reportDrops()
+				c.SSEvent("log", entry)
+			case <-heartbeat.C:
+				reportDrops()
+				_, _ = io.WriteString(w, ": heartbeat\n\n")
+			}
+			return true
+		})
+
+		log.Printf("tail closed: project=%s dropped=%d", sub.ProjectID, sub.Dropped())
+	}
+}
+
+// TailLogsWebSocket streams newly ingested logs for the authenticated project over a WebSocket.
+// Accepts the same filters as TailLogs. Each text frame is a JSON object:
+//
+//	{"type": "log", "log": {...}}
+//	{"type": "dropped", "dropped": 12}
+//
+// Messages sent by the client are ignored. The server pings every 15s.
+func TailLogsWebSocket(hub *tail.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := subscribeTail(c, hub)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		conn, err := tailUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade has already written an error response
+			log.Printf("tail websocket upgrade failed: %v", err)
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		// Reading is required to process pings/close frames; stop when the client goes away
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(tailHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		var reportedDrops uint64
+		send := func(msg tailMessage) error {
+			_ = conn.SetWriteDeadline(time.Now().Add(tailWriteTimeout))
+			return conn.WriteJSON(msg)
+		}
+		reportDrops := func() error {
+			if dropped := sub.Dropped(); dropped != reportedDrops {
+				reportedDrops = dropped
+				return send(tailMessage{Type: "dropped", Dropped: dropped})
+			}
+			return nil
+		}
+
+		for {
+			var err error
+			select {
+			case <-done:
+				log.Printf("tail closed: project=%s dropped=%d", sub.ProjectID, sub.Dropped())
+				return
+			case entry := <-sub.C():
+				if err = reportDrops(); err == nil {
+					err = send(tailMessage{Type: "log", Log: &entry})
+				}
+			case <-heartbeat.C:
+				if err = reportDrops(); err == nil {
+					err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(tailWriteTimeout))
+				}
+			}
+			if err != nil {
+				log.Printf("tail websocket write failed: project=%s err=%v", sub.ProjectID, err)
+				return
+			}
+		}
+	}
+}
+
+// subscribeTail validates the request and subscribes to the hub.
+// Writes the error response and returns false if the request is invalid.
+func subscribeTail(c *gin.Context, hub *tail.Hub) (*tail.Subscription, bool) {
+	projectID, exists := c.Get("project_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	var params models.QueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	filter, err := tail.NewFilter(params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return hub.Subscribe(projectID.(uuid.UUID), filter), true
+}
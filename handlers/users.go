@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/password"
+	"jazz/storage"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSessionTTL is how long a login lasts.
+const DefaultSessionTTL = 7 * 24 * time.Hour
+
+// Register creates a user account. It doesn't sign the user in; call Login next.
+// Emails are case-insensitive and passwords 8-72 bytes (validated by binding and bcrypt).
+//
+// Request body:
+//
+//	{"email": "alice@example.com", "name": "Alice", "password": "..."}
+//
+// Returns 201 Created with the user, 400 for validation errors, 409 if the email is taken,
+// 500 for database errors.
+func Register(store storage.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RegisterRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		user, err := store.CreateUser(ctx, req.Email, req.Name, req.Password)
+		if err != nil {
+			switch {
+			case errors.Is(err, password.ErrTooLong):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, database.ErrUserExists):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("CreateUser error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, user)
+	}
+}
+
+// Login checks an email and password and starts a session lasting DefaultSessionTTL.
+// The session token is shown only once; send it as "Authorization: Bearer <token>".
+//
+// Request body:
+//
+//	{"email": "alice@example.com", "password": "..."}
+//
+// Returns 201 Created with the session, 400 for validation errors, 401 if the email or
+// password is wrong, 500 for database errors.
+func Login(store storage.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.LoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		user, err := store.AuthenticateUser(ctx, req.Email, req.Password)
+		if err != nil {
+			if errors.Is(err, database.ErrInvalidCredentials) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("AuthenticateUser error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+			return
+		}
+
+		session, err := store.CreateSession(ctx, user.ID, DefaultSessionTTL)
+		if err != nil {
+			log.Printf("CreateSession error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, session)
+	}
+}
+
+// Logout ends the request's session; its token stops working immediately.
+// Requires a session (middleware.SessionRequired).
+func Logout(store storage.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, ok := currentSession(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		if err := store.DeleteSession(ctx, session.ID); err != nil {
+			log.Printf("DeleteSession error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+	}
+}
+
+// Me returns the signed-in user and the organizations they belong to, with their role in each.
+// Requires a session (middleware.SessionRequired).
+func Me(store storage.OrganizationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, ok := currentSession(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		orgs, err := store.ListOrganizations(ctx, session.User.ID)
+		if err != nil {
+			log.Printf("ListOrganizations error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list organizations"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.MeResponse{
+			User:          session.User,
+			Organizations: orgs,
+		})
+	}
+}
+
+// currentSession returns the session middleware.SessionRequired added to the context,
+// responding 401 Unauthorized if there is none so misrouted handlers fail closed.
+func currentSession(c *gin.Context) (*models.Session, bool) {
+	value, ok := c.Get("session")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session required"})
+		return nil, false
+	}
+	return value.(*models.Session), true
+}
@@ -3,11 +3,23 @@ package main
 
 import (
 	"context"
+	"flag"
+	"jazz/archive"
 	"jazz/database"
 	"jazz/handlers"
 	"jazz/middleware"
+	"jazz/models"
+	"jazz/oidc"
+	"jazz/reaper"
+	"jazz/retention"
+	"jazz/storage"
+	"jazz/storage/memory"
+	"jazz/storage/sqlite"
+	"jazz/tail"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,39 +29,285 @@ import (
 func main() {
 	_ = godotenv.Load()
 
+	migrate := flag.Bool("migrate", false, "apply pending PostgreSQL migrations before serving")
+	flag.Parse()
+
 	databaseURL := os.Getenv("DATABASE_URL")
 	if databaseURL == "" {
 		log.Fatal("DATABASE_URL not set")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Background workers stop when the server exits
+	bgCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
 
-	db, err := database.Connect(ctx, databaseURL)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+	hub := tail.NewHub(tail.DefaultBufferSize)
+
+	var store storage.Store
+	switch {
+	case strings.HasPrefix(databaseURL, "memory://"):
+		log.Println("Using in-memory storage - all data is lost on exit")
+		store = memory.New()
+	case strings.HasPrefix(databaseURL, "sqlite://"):
+		// sqlite://jazz.db is relative to the working directory, sqlite:///var/lib/jazz.db absolute
+		path := strings.TrimPrefix(databaseURL, "sqlite://")
+		db, err := sqlite.Open(context.Background(), path)
+		if err != nil {
+			log.Fatal("Failed to open SQLite database:", err)
+		}
+		defer db.Close()
+
+		log.Printf("Using SQLite storage at %s", path)
+		if os.Getenv("ARCHIVE_DIR") != "" {
+			log.Println("Ignoring ARCHIVE_DIR: only PostgreSQL logs can be archived")
+		}
+		go retention.NewWorker(db, retention.DefaultConfig()).Run(bgCtx)
+		store = db
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, err := database.Connect(ctx, databaseURL)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer db.Close()
+
+		if *migrate {
+			applyMigrations(db)
+		}
+
+		startWorkers(bgCtx, db, hub)
+		store = db
+	}
+
+	if _, ok := store.(*database.DB); *migrate && !ok {
+		log.Println("Ignoring --migrate: only PostgreSQL has migrations")
 	}
-	defer db.Close()
+
+	// Deleted projects can be restored for a while before the reaper purges them
+	reaperConfig := reaperConfigFromEnv()
+	go reaper.New(store, reaperConfig).Run(bgCtx)
 
 	r := gin.Default()
 
+	// Client IPs (recorded in the audit trail) come from X-Forwarded-For only when the
+	// request arrives through one of the TRUSTED_PROXIES
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	// Every request gets an ID; changes handlers make to projects, keys, groups and admin
+	// users are recorded in the audit trail with it
+	r.Use(middleware.RequestID(), middleware.Audit(store))
+
 	r.GET("/health", handlers.HealthCheck)
 
-	// Public project endpoints
-	r.POST("/projects", handlers.CreateProject(db))
-	r.GET("/projects", handlers.ListProjects(db))
-	r.GET("/projects/:id", handlers.GetProject(db))
-	r.DELETE("/projects/:id", handlers.DeleteProject(db))
+	// Project management requires an admin: the bootstrap ADMIN_TOKEN or an admin user.
+	// Routes for a single project also accept that project's project:admin keys.
+	adminToken := bootstrapAdminToken()
+
+	admin := r.Group("")
+	admin.Use(middleware.AdminRequired(store, adminToken))
+	{
+		admin.POST("/projects", handlers.CreateProject(store))
+		admin.GET("/projects", handlers.ListProjects(store))
+		admin.POST("/admin/users", handlers.CreateAdminUser(store))
+		admin.GET("/admin/users", handlers.ListAdminUsers(store))
+		admin.DELETE("/admin/users/:id", handlers.DeleteAdminUser(store))
+		admin.GET("/audit", handlers.ListAuditEvents(store))
+	}
+
+	project := r.Group("/projects/:id")
+	project.Use(middleware.ProjectAdminRequired(store, adminToken))
+	{
+		project.GET("", handlers.GetProject(store))
+		project.PATCH("", handlers.UpdateProject(store))
+		project.DELETE("", handlers.DeleteProject(store, reaperConfig.RestoreWindow))
+		project.POST("/restore", handlers.RestoreProject(store, reaperConfig.RestoreWindow))
+		project.POST("/keys", handlers.CreateAPIKey(store))
+		project.GET("/keys", handlers.ListAPIKeys(store))
+		project.DELETE("/keys/:key_id", handlers.RevokeAPIKey(store))
+		project.POST("/keys/:key_id/rotate", handlers.RotateAPIKey(store))
+		project.POST("/groups", handlers.GrantProjectGroup(store))
+		project.GET("/groups", handlers.ListProjectGroups(store))
+		project.DELETE("/groups/:group", handlers.RevokeProjectGroup(store))
+		if retentionStore, ok := store.(storage.RetentionStore); ok {
+			project.GET("/retention", handlers.GetRetentionStatus(retentionStore))
+			project.PUT("/retention", handlers.UpdateRetention(store, retentionStore))
+		}
+	}
+
+	// User accounts and organizations. Signed-in users reach their organizations' projects
+	// through the routes above and below, as their role allows.
+	r.POST("/auth/register", handlers.Register(store))
+	r.POST("/auth/login", handlers.Login(store))
 
-	// Protected log endpoints (require API key)
-	protected := r.Group("")
-	protected.Use(middleware.AuthRequired(db))
+	account := r.Group("")
+	account.Use(middleware.SessionRequired(store))
 	{
-		protected.POST("/logs", handlers.IngestLogs(db))
-		protected.GET("/logs", handlers.GetLogs(db))
-		protected.POST("/search", handlers.SearchLogs(db))
+		account.POST("/auth/logout", handlers.Logout(store))
+		account.GET("/auth/me", handlers.Me(store))
+		account.POST("/orgs", handlers.CreateOrganization(store))
+		account.GET("/orgs", handlers.ListOrganizations(store))
+		account.GET("/orgs/:org_id/members", handlers.ListMembers(store))
+		account.POST("/orgs/:org_id/members", handlers.AddMember(store))
+		account.PATCH("/orgs/:org_id/members/:user_id", handlers.UpdateMember(store))
+		account.DELETE("/orgs/:org_id/members/:user_id", handlers.RemoveMember(store))
+		account.POST("/orgs/:org_id/projects", handlers.CreateOrganizationProject(store))
+		account.GET("/orgs/:org_id/projects", handlers.ListOrganizationProjects(store))
+	}
+
+	// Protected log endpoints (require an API key with the route's scope, or a session
+	// whose role grants it for the project named by X-Project-ID); ingestion is limited
+	// by each project's rate limit and quota settings
+	// Facets, export and context are only registered when the store supports them
+	writer := r.Group("")
+	writer.Use(middleware.AuthRequired(store, models.ScopeLogsWrite), middleware.RateLimit(store))
+	{
+		writer.POST("/logs", handlers.IngestLogs(store, hub))
+	}
+
+	// People signed in with the identity provider can read projects their groups were granted
+	reader := r.Group("")
+	if verifier := oidcVerifier(); verifier != nil {
+		reader.Use(middleware.OIDCAuth(verifier, store, models.ScopeLogsRead))
+	}
+	reader.Use(middleware.AuthRequired(store, models.ScopeLogsRead))
+	{
+		reader.GET("/logs", handlers.GetLogs(store))
+		reader.GET("/logs/tail", handlers.TailLogs(hub))
+		reader.GET("/logs/tail/ws", handlers.TailLogsWebSocket(hub))
+		reader.GET("/logs/:id", handlers.GetLog(store))
+		reader.POST("/search", handlers.SearchLogs(store))
+
+		if facetStore, ok := store.(storage.FacetStore); ok {
+			reader.GET("/logs/facets", handlers.GetLogFacets(facetStore))
+			reader.POST("/search/facets", handlers.SearchLogFacets(facetStore))
+		}
+		if exportStore, ok := store.(storage.ExportStore); ok {
+			reader.GET("/logs/export", handlers.ExportLogs(exportStore))
+		}
+		if contextStore, ok := store.(storage.ContextStore); ok {
+			reader.GET("/logs/:id/context", handlers.GetLogContext(contextStore))
+		}
 	}
 
 	log.Println("Server starting on :8080")
 	log.Fatal(r.Run(":8080"))
 }
+
+// bootstrapAdminToken reads ADMIN_TOKEN, the credential that creates the first admin users.
+// Without it only existing admin users can manage projects.
+func bootstrapAdminToken() string {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		log.Println("ADMIN_TOKEN not set - only existing admin users can manage projects")
+		return ""
+	}
+	if len(token) < middleware.MinBootstrapTokenLength {
+		log.Fatalf("ADMIN_TOKEN must be at least %d characters", middleware.MinBootstrapTokenLength)
+	}
+	return token
+}
+
+// oidcVerifier reads OIDC_ISSUER, OIDC_AUDIENCE, OIDC_JWKS_URL and OIDC_GROUPS_CLAIM.
+// Returns nil, disabling identity provider sign-in, when OIDC_ISSUER is unset.
+func oidcVerifier() *oidc.Verifier {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+
+	verifier, err := oidc.NewVerifier(oidc.Config{
+		Issuer:      issuer,
+		Audience:    os.Getenv("OIDC_AUDIENCE"),
+		JWKSURL:     os.Getenv("OIDC_JWKS_URL"),
+		GroupsClaim: os.Getenv("OIDC_GROUPS_CLAIM"),
+	})
+	if err != nil {
+		log.Fatal("Invalid OIDC settings:", err)
+	}
+	log.Printf("Accepting identity provider tokens from %s", issuer)
+	return verifier
+}
+
+// applyMigrations applies the migrations embedded in the binary (--migrate).
+// Concurrent instances wait on the migrator's lock, so every replica can pass the flag.
+func applyMigrations(db *database.DB) {
+	migrations, err := database.EmbeddedMigrations()
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	count, err := database.NewMigrator(db, migrations).Up(context.Background())
+	if err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+	log.Printf("Applied %d migration(s)", count)
+}
+
+// startWorkers runs the PostgreSQL background workers until ctx is cancelled:
+// the cross-instance live tail relay, partition maintenance and retention purges.
+func startWorkers(ctx context.Context, db *database.DB, hub *tail.Hub) {
+	relay := tail.NewRelay(db, hub)
+	go relay.Run(ctx)
+
+	partitions, err := database.NewPartitionManager(db, partitionConfigFromEnv())
+	if err != nil {
+		log.Fatal("Invalid partition settings:", err)
+	}
+	go partitions.Run(ctx)
+
+	purger := retention.NewWorker(db, retention.DefaultConfig())
+	if dir := os.Getenv("ARCHIVE_DIR"); dir != "" {
+		purger.SetArchiver(archive.New(db, archive.NewDirSink(dir)))
+	}
+	go purger.Run(ctx)
+}
+
+// trustedProxies reads TRUSTED_PROXIES, a comma-separated list of the IPs or CIDR ranges
+// of the load balancers in front of the server. Without it, no proxy is trusted and the
+// client IP is the connection's remote address.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// reaperConfigFromEnv reads PROJECT_RESTORE_DAYS, how long deleted projects can be
+// restored, over the defaults.
+func reaperConfigFromEnv() reaper.Config {
+	config := reaper.DefaultConfig()
+
+	if days, err := strconv.Atoi(os.Getenv("PROJECT_RESTORE_DAYS")); err == nil {
+		if days < 1 {
+			log.Fatal("PROJECT_RESTORE_DAYS must be at least 1")
+		}
+		config.RestoreWindow = time.Duration(days) * 24 * time.Hour
+	}
+
+	return config
+}
+
+// partitionConfigFromEnv reads LOG_PARTITION_INTERVAL (daily or weekly),
+// LOG_PARTITION_PREMAKE and LOG_PARTITION_DETACH_DAYS over the defaults.
+func partitionConfigFromEnv() database.PartitionConfig {
+	config := database.DefaultPartitionConfig()
+
+	if interval := os.Getenv("LOG_PARTITION_INTERVAL"); interval != "" {
+		config.Interval = database.PartitionInterval(interval)
+	}
+	if premake, err := strconv.Atoi(os.Getenv("LOG_PARTITION_PREMAKE")); err == nil {
+		config.Premake = premake
+	}
+	if days, err := strconv.Atoi(os.Getenv("LOG_PARTITION_DETACH_DAYS")); err == nil {
+		config.DetachAfter = time.Duration(days) * 24 * time.Hour
+	}
+
+	return config
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MinBootstrapTokenLength is the shortest ADMIN_TOKEN the server accepts.
+const MinBootstrapTokenLength = 16
+
+// AdminRequired authenticates an admin for routes that manage every project.
+// Extracts "Authorization: Bearer <token>" and accepts either bootstrapToken (the
+// ADMIN_TOKEN environment variable; empty disables it) or an admin user's token.
+// On success, adds admin (the *models.AdminUser) to Gin context for use by handlers.
+// On failure, returns 401 Unauthorized and aborts the request chain.
+//
+// Usage:
+//
+//	admin := router.Group("")
+//	admin.Use(middleware.AdminRequired(store, os.Getenv("ADMIN_TOKEN")))
+//	admin.GET("/projects", handlers.ListProjects(store))
+func AdminRequired(store storage.AdminStore, bootstrapToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		admin, err := authenticateAdmin(c.Request.Context(), store, bootstrapToken, token)
+		if err != nil {
+			if !errors.Is(err, database.ErrInvalidAdminToken) {
+				log.Printf("AuthenticateAdmin error: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("admin", admin)
+		c.Next()
+	}
+}
+
+// ProjectAdminRequired authenticates routes that manage a single project (/projects/:id/...).
+// It accepts the same admin credentials as AdminRequired, one of that project's API keys
+// with the models.ScopeProjectAdmin scope, so a project can manage itself without an admin,
+// or the session of a user whose role in the project's organization grants that scope.
+// On success, adds admin or, for an API key, project_id and api_key or, for a session,
+// project_id, role, session and user to Gin context.
+// Returns 400 for an invalid project ID, 401 Unauthorized for unknown credentials,
+// 404 for a session naming an unknown project and 403 Forbidden for another project's key,
+// a non-member or missing scope, aborting the request chain.
+func ProjectAdminRequired(store storage.Store, bootstrapToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		admin, err := authenticateAdmin(ctx, store, bootstrapToken, token)
+		if err == nil {
+			c.Set("admin", admin)
+			c.Next()
+			return
+		}
+		if !errors.Is(err, database.ErrInvalidAdminToken) {
+			log.Printf("AuthenticateAdmin error: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			c.Abort()
+			return
+		}
+
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			c.Abort()
+			return
+		}
+
+		key, err := store.AuthenticateAPIKey(ctx, token)
+		if err != nil {
+			if !errors.Is(err, database.ErrInvalidAPIKey) {
+				log.Printf("AuthenticateAPIKey error: %v", err)
+			} else if session, err := lookupSession(ctx, store, token); err == nil {
+				if authorizeSession(c, store, session, projectID, models.ScopeProjectAdmin) {
+					c.Next()
+				}
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			c.Abort()
+			return
+		}
+
+		if key.ProjectID != projectID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key belongs to another project"})
+			c.Abort()
+			return
+		}
+		if !key.HasScope(models.ScopeProjectAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":          "API key is missing a required scope",
+				"required_scope": models.ScopeProjectAdmin,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("project_id", key.ProjectID)
+		c.Set("api_key", key)
+		c.Next()
+	}
+}
+
+// authenticateAdmin resolves token to the bootstrap admin or an admin user.
+// Returns database.ErrInvalidAdminToken if it is neither.
+func authenticateAdmin(ctx context.Context, store storage.AdminStore, bootstrapToken, token string) (*models.AdminUser, error) {
+	if bootstrapToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(bootstrapToken)) == 1 {
+		return &models.AdminUser{Name: models.BootstrapAdminName}, nil
+	}
+	return store.AuthenticateAdmin(ctx, token)
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBootstrapToken = "bootstrap-token-0123456789"
+
+func TestAdminRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	alice, err := store.CreateAdminUser(ctx, "alice")
+	require.NoError(t, err)
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	newRouter := func(bootstrapToken string) *gin.Engine {
+		r := gin.New()
+		r.GET("/", AdminRequired(store, bootstrapToken), func(c *gin.Context) {
+			admin, _ := c.Get("admin")
+			c.String(http.StatusOK, admin.(*models.AdminUser).Name)
+		})
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		bootstrapToken string
+		header         string
+		wantStatus     int
+		wantAdmin      string
+	}{
+		{name: "bootstrap token", bootstrapToken: testBootstrapToken, header: "Bearer " + testBootstrapToken, wantStatus: http.StatusOK, wantAdmin: models.BootstrapAdminName},
+		{name: "admin user", bootstrapToken: testBootstrapToken, header: "Bearer " + alice.Token, wantStatus: http.StatusOK, wantAdmin: "alice"},
+		{name: "admin user without bootstrap", header: "Bearer " + alice.Token, wantStatus: http.StatusOK, wantAdmin: "alice"},
+		{name: "bootstrap disabled", header: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", bootstrapToken: testBootstrapToken, header: "Bearer " + testBootstrapToken + "x", wantStatus: http.StatusUnauthorized},
+		{name: "API key", bootstrapToken: testBootstrapToken, header: "Bearer " + project.APIKey, wantStatus: http.StatusUnauthorized},
+		{name: "missing header", bootstrapToken: testBootstrapToken, wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", bootstrapToken: testBootstrapToken, header: "Basic " + testBootstrapToken, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			newRouter(tt.bootstrapToken).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantAdmin, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProjectAdminRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	other, err := store.CreateProject(ctx, "Other Project")
+	require.NoError(t, err)
+	reader, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{
+		Name:   "reader",
+		Scopes: []models.Scope{models.ScopeLogsRead},
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/projects/:id", ProjectAdminRequired(store, testBootstrapToken), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{name: "bootstrap token", path: "/projects/" + project.ID.String(), token: testBootstrapToken, wantStatus: http.StatusOK},
+		{name: "project admin key", path: "/projects/" + project.ID.String(), token: project.APIKey, wantStatus: http.StatusOK},
+		{name: "another project's key", path: "/projects/" + other.ID.String(), token: project.APIKey, wantStatus: http.StatusForbidden},
+		{name: "key without scope", path: "/projects/" + project.ID.String(), token: reader.Key, wantStatus: http.StatusForbidden},
+		{name: "unknown token", path: "/projects/" + project.ID.String(), token: "jazz_invalid", wantStatus: http.StatusUnauthorized},
+		{name: "invalid project ID", path: "/projects/not-a-uuid", token: project.APIKey, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
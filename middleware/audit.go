@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// auditRecordTimeout bounds recording an audit event once the handler has finished.
+const auditRecordTimeout = 5 * time.Second
+
+// Audit records the audit event a handler attached to the request (audit_event in Gin
+// context, a *models.AuditEvent) once the handler has finished. It fills in the actor
+// from what the authentication middleware added to the context (admin, user or
+// api_key), the request ID (see RequestID) and the client's IP.
+// Handlers only attach events for changes they made, so requests without one are
+// passed through. Failures to record are logged: the change itself has already happened.
+// Events are recorded even if the client has gone away, within auditRecordTimeout.
+// The client's IP is gin's ClientIP, which only believes X-Forwarded-For from the
+// engine's trusted proxies (see gin.Engine.SetTrustedProxies).
+//
+// Usage:
+//
+//	router.Use(middleware.RequestID(), middleware.Audit(store))
+func Audit(store storage.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		value, ok := c.Get("audit_event")
+		if !ok {
+			return
+		}
+		event := value.(*models.AuditEvent)
+
+		setAuditActor(c, event)
+		event.RequestID = c.GetString("request_id")
+		event.SourceIP = c.ClientIP()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditRecordTimeout)
+		defer cancel()
+		if err := store.RecordAuditEvent(ctx, event); err != nil {
+			log.Printf("RecordAuditEvent error: action=%s target=%s/%s: %v",
+				event.Action, event.TargetType, event.TargetID, err)
+		}
+	}
+}
+
+// setAuditActor sets event's actor to the request's admin, signed-in user or API key.
+func setAuditActor(c *gin.Context, event *models.AuditEvent) {
+	if value, ok := c.Get("admin"); ok {
+		admin := value.(*models.AdminUser)
+		event.ActorType = models.AuditActorAdmin
+		event.ActorName = admin.Name
+		if admin.ID != uuid.Nil {
+			event.ActorID = &admin.ID
+		}
+		return
+	}
+	if value, ok := c.Get("user"); ok {
+		user := value.(*models.User)
+		event.ActorType = models.AuditActorUser
+		event.ActorID = &user.ID
+		event.ActorName = user.Email
+		return
+	}
+	if value, ok := c.Get("api_key"); ok {
+		key := value.(*models.APIKey)
+		event.ActorType = models.AuditActorAPIKey
+		event.ActorID = &key.ID
+		event.ActorName = key.Name
+	}
+}
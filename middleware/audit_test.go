@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/storage"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/", RequestID(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("request_id"))
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		wantKept bool
+	}{
+		{name: "caller's ID", header: "lb-1234-abcd", wantKept: true},
+		{name: "missing", header: ""},
+		{name: "too long", header: strings.Repeat("a", 129)},
+		{name: "not printable", header: "id with spaces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			assert.Equal(t, got, w.Body.String())
+			if tt.wantKept {
+				assert.Equal(t, tt.header, got)
+				return
+			}
+			_, err := uuid.Parse(got)
+			assert.NoError(t, err, "generated IDs are UUIDs")
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	alice, err := store.CreateAdminUser(ctx, "alice")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(RequestID(), Audit(store))
+	r.POST("/change", AdminRequired(store, ""), func(c *gin.Context) {
+		c.Set("audit_event", &models.AuditEvent{
+			Action:     models.AuditProjectCreate,
+			TargetType: models.AuditTargetProject,
+			TargetID:   "target",
+		})
+		c.Status(http.StatusCreated)
+	})
+	r.GET("/read", AdminRequired(store, ""), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/read", "/change"} {
+		method := http.MethodGet
+		if path == "/change" {
+			method = http.MethodPost
+		}
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+alice.Token)
+		req.Header.Set(RequestIDHeader, "req-42")
+		req.RemoteAddr = "192.0.2.7:5123"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	events, total, err := store.ListAuditEvents(ctx, models.AuditQueryParams{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total, "only requests whose handler attached an event are recorded")
+
+	event := events[0]
+	assert.Equal(t, models.AuditActorAdmin, event.ActorType)
+	require.NotNil(t, event.ActorID)
+	assert.Equal(t, alice.ID, *event.ActorID)
+	assert.Equal(t, "alice", event.ActorName)
+	assert.Equal(t, "target", event.TargetID)
+	assert.Equal(t, "req-42", event.RequestID)
+	assert.Equal(t, "192.0.2.7", event.SourceIP)
+}
+
+// contextAuditStore notes the state of the context events are recorded with.
+type contextAuditStore struct {
+	storage.AuditStore
+	recorded    bool
+	err         error
+	hasDeadline bool
+}
+
+func (s *contextAuditStore) RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	s.recorded = true
+	s.err = ctx.Err()
+	_, s.hasDeadline = ctx.Deadline()
+	return s.AuditStore.RecordAuditEvent(ctx, event)
+}
+
+func TestAudit_SourceIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{name: "no trusted proxies", want: "192.0.2.7"},
+		{name: "trusted proxy", proxies: []string{"192.0.2.0/24"}, want: "203.0.113.9"},
+		{name: "untrusted proxy", proxies: []string{"10.0.0.0/8"}, want: "192.0.2.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			r := gin.New()
+			require.NoError(t, r.SetTrustedProxies(tt.proxies))
+			r.Use(Audit(store))
+			r.POST("/change", func(c *gin.Context) {
+				c.Set("audit_event", &models.AuditEvent{Action: models.AuditProjectCreate})
+				c.Status(http.StatusCreated)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/change", nil)
+			req.RemoteAddr = "192.0.2.7:5123"
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			events, _, err := store.ListAuditEvents(context.Background(), models.AuditQueryParams{})
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, tt.want, events[0].SourceIP)
+		})
+	}
+}
+
+func TestAudit_ClientGone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &contextAuditStore{AuditStore: memory.New()}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := gin.New()
+	r.Use(Audit(store))
+	r.POST("/change", func(c *gin.Context) {
+		c.Set("audit_event", &models.AuditEvent{Action: models.AuditProjectCreate})
+		cancel() // the client disconnects once the change is made
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/change", nil).WithContext(ctx)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.True(t, store.recorded)
+	assert.NoError(t, store.err, "recording outlives the request")
+	assert.True(t, store.hasDeadline, "recording is bounded")
+}
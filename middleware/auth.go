@@ -1,57 +1,119 @@
 // Package middleware provides HTTP middleware for the Jazz API.
-// Currently implements API key authentication and request context enrichment.
+// Implements scoped API key authentication, admin authentication for project
+// management, user session and identity provider (OIDC) authentication, request
+// context enrichment, audit recording and per-project ingestion rate limits.
 package middleware
 
 import (
+	"errors"
 	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// AuthRequired validates the API key and enriches the request context.
-// Extracts "Authorization: Bearer <api_key>" header and validates against database.
-// On success, adds project_id and project to Gin context for use by handlers.
-// On failure, returns 401 Unauthorized and aborts the request chain.
+// AuthRequired validates the API key or session and enriches the request context.
+// Extracts "Authorization: Bearer <api_key>" header and resolves it to one of a project's
+// keys; revoked and expired keys are rejected. The key must also have every scope in
+// scopes (models.ScopeProjectAdmin has them all).
+// A signed-in user's session token is accepted too, naming the project in the X-Project-ID
+// header; their role in the organization owning it must grant the scopes (see models.Role).
+// On success, adds project_id and api_key (the *models.APIKey) or, for a session, role,
+// session and user to Gin context for use by handlers.
+// On failure, returns 401 Unauthorized for a missing or invalid key, 400 for a session
+// without a valid X-Project-ID, 404 for an unknown project or 403 Forbidden naming the
+// missing scope, and aborts the request chain.
+// Requests OIDCAuth already authenticated pass straight through.
 //
 // Usage:
 //
-//	protected := router.Group("")
-//	protected.Use(middleware.AuthRequired(db))
-//	protected.POST("/logs", handlers.IngestLogs(db))
-func AuthRequired(db *database.DB) gin.HandlerFunc {
+//	writer := router.Group("")
+//	writer.Use(middleware.AuthRequired(store, models.ScopeLogsWrite))
+//	writer.POST("/logs", handlers.IngestLogs(store, hub))
+func AuthRequired(store storage.Store, scopes ...models.Scope) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
-			c.Abort()
+		if _, ok := c.Get("identity"); ok {
+			c.Next()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
-			c.Abort()
+		apiKey, ok := bearerToken(c)
+		if !ok {
 			return
 		}
 
-		apiKey := parts[1]
-
-		// Validate API key against database
+		// Validate API key against the key store
 		ctx := c.Request.Context()
-		project, err := db.GetProjectByAPIKey(ctx, apiKey)
+		key, err := store.AuthenticateAPIKey(ctx, apiKey)
 		if err != nil {
+			if !errors.Is(err, database.ErrInvalidAPIKey) {
+				log.Printf("AuthenticateAPIKey error: %v", err)
+			} else if session, err := lookupSession(ctx, store, apiKey); err == nil {
+				authenticateSessionProject(c, store, session, scopes)
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
 			c.Abort()
 			return
 		}
 
+		for _, scope := range scopes {
+			if !key.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":          "API key is missing a required scope",
+					"required_scope": scope,
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// Store project in context for handlers to use
-		c.Set("project_id", project.ID)
-		c.Set("project", project)
+		c.Set("project_id", key.ProjectID)
+		c.Set("api_key", key)
 
 		c.Next()
 	}
 }
+
+// authenticateSessionProject finishes AuthRequired for a session, reading the project
+// from the X-Project-ID header.
+func authenticateSessionProject(c *gin.Context, store storage.OrganizationStore, session *models.Session, scopes []models.Scope) {
+	projectID, err := uuid.Parse(c.GetHeader(ProjectHeader))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ProjectHeader + " header must name a project for session authentication"})
+		c.Abort()
+		return
+	}
+
+	if !authorizeSession(c, store, session, projectID, scopes...) {
+		return
+	}
+	c.Next()
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+// If the header is missing or malformed it responds 401 Unauthorized, aborts and returns false.
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+		c.Abort()
+		return "", false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
+		c.Abort()
+		return "", false
+	}
+
+	return parts[1], true
+}
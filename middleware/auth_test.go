@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := memory.New()
+	project, err := store.CreateProject(context.Background(), "Test Project")
+	require.NoError(t, err)
+	revoked, err := store.CreateAPIKey(context.Background(), project.ID, models.CreateAPIKeyRequest{Name: "revoked"})
+	require.NoError(t, err)
+	_, err = store.RevokeAPIKey(context.Background(), project.ID, revoked.ID)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/", AuthRequired(store), func(c *gin.Context) {
+		projectID, _ := c.Get("project_id")
+		c.String(http.StatusOK, projectID.(uuid.UUID).String())
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{name: "valid key", header: "Bearer " + project.APIKey, wantStatus: http.StatusOK},
+		{name: "missing header", header: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic " + project.APIKey, wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", header: "Bearer jazz_invalid", wantStatus: http.StatusUnauthorized},
+		{name: "revoked key", header: "Bearer " + revoked.Key, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, project.ID.String(), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthRequired_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	newKey := func(scopes ...models.Scope) string {
+		key, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "key", Scopes: scopes})
+		require.NoError(t, err)
+		return key.Key
+	}
+	ingest := newKey(models.ScopeLogsWrite)
+	readOnly := newKey(models.ScopeLogsRead)
+	admin := newKey(models.ScopeProjectAdmin)
+
+	r := gin.New()
+	r.POST("/logs", AuthRequired(store, models.ScopeLogsWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/logs", AuthRequired(store, models.ScopeLogsRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		wantStatus int
+		wantScope  models.Scope
+	}{
+		{name: "ingest key writes", method: http.MethodPost, key: ingest, wantStatus: http.StatusOK},
+		{name: "ingest key can't read", method: http.MethodGet, key: ingest, wantStatus: http.StatusForbidden, wantScope: models.ScopeLogsRead},
+		{name: "read-only key reads", method: http.MethodGet, key: readOnly, wantStatus: http.StatusOK},
+		{name: "read-only key can't write", method: http.MethodPost, key: readOnly, wantStatus: http.StatusForbidden, wantScope: models.ScopeLogsWrite},
+		{name: "admin key writes", method: http.MethodPost, key: admin, wantStatus: http.StatusOK},
+		{name: "admin key reads", method: http.MethodGet, key: admin, wantStatus: http.StatusOK},
+		{name: "default key reads", method: http.MethodGet, key: project.APIKey, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/logs", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantScope != "" {
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, string(tt.wantScope), body["required_scope"])
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/oidc"
+	"jazz/storage"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OIDCAuth authenticates people signed in with the configured identity provider, so
+// browsers can read logs without holding a project API key. Place it before
+// AuthRequired: bearer tokens that aren't JWTs (API keys, sessions) pass through to it.
+//
+// JWTs are verified against the provider's signing keys. The project is named by the
+// X-Project-ID header. If the token carries a verified email belonging to a user, that
+// user's role in the organization owning the project applies, as it would for one of
+// their sessions. Users don't record their provider subject, so sub is never used to
+// link them. Otherwise one of the token's groups must have been granted the project
+// (see storage.ProjectGroupStore); groups grant read access only, so every scope in
+// scopes must be models.ScopeLogsRead.
+// On success, adds project_id and identity (the *models.Identity) to Gin context, plus
+// user (the linked *models.User) and, when the access comes from it, their role.
+// AuthRequired then lets the request through.
+// On failure, returns 401 Unauthorized for an invalid token, 400 without a valid
+// X-Project-ID, 403 Forbidden if neither the user's role nor a group grants the project
+// and scopes, 503 if the provider's keys can't be fetched, and aborts the request chain.
+//
+// Usage:
+//
+//	reader := router.Group("")
+//	reader.Use(middleware.OIDCAuth(verifier, store, models.ScopeLogsRead))
+//	reader.Use(middleware.AuthRequired(store, models.ScopeLogsRead))
+func OIDCAuth(verifier *oidc.Verifier, store storage.Store, scopes ...models.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || !oidc.IsJWT(token) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		identity, err := verifier.Verify(ctx, token)
+		if err != nil {
+			if !errors.Is(err, oidc.ErrInvalidToken) {
+				log.Printf("OIDC verification error: %v", err)
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "identity provider unavailable"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		projectID, err := uuid.Parse(c.GetHeader(ProjectHeader))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ProjectHeader + " header must name a project for identity provider sign-in"})
+			c.Abort()
+			return
+		}
+
+		user, role, err := identityRole(ctx, store, identity, projectID)
+		if err != nil {
+			log.Printf("OIDC user lookup error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authorize token"})
+			c.Abort()
+			return
+		}
+		if user != nil {
+			c.Set("user", user)
+		}
+		if role != "" && hasScopes(role, scopes) {
+			c.Set("project_id", projectID)
+			c.Set("role", role)
+			c.Set("identity", identity)
+			c.Next()
+			return
+		}
+
+		for _, scope := range scopes {
+			if scope != models.ScopeLogsRead {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":          "identity provider sign-in only grants read access",
+					"required_scope": scope,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		allowed, err := store.HasProjectGroup(ctx, projectID, identity.Groups)
+		if err != nil {
+			log.Printf("HasProjectGroup error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authorize token"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "none of your groups can read this project"})
+			c.Abort()
+			return
+		}
+
+		c.Set("project_id", projectID)
+		c.Set("identity", identity)
+		c.Next()
+	}
+}
+
+// identityRole resolves identity's verified email to a user and returns it with their
+// role on projectID. The user is nil without a verified email or a matching user, and
+// the role is empty if the user isn't a member of the organization owning the project.
+func identityRole(ctx context.Context, store storage.Store, identity *models.Identity, projectID uuid.UUID) (*models.User, models.Role, error) {
+	if !identity.Verified || identity.Email == "" {
+		return nil, "", nil
+	}
+
+	user, err := store.GetUserByEmail(ctx, identity.Email)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	role, err := store.ProjectRole(ctx, projectID, user.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrProjectNotFound) || errors.Is(err, database.ErrMemberNotFound) {
+			return user, "", nil
+		}
+		return nil, "", err
+	}
+	return user, role, nil
+}
+
+func hasScopes(role models.Role, scopes []models.Scope) bool {
+	for _, scope := range scopes {
+		if !role.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/oidc"
+	"jazz/oidc/oidctest"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	other, err := store.CreateProject(ctx, "Other Project")
+	require.NoError(t, err)
+	_, err = store.GrantProjectGroup(ctx, project.ID, "sre")
+	require.NoError(t, err)
+
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+
+	r := gin.New()
+	reader := r.Group("")
+	reader.Use(OIDCAuth(verifier, store, models.ScopeLogsRead), AuthRequired(store, models.ScopeLogsRead))
+	reader.GET("/logs", func(c *gin.Context) {
+		projectID, _ := c.Get("project_id")
+		c.String(http.StatusOK, projectID.(uuid.UUID).String())
+	})
+	writer := r.Group("")
+	writer.Use(OIDCAuth(verifier, store, models.ScopeLogsWrite), AuthRequired(store, models.ScopeLogsWrite))
+	writer.POST("/logs", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	sre := issuer.Token(t, map[string]interface{}{"groups": []string{"eng", "sre"}})
+	marketing := issuer.Token(t, map[string]interface{}{"groups": []string{"marketing"}})
+	stranger := oidctest.NewIssuer(t).Token(t, map[string]interface{}{"groups": []string{"sre"}})
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		project    string
+		wantStatus int
+	}{
+		{name: "granted group reads", method: http.MethodGet, token: sre, project: project.ID.String(), wantStatus: http.StatusOK},
+		{name: "ES256 token", method: http.MethodGet, token: issuer.ES256Token(t, map[string]interface{}{"groups": "sre"}), project: project.ID.String(), wantStatus: http.StatusOK},
+		{name: "group without access", method: http.MethodGet, token: marketing, project: project.ID.String(), wantStatus: http.StatusForbidden},
+		{name: "another project", method: http.MethodGet, token: sre, project: other.ID.String(), wantStatus: http.StatusForbidden},
+		{name: "unknown project", method: http.MethodGet, token: sre, project: uuid.NewString(), wantStatus: http.StatusForbidden},
+		{name: "missing project header", method: http.MethodGet, token: sre, wantStatus: http.StatusBadRequest},
+		{name: "read-only", method: http.MethodPost, token: sre, project: project.ID.String(), wantStatus: http.StatusForbidden},
+		{name: "other issuer", method: http.MethodGet, token: stranger, project: project.ID.String(), wantStatus: http.StatusUnauthorized},
+		{name: "expired", method: http.MethodGet, token: issuer.Token(t, map[string]interface{}{"groups": "sre", "exp": 1}), project: project.ID.String(), wantStatus: http.StatusUnauthorized},
+		{name: "API key still works", method: http.MethodGet, token: project.APIKey, wantStatus: http.StatusOK},
+		{name: "API key writes", method: http.MethodPost, token: project.APIKey, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/logs", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.project != "" {
+				req.Header.Set(ProjectHeader, tt.project)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusOK && tt.method == http.MethodGet {
+				assert.Equal(t, project.ID.String(), w.Body.String())
+			}
+		})
+	}
+
+	// Revoking the group locks its members out
+	require.NoError(t, store.RevokeProjectGroup(ctx, project.ID, "sre"))
+	req := httptest.NewRequest(http.MethodGet, "/logs", nil)
+	req.Header.Set("Authorization", "Bearer "+sre)
+	req.Header.Set(ProjectHeader, project.ID.String())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestOIDCAuth_LinkedUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	owner, err := store.CreateUser(ctx, "owner@example.com", "Owner", "correct horse")
+	require.NoError(t, err)
+	viewer, err := store.CreateUser(ctx, "viewer@example.com", "Viewer", "correct horse")
+	require.NoError(t, err)
+	org, err := store.CreateOrganization(ctx, "Acme", owner.ID)
+	require.NoError(t, err)
+	_, err = store.AddMember(ctx, org.ID, viewer.Email, models.RoleViewer)
+	require.NoError(t, err)
+	project, err := store.CreateOrganizationProject(ctx, org.ID, "Test Project")
+	require.NoError(t, err)
+	other, err := store.CreateProject(ctx, "Other Project")
+	require.NoError(t, err)
+	_, err = store.GrantProjectGroup(ctx, other.ID, "sre")
+	require.NoError(t, err)
+
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+
+	userID := func(c *gin.Context) {
+		user, ok := c.Get("user")
+		if !ok {
+			c.String(http.StatusOK, "")
+			return
+		}
+		c.String(http.StatusOK, user.(*models.User).ID.String())
+	}
+	r := gin.New()
+	r.GET("/logs", OIDCAuth(verifier, store, models.ScopeLogsRead), AuthRequired(store, models.ScopeLogsRead), userID)
+	r.POST("/logs", OIDCAuth(verifier, store, models.ScopeLogsWrite), AuthRequired(store, models.ScopeLogsWrite), userID)
+
+	token := func(email string, verified bool, groups ...string) string {
+		return issuer.Token(t, map[string]interface{}{"email": email, "email_verified": verified, "groups": groups})
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		project    uuid.UUID
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "member reads without a group", method: http.MethodGet, token: token("Owner@example.com", true), project: project.ID, wantStatus: http.StatusOK, wantUser: owner.ID.String()},
+		{name: "role grants writes", method: http.MethodPost, token: token("owner@example.com", true), project: project.ID, wantStatus: http.StatusOK, wantUser: owner.ID.String()},
+		{name: "viewer reads", method: http.MethodGet, token: token("viewer@example.com", true), project: project.ID, wantStatus: http.StatusOK, wantUser: viewer.ID.String()},
+		{name: "viewer can't write", method: http.MethodPost, token: token("viewer@example.com", true), project: project.ID, wantStatus: http.StatusForbidden},
+		{name: "unverified email isn't linked", method: http.MethodGet, token: token("owner@example.com", false), project: project.ID, wantStatus: http.StatusForbidden},
+		{name: "non-member falls back to groups", method: http.MethodGet, token: token("owner@example.com", true, "sre"), project: other.ID, wantStatus: http.StatusOK, wantUser: owner.ID.String()},
+		{name: "unknown user uses groups", method: http.MethodGet, token: token("nobody@example.com", true, "sre"), project: other.ID, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/logs", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set(ProjectHeader, tt.project.String())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantUser, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOIDCAuth_ProviderUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := memory.New()
+	issuer := oidctest.NewIssuer(t)
+	token := issuer.Token(t, map[string]interface{}{"groups": "sre"})
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+	issuer.Close()
+
+	r := gin.New()
+	r.GET("/logs", OIDCAuth(verifier, store, models.ScopeLogsRead), AuthRequired(store, models.ScopeLogsRead))
+
+	req := httptest.NewRequest(http.MethodGet, "/logs", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
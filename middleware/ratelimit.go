@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RateLimit enforces the authenticated project's ingestion limits (see
+// models.ProjectSettings), so one busy project can't starve the others:
+//   - requests_per_second: one token per request
+//   - entries_per_second: one token per log entry in the batch
+//   - daily_entry_quota, daily_byte_quota: entries and request body bytes per UTC day
+//
+// Rates are token buckets holding one second's worth, so short bursts pass. Limits are
+// checked in that order and whatever a request was charged before it is rejected stays
+// spent; a batch the handler then rejects still counts towards the quotas.
+// Over a limit it responds 429 Too Many Requests with a Retry-After header (seconds,
+// until midnight UTC for quotas) naming the limit, and aborts the request chain.
+// Runs after AuthRequired (needs project_id in Gin context) and adds the project as
+// project, which IngestLogs reuses. If the limit store fails the request is let
+// through: the limits protect ingestion rather than guard data.
+//
+// Usage:
+//
+//	writer.Use(middleware.AuthRequired(store, models.ScopeLogsWrite), middleware.RateLimit(store))
+func RateLimit(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get("project_id")
+		if !ok {
+			c.Next()
+			return
+		}
+		projectID := value.(uuid.UUID)
+
+		ctx := c.Request.Context()
+		project, err := store.GetProject(ctx, projectID)
+		if err != nil {
+			log.Printf("RateLimit GetProject error: %v", err)
+			c.Next()
+			return
+		}
+		c.Set("project", project)
+		settings := project.Settings
+
+		if settings.RequestsPerSecond > 0 {
+			wait, err := store.TakeTokens(ctx, projectID, models.BucketRequests, float64(settings.RequestsPerSecond), 1)
+			if rejectOverLimit(c, "requests_per_second", wait, err) {
+				return
+			}
+		}
+
+		if settings.EntriesPerSecond == 0 && settings.DailyEntryQuota == 0 && settings.DailyByteQuota == 0 {
+			c.Next()
+			return
+		}
+		usage, ok := measureBatch(c)
+		if !ok {
+			c.Next() // IngestLogs rejects the body
+			return
+		}
+
+		if settings.EntriesPerSecond > 0 {
+			wait, err := store.TakeTokens(ctx, projectID, models.BucketEntries, float64(settings.EntriesPerSecond), float64(usage.Entries))
+			if rejectOverLimit(c, "entries_per_second", wait, err) {
+				return
+			}
+		}
+
+		if quota := settings.Quota(); quota != (models.IngestUsage{}) {
+			now := time.Now()
+			charged, err := store.ChargeIngestQuota(ctx, projectID, now, usage, quota)
+			var wait time.Duration
+			if err == nil && !charged {
+				wait = database.UTCDay(now).AddDate(0, 0, 1).Sub(now)
+			}
+			if rejectOverLimit(c, "daily_quota", wait, err) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// measureBatch reads the request's batch of logs, leaving the body for the handler.
+// Returns false if the body isn't a JSON array.
+func measureBatch(c *gin.Context) (models.IngestUsage, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return models.IngestUsage{}, false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return models.IngestUsage{}, false
+	}
+	return models.IngestUsage{Entries: int64(len(entries)), Bytes: int64(len(body))}, true
+}
+
+// rejectOverLimit responds 429 and aborts if wait is positive. Errors are logged and
+// the request let through.
+func rejectOverLimit(c *gin.Context, limit string, wait time.Duration, err error) bool {
+	if err != nil {
+		log.Printf("RateLimit %s error: %v", limit, err)
+		return false
+	}
+	if wait <= 0 {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "rate limit exceeded",
+		"limit":       limit,
+		"retry_after": retryAfter,
+	})
+	c.Abort()
+	return true
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+
+	newProject := func(settings models.ProjectSettings) *models.Project {
+		project, err := store.CreateProject(ctx, "Test Project")
+		require.NoError(t, err)
+		_, err = store.UpdateProject(ctx, project.ID, models.UpdateProjectRequest{Settings: &settings})
+		require.NoError(t, err)
+		return project
+	}
+
+	r := gin.New()
+	r.POST("/logs", AuthRequired(store, models.ScopeLogsWrite), RateLimit(store), func(c *gin.Context) {
+		// The handler still gets the whole body
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, string(body))
+	})
+
+	ingest := func(project *models.Project, entries int) *httptest.ResponseRecorder {
+		body := "[" + strings.TrimSuffix(strings.Repeat(`{"level":"info","message":"m"},`, entries), ",") + "]"
+		req := httptest.NewRequest(http.MethodPost, "/logs", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+project.APIKey)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code == http.StatusCreated {
+			assert.Equal(t, body, w.Body.String())
+		}
+		return w
+	}
+
+	assertLimited := func(w *httptest.ResponseRecorder, limit string) {
+		t.Helper()
+		require.Equal(t, http.StatusTooManyRequests, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"limit":"`+limit+`"`)
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.Positive(t, retryAfter)
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		project := newProject(models.ProjectSettings{})
+		for i := 0; i < 20; i++ {
+			require.Equal(t, http.StatusCreated, ingest(project, 10).Code)
+		}
+	})
+
+	t.Run("requests per second", func(t *testing.T) {
+		project := newProject(models.ProjectSettings{RequestsPerSecond: 2})
+		assert.Equal(t, http.StatusCreated, ingest(project, 1).Code)
+		assert.Equal(t, http.StatusCreated, ingest(project, 1).Code)
+		// A third request within the second may find a sliver of refill, but not a fourth
+		ingest(project, 1)
+		assertLimited(ingest(project, 1), "requests_per_second")
+
+		other := newProject(models.ProjectSettings{RequestsPerSecond: 2})
+		assert.Equal(t, http.StatusCreated, ingest(other, 1).Code, "projects are limited separately")
+	})
+
+	t.Run("entries per second", func(t *testing.T) {
+		project := newProject(models.ProjectSettings{EntriesPerSecond: 50})
+		assert.Equal(t, http.StatusCreated, ingest(project, 100).Code, "a full bucket admits a bigger batch")
+		w := ingest(project, 1)
+		assertLimited(w, "entries_per_second")
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("daily quota", func(t *testing.T) {
+		project := newProject(models.ProjectSettings{DailyEntryQuota: 15})
+		assert.Equal(t, http.StatusCreated, ingest(project, 10).Code)
+		assertLimited(ingest(project, 10), "daily_quota")
+		assert.Equal(t, http.StatusCreated, ingest(project, 5).Code)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		project := newProject(models.ProjectSettings{EntriesPerSecond: 1})
+		req := httptest.NewRequest(http.MethodPost, "/logs", strings.NewReader("not json"))
+		req.Header.Set("Authorization", "Bearer "+project.APIKey)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code, "left for the handler to reject")
+	})
+}
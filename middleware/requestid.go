@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries a request's ID, from the caller or generated, and is always
+// set on the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest caller-supplied request ID that is kept.
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID, so audit events and server logs can be matched
+// to it. A caller's X-Request-ID (for example from a load balancer) is kept if it is at
+// most 128 printable ASCII characters; otherwise a random UUID is used.
+// Adds request_id to Gin context and sets the X-Request-ID response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
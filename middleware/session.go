@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ProjectHeader names the project a session-authenticated request to the log routes is for.
+// API keys belong to a single project and don't need it.
+const ProjectHeader = "X-Project-ID"
+
+// SessionRequired authenticates a signed-in user for the account and organization routes.
+// Extracts "Authorization: Bearer <token>" and resolves it to an unexpired session.
+// On success, adds session (the *models.Session) and user (its *models.User) to Gin context.
+// On failure, returns 401 Unauthorized and aborts the request chain.
+//
+// Usage:
+//
+//	account := router.Group("")
+//	account.Use(middleware.SessionRequired(store))
+//	account.GET("/auth/me", handlers.Me(store))
+func SessionRequired(store storage.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		session, err := store.AuthenticateSession(c.Request.Context(), token)
+		if err != nil {
+			if !errors.Is(err, database.ErrInvalidSession) {
+				log.Printf("AuthenticateSession error: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+			c.Abort()
+			return
+		}
+
+		setSession(c, session)
+		c.Next()
+	}
+}
+
+// authorizeSession checks that a session's user has every scope in scopes on projectID
+// through their role in the organization owning it. On success it adds project_id, role,
+// session and user to Gin context. Otherwise it responds 404 for an unknown project,
+// 403 Forbidden for non-members and roles without a scope, aborts and returns false.
+func authorizeSession(c *gin.Context, store storage.OrganizationStore, session *models.Session, projectID uuid.UUID, scopes ...models.Scope) bool {
+	role, err := store.ProjectRole(c.Request.Context(), projectID, session.User.ID)
+	switch {
+	case errors.Is(err, database.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		c.Abort()
+		return false
+	case errors.Is(err, database.ErrMemberNotFound):
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a member of this project's organization"})
+		c.Abort()
+		return false
+	case err != nil:
+		log.Printf("ProjectRole error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authorize session"})
+		c.Abort()
+		return false
+	}
+
+	for _, scope := range scopes {
+		if !role.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":          "role is missing a required scope",
+				"required_scope": scope,
+			})
+			c.Abort()
+			return false
+		}
+	}
+
+	setSession(c, session)
+	c.Set("project_id", projectID)
+	c.Set("role", role)
+	return true
+}
+
+// lookupSession resolves token to a session, returning database.ErrInvalidSession if it
+// isn't one. Used after a token failed as an API key or admin token.
+func lookupSession(ctx context.Context, store storage.UserStore, token string) (*models.Session, error) {
+	session, err := store.AuthenticateSession(ctx, token)
+	if err != nil && !errors.Is(err, database.ErrInvalidSession) {
+		log.Printf("AuthenticateSession error: %v", err)
+	}
+	return session, err
+}
+
+func setSession(c *gin.Context, session *models.Session) {
+	c.Set("session", session)
+	c.Set("user", &session.User)
+}
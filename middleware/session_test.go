@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	user, err := store.CreateUser(ctx, "alice@example.com", "Alice", "correct horse")
+	require.NoError(t, err)
+	session, err := store.CreateSession(ctx, user.ID, time.Hour)
+	require.NoError(t, err)
+	expired, err := store.CreateSession(ctx, user.ID, -time.Second)
+	require.NoError(t, err)
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/", SessionRequired(store), func(c *gin.Context) {
+		user, _ := c.Get("user")
+		c.String(http.StatusOK, user.(*models.User).Email)
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{name: "valid session", header: "Bearer " + session.Token, wantStatus: http.StatusOK},
+		{name: "expired session", header: "Bearer " + expired.Token, wantStatus: http.StatusUnauthorized},
+		{name: "API key", header: "Bearer " + project.APIKey, wantStatus: http.StatusUnauthorized},
+		{name: "missing header", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, user.Email, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthRequired_Session(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	store := memory.New()
+	owner, err := store.CreateUser(ctx, "owner@example.com", "Owner", "correct horse")
+	require.NoError(t, err)
+	viewer, err := store.CreateUser(ctx, "viewer@example.com", "Viewer", "correct horse")
+	require.NoError(t, err)
+	org, err := store.CreateOrganization(ctx, "Acme", owner.ID)
+	require.NoError(t, err)
+	_, err = store.AddMember(ctx, org.ID, viewer.Email, models.RoleViewer)
+	require.NoError(t, err)
+	project, err := store.CreateOrganizationProject(ctx, org.ID, "Test Project")
+	require.NoError(t, err)
+	other, err := store.CreateProject(ctx, "Other Project")
+	require.NoError(t, err)
+
+	newSession := func(user *models.User) string {
+		session, err := store.CreateSession(ctx, user.ID, time.Hour)
+		require.NoError(t, err)
+		return session.Token
+	}
+	ownerToken, viewerToken := newSession(owner), newSession(viewer)
+
+	r := gin.New()
+	r.POST("/logs", AuthRequired(store, models.ScopeLogsWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/logs", AuthRequired(store, models.ScopeLogsRead), func(c *gin.Context) {
+		role, _ := c.Get("role")
+		c.String(http.StatusOK, string(role.(models.Role)))
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		project    string
+		wantStatus int
+	}{
+		{name: "owner writes", method: http.MethodPost, token: ownerToken, project: project.ID.String(), wantStatus: http.StatusOK},
+		{name: "viewer reads", method: http.MethodGet, token: viewerToken, project: project.ID.String(), wantStatus: http.StatusOK},
+		{name: "viewer can't write", method: http.MethodPost, token: viewerToken, project: project.ID.String(), wantStatus: http.StatusForbidden},
+		{name: "project without organization", method: http.MethodGet, token: ownerToken, project: other.ID.String(), wantStatus: http.StatusForbidden},
+		{name: "unknown project", method: http.MethodGet, token: ownerToken, project: "00000000-0000-0000-0000-000000000000", wantStatus: http.StatusNotFound},
+		{name: "missing project header", method: http.MethodGet, token: ownerToken, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/logs", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.project != "" {
+				req.Header.Set(ProjectHeader, tt.project)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BootstrapAdminName is the name of the AdminUser the bootstrap ADMIN_TOKEN authenticates as.
+const BootstrapAdminName = "bootstrap"
+
+// AdminUser is an operator allowed to manage every project.
+// Tokens are stored hashed like API keys, so Token is only set in the response that
+// creates the user; afterwards TokenPrefix (the token's first characters) identifies it.
+// The bootstrap ADMIN_TOKEN authenticates as an AdminUser named BootstrapAdminName with no ID.
+type AdminUser struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Token       string     `json:"token,omitempty"`
+	TokenPrefix string     `json:"token_prefix"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+// CreateAdminUserRequest is the payload for POST /admin/users.
+// Name is 3-255 characters and unique.
+type CreateAdminUserRequest struct {
+	Name string `json:"name" binding:"required,min=3,max=255"`
+}
+
+// AdminUsersResponse is the response format for GET /admin/users.
+type AdminUsersResponse struct {
+	AdminUsers []AdminUser `json:"admin_users"`
+	Total      int         `json:"total"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	// ScopeLogsWrite allows ingesting logs (POST /logs).
+	ScopeLogsWrite Scope = "logs:write"
+
+	// ScopeLogsRead allows querying, searching, exporting and tailing logs.
+	ScopeLogsRead Scope = "logs:read"
+
+	// ScopeProjectAdmin allows everything, including managing the project itself.
+	ScopeProjectAdmin Scope = "project:admin"
+)
+
+// AllScopes lists every scope in canonical order. A project's "default" key has all of them.
+var AllScopes = []Scope{ScopeLogsWrite, ScopeLogsRead, ScopeProjectAdmin}
+
+// DefaultScopes are granted to keys created without explicit scopes.
+var DefaultScopes = []Scope{ScopeLogsWrite, ScopeLogsRead}
+
+// NormalizeScopes returns scopes deduplicated and in canonical order, dropping unknown ones.
+func NormalizeScopes(scopes []Scope) []Scope {
+	normalized := []Scope{}
+	for _, scope := range AllScopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+// APIKey is one of a project's API keys. Keys are stored hashed, so Key (the full
+// plaintext) is only set in the response that creates it; afterwards Prefix (the
+// key's first characters) is all that identifies it.
+// A key authenticates until it is revoked or ExpiresAt passes, and may only be used for
+// what its Scopes allow. LastUsedAt is updated when the key authenticates, at most once a minute.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	ProjectID  uuid.UUID  `json:"project_id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// Active reports whether the key can authenticate at now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+// HasScope reports whether the key may be used for scope. ScopeProjectAdmin grants every scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeProjectAdmin)
+}
+
+// CreateAPIKeyRequest is the payload for POST /projects/:id/keys.
+// Name is 1-255 characters; Scopes defaults to DefaultScopes when omitted;
+// ExpiresAt must be in the future, nil never expires.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,min=1,max=255"`
+	Scopes    []Scope    `json:"scopes" binding:"omitempty,dive,oneof=logs:write logs:read project:admin"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RotateAPIKeyRequest is the payload for POST /projects/:id/keys/:key_id/rotate.
+// GracePeriod is how long the old key keeps working (a Go duration such as "24h",
+// at most 30 days); empty uses the default of 24 hours and "0s" expires it immediately.
+type RotateAPIKeyRequest struct {
+	GracePeriod string `json:"grace_period"`
+}
+
+// RotateAPIKeyResponse is the response format for key rotation: the replacement key
+// (with its plaintext and the old key's name and scopes) and the old key with its new expiry.
+type RotateAPIKeyResponse struct {
+	Key      APIKey `json:"key"`
+	Previous APIKey `json:"previous"`
+}
+
+// APIKeysResponse is the response format for GET /projects/:id/keys.
+type APIKeysResponse struct {
+	Keys  []APIKey `json:"keys"`
+	Total int      `json:"total"`
+}
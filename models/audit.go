@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditActorType is how the actor of an audit event authenticated.
+type AuditActorType string
+
+const (
+	// AuditActorAdmin is the bootstrap ADMIN_TOKEN (no ActorID) or an admin user.
+	AuditActorAdmin AuditActorType = "admin"
+
+	// AuditActorAPIKey is a project:admin API key; ActorID is the key's ID.
+	AuditActorAPIKey AuditActorType = "api_key"
+
+	// AuditActorUser is a signed-in user; ActorID is the user's ID.
+	AuditActorUser AuditActorType = "user"
+)
+
+// Audit actions, named "<target type>.<verb>".
+const (
+	AuditProjectCreate    = "project.create"
+	AuditProjectUpdate    = "project.update"
+	AuditProjectDelete    = "project.delete"
+	AuditProjectRestore   = "project.restore"
+	AuditProjectRetention = "project.retention"
+	AuditAPIKeyCreate     = "api_key.create"
+	AuditAPIKeyRevoke     = "api_key.revoke"
+	AuditAPIKeyRotate     = "api_key.rotate"
+	AuditGroupGrant       = "project_group.grant"
+	AuditGroupRevoke      = "project_group.revoke"
+	AuditAdminUserCreate  = "admin_user.create"
+	AuditAdminUserDelete  = "admin_user.delete"
+)
+
+// Audit target types.
+const (
+	AuditTargetProject      = "project"
+	AuditTargetAPIKey       = "api_key"
+	AuditTargetProjectGroup = "project_group"
+	AuditTargetAdminUser    = "admin_user"
+)
+
+// AuditEvent records an administrative action: who did what to which object, and the
+// object before and after. Events are append-only.
+//
+// TargetID is the target's ID, or the group name for project groups. ProjectID is the
+// project the target belongs to, nil for admin users.
+// Before and After are the target around the change: Before is nil for creations and
+// for changes whose After says all there is (key revocations and rotations, retention
+// updates), After is nil for deletions. Secrets (API keys, admin tokens) are never recorded.
+// RequestID matches the X-Request-ID response header of the request that made the change.
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorType  AuditActorType  `json:"actor_type"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	ActorName  string          `json:"actor_name"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	ProjectID  *uuid.UUID      `json:"project_id"`
+	RequestID  string          `json:"request_id"`
+	SourceIP   string          `json:"source_ip"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
+
+// AuditQueryParams defines filters for the GET /audit endpoint.
+// StartTime and EndTime accept the same formats as log queries (RFC3339, epoch,
+// or "now-24h" style). Limit defaults to 50 (max 1000).
+type AuditQueryParams struct {
+	ProjectID string `form:"project_id"`
+	ActorID   string `form:"actor_id"`
+	Action    string `form:"action"`
+	StartTime string `form:"start_time"`
+	EndTime   string `form:"end_time"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+// AuditEventsResponse is the response format for GET /audit.
+type AuditEventsResponse struct {
+	Events  []AuditEvent `json:"events"`
+	Total   int          `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+	HasMore bool         `json:"has_more"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity is a person signed in with the configured OpenID Connect identity provider,
+// as described by the claims of their ID or access token.
+// Verified is the email_verified claim: whether the provider vouches for Email.
+type Identity struct {
+	Subject   string    `json:"sub"`
+	Email     string    `json:"email,omitempty"`
+	Verified  bool      `json:"email_verified"`
+	Name      string    `json:"name,omitempty"`
+	Groups    []string  `json:"groups"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ProjectGroup grants members of an identity provider group read access to a project's logs.
+type ProjectGroup struct {
+	ProjectID uuid.UUID `json:"project_id"`
+	Group     string    `json:"group"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GrantProjectGroupRequest is the payload for POST /projects/:id/groups.
+type GrantProjectGroupRequest struct {
+	Group string `json:"group" binding:"required,min=1,max=255"`
+}
+
+// ProjectGroupsResponse is the response format for GET /projects/:id/groups.
+type ProjectGroupsResponse struct {
+	Groups []ProjectGroup `json:"groups"`
+	Total  int            `json:"total"`
+}
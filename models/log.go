@@ -23,6 +23,8 @@ type LogEntry struct {
 
 // QueryParams defines filtering and pagination options for log queries.
 // All fields are optional - empty values are ignored.
+// StartTime/EndTime accept RFC3339, epoch seconds/millis or relative times like "now-15m";
+// TZ (IANA name, default UTC) applies to relative times and timestamps without an offset.
 // Used with GET /logs endpoint.
 type QueryParams struct {
 	Level     string `form:"level"`
@@ -32,21 +34,44 @@ type QueryParams struct {
 	Limit     int    `form:"limit"`
 	Offset    int    `form:"offset"`
 	Search    string `form:"search"`
+	TZ        string `form:"tz"`
+	Sort      string `form:"sort"`
 }
 
 // SearchRequest defines parameters for full-text search.
 // Query field is required and must be at least 3 characters.
 // Other fields are optional filters applied after search.
+// Sort defaults to "rank:desc,timestamp:desc"; "timestamp:asc" replays hits chronologically.
 type SearchRequest struct {
 	Query     string `json:"query" binding:"required,min=3"`
 	Level     string `json:"level"`
 	Source    string `json:"source"`
 	StartTime string `json:"start_time"`
 	EndTime   string `json:"end_time"`
+	TZ        string `json:"tz"`
+	Sort      string `json:"sort"`
 	Limit     int    `json:"limit"`
 	Offset    int    `json:"offset"`
 }
 
+// LogContextParams defines options for the GET /logs/:id/context endpoint.
+// Before and After default to 10 entries each when unset (max 100); zero asks for no
+// entries on that side.
+// SameSource restricts neighbours to entries with the target's source.
+type LogContextParams struct {
+	Before     *int `form:"before" binding:"omitempty,min=0"`
+	After      *int `form:"after" binding:"omitempty,min=0"`
+	SameSource bool `form:"same_source"`
+}
+
+// LogContextResponse is the response format for GET /logs/:id/context.
+// Before and After are ordered oldest first, so Before + Log + After reads chronologically.
+type LogContextResponse struct {
+	Log    LogEntry   `json:"log"`
+	Before []LogEntry `json:"before"`
+	After  []LogEntry `json:"after"`
+}
+
 // LogsResponse is the standard response format for log queries.
 // Includes pagination metadata to support infinite scroll or pagination UI.
 // HasMore indicates if there are additional results beyond current page.
@@ -58,3 +83,43 @@ type LogsResponse struct {
 	HasMore     bool       `json:"has_more"`
 	QueryTimeMs *int64     `json:"query_time_ms,omitempty"`
 }
+
+// FacetParams defines options for the GET /logs/facets endpoint.
+// Embeds QueryParams so facets are computed over exactly the rows GET /logs would match.
+// Limit and Offset are ignored; Size caps distinct values per facet (default 10, max 100).
+type FacetParams struct {
+	QueryParams
+	Size int `form:"size"`
+}
+
+// SearchFacetRequest defines options for the POST /search/facets endpoint.
+// Embeds SearchRequest so the same query validation applies.
+// Limit and Offset are ignored; Size caps distinct values per facet (default 10, max 100).
+type SearchFacetRequest struct {
+	SearchRequest
+	Size int `json:"size"`
+}
+
+// FacetValue is a single distinct value of a facet field and how many logs have it.
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// FacetsResponse is the response format for facet queries.
+// Facets maps a field name ("level", "source") to its values, most frequent first.
+// Total is the number of logs matching the filters, regardless of Size.
+type FacetsResponse struct {
+	Facets      map[string][]FacetValue `json:"facets"`
+	Total       int64                   `json:"total"`
+	QueryTimeMs *int64                  `json:"query_time_ms,omitempty"`
+}
+
+// ExportParams defines options for the GET /logs/export endpoint.
+// Embeds QueryParams for filtering; Limit and Offset are ignored since every match is exported.
+// Format is "ndjson" (default) or "csv"; Compress "gzip" wraps the output in a .gz file.
+type ExportParams struct {
+	QueryParams
+	Format   string `form:"format" binding:"omitempty,oneof=ndjson csv"`
+	Compress string `form:"compress" binding:"omitempty,oneof=gzip"`
+}
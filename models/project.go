@@ -1,20 +1,56 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 // Project represents a multi-tenant project in Jazz.
-// Each project has a unique API key used for authentication.
+// Projects authenticate with any number of named API keys (see APIKey).
 // All logs belong to exactly one project for data isolation.
+// APIKey is only set when the project is created: it is the plaintext of the
+// project's first key, named "default".
+// RetentionDays is how long logs are kept; nil keeps them forever.
+// OrgID is the organization whose members can access the project; projects created
+// by admins outside an organization have none.
+// UpdatedAt changes whenever the project or its settings do.
+// Stats is only set in project listings.
 type Project struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" binding:"required,min=3,max=255" db:"name"`
-	APIKey    string    `json:"api_key" db:"api_key"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID            uuid.UUID       `json:"id" db:"id"`
+	Name          string          `json:"name" binding:"required,min=3,max=255" db:"name"`
+	APIKey        string          `json:"api_key,omitempty" db:"-"`
+	OrgID         *uuid.UUID      `json:"org_id" db:"org_id"`
+	RetentionDays *int            `json:"retention_days" db:"retention_days"`
+	Settings      ProjectSettings `json:"settings" db:"settings"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+	Stats         *ProjectStats   `json:"stats,omitempty" db:"-"`
+}
+
+// ProjectStats summarizes a project's stored logs, computed when it is listed.
+// LastLogAt is the newest log's timestamp, nil if the project has no logs.
+// StorageBytes is the approximate size of the project's log rows, as the backend
+// measures it (PostgreSQL reports row sizes on disk, before indexes).
+type ProjectStats struct {
+	LogCount     int64      `json:"log_count"`
+	LastLogAt    *time.Time `json:"last_log_at"`
+	StorageBytes int64      `json:"storage_bytes"`
+}
+
+// ProjectListParams defines options for the GET /projects endpoint.
+// Search keeps projects whose name contains it (case-insensitive).
+// Sort accepts "name" and "created_at", e.g. "name:asc" (default "created_at:desc").
+// Stats are aggregated for the listed page only, so they can't be sorted by.
+// Limit defaults to 50 (max 1000).
+type ProjectListParams struct {
+	Search string `form:"search"`
+	Sort   string `form:"sort"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
 }
 
 // CreateProjectRequest is the payload for creating a new project.
@@ -23,9 +59,102 @@ type CreateProjectRequest struct {
 	Name string `json:"name" binding:"required,min=3,max=255"`
 }
 
+// SearchLanguages are the text search configurations a project's search_language may
+// name: PostgreSQL's built-in stemmers, plus "simple", which only lowercases words.
+var SearchLanguages = []string{
+	"simple", "arabic", "danish", "dutch", "english", "finnish", "french", "german",
+	"greek", "hungarian", "indonesian", "irish", "italian", "lithuanian", "nepali",
+	"norwegian", "portuguese", "romanian", "russian", "spanish", "swedish", "tamil",
+	"turkish",
+}
+
+// DefaultSearchLanguage is the search language of projects that don't set one.
+const DefaultSearchLanguage = "english"
+
+// ProjectSettings tunes how a project ingests and searches logs. Zero values keep the
+// server defaults.
+//
+// AllowedLevels, when set, is the only levels ingestion accepts.
+// SearchLanguage is the PostgreSQL text search configuration used to stem messages
+// and queries (one of SearchLanguages, default english); other backends ignore it.
+// MaxBatchSize lowers the number of logs accepted per ingest request (at most 1000)
+// and MaxMessageLength caps each message, in bytes.
+// RequestsPerSecond and EntriesPerSecond rate-limit POST /logs, and DailyEntryQuota
+// and DailyByteQuota cap how many entries and request body bytes it accepts per UTC
+// day (see middleware.RateLimit).
+type ProjectSettings struct {
+	AllowedLevels     []string `json:"allowed_levels,omitempty" binding:"omitempty,max=50,dive,min=1,max=20"`
+	SearchLanguage    string   `json:"search_language,omitempty"`
+	MaxBatchSize      int      `json:"max_batch_size,omitempty" binding:"omitempty,min=1,max=1000"`
+	MaxMessageLength  int      `json:"max_message_length,omitempty" binding:"omitempty,min=1,max=1048576"`
+	RequestsPerSecond int      `json:"requests_per_second,omitempty" binding:"omitempty,min=1,max=100000"`
+	EntriesPerSecond  int      `json:"entries_per_second,omitempty" binding:"omitempty,min=1,max=10000000"`
+	DailyEntryQuota   int64    `json:"daily_entry_quota,omitempty" binding:"omitempty,min=1"`
+	DailyByteQuota    int64    `json:"daily_byte_quota,omitempty" binding:"omitempty,min=1"`
+}
+
+// Validate checks what binding tags can't: that SearchLanguage is one of SearchLanguages.
+func (s ProjectSettings) Validate() error {
+	if s.SearchLanguage != "" && !slices.Contains(SearchLanguages, s.SearchLanguage) {
+		return fmt.Errorf("unknown search_language %q", s.SearchLanguage)
+	}
+	return nil
+}
+
+// Quota returns the daily quotas as an IngestUsage; zero fields are unlimited.
+func (s ProjectSettings) Quota() IngestUsage {
+	return IngestUsage{Entries: s.DailyEntryQuota, Bytes: s.DailyByteQuota}
+}
+
+// Language returns SearchLanguage, or DefaultSearchLanguage if it isn't set.
+func (s ProjectSettings) Language() string {
+	if s.SearchLanguage == "" {
+		return DefaultSearchLanguage
+	}
+	return s.SearchLanguage
+}
+
+// UpdateProjectRequest is the payload for PATCH /projects/:id. Omitted fields are left
+// unchanged. Name is validated like CreateProjectRequest and RetentionDays like
+// UpdateRetentionRequest, except that an explicit null disables retention.
+// Settings replaces the whole settings document.
+type UpdateProjectRequest struct {
+	Name          *string          `json:"name" binding:"omitempty,min=3,max=255"`
+	RetentionDays *int             `json:"retention_days" binding:"omitempty,min=1,max=3650"`
+	Settings      *ProjectSettings `json:"settings"`
+
+	// SetRetention reports whether the body included retention_days, which
+	// distinguishes null (keep logs forever) from leaving retention as it is.
+	SetRetention bool `json:"-"`
+}
+
+// UnmarshalJSON decodes the request and records whether retention_days was present.
+func (r *UpdateProjectRequest) UnmarshalJSON(data []byte) error {
+	type request UpdateProjectRequest
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*request)(r)); err != nil {
+		return err
+	}
+	_, r.SetRetention = fields["retention_days"]
+	return nil
+}
+
+// Empty reports whether the request changes nothing.
+func (r UpdateProjectRequest) Empty() bool {
+	return r.Name == nil && !r.SetRetention && r.Settings == nil
+}
+
 // ProjectsResponse is the standard response format for project listings.
-// Includes total count for potential pagination in the future.
+// Total counts every matching project, not just this page. Limit, Offset and
+// HasMore describe the page for GET /projects; organization listings aren't paged
+// and leave them zero.
 type ProjectsResponse struct {
 	Projects []Project `json:"projects"`
 	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	HasMore  bool      `json:"has_more"`
 }
@@ -0,0 +1,29 @@
+package models
+
+// Token buckets that rate-limit a project's ingestion, one of each per project.
+// Each holds one second's worth of tokens at the project's rate.
+const (
+	// BucketRequests is spent one token per POST /logs request (RequestsPerSecond).
+	BucketRequests = "requests"
+
+	// BucketEntries is spent one token per log entry (EntriesPerSecond).
+	BucketEntries = "entries"
+)
+
+// IngestUsage is an amount of ingestion: log entries and the request body bytes
+// they arrived in. As a quota, zero fields are unlimited.
+type IngestUsage struct {
+	Entries int64 `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// Add returns the sum of u and other.
+func (u IngestUsage) Add(other IngestUsage) IngestUsage {
+	return IngestUsage{Entries: u.Entries + other.Entries, Bytes: u.Bytes + other.Bytes}
+}
+
+// Within reports whether u fits in quota.
+func (u IngestUsage) Within(quota IngestUsage) bool {
+	return (quota.Entries == 0 || u.Entries <= quota.Entries) &&
+		(quota.Bytes == 0 || u.Bytes <= quota.Bytes)
+}
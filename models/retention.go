@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UpdateRetentionRequest is the payload for PUT /projects/:id/retention.
+// RetentionDays must be 1-3650; null (or omitted) keeps logs forever.
+type UpdateRetentionRequest struct {
+	RetentionDays *int `json:"retention_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// RetentionRun records one purge of a project's expired logs.
+// FinishedAt is nil while the run is in progress; Error is set if it failed part way.
+// ArchivedRows is zero unless an archive is configured.
+type RetentionRun struct {
+	ID           uuid.UUID  `json:"id"`
+	ProjectID    uuid.UUID  `json:"project_id"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	Cutoff       time.Time  `json:"cutoff"`
+	DeletedRows  int64      `json:"deleted_rows"`
+	ArchivedRows int64      `json:"archived_rows"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// RetentionStatus is the response format for GET /projects/:id/retention.
+// Cutoff is the timestamp before which logs are expired (nil without a policy),
+// ExpiredLogs how many are still waiting to be purged, and LastRun the most recent purge.
+type RetentionStatus struct {
+	ProjectID     uuid.UUID     `json:"project_id"`
+	RetentionDays *int          `json:"retention_days"`
+	Cutoff        *time.Time    `json:"cutoff"`
+	OldestLog     *time.Time    `json:"oldest_log"`
+	ExpiredLogs   int64         `json:"expired_logs"`
+	LastRun       *RetentionRun `json:"last_run"`
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role is a user's role in an organization. Each role can do everything the roles
+// after it can:
+//
+//   - owner: manage owners, plus everything an admin can
+//   - admin: manage members and the organization's projects, their keys and settings
+//   - member: create projects and read and ingest logs
+//   - viewer: list projects and read logs
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+// Roles lists every role, most privileged first.
+var Roles = []Role{RoleOwner, RoleAdmin, RoleMember, RoleViewer}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// AtLeast reports whether r is min or a more privileged role.
+func (r Role) AtLeast(min Role) bool {
+	i, j := slices.Index(Roles, r), slices.Index(Roles, min)
+	return i >= 0 && j >= 0 && i <= j
+}
+
+// HasScope reports whether the role grants what an API key with scope may do,
+// so users and keys share the checks on log and project routes.
+func (r Role) HasScope(scope Scope) bool {
+	switch scope {
+	case ScopeLogsRead:
+		return r.AtLeast(RoleViewer)
+	case ScopeLogsWrite:
+		return r.AtLeast(RoleMember)
+	case ScopeProjectAdmin:
+		return r.AtLeast(RoleAdmin)
+	}
+	return false
+}
+
+// User is a person who signs in with an email address and password.
+// Emails are stored lowercased; the password is stored as a bcrypt hash and never returned.
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeEmail returns email as users are stored and looked up: trimmed and lowercased.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Session is a signed-in user's bearer token. Like API keys, only a hash of the token
+// is stored, so Token is only set in the login response.
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	User      User      `json:"user"`
+	Token     string    `json:"token,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Organization owns projects and grants its members access to them by role.
+// Role is the requesting user's role, in listings of their organizations.
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Membership is a user's role in an organization.
+type Membership struct {
+	OrgID     uuid.UUID `json:"org_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RegisterRequest is the payload for POST /auth/register.
+// Passwords are 8-72 bytes, bcrypt's limit.
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Name     string `json:"name" binding:"required,min=1,max=255"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// LoginRequest is the payload for POST /auth/login.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// MeResponse is the response format for GET /auth/me.
+type MeResponse struct {
+	User          User           `json:"user"`
+	Organizations []Organization `json:"organizations"`
+}
+
+// CreateOrganizationRequest is the payload for POST /orgs.
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=3,max=255"`
+}
+
+// OrganizationsResponse is the response format for GET /orgs.
+type OrganizationsResponse struct {
+	Organizations []Organization `json:"organizations"`
+	Total         int            `json:"total"`
+}
+
+// AddMemberRequest is the payload for POST /orgs/:org_id/members.
+// The user must already have an account.
+type AddMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  Role   `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+// UpdateMemberRequest is the payload for PATCH /orgs/:org_id/members/:user_id.
+type UpdateMemberRequest struct {
+	Role Role `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+// MembersResponse is the response format for GET /orgs/:org_id/members.
+type MembersResponse struct {
+	Members []Membership `json:"members"`
+	Total   int          `json:"total"`
+}
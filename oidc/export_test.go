@@ -0,0 +1,10 @@
+package oidc
+
+// AllowRefresh lets the next unknown key ID refetch the signing keys, as if
+// minRefreshInterval had passed.
+func AllowRefresh(v *Verifier) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.lastRefresh = v.lastRefresh.Add(-minRefreshInterval)
+}
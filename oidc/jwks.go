@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxResponseSize caps discovery documents and key sets read from the provider.
+const maxResponseSize = 1 << 20
+
+// signingKey is a public key from the provider's JWKS. alg, if set, is the only
+// algorithm the key may be used with.
+type signingKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// jwk is a JSON Web Key; only the members needed for RSA and EC public keys are decoded.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keyRefresh is a fetch of the provider's keys in progress. done is closed once keys
+// and err are set.
+type keyRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// key returns the signing key with ID kid, fetching the provider's keys when the cache is
+// stale or doesn't have it. A token without a kid matches the only key of a single-key set.
+// The lock isn't held while fetching, so cached keys keep verifying behind a slow provider,
+// and concurrent callers share one fetch.
+func (v *Verifier) key(ctx context.Context, kid string) (signingKey, error) {
+	v.mu.Lock()
+	now := time.Now()
+	fresh := now.Sub(v.fetchedAt) < keyCacheTTL
+	if key, ok := v.lookup(kid); ok && fresh {
+		v.mu.Unlock()
+		return key, nil
+	}
+
+	refresh := v.refresh
+	if refresh == nil {
+		if fresh && now.Sub(v.lastRefresh) < minRefreshInterval {
+			v.mu.Unlock()
+			return signingKey{}, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+		}
+		refresh = &keyRefresh{done: make(chan struct{})}
+		v.refresh = refresh
+		v.lastRefresh = now
+		go v.fetch(ctx, refresh, v.jwksURL)
+	}
+	v.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+	case <-ctx.Done():
+		return signingKey{}, ctx.Err()
+	}
+	if refresh.err != nil {
+		return signingKey{}, refresh.err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key, ok := v.lookup(kid)
+	if !ok {
+		return signingKey{}, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// fetch runs refresh, storing the keys it fetches. It isn't cancelled with the request
+// that started it, since other callers may be waiting on it; the HTTP client's timeout
+// bounds it instead.
+func (v *Verifier) fetch(ctx context.Context, refresh *keyRefresh, jwksURL string) {
+	keys, jwksURL, err := v.fetchKeys(context.WithoutCancel(ctx), jwksURL)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err == nil {
+		v.keys = keys
+		v.jwksURL = jwksURL
+		v.fetchedAt = time.Now()
+	}
+	refresh.err = err
+	v.refresh = nil
+	close(refresh.done)
+}
+
+// lookup finds kid in the cached keys. Callers hold the lock.
+func (v *Verifier) lookup(kid string) (signingKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// fetchKeys downloads and parses the provider's JWKS, discovering its URL first if
+// jwksURL is empty, and returns the keys and the URL. Keys of unsupported types, and
+// encryption keys, are skipped.
+func (v *Verifier) fetchKeys(ctx context.Context, jwksURL string) (map[string]signingKey, string, error) {
+	if jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		url := strings.TrimSuffix(v.config.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, url, &discovery); err != nil {
+			return nil, "", fmt.Errorf("oidc: discovery failed: %w", err)
+		}
+		if discovery.Issuer != v.config.Issuer {
+			return nil, "", fmt.Errorf("oidc: discovery document is for issuer %q, not %q", discovery.Issuer, v.config.Issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, "", errors.New("oidc: discovery document has no jwks_uri")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, "", fmt.Errorf("oidc: failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]signingKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = signingKey{key: key, alg: k.Alg}
+	}
+	return keys, jwksURL, nil
+}
+
+// getJSON GETs url and decodes its JSON body into v.
+func (v *Verifier) getJSON(ctx context.Context, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(dest)
+}
+
+// publicKey converts an RSA or EC JWK to a public key.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("EC coordinates have the wrong length")
+		}
+		// Rejects points that aren't on the curve
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,289 @@
+// Package oidc verifies JSON Web Tokens issued by an OpenID Connect identity provider.
+//
+// Tokens are checked against the issuer's published signing keys (its JWKS), which are
+// discovered from /.well-known/openid-configuration, cached, and refetched when a token
+// names a key the cache doesn't have, so the provider can rotate keys freely. Only
+// asymmetric algorithms are accepted: a token can't be forged with anything the
+// server knows.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jazz/models"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGroupsClaim is the claim listing a user's groups unless Config says otherwise.
+	DefaultGroupsClaim = "groups"
+
+	// Leeway absorbs clock skew between the server and the identity provider.
+	Leeway = time.Minute
+
+	// keyCacheTTL is how long fetched signing keys are trusted before refetching.
+	keyCacheTTL = time.Hour
+
+	// minRefreshInterval limits refetches triggered by unknown key IDs, so tokens with
+	// made-up key IDs can't make the server hammer the identity provider.
+	minRefreshInterval = 30 * time.Second
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, badly signed, expired or
+// meant for another issuer or audience. Errors wrap it with the reason.
+// Safe to expose to clients.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config describes the identity provider whose tokens are accepted.
+type Config struct {
+	// Issuer is the provider's issuer URL; tokens' iss claim must equal it.
+	Issuer string
+
+	// Audience must appear in tokens' aud claim, typically the client ID registered
+	// for Jazz with the provider.
+	Audience string
+
+	// JWKSURL overrides the signing key URL from the issuer's discovery document.
+	JWKSURL string
+
+	// GroupsClaim names the claim listing a user's groups (default "groups").
+	GroupsClaim string
+
+	// HTTPClient fetches discovery documents and keys (default: 10 second timeout).
+	HTTPClient *http.Client
+}
+
+// Verifier checks tokens against a Config. It is safe for concurrent use.
+type Verifier struct {
+	config Config
+
+	mu          sync.Mutex
+	jwksURL     string
+	keys        map[string]signingKey
+	fetchedAt   time.Time
+	lastRefresh time.Time
+	refresh     *keyRefresh // fetch in progress, if any
+}
+
+// NewVerifier returns a Verifier for config. It doesn't contact the identity provider;
+// discovery and key fetching happen on first use, so the server can start while the
+// provider is unreachable.
+func NewVerifier(config Config) (*Verifier, error) {
+	if config.Issuer == "" {
+		return nil, errors.New("oidc: issuer is required")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("oidc: audience is required")
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = DefaultGroupsClaim
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Verifier{config: config, jwksURL: config.JWKSURL}, nil
+}
+
+// IsJWT reports whether token looks like a JWT rather than a Jazz API key or session
+// token, which never contain dots.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// header is the JOSE header of a signed JWT.
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// claims are the registered claims every token is checked against.
+type claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Email     string   `json:"email"`
+	Verified  bool     `json:"email_verified"`
+	Name      string   `json:"name"`
+}
+
+// audience is the aud claim, which may be a single string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verify checks token's signature, issuer, audience and validity period and returns
+// the identity it describes. Errors wrap ErrInvalidToken unless the signing keys
+// couldn't be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string) (*models.Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	verify, ok := algorithms[h.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, h.Alg)
+	}
+
+	key, err := v.key(ctx, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != h.Alg {
+		return nil, fmt.Errorf("%w: key %q is for %s", ErrInvalidToken, h.Kid, key.alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if !verify(key.key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.validate(c, time.Now()); err != nil {
+		return nil, err
+	}
+
+	groups, err := stringList(raw[v.config.GroupsClaim])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed %s claim", ErrInvalidToken, v.config.GroupsClaim)
+	}
+
+	return &models.Identity{
+		Subject:   c.Subject,
+		Email:     models.NormalizeEmail(c.Email),
+		Verified:  c.Verified,
+		Name:      c.Name,
+		Groups:    groups,
+		ExpiresAt: time.Unix(*c.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// validate checks the registered claims at now.
+func (v *Verifier) validate(c claims, now time.Time) error {
+	switch {
+	case c.Issuer != v.config.Issuer:
+		return fmt.Errorf("%w: wrong issuer", ErrInvalidToken)
+	case !slices.Contains(c.Audience, v.config.Audience):
+		return fmt.Errorf("%w: wrong audience", ErrInvalidToken)
+	case c.Subject == "":
+		return fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	case c.ExpiresAt == nil:
+		return fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	case !now.Before(time.Unix(*c.ExpiresAt, 0).Add(Leeway)):
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	case c.NotBefore != nil && now.Add(Leeway).Before(time.Unix(*c.NotBefore, 0)):
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	return nil
+}
+
+// verifyFunc reports whether signature is a valid signature of signed by key.
+type verifyFunc func(key crypto.PublicKey, signed, signature []byte) bool
+
+// algorithms are the JWS algorithms accepted, all asymmetric.
+var algorithms = map[string]verifyFunc{
+	"RS256": rsaAlgorithm(crypto.SHA256),
+	"RS384": rsaAlgorithm(crypto.SHA384),
+	"RS512": rsaAlgorithm(crypto.SHA512),
+	"ES256": ecdsaAlgorithm(crypto.SHA256),
+	"ES384": ecdsaAlgorithm(crypto.SHA384),
+	"ES512": ecdsaAlgorithm(crypto.SHA512),
+}
+
+func rsaAlgorithm(hash crypto.Hash) verifyFunc {
+	return func(key crypto.PublicKey, signed, signature []byte) bool {
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := hash.New()
+		digest.Write(signed)
+		return rsa.VerifyPKCS1v15(pub, hash, digest.Sum(nil), signature) == nil
+	}
+}
+
+func ecdsaAlgorithm(hash crypto.Hash) verifyFunc {
+	return func(key crypto.PublicKey, signed, signature []byte) bool {
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		// JWS signatures are r || s, each padded to the curve's size
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		digest := hash.New()
+		digest.Write(signed)
+		return ecdsa.Verify(pub, digest.Sum(nil), r, s)
+	}
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT into v.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringList decodes a claim holding a list of strings, a single string, or nothing.
+func stringList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return []string{}, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	list := []string{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
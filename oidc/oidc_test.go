@@ -0,0 +1,181 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/base64"
+	"jazz/oidc"
+	"jazz/oidc/oidctest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	claims := map[string]interface{}{
+		"email":  "Alice@Example.com",
+		"name":   "Alice",
+		"groups": []string{"eng", "sre"},
+	}
+	for name, token := range map[string]string{
+		"RS256": issuer.Token(t, claims),
+		"ES256": issuer.ES256Token(t, claims),
+	} {
+		t.Run(name, func(t *testing.T) {
+			identity, err := verifier.Verify(ctx, token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", identity.Subject)
+			assert.Equal(t, "alice@example.com", identity.Email)
+			assert.Equal(t, "Alice", identity.Name)
+			assert.Equal(t, []string{"eng", "sre"}, identity.Groups)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), identity.ExpiresAt, time.Minute)
+		})
+	}
+	assert.Equal(t, 1, issuer.KeyFetches(), "keys are cached")
+
+	identity, err := verifier.Verify(ctx, issuer.Token(t, map[string]interface{}{"groups": "eng"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eng"}, identity.Groups, "a single group may be a string")
+
+	identity, err = verifier.Verify(ctx, issuer.Token(t, map[string]interface{}{"aud": []string{"other", oidctest.Audience}}))
+	require.NoError(t, err)
+	assert.Empty(t, identity.Groups)
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+
+	valid := issuer.Token(t, nil)
+	parts := strings.Split(valid, ".")
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "wrong issuer", token: issuer.Token(t, map[string]interface{}{"iss": "https://evil.example.com"})},
+		{name: "wrong audience", token: issuer.Token(t, map[string]interface{}{"aud": "someone-else"})},
+		{name: "no audience", token: issuer.Token(t, map[string]interface{}{"aud": nil})},
+		{name: "no subject", token: issuer.Token(t, map[string]interface{}{"sub": nil})},
+		{name: "no expiry", token: issuer.Token(t, map[string]interface{}{"exp": nil})},
+		{name: "expired", token: issuer.Token(t, map[string]interface{}{"exp": now.Add(-2 * oidc.Leeway).Unix()})},
+		{name: "not valid yet", token: issuer.Token(t, map[string]interface{}{"nbf": now.Add(2 * oidc.Leeway).Unix()})},
+		{name: "malformed groups", token: issuer.Token(t, map[string]interface{}{"groups": 42})},
+		{name: "tampered claims", token: parts[0] + "." + encode(`{"iss":"`+issuer.URL+`","aud":"jazz-test","sub":"admin","exp":9999999999}`) + "." + parts[2]},
+		{name: "unsigned", token: encode(`{"alg":"none"}`) + "." + parts[1] + "."},
+		{name: "symmetric algorithm", token: encode(`{"alg":"HS256","kid":"rsa-1"}`) + "." + parts[1] + "." + parts[2]},
+		{name: "algorithm mismatch", token: encode(`{"alg":"ES256","kid":"rsa-1"}`) + "." + parts[1] + "." + parts[2]},
+		{name: "unknown key", token: encode(`{"alg":"RS256","kid":"made-up"}`) + "." + parts[1] + "." + parts[2]},
+		{name: "malformed", token: "not.a.jwt"},
+		{name: "API key", token: "jazz_0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.token)
+			assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+		})
+	}
+}
+
+func TestVerify_ProviderUnavailable(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	token := issuer.Token(t, nil)
+	config := issuer.Config()
+	issuer.Close()
+
+	verifier, err := oidc.NewVerifier(config)
+	require.NoError(t, err, "the provider isn't contacted until a token arrives")
+
+	_, err = verifier.Verify(context.Background(), token)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, oidc.ErrInvalidToken)
+}
+
+func TestNewVerifier_RequiresIssuerAndAudience(t *testing.T) {
+	_, err := oidc.NewVerifier(oidc.Config{Audience: "jazz"})
+	assert.Error(t, err)
+	_, err = oidc.NewVerifier(oidc.Config{Issuer: "https://idp.example.com"})
+	assert.Error(t, err)
+}
+
+func TestIsJWT(t *testing.T) {
+	assert.True(t, oidc.IsJWT("a.b.c"))
+	assert.False(t, oidc.IsJWT("jazz_0123456789abcdef0123456789abcdef01234567"))
+	assert.False(t, oidc.IsJWT("a.b"))
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = verifier.Verify(ctx, issuer.Token(t, nil))
+	require.NoError(t, err)
+
+	issuer.RotateKeys(t)
+	rotated := issuer.Token(t, nil)
+
+	// Unknown key IDs only trigger a refetch every so often
+	_, err = verifier.Verify(ctx, rotated)
+	assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+	assert.Equal(t, 1, issuer.KeyFetches())
+
+	oidc.AllowRefresh(verifier)
+	_, err = verifier.Verify(ctx, rotated)
+	require.NoError(t, err)
+	assert.Equal(t, 2, issuer.KeyFetches())
+}
+
+func TestVerify_SlowProvider(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier(issuer.Config())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cached := issuer.Token(t, nil)
+	_, err = verifier.Verify(ctx, cached)
+	require.NoError(t, err)
+
+	issuer.RotateKeys(t)
+	rotated := issuer.Token(t, nil)
+	oidc.AllowRefresh(verifier)
+	release := issuer.HoldKeys(t)
+
+	// Tokens with an unknown key ID wait for one shared fetch...
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := verifier.Verify(ctx, rotated)
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return issuer.KeyFetches() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// ...while tokens signed with cached keys don't wait at all
+	_, err = verifier.Verify(ctx, cached)
+	require.NoError(t, err)
+
+	// Callers can give up on a slow fetch
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = verifier.Verify(cancelled, rotated)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	release()
+	for range 3 {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, 2, issuer.KeyFetches())
+}
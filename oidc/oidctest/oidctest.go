@@ -0,0 +1,229 @@
+// Package oidctest runs a stand-in OpenID Connect identity provider for tests.
+//
+// An Issuer serves a discovery document and JWKS over httptest and signs tokens with
+// its keys, so code using package oidc can be tested without a real provider:
+//
+//	issuer := oidctest.NewIssuer(t)
+//	verifier, _ := oidc.NewVerifier(issuer.Config())
+//	token := issuer.Token(t, map[string]interface{}{"groups": []string{"eng"}})
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"jazz/oidc"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Audience is the audience of the tokens an Issuer signs by default.
+const Audience = "jazz-test"
+
+// Issuer is a stand-in identity provider with one RSA and one P-256 signing key.
+type Issuer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	rsaKey     *rsa.PrivateKey
+	rsaKid     string
+	ecKey      *ecdsa.PrivateKey
+	ecKid      string
+	rotation   int
+	keyFetches int
+	hold       chan struct{} // JWKS responses wait for it to close, if set
+}
+
+// NewIssuer starts an Issuer, shut down when the test ends.
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+
+	issuer := &Issuer{}
+	issuer.RotateKeys(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":   issuer.URL,
+			"jwks_uri": issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		issuer.keyFetches++
+		hold := issuer.hold
+		issuer.mu.Unlock()
+		if hold != nil {
+			<-hold
+		}
+		writeJSON(w, issuer.jwks())
+	})
+
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// Config returns an oidc.Config accepting this issuer's tokens.
+func (i *Issuer) Config() oidc.Config {
+	return oidc.Config{Issuer: i.URL, Audience: Audience}
+}
+
+// RotateKeys replaces the signing keys with new ones under new key IDs.
+func (i *Issuer) RotateKeys(t testing.TB) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.rotation++
+	i.rsaKey, i.rsaKid = rsaKey, "rsa-"+strconv.Itoa(i.rotation)
+	i.ecKey, i.ecKid = ecKey, "ec-"+strconv.Itoa(i.rotation)
+}
+
+// HoldKeys makes JWKS requests wait until release is called, as a slow provider would.
+// Requests are released when the test ends too.
+func (i *Issuer) HoldKeys(t testing.TB) (release func()) {
+	hold := make(chan struct{})
+	i.mu.Lock()
+	i.hold = hold
+	i.mu.Unlock()
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			i.mu.Lock()
+			i.hold = nil
+			i.mu.Unlock()
+			close(hold)
+		})
+	}
+	t.Cleanup(release)
+	return release
+}
+
+// KeyFetches returns how many times the JWKS has been requested.
+func (i *Issuer) KeyFetches() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.keyFetches
+}
+
+// Token returns an RS256 token with claims over the defaults: this issuer, Audience,
+// subject "user-1", issued now and expiring in an hour. A nil value removes a claim.
+func (i *Issuer) Token(t testing.TB, claims map[string]interface{}) string {
+	t.Helper()
+
+	i.mu.Lock()
+	key, kid := i.rsaKey, i.rsaKid
+	i.mu.Unlock()
+
+	return i.sign(t, "RS256", kid, claims, func(digest []byte) ([]byte, error) {
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+	})
+}
+
+// ES256Token is Token signed with the issuer's P-256 key.
+func (i *Issuer) ES256Token(t testing.TB, claims map[string]interface{}) string {
+	t.Helper()
+
+	i.mu.Lock()
+	key, kid := i.ecKey, i.ecKid
+	i.mu.Unlock()
+
+	return i.sign(t, "ES256", kid, claims, func(digest []byte) ([]byte, error) {
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), nil
+	})
+}
+
+func (i *Issuer) sign(t testing.TB, alg, kid string, claims map[string]interface{}, sign func(digest []byte) ([]byte, error)) string {
+	t.Helper()
+
+	now := time.Now()
+	payload := map[string]interface{}{
+		"iss": i.URL,
+		"aud": Audience,
+		"sub": "user-1",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(payload, name)
+			continue
+		}
+		payload[name] = value
+	}
+
+	signed := encodeSegment(t, map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeSegment(t, payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := sign(digest[:])
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// jwks returns the current public keys as a JSON Web Key Set.
+func (i *Issuer) jwks() map[string]interface{} {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Uncompressed point: 0x04 || x || y
+	point, _ := i.ecKey.PublicKey.Bytes()
+	return map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": i.rsaKid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(i.rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": i.ecKid,
+				"use": "sig",
+				"alg": "ES256",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+				"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+			},
+		},
+	}
+}
+
+func encodeSegment(t testing.TB, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
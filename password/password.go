@@ -0,0 +1,43 @@
+// Package password hashes user passwords with bcrypt and verifies them.
+//
+// Unlike API keys, passwords are chosen by people and can be guessed, so they get a
+// deliberately slow, salted hash rather than SHA-256.
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength is the longest password bcrypt accepts, in bytes.
+const MaxLength = 72
+
+// Cost is the bcrypt work factor used for new hashes. Existing hashes keep the cost
+// they were created with.
+var Cost = bcrypt.DefaultCost
+
+// ErrTooLong is returned by Hash for passwords longer than MaxLength bytes.
+var ErrTooLong = errors.New("password is longer than 72 bytes")
+
+// dummyHash is compared against when there is no user to check, so unknown and
+// known users take as long to reject.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("jazz-dummy-password"), bcrypt.DefaultCost)
+
+// Hash returns the bcrypt hash of plaintext.
+func Hash(plaintext string) ([]byte, error) {
+	if len(plaintext) > MaxLength {
+		return nil, ErrTooLong
+	}
+	return bcrypt.GenerateFromPassword([]byte(plaintext), Cost)
+}
+
+// Verify reports whether plaintext matches hash. A nil hash (no such user) never
+// matches but still costs a bcrypt comparison.
+func Verify(hash []byte, plaintext string) bool {
+	if hash == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(plaintext))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(plaintext)) == nil
+}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotContains(t, string(hash), "correct horse")
+
+	assert.True(t, Verify(hash, "correct horse battery staple"))
+	assert.False(t, Verify(hash, "correct horse battery staplf"))
+	assert.False(t, Verify(hash, ""))
+
+	again, err := Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, again, "hashes are salted")
+}
+
+func TestHash_TooLong(t *testing.T) {
+	_, err := Hash(strings.Repeat("a", MaxLength+1))
+	assert.ErrorIs(t, err, ErrTooLong)
+
+	_, err = Hash(strings.Repeat("a", MaxLength))
+	assert.NoError(t, err)
+}
+
+func TestVerify_NoHash(t *testing.T) {
+	assert.False(t, Verify(nil, "jazz-dummy-password"))
+	assert.False(t, Verify(nil, ""))
+}
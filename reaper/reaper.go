@@ -0,0 +1,159 @@
+// Package reaper permanently deletes projects whose restore window has passed, and
+// the ingest usage of past days.
+package reaper
+
+import (
+	"context"
+	"errors"
+	"jazz/database"
+	"jazz/storage"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config controls how long deleted projects can be restored and how they are purged.
+type Config struct {
+	// RestoreWindow is how long after deletion a project can still be restored.
+	RestoreWindow time.Duration
+
+	// Interval is the time between passes.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of logs deleted per statement.
+	BatchSize int
+
+	// BatchPause is the delay between batches, leaving room for ingestion.
+	BatchPause time.Duration
+}
+
+// DefaultConfig returns a 7 day restore window and hourly passes deleting 5000 logs
+// per batch with a 100ms pause.
+func DefaultConfig() Config {
+	return Config{
+		RestoreWindow: 7 * 24 * time.Hour,
+		Interval:      time.Hour,
+		BatchSize:     5000,
+		BatchPause:    100 * time.Millisecond,
+	}
+}
+
+// Store is what the reaper purges from: deleted projects and ingest usage.
+type Store interface {
+	storage.DeletedProjectStore
+	storage.RateLimitStore
+}
+
+// Reaper periodically purges projects deleted more than RestoreWindow ago.
+// A project's logs are deleted in bounded batches before the project row itself, so
+// the final cascade only has its keys and grants left to remove.
+// Each pass also drops the daily ingest usage (storage.RateLimitStore) of days before
+// the current UTC day, which quotas no longer look at.
+//
+// Purges are idempotent, so instances sharing a database may reap at the same time;
+// one of them finds the project already gone.
+type Reaper struct {
+	store  Store
+	config Config
+}
+
+// New creates a Reaper. Zero config fields fall back to DefaultConfig values.
+func New(store Store, config Config) *Reaper {
+	defaults := DefaultConfig()
+	if config.RestoreWindow <= 0 {
+		config.RestoreWindow = defaults.RestoreWindow
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.BatchPause < 0 {
+		config.BatchPause = 0
+	}
+	return &Reaper{store: store, config: config}
+}
+
+// Run reaps immediately and then every Interval until ctx is cancelled.
+// Failures are logged and retried on the next pass.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("reaper: purge failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce purges every project deleted more than RestoreWindow before now, then the
+// ingest usage of days before now's. A failing project doesn't stop the others; all
+// errors are returned joined.
+func (r *Reaper) RunOnce(ctx context.Context, now time.Time) error {
+	deletedBefore := now.Add(-r.config.RestoreWindow)
+	projects, err := r.store.ListDeletedProjects(ctx, deletedBefore)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, projectID := range projects {
+		if err := r.purge(ctx, projectID, deletedBefore); err != nil {
+			errs = append(errs, err)
+		}
+		if ctx.Err() != nil {
+			return errors.Join(errs...)
+		}
+	}
+
+	purged, err := r.store.PurgeIngestUsage(ctx, now)
+	if err != nil {
+		errs = append(errs, err)
+	} else if purged > 0 {
+		log.Printf("reaper: purged ingest usage days=%d", purged)
+	}
+
+	return errors.Join(errs...)
+}
+
+// purge deletes a project's logs in batches, pausing between them, then the project.
+func (r *Reaper) purge(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time) error {
+	var deleted int64
+	for {
+		n, err := r.store.PurgeDeletedProjectLogs(ctx, projectID, deletedBefore, r.config.BatchSize)
+		deleted += n
+		if err != nil {
+			return err
+		}
+		if n < int64(r.config.BatchSize) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.config.BatchPause):
+		}
+	}
+
+	err := r.store.PurgeDeletedProject(ctx, projectID, deletedBefore)
+	if errors.Is(err, database.ErrProjectNotFound) {
+		// Another instance got there first
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("reaper: purged project=%s logs=%d", projectID, deleted)
+	return nil
+}
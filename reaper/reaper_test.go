@@ -0,0 +1,86 @@
+package reaper
+
+import (
+	"context"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaper(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	deleted, err := store.CreateProject(ctx, "Deleted")
+	require.NoError(t, err)
+	live, err := store.CreateProject(ctx, "Live")
+	require.NoError(t, err)
+
+	var logs []models.LogEntry
+	for _, project := range []*models.Project{deleted, live} {
+		for range 5 {
+			logs = append(logs, models.LogEntry{
+				ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "hello", Timestamp: time.Now(),
+			})
+		}
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+	require.NoError(t, store.DeleteProject(ctx, deleted.ID))
+
+	reaper := New(store, Config{RestoreWindow: time.Hour, BatchSize: 2})
+
+	// Still inside the restore window
+	require.NoError(t, reaper.RunOnce(ctx, time.Now()))
+	ids, err := store.ListDeletedProjects(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{deleted.ID}, ids)
+
+	later := time.Now().Add(2 * time.Hour)
+	require.NoError(t, reaper.RunOnce(ctx, later))
+
+	ids, err = store.ListDeletedProjects(ctx, later)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	_, err = store.RestoreProject(ctx, deleted.ID, time.Time{})
+	assert.ErrorIs(t, err, database.ErrProjectNotFound, "purged projects are gone for good")
+
+	// Other projects are untouched
+	_, total, err := store.QueryLogs(ctx, live.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	_, err = store.AuthenticateAPIKey(ctx, live.APIKey)
+	assert.NoError(t, err)
+}
+
+func TestReaper_IngestUsage(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Date(2024, 11, 21, 8, 0, 0, 0, time.UTC)
+	quota := models.IngestUsage{Entries: 10}
+	charged, err := store.ChargeIngestQuota(ctx, project.ID, now, models.IngestUsage{Entries: 10}, quota)
+	require.NoError(t, err)
+	require.True(t, charged)
+
+	reaper := New(store, Config{})
+
+	// The day's usage is kept while it counts against the quota
+	require.NoError(t, reaper.RunOnce(ctx, now.Add(time.Hour)))
+	charged, err = store.ChargeIngestQuota(ctx, project.ID, now, models.IngestUsage{Entries: 1}, quota)
+	require.NoError(t, err)
+	assert.False(t, charged)
+
+	// The next day it is purged, leaving nothing behind to charge against
+	require.NoError(t, reaper.RunOnce(ctx, now.AddDate(0, 0, 1)))
+	charged, err = store.ChargeIngestQuota(ctx, project.ID, now, models.IngestUsage{Entries: 10}, quota)
+	require.NoError(t, err)
+	assert.True(t, charged)
+}
@@ -0,0 +1,290 @@
+// Package retention purges logs that have outlived their project's retention policy.
+// The worker runs over any Store: PostgreSQL (*database.DB) or SQLite.
+package retention
+
+import (
+	"context"
+	"errors"
+	"jazz/database"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// lockID keeps purges to one API instance at a time.
+	lockID = 0x6a617a72 // "jazr"
+
+	// runHistory is how long retention_runs rows are kept.
+	runHistory = 30 * 24 * time.Hour
+
+	// archiveSettle excludes rows stored this recently from an archive pass, so
+	// inserts still in flight when a day is archived are picked up by the next pass
+	// instead of being deleted unarchived.
+	archiveSettle = time.Minute
+)
+
+// Archiver copies logs somewhere durable before they are deleted.
+// Archive must return without error only once the rows in r are safely stored.
+type Archiver interface {
+	Archive(ctx context.Context, r database.LogRange) (int64, error)
+}
+
+// Store is what the Worker purges logs from and records its runs in.
+type Store interface {
+	// ListRetentionPolicies returns every project that has a retention policy.
+	ListRetentionPolicies(ctx context.Context) ([]database.RetentionPolicy, error)
+
+	// PurgeExpiredLogs deletes up to batchSize of a project's logs older than cutoff.
+	PurgeExpiredLogs(ctx context.Context, projectID uuid.UUID, cutoff time.Time, batchSize int) (int64, error)
+
+	// NextLogTime returns the earliest timestamp of a project's logs with
+	// from <= timestamp < before, or nil if there are none.
+	NextLogTime(ctx context.Context, projectID uuid.UUID, from, before time.Time) (*time.Time, error)
+
+	// PurgeLogRange deletes up to batchSize logs in r.
+	PurgeLogRange(ctx context.Context, r database.LogRange, batchSize int) (int64, error)
+
+	StartRetentionRun(ctx context.Context, projectID uuid.UUID, cutoff time.Time) (uuid.UUID, error)
+	FinishRetentionRun(ctx context.Context, runID uuid.UUID, deleted, archived int64, runErr error) error
+	PruneRetentionRuns(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PartitionStore is implemented by stores that partition logs by time (PostgreSQL).
+// The Worker drops expired partitions before purging rows when there is no Archiver.
+type PartitionStore interface {
+	DropExpiredLogPartitions(ctx context.Context, now time.Time) ([]string, error)
+}
+
+// Locker is implemented by stores shared by several API instances (PostgreSQL).
+// The Worker only purges while holding the lock, so one instance purges at a time.
+type Locker interface {
+	TryAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+// Config controls how often and how aggressively the Worker purges.
+type Config struct {
+	// Interval is the time between purge passes.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of rows deleted per statement.
+	BatchSize int
+
+	// BatchPause is the delay between batches, leaving room for ingestion.
+	BatchPause time.Duration
+}
+
+// DefaultConfig returns hourly passes deleting 5000 rows per batch with a 100ms pause.
+func DefaultConfig() Config {
+	return Config{
+		Interval:   time.Hour,
+		BatchSize:  5000,
+		BatchPause: 100 * time.Millisecond,
+	}
+}
+
+// Worker periodically deletes logs older than each project's retention_days.
+// On a PartitionStore whole partitions are dropped when every project's policy allows it; remaining
+// expired rows are deleted per project in bounded batches, and each project's
+// purge is recorded in retention_runs.
+//
+// With an Archiver set, expired logs are archived one UTC day at a time and each
+// day is deleted only after it has been archived. Only whole days are purged, and
+// partitions are never dropped since their rows would skip the archive.
+type Worker struct {
+	store    Store
+	config   Config
+	archiver Archiver
+}
+
+// NewWorker creates a Worker. Zero config fields fall back to DefaultConfig values.
+func NewWorker(store Store, config Config) *Worker {
+	defaults := DefaultConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.BatchPause < 0 {
+		config.BatchPause = 0
+	}
+
+	return &Worker{store: store, config: config}
+}
+
+// SetArchiver archives logs before they are deleted.
+// Must be called before Run.
+func (w *Worker) SetArchiver(archiver Archiver) {
+	w.archiver = archiver
+}
+
+// Run purges immediately and then every Interval until ctx is cancelled.
+// Failures are logged and retried on the next pass.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("retention: purge failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single purge pass as of now.
+// On a Locker, skipped (returning nil) if another instance is already purging.
+// A failing project doesn't stop the others; all errors are returned joined.
+func (w *Worker) RunOnce(ctx context.Context, now time.Time) error {
+	locker, ok := w.store.(Locker)
+	if !ok {
+		return w.purge(ctx, now)
+	}
+
+	ran, err := locker.TryAdvisoryLock(ctx, lockID, func(ctx context.Context) error {
+		return w.purge(ctx, now)
+	})
+	if err != nil {
+		return err
+	}
+	if !ran {
+		log.Println("retention: another instance is purging, skipping")
+	}
+	return nil
+}
+
+func (w *Worker) purge(ctx context.Context, now time.Time) error {
+	if partitions, ok := w.store.(PartitionStore); ok && w.archiver == nil {
+		dropped, err := partitions.DropExpiredLogPartitions(ctx, now)
+		for _, name := range dropped {
+			log.Printf("retention: dropped partition %s", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	policies, err := w.store.ListRetentionPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, policy := range policies {
+		if _, err := w.PurgeProject(ctx, policy, now); err != nil {
+			errs = append(errs, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if _, err := w.store.PruneRetentionRuns(ctx, now.Add(-runHistory)); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// PurgeProject deletes a project's expired logs in batches and records the run.
+// Returns the number of rows deleted, including those deleted before an error.
+func (w *Worker) PurgeProject(ctx context.Context, policy database.RetentionPolicy, now time.Time) (int64, error) {
+	cutoff := database.RetentionCutoff(now, policy.RetentionDays)
+	if w.archiver != nil {
+		// Archives hold whole days, so the partially expired day waits until tomorrow
+		cutoff = cutoff.Truncate(24 * time.Hour)
+	}
+
+	runID, err := w.store.StartRetentionRun(ctx, policy.ProjectID, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted, archived int64
+	var purgeErr error
+	if w.archiver == nil {
+		deleted, purgeErr = w.deleteBatches(ctx, func(ctx context.Context) (int64, error) {
+			return w.store.PurgeExpiredLogs(ctx, policy.ProjectID, cutoff, w.config.BatchSize)
+		})
+	} else {
+		deleted, archived, purgeErr = w.archiveAndDelete(ctx, policy.ProjectID, cutoff, now.Add(-archiveSettle))
+	}
+
+	// Record the outcome even when ctx was cancelled mid-purge
+	if err := w.store.FinishRetentionRun(context.WithoutCancel(ctx), runID, deleted, archived, purgeErr); err != nil {
+		log.Printf("retention: %v", err)
+	}
+
+	if deleted > 0 {
+		log.Printf("retention: project=%s deleted=%d archived=%d cutoff=%s",
+			policy.ProjectID, deleted, archived, cutoff.Format(time.RFC3339))
+	}
+
+	return deleted, purgeErr
+}
+
+// archiveAndDelete archives and then deletes each UTC day with logs before cutoff.
+// Returns the rows deleted and archived, including those handled before an error.
+func (w *Worker) archiveAndDelete(ctx context.Context, projectID uuid.UUID, cutoff, createdBefore time.Time) (int64, int64, error) {
+	var deleted, archived int64
+	var from time.Time
+	for {
+		next, err := w.store.NextLogTime(ctx, projectID, from, cutoff)
+		if err != nil || next == nil {
+			return deleted, archived, err
+		}
+
+		day := next.Truncate(24 * time.Hour)
+		r := database.LogRange{
+			ProjectID:     projectID,
+			Start:         day,
+			End:           day.AddDate(0, 0, 1),
+			CreatedBefore: createdBefore,
+		}
+
+		n, err := w.archiver.Archive(ctx, r)
+		archived += n
+		if err != nil {
+			return deleted, archived, err
+		}
+
+		n, err = w.deleteBatches(ctx, func(ctx context.Context) (int64, error) {
+			return w.store.PurgeLogRange(ctx, r, w.config.BatchSize)
+		})
+		deleted += n
+		if err != nil {
+			return deleted, archived, err
+		}
+
+		from = r.End
+	}
+}
+
+// deleteBatches calls purge until it deletes less than a full batch,
+// pausing between batches. Returns the total rows deleted.
+func (w *Worker) deleteBatches(ctx context.Context, purge func(ctx context.Context) (int64, error)) (int64, error) {
+	var deleted int64
+	for {
+		n, err := purge(ctx)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+		if n < int64(w.config.BatchSize) {
+			return deleted, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return deleted, ctx.Err()
+		case <-time.After(w.config.BatchPause):
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage/sqlite"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore counts the batches the worker deletes and can fail them; everything else
+// goes to SQLite. Run history is kept, since runs are recorded at the wall clock time
+// and tests purge as of a fixed now.
+type testStore struct {
+	*sqlite.Store
+
+	batches   int
+	failAfter int // fail batches after this many, when > 0
+
+	// pruned is every before PruneRetentionRuns was called with
+	pruned []time.Time
+
+	// dropped is every now DropExpiredLogPartitions was called with
+	dropped []time.Time
+}
+
+func (s *testStore) PurgeExpiredLogs(ctx context.Context, projectID uuid.UUID, cutoff time.Time, batchSize int) (int64, error) {
+	if s.failAfter > 0 && s.batches >= s.failAfter {
+		return 0, errors.New("disk full")
+	}
+	s.batches++
+	return s.Store.PurgeExpiredLogs(ctx, projectID, cutoff, batchSize)
+}
+
+func (s *testStore) PurgeLogRange(ctx context.Context, r database.LogRange, batchSize int) (int64, error) {
+	s.batches++
+	return s.Store.PurgeLogRange(ctx, r, batchSize)
+}
+
+func (s *testStore) PruneRetentionRuns(ctx context.Context, before time.Time) (int64, error) {
+	s.pruned = append(s.pruned, before)
+	return 0, nil
+}
+
+// partitionedStore is a testStore that is also a PartitionStore.
+type partitionedStore struct {
+	*testStore
+}
+
+func (s partitionedStore) DropExpiredLogPartitions(ctx context.Context, now time.Time) ([]string, error) {
+	s.dropped = append(s.dropped, now)
+	return nil, nil
+}
+
+func newTestStore(t *testing.T) *testStore {
+	t.Helper()
+
+	store, err := sqlite.Open(context.Background(), ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	return &testStore{Store: store}
+}
+
+// createProject creates a project keeping days of logs (forever if days is 0) with a
+// log at each of times.
+func createProject(t *testing.T, store *testStore, days int, times ...time.Time) *models.Project {
+	t.Helper()
+	ctx := context.Background()
+
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	if days > 0 {
+		_, err = store.SetProjectRetention(ctx, project.ID, &days)
+		require.NoError(t, err)
+	}
+
+	logs := make([]models.LogEntry, len(times))
+	for i, ts := range times {
+		logs[i] = models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Entry", Timestamp: ts}
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+	return project
+}
+
+// remaining returns the timestamps of a project's logs, oldest first.
+func remaining(t *testing.T, store *testStore, projectID uuid.UUID) []time.Time {
+	t.Helper()
+
+	logs, _, err := store.QueryLogs(context.Background(), projectID, models.QueryParams{Sort: "timestamp:asc", Limit: 1000})
+	require.NoError(t, err)
+	times := make([]time.Time, len(logs))
+	for i, entry := range logs {
+		times[i] = entry.Timestamp
+	}
+	return times
+}
+
+func lastRun(t *testing.T, store *testStore, projectID uuid.UUID, now time.Time) *models.RetentionRun {
+	t.Helper()
+
+	status, err := store.GetRetentionStatus(context.Background(), projectID, now)
+	require.NoError(t, err)
+	return status.LastRun
+}
+
+var testNow = time.Date(2030, 3, 17, 12, 0, 0, 0, time.UTC)
+
+func TestRunOnce_DeletesInBatchesUpToCutoff(t *testing.T) {
+	store := newTestStore(t)
+	cutoff := database.RetentionCutoff(testNow, 7)
+
+	var expired []time.Time
+	for i := 1; i <= 5; i++ {
+		expired = append(expired, cutoff.Add(-time.Duration(i)*time.Hour))
+	}
+	kept := []time.Time{cutoff, cutoff.Add(time.Microsecond), testNow}
+	project := createProject(t, store, 7, append(expired, kept...)...)
+
+	// A longer policy has its own cutoff: none of these are past 30 days
+	longer := createProject(t, store, 30, expired...)
+
+	worker := NewWorker(store, Config{BatchSize: 2})
+	require.NoError(t, worker.RunOnce(context.Background(), testNow))
+
+	assert.Equal(t, kept, remaining(t, store, project.ID), "logs at or after the cutoff are kept")
+	assert.Len(t, remaining(t, store, longer.ID), 5)
+	assert.Equal(t, 3+1, store.batches, "2 + 2 + 1 rows for the first project, an empty batch for the second")
+	assert.Equal(t, []time.Time{testNow.Add(-runHistory)}, store.pruned)
+}
+
+func TestRunOnce_LeavesProjectsWithoutPolicy(t *testing.T) {
+	store := newTestStore(t)
+	old := testNow.AddDate(-1, 0, 0)
+	forever := createProject(t, store, 0, old, testNow)
+	createProject(t, store, 1, old)
+
+	worker := NewWorker(store, Config{BatchSize: 10})
+	require.NoError(t, worker.RunOnce(context.Background(), testNow))
+
+	assert.Len(t, remaining(t, store, forever.ID), 2)
+	assert.Nil(t, lastRun(t, store, forever.ID, testNow), "no run is recorded without a policy")
+}
+
+func TestPurgeProject_RecordsRun(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	cutoff := database.RetentionCutoff(testNow, 7)
+	var times []time.Time
+	for i := 1; i <= 5; i++ {
+		times = append(times, cutoff.Add(-time.Duration(i)*time.Minute))
+	}
+	project := createProject(t, store, 7, append(times, testNow)...)
+
+	worker := NewWorker(store, Config{BatchSize: 2})
+	deleted, err := worker.PurgeProject(ctx, database.RetentionPolicy{ProjectID: project.ID, RetentionDays: 7}, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+
+	run := lastRun(t, store, project.ID, testNow)
+	require.NotNil(t, run)
+	assert.Equal(t, int64(5), run.DeletedRows)
+	assert.Zero(t, run.ArchivedRows)
+	assert.True(t, cutoff.Equal(run.Cutoff))
+	assert.NotNil(t, run.FinishedAt)
+	assert.Empty(t, run.Error)
+
+	// A failing batch is recorded with the rows deleted before it
+	other := createProject(t, store, 7, times...)
+	store.batches, store.failAfter = 0, 1
+	deleted, err = worker.PurgeProject(ctx, database.RetentionPolicy{ProjectID: other.ID, RetentionDays: 7}, testNow)
+	require.Error(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	run = lastRun(t, store, other.ID, testNow)
+	require.NotNil(t, run)
+	assert.Equal(t, int64(2), run.DeletedRows)
+	assert.Equal(t, "disk full", run.Error)
+	assert.Len(t, remaining(t, store, other.ID), 3)
+}
+
+func TestRunOnce_DropsPartitionsFirst(t *testing.T) {
+	store := newTestStore(t)
+	project := createProject(t, store, 7, testNow.AddDate(0, 0, -8))
+
+	worker := NewWorker(partitionedStore{store}, Config{BatchSize: 10})
+	require.NoError(t, worker.RunOnce(context.Background(), testNow))
+
+	// Which partitions can go is the store's decision (see database.DB.DropExpiredLogPartitions);
+	// rows the dropped partitions didn't cover are still purged
+	assert.Equal(t, []time.Time{testNow}, store.dropped)
+	assert.Empty(t, remaining(t, store, project.ID))
+}
+
+// fakeArchiver records the ranges it is asked to archive, with the rows each one held
+// at the time, and fails with err if set.
+type fakeArchiver struct {
+	t      *testing.T
+	store  *testStore
+	err    error
+	ranges []database.LogRange
+	rows   []int64
+}
+
+func (a *fakeArchiver) Archive(ctx context.Context, r database.LogRange) (int64, error) {
+	if a.err != nil {
+		return 0, a.err
+	}
+
+	var rows int64
+	for _, ts := range remaining(a.t, a.store, r.ProjectID) {
+		if !ts.Before(r.Start) && ts.Before(r.End) {
+			rows++
+		}
+	}
+	a.ranges = append(a.ranges, r)
+	a.rows = append(a.rows, rows)
+	return rows, nil
+}
+
+func TestRunOnce_ArchivesWholeDaysBeforeDeleting(t *testing.T) {
+	store := newTestStore(t)
+
+	// A 7 day policy expires everything before 03-10 12:00, but only whole days go
+	day := time.Date(2030, 3, 8, 0, 0, 0, 0, time.UTC)
+	expired := []time.Time{day.Add(time.Hour), day.Add(23 * time.Hour), day.AddDate(0, 0, 1).Add(5 * time.Hour)}
+	kept := []time.Time{day.AddDate(0, 0, 2).Add(time.Hour), testNow}
+	project := createProject(t, store, 7, append(expired, kept...)...)
+
+	archiver := &fakeArchiver{t: t, store: store}
+	worker := NewWorker(partitionedStore{store}, Config{BatchSize: 10})
+	worker.SetArchiver(archiver)
+	require.NoError(t, worker.RunOnce(context.Background(), testNow))
+
+	require.Len(t, archiver.ranges, 2)
+	for i, r := range archiver.ranges {
+		start := day.AddDate(0, 0, i)
+		assert.True(t, start.Equal(r.Start), "range %d starts at midnight", i)
+		assert.True(t, start.AddDate(0, 0, 1).Equal(r.End), "range %d is one day", i)
+		assert.True(t, testNow.Add(-archiveSettle).Equal(r.CreatedBefore))
+	}
+	assert.Equal(t, []int64{2, 1}, archiver.rows, "each day is archived before any of it is deleted")
+
+	assert.Equal(t, kept, remaining(t, store, project.ID), "the partially expired day waits")
+	assert.Empty(t, store.dropped, "partitions are never dropped while archiving")
+
+	run := lastRun(t, store, project.ID, testNow)
+	require.NotNil(t, run)
+	assert.True(t, day.AddDate(0, 0, 2).Equal(run.Cutoff))
+	assert.Equal(t, int64(3), run.DeletedRows)
+	assert.Equal(t, int64(3), run.ArchivedRows)
+}
+
+func TestRunOnce_ArchiveFailureDeletesNothing(t *testing.T) {
+	store := newTestStore(t)
+	times := []time.Time{testNow.AddDate(0, 0, -30), testNow.AddDate(0, 0, -20), testNow}
+	project := createProject(t, store, 7, times...)
+
+	archiver := &fakeArchiver{t: t, store: store, err: errors.New("bucket unavailable")}
+	worker := NewWorker(partitionedStore{store}, Config{BatchSize: 10})
+	worker.SetArchiver(archiver)
+	require.ErrorIs(t, worker.RunOnce(context.Background(), testNow), archiver.err)
+
+	assert.Equal(t, times, remaining(t, store, project.ID))
+	assert.Zero(t, store.batches, "no delete is attempted")
+	assert.Empty(t, store.dropped)
+
+	run := lastRun(t, store, project.ID, testNow)
+	require.NotNil(t, run)
+	assert.Zero(t, run.DeletedRows)
+	assert.Equal(t, "bucket unavailable", run.Error)
+}
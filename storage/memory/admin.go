@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"jazz/apikey"
+	"jazz/database"
+	"jazz/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// adminUserEntry is a stored admin user: their metadata and token hash, never the plaintext.
+type adminUserEntry struct {
+	user   models.AdminUser
+	secret apikey.Key
+}
+
+// CreateAdminUser implements storage.AdminStore.
+func (s *Store) CreateAdminUser(ctx context.Context, name string) (*models.AdminUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.admins {
+		if entry.user.Name == name {
+			return nil, database.ErrAdminUserExists
+		}
+	}
+
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := adminUserEntry{
+		user: models.AdminUser{
+			ID:          uuid.New(),
+			Name:        name,
+			TokenPrefix: secret.Prefix,
+			CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+		},
+		secret: secret,
+	}
+	s.admins[entry.user.ID] = entry
+
+	user := entry.user
+	user.Token = plaintext
+	return &user, nil
+}
+
+// ListAdminUsers implements storage.AdminStore. Oldest first.
+func (s *Store) ListAdminUsers(ctx context.Context) ([]models.AdminUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]models.AdminUser, 0, len(s.admins))
+	for _, entry := range s.admins {
+		users = append(users, entry.user)
+	}
+	slices.SortFunc(users, func(a, b models.AdminUser) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+
+	return users, nil
+}
+
+// DeleteAdminUser implements storage.AdminStore.
+func (s *Store) DeleteAdminUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.admins[userID]; !ok {
+		return database.ErrAdminUserNotFound
+	}
+	delete(s.admins, userID)
+	return nil
+}
+
+// AuthenticateAdmin implements storage.AdminStore.
+func (s *Store) AuthenticateAdmin(ctx context.Context, token string) (*models.AdminUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	prefix := apikey.Prefix(token)
+	for id, entry := range s.admins {
+		if entry.secret.Prefix != prefix || !entry.secret.Verify(token) {
+			continue
+		}
+
+		if entry.user.LastUsedAt == nil || now.Sub(*entry.user.LastUsedAt) >= database.APIKeyLastUsedInterval {
+			entry.user.LastUsedAt = &now
+			s.admins[id] = entry
+		}
+		user := entry.user
+		return &user, nil
+	}
+
+	return nil, database.ErrInvalidAdminToken
+}
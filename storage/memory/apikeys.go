@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"jazz/apikey"
+	"jazz/database"
+	"jazz/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyEntry is a stored API key: its metadata and hash, never the plaintext.
+type apiKeyEntry struct {
+	key    models.APIKey
+	secret apikey.Key
+}
+
+// CreateAPIKey implements storage.APIKeyStore.
+func (s *Store) CreateAPIKey(ctx context.Context, projectID uuid.UUID, req models.CreateAPIKeyRequest) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.projectExists(projectID) {
+		return nil, database.ErrProjectNotFound
+	}
+	return s.insertAPIKey(projectID, req.Name, req.Scopes, req.ExpiresAt)
+}
+
+// ListAPIKeys implements storage.APIKeyStore. Newest first.
+func (s *Store) ListAPIKeys(ctx context.Context, projectID uuid.UUID) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.projectExists(projectID) {
+		return nil, database.ErrProjectNotFound
+	}
+
+	keys := []models.APIKey{}
+	for _, entry := range s.keys {
+		if entry.key.ProjectID == projectID {
+			keys = append(keys, entry.key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b models.APIKey) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+
+	return keys, nil
+}
+
+// RevokeAPIKey implements storage.APIKeyStore.
+func (s *Store) RevokeAPIKey(ctx context.Context, projectID, keyID uuid.UUID) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.getAPIKey(projectID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.key.RevokedAt == nil {
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		entry.key.RevokedAt = &now
+		s.keys[keyID] = entry
+	}
+
+	key := entry.key
+	return &key, nil
+}
+
+// RotateAPIKey implements storage.APIKeyStore.
+func (s *Store) RotateAPIKey(ctx context.Context, projectID, keyID uuid.UUID, gracePeriod time.Duration) (*models.APIKey, *models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.getAPIKey(projectID, keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !entry.key.Active(now) {
+		return nil, nil, database.ErrAPIKeyInactive
+	}
+
+	key, err := s.insertAPIKey(projectID, entry.key.Name, entry.key.Scopes, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expiresAt := now.Add(gracePeriod).Truncate(time.Microsecond)
+	if entry.key.ExpiresAt == nil || expiresAt.Before(*entry.key.ExpiresAt) {
+		entry.key.ExpiresAt = &expiresAt
+		s.keys[keyID] = entry
+	}
+
+	old := entry.key
+	return key, &old, nil
+}
+
+// AuthenticateAPIKey implements storage.APIKeyStore.
+func (s *Store) AuthenticateAPIKey(ctx context.Context, apiKey string) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	prefix := apikey.Prefix(apiKey)
+	for id, entry := range s.keys {
+		if entry.secret.Prefix != prefix || !entry.key.Active(now) || !s.projectLive(entry.key.ProjectID) ||
+			!entry.secret.Verify(apiKey) {
+			continue
+		}
+
+		if entry.key.LastUsedAt == nil || now.Sub(*entry.key.LastUsedAt) >= database.APIKeyLastUsedInterval {
+			entry.key.LastUsedAt = &now
+			s.keys[id] = entry
+		}
+		key := entry.key
+		return &key, nil
+	}
+
+	return nil, database.ErrInvalidAPIKey
+}
+
+// insertAPIKey generates a key for projectID. Empty scopes means models.DefaultScopes.
+// Callers hold the write lock.
+func (s *Store) insertAPIKey(projectID uuid.UUID, name string, scopes []models.Scope, expiresAt *time.Time) (*models.APIKey, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+
+	if expiresAt != nil {
+		t := expiresAt.UTC().Truncate(time.Microsecond)
+		expiresAt = &t
+	}
+
+	entry := apiKeyEntry{
+		key: models.APIKey{
+			ID:        uuid.New(),
+			ProjectID: projectID,
+			Name:      name,
+			Prefix:    secret.Prefix,
+			Scopes:    models.NormalizeScopes(scopes),
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+			ExpiresAt: expiresAt,
+		},
+		secret: secret,
+	}
+	s.keys[entry.key.ID] = entry
+
+	key := entry.key
+	key.Key = plaintext
+	return &key, nil
+}
+
+// getAPIKey returns a project's key, or the error for whichever of the two is missing.
+func (s *Store) getAPIKey(projectID, keyID uuid.UUID) (apiKeyEntry, error) {
+	if !s.projectExists(projectID) {
+		return apiKeyEntry{}, database.ErrProjectNotFound
+	}
+
+	entry, ok := s.keys[keyID]
+	if !ok || entry.key.ProjectID != projectID {
+		return apiKeyEntry{}, database.ErrAPIKeyNotFound
+	}
+	return entry, nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"jazz/database"
+	"jazz/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecordAuditEvent implements storage.AuditStore.
+func (s *Store) RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = uuid.New()
+	event.OccurredAt = time.Now().UTC().Truncate(time.Microsecond)
+	s.audit = append(s.audit, *event)
+	return nil
+}
+
+// ListAuditEvents implements storage.AuditStore.
+func (s *Store) ListAuditEvents(ctx context.Context, params models.AuditQueryParams) ([]models.AuditEvent, int, error) {
+	filter, err := database.ResolveAuditQueryParams(params, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	events := []models.AuditEvent{}
+	for _, event := range slices.Backward(s.audit) {
+		if filter.Match(event) {
+			events = append(events, event)
+		}
+	}
+	s.mu.RUnlock()
+
+	total := len(events)
+	limit, offset := database.Page(params.Limit, params.Offset)
+	if offset >= total {
+		return []models.AuditEvent{}, total, nil
+	}
+
+	return events[offset:min(offset+limit, total)], total, nil
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"jazz/database"
+	"jazz/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GrantProjectGroup implements storage.ProjectGroupStore.
+func (s *Store) GrantProjectGroup(ctx context.Context, projectID uuid.UUID, group string) (*models.ProjectGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.projectExists(projectID) {
+		return nil, database.ErrProjectNotFound
+	}
+	if _, ok := s.groups[projectID][group]; ok {
+		return nil, database.ErrProjectGroupExists
+	}
+
+	grant := models.ProjectGroup{
+		ProjectID: projectID,
+		Group:     group,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if s.groups[projectID] == nil {
+		s.groups[projectID] = map[string]models.ProjectGroup{}
+	}
+	s.groups[projectID][group] = grant
+	return &grant, nil
+}
+
+// ListProjectGroups implements storage.ProjectGroupStore.
+func (s *Store) ListProjectGroups(ctx context.Context, projectID uuid.UUID) ([]models.ProjectGroup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.projectLive(projectID) {
+		return nil, database.ErrProjectNotFound
+	}
+
+	groups := []models.ProjectGroup{}
+	for _, grant := range s.groups[projectID] {
+		groups = append(groups, grant)
+	}
+	slices.SortFunc(groups, func(a, b models.ProjectGroup) int {
+		return cmp.Compare(a.Group, b.Group)
+	})
+
+	return groups, nil
+}
+
+// RevokeProjectGroup implements storage.ProjectGroupStore.
+func (s *Store) RevokeProjectGroup(ctx context.Context, projectID uuid.UUID, group string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[projectID][group]; !ok {
+		return database.ErrProjectGroupNotFound
+	}
+	delete(s.groups[projectID], group)
+	return nil
+}
+
+// HasProjectGroup implements storage.ProjectGroupStore.
+func (s *Store) HasProjectGroup(ctx context.Context, projectID uuid.UUID, groups []string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.projectLive(projectID) {
+		return false, nil
+	}
+	for _, group := range groups {
+		if _, ok := s.groups[projectID][group]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,506 @@
+// Package memory provides an in-memory storage.Store for handler tests and for
+// running the API without PostgreSQL (DATABASE_URL=memory://).
+//
+// Filters, sorting and pagination are validated exactly like the PostgreSQL backend.
+// Full-text search is approximated: every search term must appear in the message
+// (case-insensitive, no stemming), and rank is the share of message words that
+// match a term. Data is lost when the process exits.
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Column limits enforced by the PostgreSQL schema.
+	maxLevelLength  = 20
+	maxSourceLength = 100
+)
+
+// Store is an in-memory storage.Store. Safe for concurrent use.
+type Store struct {
+	mu       sync.RWMutex
+	projects map[uuid.UUID]models.Project
+	keys     map[uuid.UUID]apiKeyEntry       // by key ID
+	logs     map[uuid.UUID][]models.LogEntry // by project ID
+	admins   map[uuid.UUID]adminUserEntry    // by user ID
+	users    map[uuid.UUID]userEntry         // by user ID
+	sessions map[uuid.UUID]sessionEntry      // by session ID
+	orgs     map[uuid.UUID]models.Organization
+	members  map[uuid.UUID]map[uuid.UUID]models.Membership // by org ID, then user ID
+	groups   map[uuid.UUID]map[string]models.ProjectGroup  // by project ID, then group name
+	deleted  map[uuid.UUID]time.Time                       // deletion time by project ID
+	audit    []models.AuditEvent                           // oldest first
+	buckets  map[bucketKey]*database.TokenBucket
+	usage    map[uuid.UUID]dailyUsage // today's (or the last active day's) by project ID
+}
+
+// New creates an empty Store.
+func New() *Store {
+	return &Store{
+		projects: map[uuid.UUID]models.Project{},
+		keys:     map[uuid.UUID]apiKeyEntry{},
+		admins:   map[uuid.UUID]adminUserEntry{},
+		users:    map[uuid.UUID]userEntry{},
+		sessions: map[uuid.UUID]sessionEntry{},
+		orgs:     map[uuid.UUID]models.Organization{},
+		members:  map[uuid.UUID]map[uuid.UUID]models.Membership{},
+		groups:   map[uuid.UUID]map[string]models.ProjectGroup{},
+		deleted:  map[uuid.UUID]time.Time{},
+		logs:     map[uuid.UUID][]models.LogEntry{},
+		buckets:  map[bucketKey]*database.TokenBucket{},
+		usage:    map[uuid.UUID]dailyUsage{},
+	}
+}
+
+// InsertLogsBatch stores logs atomically: if any entry is invalid (unknown project,
+// level or source too long) nothing is stored and a *database.BatchInsertError is returned.
+func (s *Store) InsertLogsBatch(ctx context.Context, logs []models.LogEntry) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, entry := range logs {
+		var err error
+		switch {
+		case !s.projectExists(entry.ProjectID):
+			err = fmt.Errorf("project %s does not exist", entry.ProjectID)
+		case len(entry.Level) > maxLevelLength:
+			err = fmt.Errorf("level longer than %d characters", maxLevelLength)
+		case len(entry.Source) > maxSourceLength:
+			err = fmt.Errorf("source longer than %d characters", maxSourceLength)
+		}
+		if err != nil {
+			return &database.BatchInsertError{FailedIndex: i, TotalLogs: len(logs), Err: err}
+		}
+	}
+
+	for _, entry := range logs {
+		entry.Timestamp = entry.Timestamp.UTC().Truncate(time.Microsecond)
+		entry.Rank = nil
+		s.logs[entry.ProjectID] = append(s.logs[entry.ProjectID], entry)
+	}
+
+	return nil
+}
+
+// QueryLogs implements storage.LogStore.
+func (s *Store) QueryLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams) ([]models.LogEntry, int64, error) {
+	if params.Search != "" {
+		return s.SearchLogs(ctx, projectID, models.SearchRequest{
+			Query:     params.Search,
+			Level:     params.Level,
+			Source:    params.Source,
+			StartTime: params.StartTime,
+			EndTime:   params.EndTime,
+			TZ:        params.TZ,
+			Sort:      params.Sort,
+			Limit:     params.Limit,
+			Offset:    params.Offset,
+		})
+	}
+
+	sortFields, err := database.LogSort(params.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter, err := database.ResolveQueryParams(params, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.find(projectID, filter, sortFields, params.Limit, params.Offset, false)
+}
+
+// SearchLogs implements storage.LogStore.
+func (s *Store) SearchLogs(ctx context.Context, projectID uuid.UUID, req models.SearchRequest) ([]models.LogEntry, int64, error) {
+	sortFields, err := database.SearchSort(req.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter, err := database.ResolveSearchRequest(req, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.find(projectID, filter, sortFields, req.Limit, req.Offset, true)
+}
+
+// GetLog implements storage.LogStore.
+func (s *Store) GetLog(ctx context.Context, projectID, logID uuid.UUID) (*models.LogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entry := range s.logs[projectID] {
+		if entry.ID == logID {
+			return &entry, nil
+		}
+	}
+
+	return nil, database.ErrLogNotFound
+}
+
+// CreateProject implements storage.ProjectStore.
+func (s *Store) CreateProject(ctx context.Context, name string) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createProject(name, nil)
+}
+
+// createProject creates a project, owned by orgID if it isn't nil, with its "default" key.
+// Callers hold the write lock.
+func (s *Store) createProject(name string, orgID *uuid.UUID) (*models.Project, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	project := models.Project{
+		ID:        uuid.New(),
+		Name:      name,
+		OrgID:     orgID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	key, err := s.insertAPIKey(project.ID, database.DefaultAPIKeyName, models.AllScopes, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.projects[project.ID] = project
+
+	project.APIKey = key.Key
+	return &project, nil
+}
+
+// ListProjects implements storage.ProjectStore. Stats are aggregated for the page's
+// projects only; StorageBytes counts the bytes of each log's level, message and source.
+func (s *Store) ListProjects(ctx context.Context, params models.ProjectListParams) ([]models.Project, int, error) {
+	sortFields, err := database.ProjectSort(params.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	search := strings.ToLower(params.Search)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := []models.Project{}
+	for id, project := range s.projects {
+		if s.projectLive(id) && strings.Contains(strings.ToLower(project.Name), search) {
+			projects = append(projects, project)
+		}
+	}
+
+	slices.SortFunc(projects, func(a, b models.Project) int {
+		return compareProjects(a, b, sortFields)
+	})
+
+	total := len(projects)
+	limit, offset := database.Page(params.Limit, params.Offset)
+	if offset >= total {
+		return []models.Project{}, total, nil
+	}
+
+	page := slices.Clone(projects[offset:min(offset+limit, total)])
+	for i := range page {
+		stats := models.ProjectStats{LogCount: int64(len(s.logs[page[i].ID]))}
+		for _, entry := range s.logs[page[i].ID] {
+			if stats.LastLogAt == nil || entry.Timestamp.After(*stats.LastLogAt) {
+				last := entry.Timestamp
+				stats.LastLogAt = &last
+			}
+			stats.StorageBytes += int64(len(entry.Level) + len(entry.Message) + len(entry.Source))
+		}
+		page[i].Stats = &stats
+	}
+	return page, total, nil
+}
+
+// GetProject implements storage.ProjectStore.
+func (s *Store) GetProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, ok := s.projects[projectID]
+	if !ok || !s.projectLive(projectID) {
+		return nil, database.ErrProjectNotFound
+	}
+	return &project, nil
+}
+
+// UpdateProject implements storage.ProjectStore.
+func (s *Store) UpdateProject(ctx context.Context, projectID uuid.UUID, req models.UpdateProjectRequest) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok || !s.projectLive(projectID) {
+		return nil, database.ErrProjectNotFound
+	}
+
+	if req.Name != nil {
+		project.Name = *req.Name
+	}
+	if req.SetRetention {
+		project.RetentionDays = req.RetentionDays
+	}
+	if req.Settings != nil {
+		project.Settings = *req.Settings
+		project.Settings.AllowedLevels = slices.Clone(req.Settings.AllowedLevels)
+	}
+	project.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	s.projects[projectID] = project
+
+	return &project, nil
+}
+
+// DeleteProject implements storage.ProjectStore.
+func (s *Store) DeleteProject(ctx context.Context, projectID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.projectLive(projectID) {
+		return database.ErrProjectNotFound
+	}
+	s.deleted[projectID] = time.Now().UTC().Truncate(time.Microsecond)
+	return nil
+}
+
+// RestoreProject implements storage.ProjectStore.
+func (s *Store) RestoreProject(ctx context.Context, projectID uuid.UUID, deletedSince time.Time) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok {
+		return nil, database.ErrProjectNotFound
+	}
+	deletedAt, ok := s.deleted[projectID]
+	if !ok {
+		return nil, database.ErrProjectNotDeleted
+	}
+	if deletedAt.Before(deletedSince) {
+		return nil, database.ErrProjectNotFound
+	}
+
+	delete(s.deleted, projectID)
+	project.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	s.projects[projectID] = project
+	return &project, nil
+}
+
+// GetDeletedProject implements storage.ProjectStore.
+func (s *Store) GetDeletedProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, ok := s.projects[projectID]
+	if !ok {
+		return nil, database.ErrProjectNotFound
+	}
+	if _, deleted := s.deleted[projectID]; !deleted {
+		return nil, database.ErrProjectNotDeleted
+	}
+	return &project, nil
+}
+
+// ListDeletedProjects implements storage.DeletedProjectStore.
+func (s *Store) ListDeletedProjects(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := []uuid.UUID{}
+	for id, deletedAt := range s.deleted {
+		if deletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return s.deleted[a].Compare(s.deleted[b])
+	})
+
+	return ids, nil
+}
+
+// PurgeDeletedProjectLogs implements storage.DeletedProjectStore.
+func (s *Store) PurgeDeletedProjectLogs(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time, batchSize int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.deletedBefore(projectID, deletedBefore) {
+		return 0, nil
+	}
+
+	logs := s.logs[projectID]
+	n := min(batchSize, len(logs))
+	s.logs[projectID] = logs[n:]
+	return int64(n), nil
+}
+
+// PurgeDeletedProject implements storage.DeletedProjectStore.
+// The project's logs, API keys and group grants are deleted with it.
+func (s *Store) PurgeDeletedProject(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.deletedBefore(projectID, deletedBefore) {
+		return database.ErrProjectNotFound
+	}
+	delete(s.projects, projectID)
+	delete(s.deleted, projectID)
+	delete(s.logs, projectID)
+	delete(s.groups, projectID)
+	delete(s.usage, projectID)
+	delete(s.buckets, bucketKey{projectID, models.BucketRequests})
+	delete(s.buckets, bucketKey{projectID, models.BucketEntries})
+	for id, entry := range s.keys {
+		if entry.key.ProjectID == projectID {
+			delete(s.keys, id)
+		}
+	}
+	return nil
+}
+
+// projectLive reports whether a project exists and isn't deleted. Callers hold the lock.
+func (s *Store) projectLive(projectID uuid.UUID) bool {
+	_, deleted := s.deleted[projectID]
+	return s.projectExists(projectID) && !deleted
+}
+
+// deletedBefore reports whether a project was deleted before t. Callers hold the lock.
+func (s *Store) deletedBefore(projectID uuid.UUID, t time.Time) bool {
+	deletedAt, ok := s.deleted[projectID]
+	return ok && deletedAt.Before(t)
+}
+
+func (s *Store) projectExists(projectID uuid.UUID) bool {
+	_, ok := s.projects[projectID]
+	return ok
+}
+
+// find filters, sorts and pages a project's logs.
+// With withRank, each match's Rank is set before sorting.
+func (s *Store) find(projectID uuid.UUID, filter database.LogFilter, sortFields []database.SortField, limit, offset int, withRank bool) ([]models.LogEntry, int64, error) {
+	s.mu.RLock()
+	var matches []models.LogEntry
+	for _, entry := range s.logs[projectID] {
+		if filter.Match(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	s.mu.RUnlock()
+
+	if withRank {
+		for i := range matches {
+			rank := rank(matches[i].Message, filter.Terms)
+			matches[i].Rank = &rank
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b models.LogEntry) int {
+		return compareEntries(a, b, sortFields)
+	})
+
+	total := int64(len(matches))
+	limit, offset = database.Page(limit, offset)
+	if offset >= len(matches) {
+		return []models.LogEntry{}, total, nil
+	}
+
+	page := matches[offset:min(offset+limit, len(matches))]
+	return slices.Clone(page), total, nil
+}
+
+// compareEntries orders like database.OrderByClause: by each sort field, then by ID
+// in the first field's direction.
+func compareEntries(a, b models.LogEntry, fields []database.SortField) int {
+	for _, field := range fields {
+		var c int
+		switch field.Column {
+		case "timestamp":
+			c = a.Timestamp.Compare(b.Timestamp)
+		case "level":
+			c = strings.Compare(a.Level, b.Level)
+		case "source":
+			c = strings.Compare(a.Source, b.Source)
+		case "rank":
+			c = cmp.Compare(deref(a.Rank), deref(b.Rank))
+		}
+		if field.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	c := bytes.Compare(a.ID[:], b.ID[:])
+	if len(fields) > 0 && fields[0].Descending {
+		c = -c
+	}
+	return c
+}
+
+// compareProjects orders like database.OrderByClause: by each sort field, then by ID
+// in the first field's direction.
+func compareProjects(a, b models.Project, fields []database.SortField) int {
+	for _, field := range fields {
+		var c int
+		switch field.Column {
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "created_at":
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if field.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	c := bytes.Compare(a.ID[:], b.ID[:])
+	if len(fields) > 0 && fields[0].Descending {
+		c = -c
+	}
+	return c
+}
+
+// rank is the fraction of the message's words that contain a search term.
+func rank(message string, terms []string) float64 {
+	words := strings.Fields(strings.ToLower(message))
+	if len(words) == 0 {
+		return 0
+	}
+
+	matched := 0
+	for _, word := range words {
+		for _, term := range terms {
+			if strings.Contains(word, term) {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(words))
+}
+
+func deref(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"jazz/storage/storagetest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_InsertAndQuery(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	base := time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC)
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Server started", Source: "api", Timestamp: base},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database timeout", Source: "api", Timestamp: base.Add(time.Minute)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Queue full", Source: "worker", Timestamp: base.Add(2 * time.Minute)},
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	// Newest first by default
+	result, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"Queue full", "Database timeout", "Server started"}, messages(result))
+
+	result, total, err = store.QueryLogs(ctx, project.ID, models.QueryParams{Level: "error", Sort: "timestamp:asc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"Database timeout", "Queue full"}, messages(result))
+
+	result, total, err = store.QueryLogs(ctx, project.ID, models.QueryParams{
+		StartTime: base.Add(30 * time.Second).Format(time.RFC3339),
+		Limit:     1,
+		Offset:    1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"Database timeout"}, messages(result))
+
+	// Other projects see nothing
+	result, total, err = store.QueryLogs(ctx, uuid.New(), models.QueryParams{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, result)
+}
+
+func TestStore_InsertIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	err = store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "ok", Timestamp: time.Now()},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "a-level-that-is-far-too-long", Message: "bad", Timestamp: time.Now()},
+	})
+	var batchErr *database.BatchInsertError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 1, batchErr.FailedIndex)
+
+	_, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	err = store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: uuid.New(), Level: "info", Message: "orphan", Timestamp: time.Now()},
+	})
+	assert.Error(t, err)
+}
+
+func TestStore_Search(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+
+	now := time.Now()
+	err = store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database connection timeout", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database timeout", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Database ready", Timestamp: now},
+	})
+	require.NoError(t, err)
+
+	result, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "DATABASE timeout"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	// Denser matches rank higher
+	assert.Equal(t, []string{"Database timeout", "Database connection timeout"}, messages(result))
+	for _, entry := range result {
+		assert.NotNil(t, entry.Rank)
+	}
+
+	// QueryParams.Search delegates to search
+	_, total, err = store.QueryLogs(ctx, project.ID, models.QueryParams{Search: "ready"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestStore_ValidationMatchesPostgres(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	projectID := uuid.New()
+
+	var queryErr *database.QueryError
+
+	_, _, err := store.QueryLogs(ctx, projectID, models.QueryParams{StartTime: "not-a-date"})
+	assert.True(t, errors.As(err, &queryErr))
+
+	_, _, err = store.QueryLogs(ctx, projectID, models.QueryParams{Sort: "message"})
+	assert.True(t, errors.As(err, &queryErr))
+
+	_, _, err = store.SearchLogs(ctx, projectID, models.SearchRequest{Query: "a"})
+	assert.True(t, errors.As(err, &queryErr))
+
+	_, _, err = store.SearchLogs(ctx, projectID, models.SearchRequest{})
+	assert.True(t, errors.As(err, &queryErr))
+}
+
+func TestStore_Projects(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	first, err := store.CreateProject(ctx, "First")
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	second, err := store.CreateProject(ctx, "Second")
+	require.NoError(t, err)
+
+	projects, _, err := store.ListProjects(ctx, models.ProjectListParams{})
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	assert.Equal(t, second.ID, projects[0].ID, "newest first")
+
+	found, err := store.AuthenticateAPIKey(ctx, first.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, found.ProjectID)
+
+	_, err = store.AuthenticateAPIKey(ctx, "jazz_invalid")
+	assert.Error(t, err)
+
+	logID := uuid.New()
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: logID, ProjectID: first.ID, Level: "info", Message: "hello", Timestamp: time.Now()},
+	}))
+	_, err = store.GetLog(ctx, first.ID, logID)
+	require.NoError(t, err)
+	_, err = store.GetLog(ctx, second.ID, logID)
+	assert.ErrorIs(t, err, database.ErrLogNotFound)
+
+	require.NoError(t, store.DeleteProject(ctx, first.ID))
+	_, err = store.GetProject(ctx, first.ID)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	assert.ErrorIs(t, store.DeleteProject(ctx, first.ID), database.ErrProjectNotFound)
+
+	cutoff := time.Now().Add(time.Minute)
+	_, err = store.PurgeDeletedProjectLogs(ctx, first.ID, cutoff, 10)
+	require.NoError(t, err)
+	require.NoError(t, store.PurgeDeletedProject(ctx, first.ID, cutoff))
+	_, err = store.GetLog(ctx, first.ID, logID)
+	assert.ErrorIs(t, err, database.ErrLogNotFound, "logs are purged with the project")
+}
+
+func messages(logs []models.LogEntry) []string {
+	result := make([]string, len(logs))
+	for i, entry := range logs {
+		result[i] = entry.Message
+	}
+	return result
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		return New()
+	})
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"jazz/database"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type bucketKey struct {
+	projectID uuid.UUID
+	bucket    string
+}
+
+type dailyUsage struct {
+	day time.Time // midnight UTC
+	models.IngestUsage
+}
+
+// TakeTokens implements storage.RateLimitStore. Buckets are per process, so
+// several servers on memory:// each enforce the limits separately.
+func (s *Store) TakeTokens(ctx context.Context, projectID uuid.UUID, bucket string, rate, cost float64) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := bucketKey{projectID, bucket}
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &database.TokenBucket{}
+		s.buckets[key] = b
+	}
+	return b.Take(time.Now(), rate, cost), nil
+}
+
+// ChargeIngestQuota implements storage.RateLimitStore.
+func (s *Store) ChargeIngestQuota(ctx context.Context, projectID uuid.UUID, day time.Time, usage, quota models.IngestUsage) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day = database.UTCDay(day)
+	current := s.usage[projectID]
+	if !current.day.Equal(day) {
+		current = dailyUsage{day: day}
+	}
+
+	total := current.Add(usage)
+	if !total.Within(quota) {
+		return false, nil
+	}
+	s.usage[projectID] = dailyUsage{day: day, IngestUsage: total}
+	return true, nil
+}
+
+// PurgeIngestUsage implements storage.RateLimitStore.
+func (s *Store) PurgeIngestUsage(ctx context.Context, day time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day = database.UTCDay(day)
+	var purged int64
+	for projectID, usage := range s.usage {
+		if usage.day.Before(day) {
+			delete(s.usage, projectID)
+			purged++
+		}
+	}
+	return purged, nil
+}
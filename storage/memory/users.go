@@ -0,0 +1,357 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"jazz/apikey"
+	"jazz/database"
+	"jazz/models"
+	"jazz/password"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userEntry is a stored user: their profile and bcrypt password hash.
+type userEntry struct {
+	user         models.User
+	passwordHash []byte
+}
+
+// sessionEntry is a stored session: its metadata and token hash, never the plaintext.
+type sessionEntry struct {
+	session models.Session
+	secret  apikey.Key
+}
+
+// CreateUser implements storage.UserStore.
+func (s *Store) CreateUser(ctx context.Context, email, name, plaintext string) (*models.User, error) {
+	hash, err := password.Hash(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email = models.NormalizeEmail(email)
+	if _, ok := s.userByEmail(email); ok {
+		return nil, database.ErrUserExists
+	}
+
+	user := models.User{
+		ID:        uuid.New(),
+		Email:     email,
+		Name:      name,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	s.users[user.ID] = userEntry{user: user, passwordHash: hash}
+	return &user, nil
+}
+
+// AuthenticateUser implements storage.UserStore.
+func (s *Store) AuthenticateUser(ctx context.Context, email, plaintext string) (*models.User, error) {
+	s.mu.RLock()
+	entry, _ := s.userByEmail(models.NormalizeEmail(email))
+	s.mu.RUnlock()
+
+	// Unknown emails have a nil hash, which still costs a comparison
+	if !password.Verify(entry.passwordHash, plaintext) {
+		return nil, database.ErrInvalidCredentials
+	}
+	user := entry.user
+	return &user, nil
+}
+
+// GetUserByEmail implements storage.UserStore.
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.userByEmail(models.NormalizeEmail(email))
+	if !ok {
+		return nil, database.ErrUserNotFound
+	}
+	user := entry.user
+	return &user, nil
+}
+
+// CreateSession implements storage.UserStore. The user's expired sessions are deleted.
+func (s *Store) CreateSession(ctx context.Context, userID uuid.UUID, ttl time.Duration) (*models.Session, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.users[userID]
+	if !ok {
+		return nil, database.ErrUserNotFound
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for id, existing := range s.sessions {
+		if existing.session.User.ID == userID && !existing.session.ExpiresAt.After(now) {
+			delete(s.sessions, id)
+		}
+	}
+
+	session := models.Session{
+		ID:        uuid.New(),
+		User:      entry.user,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl).Truncate(time.Microsecond),
+	}
+	s.sessions[session.ID] = sessionEntry{session: session, secret: secret}
+
+	session.Token = plaintext
+	return &session, nil
+}
+
+// AuthenticateSession implements storage.UserStore.
+func (s *Store) AuthenticateSession(ctx context.Context, token string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	prefix := apikey.Prefix(token)
+	for _, entry := range s.sessions {
+		if entry.secret.Prefix == prefix && entry.session.ExpiresAt.After(now) && entry.secret.Verify(token) {
+			session := entry.session
+			return &session, nil
+		}
+	}
+
+	return nil, database.ErrInvalidSession
+}
+
+// DeleteSession implements storage.UserStore.
+func (s *Store) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// CreateOrganization implements storage.OrganizationStore.
+func (s *Store) CreateOrganization(ctx context.Context, name string, ownerID uuid.UUID) (*models.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, ok := s.users[ownerID]
+	if !ok {
+		return nil, database.ErrUserNotFound
+	}
+
+	org := models.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	s.orgs[org.ID] = org
+	s.members[org.ID] = map[uuid.UUID]models.Membership{
+		ownerID: newMembership(org.ID, owner.user, models.RoleOwner),
+	}
+
+	org.Role = models.RoleOwner
+	return &org, nil
+}
+
+// ListOrganizations implements storage.OrganizationStore. Oldest first.
+func (s *Store) ListOrganizations(ctx context.Context, userID uuid.UUID) ([]models.Organization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for orgID, members := range s.members {
+		if member, ok := members[userID]; ok {
+			org := s.orgs[orgID]
+			org.Role = member.Role
+			orgs = append(orgs, org)
+		}
+	}
+	slices.SortFunc(orgs, func(a, b models.Organization) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+
+	return orgs, nil
+}
+
+// GetMembership implements storage.OrganizationStore.
+func (s *Store) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*models.Membership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.members[orgID][userID]
+	if !ok {
+		return nil, database.ErrMemberNotFound
+	}
+	return &member, nil
+}
+
+// ListMembers implements storage.OrganizationStore. Oldest first.
+func (s *Store) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.Membership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.orgs[orgID]; !ok {
+		return nil, database.ErrOrganizationNotFound
+	}
+
+	members := []models.Membership{}
+	for _, member := range s.members[orgID] {
+		members = append(members, member)
+	}
+	slices.SortFunc(members, func(a, b models.Membership) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Email, b.Email)
+	})
+
+	return members, nil
+}
+
+// AddMember implements storage.OrganizationStore.
+func (s *Store) AddMember(ctx context.Context, orgID uuid.UUID, email string, role models.Role) (*models.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orgs[orgID]; !ok {
+		return nil, database.ErrOrganizationNotFound
+	}
+	entry, ok := s.userByEmail(models.NormalizeEmail(email))
+	if !ok {
+		return nil, database.ErrUserNotFound
+	}
+	if _, ok := s.members[orgID][entry.user.ID]; ok {
+		return nil, database.ErrMemberExists
+	}
+
+	member := newMembership(orgID, entry.user, role)
+	s.members[orgID][entry.user.ID] = member
+	return &member, nil
+}
+
+// UpdateMemberRole implements storage.OrganizationStore.
+func (s *Store) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.Role) (*models.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[orgID][userID]
+	if !ok {
+		return nil, database.ErrMemberNotFound
+	}
+	if role != models.RoleOwner && s.isLastOwner(orgID, userID) {
+		return nil, database.ErrLastOwner
+	}
+
+	member.Role = role
+	s.members[orgID][userID] = member
+	return &member, nil
+}
+
+// RemoveMember implements storage.OrganizationStore.
+func (s *Store) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[orgID][userID]; !ok {
+		return database.ErrMemberNotFound
+	}
+	if s.isLastOwner(orgID, userID) {
+		return database.ErrLastOwner
+	}
+
+	delete(s.members[orgID], userID)
+	return nil
+}
+
+// CreateOrganizationProject implements storage.OrganizationStore.
+func (s *Store) CreateOrganizationProject(ctx context.Context, orgID uuid.UUID, name string) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orgs[orgID]; !ok {
+		return nil, database.ErrOrganizationNotFound
+	}
+	return s.createProject(name, &orgID)
+}
+
+// ListOrganizationProjects implements storage.OrganizationStore. Newest first.
+func (s *Store) ListOrganizationProjects(ctx context.Context, orgID uuid.UUID) ([]models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := []models.Project{}
+	for _, project := range s.projects {
+		if project.OrgID != nil && *project.OrgID == orgID && s.projectLive(project.ID) {
+			projects = append(projects, project)
+		}
+	}
+	slices.SortFunc(projects, func(a, b models.Project) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return projects, nil
+}
+
+// ProjectRole implements storage.OrganizationStore.
+func (s *Store) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (models.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, ok := s.projects[projectID]
+	if !ok || !s.projectLive(projectID) {
+		return "", database.ErrProjectNotFound
+	}
+	if project.OrgID == nil {
+		return "", database.ErrMemberNotFound
+	}
+	member, ok := s.members[*project.OrgID][userID]
+	if !ok {
+		return "", database.ErrMemberNotFound
+	}
+	return member.Role, nil
+}
+
+// userByEmail finds a user by normalized email. Callers hold the lock.
+func (s *Store) userByEmail(email string) (userEntry, bool) {
+	for _, entry := range s.users {
+		if entry.user.Email == email {
+			return entry, true
+		}
+	}
+	return userEntry{}, false
+}
+
+// isLastOwner reports whether userID is orgID's only owner. Callers hold the lock.
+func (s *Store) isLastOwner(orgID, userID uuid.UUID) bool {
+	if s.members[orgID][userID].Role != models.RoleOwner {
+		return false
+	}
+	for id, member := range s.members[orgID] {
+		if id != userID && member.Role == models.RoleOwner {
+			return false
+		}
+	}
+	return true
+}
+
+func newMembership(orgID uuid.UUID, user models.User, role models.Role) models.Membership {
+	return models.Membership{
+		OrgID:     orgID,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Role:      role,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
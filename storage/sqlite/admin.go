@@ -0,0 +1,158 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"jazz/apikey"
+	"jazz/database"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// adminUserColumns are the columns scanAdminUser reads, in order.
+const adminUserColumns = "id, name, token_prefix, created_at, last_used_at"
+
+// CreateAdminUser implements storage.AdminStore.
+func (s *Store) CreateAdminUser(ctx context.Context, name string) (*models.AdminUser, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.AdminUser{
+		ID:          uuid.New(),
+		Name:        name,
+		Token:       plaintext,
+		TokenPrefix: secret.Prefix,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO admin_users (id, name, token_prefix, token_salt, token_hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO NOTHING`,
+		user.ID.String(), name, secret.Prefix, secret.Salt, secret.Hash, user.CreatedAt.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to create admin user: %w", err)
+	} else if n == 0 {
+		return nil, database.ErrAdminUserExists
+	}
+
+	return &user, nil
+}
+
+// ListAdminUsers implements storage.AdminStore. Oldest first.
+func (s *Store) ListAdminUsers(ctx context.Context) ([]models.AdminUser, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+adminUserColumns+" FROM admin_users ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.AdminUser{}
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating admin users: %w", err)
+	}
+
+	return users, nil
+}
+
+// DeleteAdminUser implements storage.AdminStore.
+func (s *Store) DeleteAdminUser(ctx context.Context, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM admin_users WHERE id = ?", userID.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete admin user: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete admin user: %w", err)
+	}
+	if n == 0 {
+		return database.ErrAdminUserNotFound
+	}
+	return nil
+}
+
+// AuthenticateAdmin implements storage.AdminStore. Candidates are found by the
+// token's prefix and verified against their salted hash.
+func (s *Store) AuthenticateAdmin(ctx context.Context, token string) (*models.AdminUser, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+adminUserColumns+", token_salt, token_hash FROM admin_users WHERE token_prefix = ?",
+		apikey.Prefix(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+	defer rows.Close()
+
+	var found *models.AdminUser
+	for rows.Next() {
+		var secret apikey.Key
+		user, err := scanAdminUser(rows, &secret.Salt, &secret.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan admin user: %w", err)
+		}
+		if secret.Verify(token) {
+			found = user
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+	// Releases the connection for the update below
+	_ = rows.Close()
+	if found == nil {
+		return nil, database.ErrInvalidAdminToken
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if found.LastUsedAt == nil || now.Sub(*found.LastUsedAt) >= database.APIKeyLastUsedInterval {
+		_, err := s.db.ExecContext(ctx, "UPDATE admin_users SET last_used_at = ? WHERE id = ?",
+			now.UnixMicro(), found.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to record admin user use: %w", err)
+		}
+		found.LastUsedAt = &now
+	}
+
+	return found, nil
+}
+
+// scanAdminUser scans adminUserColumns followed by any extra columns into extra.
+func scanAdminUser(row rowScanner, extra ...any) (*models.AdminUser, error) {
+	var (
+		user       models.AdminUser
+		id         string
+		createdAt  int64
+		lastUsedAt sql.NullInt64
+	)
+
+	dest := append([]any{&id, &user.Name, &user.TokenPrefix, &createdAt, &lastUsedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if user.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+	user.CreatedAt = time.UnixMicro(createdAt).UTC()
+	user.LastUsedAt = timeFromMicros(lastUsedAt)
+
+	return &user, nil
+}
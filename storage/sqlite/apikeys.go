@@ -0,0 +1,280 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"jazz/apikey"
+	"jazz/database"
+	"jazz/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyColumns are the columns scanAPIKey reads, in order.
+const apiKeyColumns = "id, project_id, name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at"
+
+// CreateAPIKey implements storage.APIKeyStore.
+func (s *Store) CreateAPIKey(ctx context.Context, projectID uuid.UUID, req models.CreateAPIKeyRequest) (*models.APIKey, error) {
+	if _, err := s.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return insertAPIKey(ctx, s.db, projectID, req.Name, req.Scopes, req.ExpiresAt)
+}
+
+// ListAPIKeys implements storage.APIKeyStore. Newest first.
+func (s *Store) ListAPIKeys(ctx context.Context, projectID uuid.UUID) ([]models.APIKey, error) {
+	if _, err := s.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE project_id = ? ORDER BY created_at DESC, id",
+		projectID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey implements storage.APIKeyStore.
+func (s *Store) RevokeAPIKey(ctx context.Context, projectID, keyID uuid.UUID) (*models.APIKey, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	row := s.db.QueryRowContext(ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND project_id = ? RETURNING "+apiKeyColumns,
+		now.UnixMicro(), keyID.String(), projectID.String())
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, s.apiKeyNotFound(ctx, projectID)
+		}
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+// RotateAPIKey implements storage.APIKeyStore.
+func (s *Store) RotateAPIKey(ctx context.Context, projectID, keyID uuid.UUID, gracePeriod time.Duration) (*models.APIKey, *models.APIKey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	row := tx.QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ? AND project_id = ?",
+		keyID.String(), projectID.String())
+	old, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = tx.Rollback() // frees the only connection for the lookup
+			return nil, nil, s.apiKeyNotFound(ctx, projectID)
+		}
+		return nil, nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !old.Active(now) {
+		return nil, nil, database.ErrAPIKeyInactive
+	}
+
+	expiresAt := now.Add(gracePeriod).Truncate(time.Microsecond)
+	if old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+		if _, err := tx.ExecContext(ctx, "UPDATE api_keys SET expires_at = ? WHERE id = ?",
+			expiresAt.UnixMicro(), keyID.String()); err != nil {
+			return nil, nil, fmt.Errorf("failed to expire API key: %w", err)
+		}
+		old.ExpiresAt = &expiresAt
+	}
+
+	key, err := insertAPIKey(ctx, tx, projectID, old.Name, old.Scopes, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+	return key, old, nil
+}
+
+// AuthenticateAPIKey implements storage.APIKeyStore. Candidates are found by the
+// key's prefix and verified against their salted hash.
+func (s *Store) AuthenticateAPIKey(ctx context.Context, apiKey string) (*models.APIKey, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`, salt, hash FROM api_keys
+		WHERE prefix = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+		  AND project_id IN (SELECT id FROM projects WHERE deleted_at IS NULL)`,
+		apikey.Prefix(apiKey), now.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	defer rows.Close()
+
+	var found *models.APIKey
+	for rows.Next() {
+		var secret apikey.Key
+		key, err := scanAPIKey(rows, &secret.Salt, &secret.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		if secret.Verify(apiKey) {
+			found = key
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	// Releases the connection for the update below
+	_ = rows.Close()
+	if found == nil {
+		return nil, database.ErrInvalidAPIKey
+	}
+
+	if found.LastUsedAt == nil || now.Sub(*found.LastUsedAt) >= database.APIKeyLastUsedInterval {
+		_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = ? WHERE id = ?",
+			now.UnixMicro(), found.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to record API key use: %w", err)
+		}
+		found.LastUsedAt = &now
+	}
+
+	return found, nil
+}
+
+// apiKeyNotFound tells a missing project from a missing key after a lookup by both.
+func (s *Store) apiKeyNotFound(ctx context.Context, projectID uuid.UUID) error {
+	if _, err := s.GetProject(ctx, projectID); err != nil {
+		return err
+	}
+	return database.ErrAPIKeyNotFound
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// insertAPIKey generates a key and stores its prefix and salted hash.
+// Empty scopes means models.DefaultScopes. The returned key has Key set to the plaintext.
+func insertAPIKey(ctx context.Context, db execer, projectID uuid.UUID, name string, scopes []models.Scope, expiresAt *time.Time) (*models.APIKey, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+
+	key := models.APIKey{
+		ID:        uuid.New(),
+		ProjectID: projectID,
+		Name:      name,
+		Key:       plaintext,
+		Prefix:    secret.Prefix,
+		Scopes:    models.NormalizeScopes(scopes),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if expiresAt != nil {
+		t := expiresAt.UTC().Truncate(time.Microsecond)
+		key.ExpiresAt = &t
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, project_id, name, prefix, scopes, salt, hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID.String(), projectID.String(), name, secret.Prefix, joinScopes(key.Scopes), secret.Salt, secret.Hash,
+		key.CreatedAt.UnixMicro(), micros(key.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// scanAPIKey scans apiKeyColumns followed by any extra columns into extra.
+func scanAPIKey(row rowScanner, extra ...any) (*models.APIKey, error) {
+	var (
+		key                              models.APIKey
+		id, projectID, scopes            string
+		createdAt                        int64
+		lastUsedAt, expiresAt, revokedAt sql.NullInt64
+	)
+
+	dest := append([]any{&id, &projectID, &key.Name, &key.Prefix, &scopes, &createdAt, &lastUsedAt, &expiresAt, &revokedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if key.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+	if key.ProjectID, err = uuid.Parse(projectID); err != nil {
+		return nil, err
+	}
+	key.Scopes = splitScopes(scopes)
+	key.CreatedAt = time.UnixMicro(createdAt).UTC()
+	key.LastUsedAt = timeFromMicros(lastUsedAt)
+	key.ExpiresAt = timeFromMicros(expiresAt)
+	key.RevokedAt = timeFromMicros(revokedAt)
+
+	return &key, nil
+}
+
+// joinScopes and splitScopes convert scopes to and from the space-separated scopes column.
+func joinScopes(scopes []models.Scope) string {
+	strs := make([]string, len(scopes))
+	for i, scope := range scopes {
+		strs[i] = string(scope)
+	}
+	return strings.Join(strs, " ")
+}
+
+func splitScopes(s string) []models.Scope {
+	scopes := []models.Scope{}
+	for _, scope := range strings.Fields(s) {
+		scopes = append(scopes, models.Scope(scope))
+	}
+	return scopes
+}
+
+// micros converts an optional time to a nullable Unix microsecond column value.
+func micros(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMicro()
+}
+
+func timeFromMicros(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMicro(v.Int64).UTC()
+	return &t
+}
@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecordAuditEvent implements storage.AuditStore.
+func (s *Store) RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	event.ID = uuid.New()
+	event.OccurredAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_events (
+			id, occurred_at, actor_type, actor_id, actor_name, action, target_type, target_id,
+			project_id, request_id, source_ip, before, after
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID.String(), event.OccurredAt.UnixMicro(), string(event.ActorType), nullableID(event.ActorID),
+		event.ActorName, event.Action, event.TargetType, event.TargetID, nullableID(event.ProjectID),
+		event.RequestID, event.SourceIP, nullableJSON(event.Before), nullableJSON(event.After))
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditEvents implements storage.AuditStore.
+func (s *Store) ListAuditEvents(ctx context.Context, params models.AuditQueryParams) ([]models.AuditEvent, int, error) {
+	filter, err := database.ResolveAuditQueryParams(params, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	limit, offset := database.Page(params.Limit, params.Offset)
+
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.ProjectID != nil {
+		conditions = append(conditions, "project_id = ?")
+		args = append(args, filter.ProjectID.String())
+	}
+	if filter.ActorID != nil {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID.String())
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Start != nil {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, filter.Start.UnixMicro())
+	}
+	if filter.End != nil {
+		conditions = append(conditions, "occurred_at <= ?")
+		args = append(args, filter.End.UnixMicro())
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			id, occurred_at, actor_type, actor_id, actor_name, action, target_type, target_id,
+			project_id, request_id, source_ip, before, after, COUNT(*) OVER()
+		FROM audit_events
+		`+where+`
+		ORDER BY occurred_at DESC, seq DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	var total int
+	for rows.Next() {
+		var (
+			event                             models.AuditEvent
+			id                                string
+			occurredAt                        int64
+			actorType                         string
+			actorID, projectID, before, after sql.NullString
+		)
+		err := rows.Scan(
+			&id, &occurredAt, &actorType, &actorID, &event.ActorName, &event.Action, &event.TargetType,
+			&event.TargetID, &projectID, &event.RequestID, &event.SourceIP, &before, &after, &total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+
+		if event.ID, err = uuid.Parse(id); err != nil {
+			return nil, 0, err
+		}
+		if event.ActorID, err = parseNullableID(actorID); err != nil {
+			return nil, 0, err
+		}
+		if event.ProjectID, err = parseNullableID(projectID); err != nil {
+			return nil, 0, err
+		}
+		event.OccurredAt = time.UnixMicro(occurredAt).UTC()
+		event.ActorType = models.AuditActorType(actorType)
+		if before.Valid {
+			event.Before = []byte(before.String)
+		}
+		if after.Valid {
+			event.After = []byte(after.String)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// nullableJSON converts an optional JSON document to a nullable TEXT column value.
+func nullableJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// parseNullableID parses a nullable TEXT ID column.
+func parseNullableID(value sql.NullString) (*uuid.UUID, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportLogs implements storage.ExportStore. Search exports sort like plain queries,
+// as with PostgreSQL.
+//
+// Rows are streamed from a single query on the store's only connection, so other
+// requests wait until the export has been written out.
+func (s *Store) ExportLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams, fn func(models.LogEntry) error) (int64, error) {
+	sortFields, err := database.LogSort(params.Sort)
+	if err != nil {
+		return 0, err
+	}
+	filter, err := database.ResolveQueryParams(params, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	matching, args := matchingLogs(projectID, filter)
+	query := fmt.Sprintf("SELECT %s, NULL, 0 FROM (%s) %s", logColumns, matching, database.OrderByClause(sortFields))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export logs: %w", err)
+	}
+	defer rows.Close()
+
+	var exported int64
+	for rows.Next() {
+		entry, _, err := scanLog(rows)
+		if err != nil {
+			return exported, fmt.Errorf("failed to scan log: %w", err)
+		}
+		if err := fn(*entry); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		return exported, fmt.Errorf("error iterating logs: %w", err)
+	}
+
+	return exported, nil
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FacetLogs implements storage.FacetStore.
+func (s *Store) FacetLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams, size int) (*models.FacetsResponse, error) {
+	filter, err := database.ResolveQueryParams(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.facetLogs(ctx, projectID, filter, size)
+}
+
+// FacetSearch implements storage.FacetStore.
+func (s *Store) FacetSearch(ctx context.Context, projectID uuid.UUID, req models.SearchRequest, size int) (*models.FacetsResponse, error) {
+	filter, err := database.ResolveSearchRequest(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.facetLogs(ctx, projectID, filter, size)
+}
+
+// facetLogs counts the level and source values of the logs matching filter, tagging
+// each row with its facet like the PostgreSQL query; the "total" row carries the match count.
+func (s *Store) facetLogs(ctx context.Context, projectID uuid.UUID, filter database.LogFilter, size int) (*models.FacetsResponse, error) {
+	matching, args := matchingLogs(projectID, filter)
+
+	query := fmt.Sprintf(`
+		WITH filtered AS (%s),
+		level_facets AS (
+			SELECT 'level' AS field, level AS value, COUNT(*) AS value_count
+			FROM filtered
+			GROUP BY level
+			ORDER BY value_count DESC, value
+			LIMIT ?
+		),
+		source_facets AS (
+			SELECT 'source' AS field, source AS value, COUNT(*) AS value_count
+			FROM filtered
+			GROUP BY source
+			ORDER BY value_count DESC, value
+			LIMIT ?
+		)
+		SELECT field, value, value_count FROM level_facets
+		UNION ALL
+		SELECT field, value, value_count FROM source_facets
+		UNION ALL
+		SELECT 'total', '', COUNT(*) FROM filtered
+	`, matching)
+
+	size = database.FacetSize(size)
+	rows, err := s.db.QueryContext(ctx, query, append(args, size, size)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facets: %w", err)
+	}
+	defer rows.Close()
+
+	response := &models.FacetsResponse{
+		Facets: map[string][]models.FacetValue{
+			"level":  {},
+			"source": {},
+		},
+	}
+
+	for rows.Next() {
+		var field string
+		var value models.FacetValue
+		if err := rows.Scan(&field, &value.Value, &value.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
+		}
+		if field == "total" {
+			response.Total = value.Count
+			continue
+		}
+		response.Facets[field] = append(response.Facets[field], value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facets: %w", err)
+	}
+
+	// UNION ALL does not guarantee row order, so re-apply the per-facet ordering
+	for _, values := range response.Facets {
+		sort.SliceStable(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+			return values[i].Value < values[j].Value
+		})
+	}
+
+	return response, nil
+}
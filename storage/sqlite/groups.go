@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GrantProjectGroup implements storage.ProjectGroupStore.
+func (s *Store) GrantProjectGroup(ctx context.Context, projectID uuid.UUID, group string) (*models.ProjectGroup, error) {
+	exists, err := s.projectLive(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, database.ErrProjectNotFound
+	}
+
+	grant := models.ProjectGroup{
+		ProjectID: projectID,
+		Group:     group,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO project_groups (project_id, group_name, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (project_id, group_name) DO NOTHING`,
+		projectID.String(), group, grant.CreatedAt.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant group: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to grant group: %w", err)
+	} else if n == 0 {
+		return nil, database.ErrProjectGroupExists
+	}
+
+	return &grant, nil
+}
+
+// ListProjectGroups implements storage.ProjectGroupStore.
+func (s *Store) ListProjectGroups(ctx context.Context, projectID uuid.UUID) ([]models.ProjectGroup, error) {
+	exists, err := s.projectLive(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, database.ErrProjectNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT group_name, created_at FROM project_groups WHERE project_id = ? ORDER BY group_name",
+		projectID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.ProjectGroup{}
+	for rows.Next() {
+		grant := models.ProjectGroup{ProjectID: projectID}
+		var createdAt int64
+		if err := rows.Scan(&grant.Group, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		grant.CreatedAt = time.UnixMicro(createdAt).UTC()
+		groups = append(groups, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// RevokeProjectGroup implements storage.ProjectGroupStore.
+func (s *Store) RevokeProjectGroup(ctx context.Context, projectID uuid.UUID, group string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM project_groups WHERE project_id = ? AND group_name = ?",
+		projectID.String(), group)
+	if err != nil {
+		return fmt.Errorf("failed to revoke group: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke group: %w", err)
+	}
+	if deleted == 0 {
+		return database.ErrProjectGroupNotFound
+	}
+	return nil
+}
+
+// HasProjectGroup implements storage.ProjectGroupStore.
+func (s *Store) HasProjectGroup(ctx context.Context, projectID uuid.UUID, groups []string) (bool, error) {
+	if len(groups) == 0 {
+		return false, nil
+	}
+
+	args := []any{projectID.String()}
+	for _, group := range groups {
+		args = append(args, group)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(groups)), ", ")
+
+	var ok bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM project_groups g
+			JOIN projects p ON p.id = g.project_id
+			WHERE g.project_id = ? AND p.deleted_at IS NULL AND g.group_name IN (` + placeholders + `)
+		)`
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check groups: %w", err)
+	}
+	return ok, nil
+}
+
+// projectLive reports whether a project exists and isn't deleted.
+func (s *Store) projectLive(ctx context.Context, projectID uuid.UUID) (bool, error) {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM projects WHERE id = ? AND deleted_at IS NULL)"
+	if err := s.db.QueryRowContext(ctx, query, projectID.String()).
+		Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to get project: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TakeTokens implements storage.RateLimitStore.
+func (s *Store) TakeTokens(ctx context.Context, projectID uuid.UUID, bucket string, rate, cost float64) (time.Duration, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var (
+		b         database.TokenBucket
+		updatedAt int64
+	)
+	err = tx.QueryRowContext(ctx,
+		"SELECT tokens, updated_at FROM ingest_rate_buckets WHERE project_id = ? AND bucket = ?",
+		projectID.String(), bucket).Scan(&b.Tokens, &updatedAt)
+	switch {
+	case err == nil:
+		b.UpdatedAt = time.UnixMicro(updatedAt)
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, fmt.Errorf("failed to get rate limit bucket: %w", err)
+	}
+
+	wait := b.Take(time.Now(), rate, cost)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ingest_rate_buckets (project_id, bucket, tokens, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (project_id, bucket) DO UPDATE
+		SET tokens = excluded.tokens, updated_at = excluded.updated_at`,
+		projectID.String(), bucket, b.Tokens, b.UpdatedAt.UnixMicro())
+	if err != nil {
+		return 0, fmt.Errorf("failed to update rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to take rate limit tokens: %w", err)
+	}
+	return wait, nil
+}
+
+// ChargeIngestQuota implements storage.RateLimitStore.
+func (s *Store) ChargeIngestQuota(ctx context.Context, projectID uuid.UUID, day time.Time, usage, quota models.IngestUsage) (bool, error) {
+	// A new day's row is inserted without the quota check
+	if !usage.Within(quota) {
+		return false, nil
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO ingest_usage (project_id, day, entries, bytes)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (project_id, day) DO UPDATE
+		SET entries = entries + excluded.entries, bytes = bytes + excluded.bytes
+		WHERE (?5 = 0 OR entries + excluded.entries <= ?5)
+		  AND (?6 = 0 OR bytes + excluded.bytes <= ?6)`,
+		projectID.String(), database.UTCDay(day).UnixMicro(), usage.Entries, usage.Bytes, quota.Entries, quota.Bytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to charge ingest quota: %w", err)
+	}
+
+	charged, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to charge ingest quota: %w", err)
+	}
+	return charged == 1, nil
+}
+
+// PurgeIngestUsage implements storage.RateLimitStore.
+func (s *Store) PurgeIngestUsage(ctx context.Context, day time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM ingest_usage WHERE day < ?", database.UTCDay(day).UnixMicro())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge ingest usage: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge ingest usage: %w", err)
+	}
+	return purged, nil
+}
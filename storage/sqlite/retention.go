@@ -0,0 +1,230 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SetProjectRetention implements storage.RetentionStore.
+func (s *Store) SetProjectRetention(ctx context.Context, projectID uuid.UUID, days *int) (*models.Project, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE projects
+		SET retention_days = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+		RETURNING `+projectColumns,
+		days, time.Now().UTC().UnixMicro(), projectID.String())
+
+	project, err := scanProject(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to set retention: %w", err)
+	}
+	return project, nil
+}
+
+// GetRetentionStatus implements storage.RetentionStore.
+func (s *Store) GetRetentionStatus(ctx context.Context, projectID uuid.UUID, now time.Time) (*models.RetentionStatus, error) {
+	status := models.RetentionStatus{ProjectID: projectID}
+
+	var retentionDays sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT retention_days FROM projects WHERE id = ? AND deleted_at IS NULL",
+		projectID.String()).Scan(&retentionDays)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get retention status: %w", err)
+	}
+
+	// Without a policy the cutoff is NULL and no log counts as expired
+	var cutoff any
+	if retentionDays.Valid {
+		days := int(retentionDays.Int64)
+		expiry := database.RetentionCutoff(now, days)
+		status.RetentionDays = &days
+		status.Cutoff = &expiry
+		cutoff = expiry.UnixMicro()
+	}
+
+	var oldest sql.NullInt64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT MIN(timestamp), COUNT(*) FILTER (WHERE timestamp < ?)
+		FROM logs
+		WHERE project_id = ?`,
+		cutoff, projectID.String()).Scan(&oldest, &status.ExpiredLogs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention status: %w", err)
+	}
+	status.OldestLog = timeFromMicros(oldest)
+
+	var (
+		run                  models.RetentionRun
+		runID                string
+		startedAt, runCutoff int64
+		finishedAt           sql.NullInt64
+		runError             sql.NullString
+	)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT id, started_at, finished_at, cutoff, deleted_rows, archived_rows, error
+		FROM retention_runs
+		WHERE project_id = ?
+		ORDER BY started_at DESC
+		LIMIT 1`,
+		projectID.String()).Scan(&runID, &startedAt, &finishedAt, &runCutoff, &run.DeletedRows, &run.ArchivedRows, &runError)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &status, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get retention status: %w", err)
+	}
+
+	if run.ID, err = uuid.Parse(runID); err != nil {
+		return nil, fmt.Errorf("failed to get retention status: %w", err)
+	}
+	run.ProjectID = projectID
+	run.StartedAt = time.UnixMicro(startedAt).UTC()
+	run.FinishedAt = timeFromMicros(finishedAt)
+	run.Cutoff = time.UnixMicro(runCutoff).UTC()
+	run.Error = runError.String
+	status.LastRun = &run
+
+	return &status, nil
+}
+
+// ListRetentionPolicies returns every project that has a retention policy,
+// as database.DB.ListRetentionPolicies does.
+func (s *Store) ListRetentionPolicies(ctx context.Context) ([]database.RetentionPolicy, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, retention_days FROM projects WHERE retention_days IS NOT NULL ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []database.RetentionPolicy
+	for rows.Next() {
+		var (
+			policy database.RetentionPolicy
+			id     string
+		)
+		if err := rows.Scan(&id, &policy.RetentionDays); err != nil {
+			return nil, fmt.Errorf("failed to scan retention policies: %w", err)
+		}
+		if policy.ProjectID, err = uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("failed to scan retention policies: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan retention policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// PurgeExpiredLogs deletes up to batchSize of a project's logs older than cutoff.
+// Returns the number of rows deleted.
+func (s *Store) PurgeExpiredLogs(ctx context.Context, projectID uuid.UUID, cutoff time.Time, batchSize int) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM logs
+		WHERE seq IN (
+			SELECT seq FROM logs
+			WHERE project_id = ? AND timestamp < ?
+			LIMIT ?
+		)`,
+		projectID.String(), cutoff.UnixMicro(), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge logs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// NextLogTime returns the earliest timestamp of a project's logs with
+// from <= timestamp < before, or nil if there are none.
+func (s *Store) NextLogTime(ctx context.Context, projectID uuid.UUID, from, before time.Time) (*time.Time, error) {
+	var next sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT MIN(timestamp) FROM logs WHERE project_id = ? AND timestamp >= ? AND timestamp < ?",
+		projectID.String(), from.UnixMicro(), before.UnixMicro()).Scan(&next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find next log: %w", err)
+	}
+
+	return timeFromMicros(next), nil
+}
+
+// PurgeLogRange deletes up to batchSize logs in r.
+// Call repeatedly until it returns fewer than batchSize rows.
+// Returns the number of rows deleted.
+func (s *Store) PurgeLogRange(ctx context.Context, r database.LogRange, batchSize int) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM logs
+		WHERE seq IN (
+			SELECT seq FROM logs
+			WHERE project_id = ? AND timestamp >= ? AND timestamp < ?
+			  AND (created_at < ? OR created_at IS NULL)
+			LIMIT ?
+		)`,
+		r.ProjectID.String(), r.Start.UnixMicro(), r.End.UnixMicro(), r.CreatedBefore.UnixMicro(), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge logs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// StartRetentionRun records the start of a purge and returns the run's ID.
+func (s *Store) StartRetentionRun(ctx context.Context, projectID uuid.UUID, cutoff time.Time) (uuid.UUID, error) {
+	runID := uuid.New()
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO retention_runs (id, project_id, started_at, cutoff) VALUES (?, ?, ?, ?)",
+		runID.String(), projectID.String(), time.Now().UnixMicro(), cutoff.UnixMicro())
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to start retention run: %w", err)
+	}
+
+	return runID, nil
+}
+
+// FinishRetentionRun records the outcome of a purge started with StartRetentionRun.
+// runErr is stored as the run's error message when non-nil.
+func (s *Store) FinishRetentionRun(ctx context.Context, runID uuid.UUID, deleted, archived int64, runErr error) error {
+	var message *string
+	if runErr != nil {
+		msg := runErr.Error()
+		message = &msg
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE retention_runs
+		SET finished_at = ?, deleted_rows = ?, archived_rows = ?, error = ?
+		WHERE id = ?`,
+		time.Now().UnixMicro(), deleted, archived, message, runID.String())
+	if err != nil {
+		return fmt.Errorf("failed to finish retention run: %w", err)
+	}
+
+	return nil
+}
+
+// PruneRetentionRuns deletes run history started before before.
+// Returns the number of runs deleted.
+func (s *Store) PruneRetentionRuns(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM retention_runs WHERE started_at < ?", before.UnixMicro())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune retention runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
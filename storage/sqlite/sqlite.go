@@ -0,0 +1,901 @@
+// Package sqlite provides a storage.Store backed by an embedded SQLite database, for
+// single-node deployments that don't want to run PostgreSQL (DATABASE_URL=sqlite://path).
+//
+// Filters, sorting and pagination are validated exactly like the PostgreSQL backend.
+// Search uses an FTS5 index over log messages with the porter stemmer, and rank is
+// the negated bm25 score, so higher is more relevant as with ts_rank. Unlike
+// PostgreSQL's english configuration, stop words are indexed and can be searched for,
+// and a project's search_language setting has no effect. Facets, export, log context and
+// retention (storage.FacetStore, ExportStore, ContextStore and RetentionStore) behave as
+// they do on PostgreSQL, and the store is a retention.Store for the purge worker.
+//
+// Timestamps are stored as Unix microseconds (the precision PostgreSQL keeps) and
+// IDs as lowercase UUID strings, which sort in the same order as PostgreSQL's uuid type.
+// The schema is created on Open; there is no separate migration step.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS projects (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	scopes TEXT NOT NULL, -- space-separated
+	salt BLOB NOT NULL,
+	hash BLOB NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_used_at INTEGER,
+	expires_at INTEGER,
+	revoked_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_project_id ON api_keys(project_id);
+
+CREATE TABLE IF NOT EXISTS admin_users (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	token_prefix TEXT NOT NULL,
+	token_salt BLOB NOT NULL,
+	token_hash BLOB NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_used_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_admin_users_token_prefix ON admin_users(token_prefix);
+
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE, -- lowercased
+	name TEXT NOT NULL,
+	password_hash BLOB NOT NULL,
+	created_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_prefix TEXT NOT NULL,
+	token_salt BLOB NOT NULL,
+	token_hash BLOB NOT NULL,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_token_prefix ON sessions(token_prefix);
+
+CREATE TABLE IF NOT EXISTS organizations (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+	org_id TEXT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (org_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_memberships_user_id ON memberships(user_id);
+
+CREATE TABLE IF NOT EXISTS project_groups (
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	group_name TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (project_id, group_name)
+);
+
+-- seq is an explicit rowid so the FTS index keeps pointing at the right rows after VACUUM
+CREATE TABLE IF NOT EXISTS logs (
+	seq INTEGER PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	level TEXT NOT NULL CHECK (length(level) <= 20),
+	message TEXT NOT NULL,
+	source TEXT NOT NULL DEFAULT '' CHECK (length(source) <= 100),
+	timestamp INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_logs_project_timestamp ON logs(project_id, timestamp DESC);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS logs_fts USING fts5(
+	message, content='logs', content_rowid='seq', tokenize='porter unicode61'
+);
+
+-- Logs are never updated, so inserts and deletes are all the index has to follow
+CREATE TRIGGER IF NOT EXISTS logs_fts_insert AFTER INSERT ON logs BEGIN
+	INSERT INTO logs_fts(rowid, message) VALUES (new.seq, new.message);
+END;
+CREATE TRIGGER IF NOT EXISTS logs_fts_delete AFTER DELETE ON logs BEGIN
+	INSERT INTO logs_fts(logs_fts, rowid, message) VALUES ('delete', old.seq, old.message);
+END;
+
+-- Append-only trail of administrative actions. IDs aren't foreign keys, so events
+-- outlive what they describe; seq orders events recorded in the same microsecond.
+CREATE TABLE IF NOT EXISTS audit_events (
+	seq INTEGER PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE,
+	occurred_at INTEGER NOT NULL,
+	actor_type TEXT NOT NULL,
+	actor_id TEXT,
+	actor_name TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target_type TEXT NOT NULL,
+	target_id TEXT NOT NULL,
+	project_id TEXT,
+	request_id TEXT NOT NULL,
+	source_ip TEXT NOT NULL,
+	before TEXT,
+	after TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_project ON audit_events(project_id, occurred_at DESC);
+
+CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+-- Counters behind per-project ingestion limits (see database.TokenBucket)
+CREATE TABLE IF NOT EXISTS ingest_rate_buckets (
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	bucket TEXT NOT NULL,
+	tokens REAL NOT NULL,
+	updated_at INTEGER NOT NULL,
+	PRIMARY KEY (project_id, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS ingest_usage (
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	day INTEGER NOT NULL, -- midnight UTC
+	entries INTEGER NOT NULL,
+	bytes INTEGER NOT NULL,
+	PRIMARY KEY (project_id, day)
+);
+
+-- History of retention purges, shown by GET /projects/:id/retention
+CREATE TABLE IF NOT EXISTS retention_runs (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	started_at INTEGER NOT NULL,
+	finished_at INTEGER,
+	cutoff INTEGER NOT NULL,
+	deleted_rows INTEGER NOT NULL DEFAULT 0,
+	archived_rows INTEGER NOT NULL DEFAULT 0,
+	error TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_retention_runs_project_started ON retention_runs(project_id, started_at DESC);
+`
+
+const logColumns = "id, project_id, level, message, source, timestamp"
+
+// Store is a SQLite-backed storage.Store. Safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (creating if needed) the SQLite database at path and ensures the schema exists.
+// path may be ":memory:" for a private in-memory database.
+//
+// SQLite allows a single writer, so the store uses one connection and requests are
+// serialized. That comfortably covers the single-node deployments it is meant for.
+func Open(ctx context.Context, path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite database path is required")
+	}
+
+	db, err := sql.Open("sqlite", dsn(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxIdleTime(0)
+	db.SetConnMaxLifetime(0)
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	for _, column := range addedColumns {
+		if err := addColumn(ctx, db, column.table, column.name, column.definition); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	return &Store{db: db}, nil
+}
+
+// addedColumns were added to tables after they were first created; Open adds them
+// to older databases in order.
+var addedColumns = []struct{ table, name, definition string }{
+	{"projects", "org_id", "TEXT REFERENCES organizations(id) ON DELETE SET NULL"},
+	{"projects", "retention_days", "INTEGER CHECK (retention_days > 0)"},
+	{"projects", "settings", "TEXT NOT NULL DEFAULT '{}'"}, // JSON models.ProjectSettings
+	{"projects", "deleted_at", "INTEGER"},                  // NULL while the project is live
+	{"logs", "created_at", "INTEGER"},                      // when the row was stored; NULL for older rows
+}
+
+// addColumn adds a column to a table created by an older version of the schema,
+// since CREATE TABLE IF NOT EXISTS leaves existing tables as they are.
+func addColumn(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	var exists bool
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pragma_table_info(?) WHERE name = ?)", table, column).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to inspect sqlite schema: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column+" "+definition); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// dsn enables foreign keys (for ON DELETE CASCADE), write-ahead logging and a busy
+// timeout, keeping any query parameters already present in path.
+func dsn(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// InsertLogsBatch stores logs in a single transaction: if any entry is rejected
+// (unknown project, level or source too long) nothing is stored and a
+// *database.BatchInsertError is returned.
+func (s *Store) InsertLogsBatch(ctx context.Context, logs []models.LogEntry) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO logs ("+logColumns+", created_at) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	createdAt := time.Now().UnixMicro()
+	for i, entry := range logs {
+		_, err := stmt.ExecContext(ctx, entry.ID.String(), entry.ProjectID.String(), entry.Level,
+			entry.Message, entry.Source, entry.Timestamp.UnixMicro(), createdAt)
+		if err != nil {
+			return &database.BatchInsertError{FailedIndex: i, TotalLogs: len(logs), Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit logs: %w", err)
+	}
+	return nil
+}
+
+// QueryLogs implements storage.LogStore.
+func (s *Store) QueryLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams) ([]models.LogEntry, int64, error) {
+	if params.Search != "" {
+		return s.SearchLogs(ctx, projectID, models.SearchRequest{
+			Query:     params.Search,
+			Level:     params.Level,
+			Source:    params.Source,
+			StartTime: params.StartTime,
+			EndTime:   params.EndTime,
+			TZ:        params.TZ,
+			Sort:      params.Sort,
+			Limit:     params.Limit,
+			Offset:    params.Offset,
+		})
+	}
+
+	sortFields, err := database.LogSort(params.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter, err := database.ResolveQueryParams(params, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where, args := whereClause(projectID, filter)
+	limit, offset := database.Page(params.Limit, params.Offset)
+
+	query := fmt.Sprintf(`
+		SELECT %s, NULL, COUNT(*) OVER()
+		FROM logs
+		%s
+		%s
+		LIMIT ? OFFSET ?
+	`, logColumns, where, database.OrderByClause(sortFields))
+
+	return s.queryLogs(ctx, query, append(args, limit, offset)...)
+}
+
+// SearchLogs implements storage.LogStore.
+func (s *Store) SearchLogs(ctx context.Context, projectID uuid.UUID, req models.SearchRequest) ([]models.LogEntry, int64, error) {
+	sortFields, err := database.SearchSort(req.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter, err := database.ResolveSearchRequest(req, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where, args := whereClause(projectID, filter)
+	limit, offset := database.Page(req.Limit, req.Offset)
+
+	// bm25 is only valid directly over the FTS table, so rank is computed in a
+	// subquery; the outer query can then sort by it like any other column
+	query := fmt.Sprintf(`
+		SELECT %s, rank, COUNT(*) OVER()
+		FROM (
+			SELECT logs.*, -bm25(logs_fts) AS rank
+			FROM logs_fts JOIN logs ON logs.seq = logs_fts.rowid
+			WHERE logs_fts MATCH ?
+		)
+		%s
+		%s
+		LIMIT ? OFFSET ?
+	`, logColumns, where, database.OrderByClause(sortFields))
+
+	args = append([]any{matchExpression(filter.Terms)}, args...)
+	return s.queryLogs(ctx, query, append(args, limit, offset)...)
+}
+
+// GetLog implements storage.LogStore.
+func (s *Store) GetLog(ctx context.Context, projectID, logID uuid.UUID) (*models.LogEntry, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+logColumns+", NULL, 0 FROM logs WHERE id = ? AND project_id = ?",
+		logID.String(), projectID.String())
+
+	entry, _, err := scanLog(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrLogNotFound
+		}
+		return nil, fmt.Errorf("failed to get log: %w", err)
+	}
+	return entry, nil
+}
+
+// GetLogContext implements storage.ContextStore. Neighbours are ordered by
+// (timestamp, id), so entries logged in the same microsecond keep a stable order.
+func (s *Store) GetLogContext(ctx context.Context, projectID, logID uuid.UUID, params models.LogContextParams) (*models.LogContextResponse, error) {
+	target, err := s.GetLog(ctx, projectID, logID)
+	if err != nil {
+		return nil, err
+	}
+
+	// comparison is "<" or ">" and direction is the matching ORDER BY direction
+	neighbours := func(comparison, direction string, count int) ([]models.LogEntry, error) {
+		conditions := "project_id = ? AND (timestamp, id) " + comparison + " (?, ?)"
+		args := []any{projectID.String(), target.Timestamp.UnixMicro(), target.ID.String()}
+		if params.SameSource {
+			conditions += " AND source = ?"
+			args = append(args, target.Source)
+		}
+
+		query := fmt.Sprintf(`
+			SELECT %s, NULL, 0
+			FROM logs
+			WHERE %s
+			ORDER BY timestamp %s, id %s
+			LIMIT ?
+		`, logColumns, conditions, direction, direction)
+
+		logs, _, err := s.queryLogs(ctx, query, append(args, count)...)
+		return logs, err
+	}
+
+	before, err := neighbours("<", "DESC", database.ContextSize(params.Before))
+	if err != nil {
+		return nil, err
+	}
+	// Fetched newest first so LIMIT keeps the closest entries; return oldest first
+	for i, j := 0, len(before)-1; i < j; i, j = i+1, j-1 {
+		before[i], before[j] = before[j], before[i]
+	}
+
+	after, err := neighbours(">", "ASC", database.ContextSize(params.After))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LogContextResponse{Log: *target, Before: before, After: after}, nil
+}
+
+// CreateProject implements storage.ProjectStore.
+func (s *Store) CreateProject(ctx context.Context, name string) (*models.Project, error) {
+	return s.createProject(ctx, name, nil)
+}
+
+// createProject creates a project, owned by orgID if it isn't nil, with its "default" key.
+func (s *Store) createProject(ctx context.Context, name string, orgID *uuid.UUID) (*models.Project, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	project := models.Project{
+		ID:        uuid.New(),
+		Name:      name,
+		OrgID:     orgID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO projects (id, name, org_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		project.ID.String(), project.Name, nullableID(orgID), now.UnixMicro(), now.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	key, err := insertAPIKey(ctx, tx, project.ID, database.DefaultAPIKeyName, models.AllScopes, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	project.APIKey = key.Key
+	return &project, nil
+}
+
+// ListProjects implements storage.ProjectStore. Stats are aggregated for the page's
+// projects only; StorageBytes counts the bytes of each log's level, message and source.
+func (s *Store) ListProjects(ctx context.Context, params models.ProjectListParams) ([]models.Project, int, error) {
+	sortFields, err := database.ProjectSort(params.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	limit, offset := database.Page(params.Limit, params.Offset)
+	orderBy := database.OrderByClause(sortFields)
+
+	query := fmt.Sprintf(`
+		SELECT
+			p.id, p.name, p.org_id, p.retention_days, p.settings, p.created_at, p.updated_at,
+			COUNT(l.project_id), MAX(l.timestamp),
+			COALESCE(SUM(length(CAST(l.level AS BLOB)) + length(CAST(l.message AS BLOB)) + length(CAST(l.source AS BLOB))), 0),
+			p.total_count
+		FROM (
+			SELECT %s, COUNT(*) OVER() AS total_count
+			FROM projects
+			WHERE deleted_at IS NULL AND instr(lower(name), lower(?)) > 0
+			%s
+			LIMIT ? OFFSET ?
+		) p
+		LEFT JOIN (
+			SELECT project_id, level, message, source, timestamp FROM logs
+		) l ON l.project_id = p.id
+		GROUP BY p.id
+		%s
+	`, projectColumns, orderBy, orderBy)
+
+	rows, err := s.db.QueryContext(ctx, query, params.Search, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	var total int
+	for rows.Next() {
+		var (
+			stats     models.ProjectStats
+			lastLogAt sql.NullInt64
+		)
+		project, err := scanProject(scanFunc(func(dest ...any) error {
+			return rows.Scan(append(dest, &stats.LogCount, &lastLogAt, &stats.StorageBytes, &total)...)
+		}))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan project: %w", err)
+		}
+		stats.LastLogAt = timeFromMicros(lastLogAt)
+		project.Stats = &stats
+		projects = append(projects, *project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating projects: %w", err)
+	}
+
+	return projects, total, nil
+}
+
+// GetProject implements storage.ProjectStore.
+func (s *Store) GetProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+projectColumns+" FROM projects WHERE id = ? AND deleted_at IS NULL", projectID.String())
+
+	project, err := scanProject(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return project, nil
+}
+
+// UpdateProject implements storage.ProjectStore.
+func (s *Store) UpdateProject(ctx context.Context, projectID uuid.UUID, req models.UpdateProjectRequest) (*models.Project, error) {
+	var settings any
+	if req.Settings != nil {
+		encoded, err := json.Marshal(req.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode project settings: %w", err)
+		}
+		settings = string(encoded)
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE projects
+		SET name = COALESCE(?, name),
+			retention_days = CASE WHEN ? THEN ? ELSE retention_days END,
+			settings = COALESCE(?, settings),
+			updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+		RETURNING `+projectColumns,
+		req.Name, req.SetRetention, req.RetentionDays, settings,
+		time.Now().UTC().UnixMicro(), projectID.String())
+
+	project, err := scanProject(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+	return project, nil
+}
+
+// DeleteProject implements storage.ProjectStore.
+func (s *Store) DeleteProject(ctx context.Context, projectID uuid.UUID) error {
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE projects SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+		now.UnixMicro(), now.UnixMicro(), projectID.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	if deleted == 0 {
+		return database.ErrProjectNotFound
+	}
+	return nil
+}
+
+// RestoreProject implements storage.ProjectStore.
+func (s *Store) RestoreProject(ctx context.Context, projectID uuid.UUID, deletedSince time.Time) (*models.Project, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE projects
+		SET deleted_at = NULL, updated_at = ?
+		WHERE id = ? AND deleted_at >= ?
+		RETURNING `+projectColumns,
+		time.Now().UTC().UnixMicro(), projectID.String(), deletedSince.UnixMicro())
+
+	project, err := scanProject(row)
+	if err == nil {
+		return project, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to restore project: %w", err)
+	}
+
+	var live bool
+	err = s.db.QueryRowContext(ctx,
+		"SELECT deleted_at IS NULL FROM projects WHERE id = ?", projectID.String()).Scan(&live)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, database.ErrProjectNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to restore project: %w", err)
+	case live:
+		return nil, database.ErrProjectNotDeleted
+	}
+	return nil, database.ErrProjectNotFound
+}
+
+// GetDeletedProject implements storage.ProjectStore.
+func (s *Store) GetDeletedProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error) {
+	var deleted bool
+	project, err := scanProject(scanFunc(func(dest ...any) error {
+		return s.db.QueryRowContext(ctx,
+			"SELECT "+projectColumns+", deleted_at IS NOT NULL FROM projects WHERE id = ?",
+			projectID.String()).Scan(append(dest, &deleted)...)
+	}))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get deleted project: %w", err)
+	}
+	if !deleted {
+		return nil, database.ErrProjectNotDeleted
+	}
+	return project, nil
+}
+
+// ListDeletedProjects implements storage.DeletedProjectStore.
+func (s *Store) ListDeletedProjects(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id FROM projects WHERE deleted_at < ? ORDER BY deleted_at", deletedBefore.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted projects: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted project: %w", err)
+		}
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deleted project: %w", err)
+		}
+		ids = append(ids, parsed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted projects: %w", err)
+	}
+
+	return ids, nil
+}
+
+// PurgeDeletedProjectLogs implements storage.DeletedProjectStore.
+func (s *Store) PurgeDeletedProjectLogs(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time, batchSize int) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM logs
+		WHERE seq IN (
+			SELECT seq FROM logs
+			WHERE project_id = ?1
+			  AND EXISTS (SELECT 1 FROM projects WHERE id = ?1 AND deleted_at < ?2)
+			LIMIT ?3
+		)`,
+		projectID.String(), deletedBefore.UnixMicro(), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted project logs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// PurgeDeletedProject implements storage.DeletedProjectStore.
+// The project's remaining logs, API keys and group grants are deleted with it.
+func (s *Store) PurgeDeletedProject(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM projects WHERE id = ? AND deleted_at < ?", projectID.String(), deletedBefore.UnixMicro())
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted project: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted project: %w", err)
+	}
+	if purged == 0 {
+		return database.ErrProjectNotFound
+	}
+	return nil
+}
+
+// whereClause restricts logs to projectID and the filter's level, source and time bounds.
+// Search terms are matched separately, against the FTS index.
+func whereClause(projectID uuid.UUID, filter database.LogFilter) (string, []any) {
+	conditions := []string{"project_id = ?"}
+	args := []any{projectID.String()}
+
+	if filter.Level != "" {
+		conditions = append(conditions, "level = ?")
+		args = append(args, filter.Level)
+	}
+	if filter.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
+	if filter.Start != nil {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.Start.UnixMicro())
+	}
+	if filter.End != nil {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, filter.End.UnixMicro())
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// matchingLogs returns a query selecting every column of the logs matching filter,
+// searching the FTS index when it has terms, and its arguments.
+func matchingLogs(projectID uuid.UUID, filter database.LogFilter) (string, []any) {
+	where, args := whereClause(projectID, filter)
+	if len(filter.Terms) == 0 {
+		return "SELECT * FROM logs " + where, args
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT logs.* FROM logs_fts JOIN logs ON logs.seq = logs_fts.rowid
+			WHERE logs_fts MATCH ?
+		)
+		` + where
+	return query, append([]any{matchExpression(filter.Terms)}, args...)
+}
+
+// matchExpression quotes each term as an FTS5 string, so operators and punctuation
+// in user input are matched literally. Space-separated strings must all match.
+func matchExpression(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+func (s *Store) queryLogs(ctx context.Context, query string, args ...any) ([]models.LogEntry, int64, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []models.LogEntry{}
+	var total int64
+	for rows.Next() {
+		entry, count, err := scanLog(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan log: %w", err)
+		}
+		total = count
+		logs = append(logs, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating logs: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFunc adapts a function to rowScanner, so callers can scan columns selected after
+// the ones a scan helper reads.
+type scanFunc func(dest ...any) error
+
+func (f scanFunc) Scan(dest ...any) error {
+	return f(dest...)
+}
+
+// scanLog scans logColumns followed by the rank (NULL outside search) and total count.
+func scanLog(row rowScanner) (*models.LogEntry, int64, error) {
+	var (
+		entry            models.LogEntry
+		id, projectID    string
+		timestamp, total int64
+		rank             sql.NullFloat64
+	)
+
+	err := row.Scan(&id, &projectID, &entry.Level, &entry.Message, &entry.Source, &timestamp, &rank, &total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if entry.ID, err = uuid.Parse(id); err != nil {
+		return nil, 0, err
+	}
+	if entry.ProjectID, err = uuid.Parse(projectID); err != nil {
+		return nil, 0, err
+	}
+	entry.Timestamp = time.UnixMicro(timestamp).UTC()
+	if rank.Valid {
+		entry.Rank = &rank.Float64
+	}
+
+	return &entry, total, nil
+}
+
+// projectColumns are the columns scanProject reads, in order.
+const projectColumns = "id, name, org_id, retention_days, settings, created_at, updated_at"
+
+func scanProjects(rows *sql.Rows) ([]models.Project, error) {
+	projects := []models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, *project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func scanProject(row rowScanner) (*models.Project, error) {
+	var (
+		project              models.Project
+		id, settings         string
+		orgID                sql.NullString
+		retentionDays        sql.NullInt64
+		createdAt, updatedAt int64
+	)
+
+	if err := row.Scan(&id, &project.Name, &orgID, &retentionDays, &settings, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(settings), &project.Settings); err != nil {
+		return nil, fmt.Errorf("failed to decode project settings: %w", err)
+	}
+
+	var err error
+	if project.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+	if orgID.Valid {
+		parsed, err := uuid.Parse(orgID.String)
+		if err != nil {
+			return nil, err
+		}
+		project.OrgID = &parsed
+	}
+	if retentionDays.Valid {
+		days := int(retentionDays.Int64)
+		project.RetentionDays = &days
+	}
+	project.CreatedAt = time.UnixMicro(createdAt).UTC()
+	project.UpdatedAt = time.UnixMicro(updatedAt).UTC()
+
+	return &project, nil
+}
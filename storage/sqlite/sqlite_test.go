@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"jazz/models"
+	"jazz/storage"
+	"jazz/storage/storagetest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+
+	store, err := Open(context.Background(), path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	return store
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		return openTestStore(t, filepath.Join(t.TempDir(), "jazz.db"))
+	})
+}
+
+func TestOpen_InMemory(t *testing.T) {
+	store := openTestStore(t, ":memory:")
+
+	_, err := store.CreateProject(context.Background(), "Test Project")
+	require.NoError(t, err)
+
+	projects, _, err := store.ListProjects(context.Background(), models.ProjectListParams{})
+	require.NoError(t, err)
+	assert.Len(t, projects, 1)
+}
+
+func TestOpen_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jazz.db")
+
+	store, err := Open(ctx, path)
+	require.NoError(t, err)
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database down", Timestamp: time.Now()},
+	}))
+	require.NoError(t, store.Close())
+
+	// Opening an existing database keeps its data and search index
+	store = openTestStore(t, path)
+	_, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestSearchLogs_Stemming(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t, ":memory:")
+
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Connections refused by upstream", Timestamp: time.Now()},
+	}))
+
+	// Terms match other forms of the same word, and FTS5 syntax in input is literal
+	for _, query := range []string{"connection", "refusing", "upstream*", "-refused", "upstream:"} {
+		_, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: query})
+		require.NoError(t, err, query)
+		assert.Equal(t, int64(1), total, query)
+	}
+}
+
+func TestDeleteProject_KeepsSearchIndexConsistent(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t, ":memory:")
+
+	project, err := store.CreateProject(ctx, "Test Project")
+	require.NoError(t, err)
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database down", Timestamp: time.Now()},
+	}))
+	require.NoError(t, store.DeleteProject(ctx, project.ID))
+	cutoff := time.Now().Add(time.Minute)
+	_, err = store.PurgeDeletedProjectLogs(ctx, project.ID, cutoff, 10)
+	require.NoError(t, err)
+	require.NoError(t, store.PurgeDeletedProject(ctx, project.ID, cutoff))
+
+	// Purged logs go through the trigger, leaving no stale index entries
+	_, err = store.db.ExecContext(ctx, "INSERT INTO logs_fts(logs_fts, rank) VALUES ('integrity-check', 1)")
+	require.NoError(t, err)
+
+	var indexed int
+	require.NoError(t, store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM logs_fts WHERE logs_fts MATCH 'database'").Scan(&indexed))
+	assert.Zero(t, indexed)
+}
@@ -0,0 +1,546 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"jazz/apikey"
+	"jazz/database"
+	"jazz/models"
+	"jazz/password"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userColumns are the columns scanUser reads, in order.
+const userColumns = "id, email, name, created_at"
+
+// membershipColumns are the columns scanMembership reads, in order, from memberships m
+// joined to users u.
+const membershipColumns = "m.org_id, m.user_id, u.email, u.name, m.role, m.created_at"
+
+// CreateUser implements storage.UserStore.
+func (s *Store) CreateUser(ctx context.Context, email, name, plaintext string) (*models.User, error) {
+	hash, err := password.Hash(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		ID:        uuid.New(),
+		Email:     models.NormalizeEmail(email),
+		Name:      name,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, email, name, password_hash, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (email) DO NOTHING`,
+		user.ID.String(), user.Email, name, hash, user.CreatedAt.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	} else if n == 0 {
+		return nil, database.ErrUserExists
+	}
+
+	return &user, nil
+}
+
+// AuthenticateUser implements storage.UserStore.
+func (s *Store) AuthenticateUser(ctx context.Context, email, plaintext string) (*models.User, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+", password_hash FROM users WHERE email = ?", models.NormalizeEmail(email))
+
+	var hash []byte
+	user, err := scanUser(row, &hash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Unknown emails have a nil hash, which still costs a comparison
+	if !password.Verify(hash, plaintext) {
+		return nil, database.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// GetUserByEmail implements storage.UserStore.
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE email = ?", models.NormalizeEmail(email)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateSession implements storage.UserStore. The user's expired sessions are deleted.
+func (s *Store) CreateSession(ctx context.Context, userID uuid.UUID, ttl time.Duration) (*models.Session, error) {
+	plaintext, secret, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := scanUser(s.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE id = ?", userID.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = ? AND expires_at <= ?",
+		userID.String(), now.UnixMicro()); err != nil {
+		return nil, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	session := models.Session{
+		ID:        uuid.New(),
+		User:      *user,
+		Token:     plaintext,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl).Truncate(time.Microsecond),
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO sessions (id, user_id, token_prefix, token_salt, token_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		session.ID.String(), userID.String(), secret.Prefix, secret.Salt, secret.Hash,
+		session.CreatedAt.UnixMicro(), session.ExpiresAt.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// AuthenticateSession implements storage.UserStore.
+func (s *Store) AuthenticateSession(ctx context.Context, token string) (*models.Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.created_at, s.expires_at, u.id, u.email, u.name, u.created_at, s.token_salt, s.token_hash
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_prefix = ? AND s.expires_at > ?`,
+		apikey.Prefix(token), time.Now().UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			session                      models.Session
+			id, userID                   string
+			createdAt, expiresAt, userAt int64
+			secret                       apikey.Key
+		)
+		err := rows.Scan(&id, &createdAt, &expiresAt, &userID, &session.User.Email, &session.User.Name, &userAt,
+			&secret.Salt, &secret.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if !secret.Verify(token) {
+			continue
+		}
+
+		if session.ID, err = uuid.Parse(id); err != nil {
+			return nil, err
+		}
+		if session.User.ID, err = uuid.Parse(userID); err != nil {
+			return nil, err
+		}
+		session.CreatedAt = time.UnixMicro(createdAt).UTC()
+		session.ExpiresAt = time.UnixMicro(expiresAt).UTC()
+		session.User.CreatedAt = time.UnixMicro(userAt).UTC()
+		return &session, nil
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return nil, database.ErrInvalidSession
+}
+
+// DeleteSession implements storage.UserStore.
+func (s *Store) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", sessionID.String()); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// CreateOrganization implements storage.OrganizationStore.
+func (s *Store) CreateOrganization(ctx context.Context, name string, ownerID uuid.UUID) (*models.Organization, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = ?)", ownerID.String()).
+		Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !exists {
+		return nil, database.ErrUserNotFound
+	}
+
+	org := models.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		Role:      models.RoleOwner,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO organizations (id, name, created_at) VALUES (?, ?, ?)",
+		org.ID.String(), name, org.CreatedAt.UnixMicro()); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO memberships (org_id, user_id, role, created_at) VALUES (?, ?, ?, ?)",
+		org.ID.String(), ownerID.String(), models.RoleOwner, org.CreatedAt.UnixMicro()); err != nil {
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return &org, nil
+}
+
+// ListOrganizations implements storage.OrganizationStore. Oldest first.
+func (s *Store) ListOrganizations(ctx context.Context, userID uuid.UUID) ([]models.Organization, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT o.id, o.name, m.role, o.created_at
+		FROM organizations o
+		JOIN memberships m ON m.org_id = o.id
+		WHERE m.user_id = ?
+		ORDER BY o.created_at, o.id`,
+		userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		var (
+			org       models.Organization
+			id        string
+			createdAt int64
+		)
+		if err := rows.Scan(&id, &org.Name, &org.Role, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		if org.ID, err = uuid.Parse(id); err != nil {
+			return nil, err
+		}
+		org.CreatedAt = time.UnixMicro(createdAt).UTC()
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// GetMembership implements storage.OrganizationStore.
+func (s *Store) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*models.Membership, error) {
+	return getMembership(ctx, s.db, orgID, userID)
+}
+
+// ListMembers implements storage.OrganizationStore. Oldest first.
+func (s *Store) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.Membership, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+membershipColumns+`
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = ?
+		ORDER BY m.created_at, u.email`,
+		orgID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.Membership{}
+	for rows.Next() {
+		member, err := scanMembership(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, *member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating members: %w", err)
+	}
+
+	// Organizations always have an owner, so no members means no organization
+	if len(members) == 0 {
+		return nil, database.ErrOrganizationNotFound
+	}
+	return members, nil
+}
+
+// AddMember implements storage.OrganizationStore.
+func (s *Store) AddMember(ctx context.Context, orgID uuid.UUID, email string, role models.Role) (*models.Membership, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM organizations WHERE id = ?)", orgID.String()).
+		Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if !exists {
+		return nil, database.ErrOrganizationNotFound
+	}
+
+	var userID string
+	err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE email = ?", models.NormalizeEmail(email)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO memberships (org_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (org_id, user_id) DO NOTHING`,
+		orgID.String(), userID, role, time.Now().UTC().UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	} else if n == 0 {
+		return nil, database.ErrMemberExists
+	}
+
+	member, err := getMembership(ctx, tx, orgID, uuid.MustParse(userID))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	return member, nil
+}
+
+// UpdateMemberRole implements storage.OrganizationStore.
+func (s *Store) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.Role) (*models.Membership, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	member, err := getMembership(ctx, tx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.RoleOwner {
+		if err := checkNotLastOwner(ctx, tx, member); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE memberships SET role = ? WHERE org_id = ? AND user_id = ?",
+		role, orgID.String(), userID.String()); err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember implements storage.OrganizationStore.
+func (s *Store) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	member, err := getMembership(ctx, tx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkNotLastOwner(ctx, tx, member); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM memberships WHERE org_id = ? AND user_id = ?",
+		orgID.String(), userID.String()); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	return nil
+}
+
+// CreateOrganizationProject implements storage.OrganizationStore.
+func (s *Store) CreateOrganizationProject(ctx context.Context, orgID uuid.UUID, name string) (*models.Project, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM organizations WHERE id = ?)", orgID.String()).
+		Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if !exists {
+		return nil, database.ErrOrganizationNotFound
+	}
+	return s.createProject(ctx, name, &orgID)
+}
+
+// ListOrganizationProjects implements storage.OrganizationStore. Newest first.
+func (s *Store) ListOrganizationProjects(ctx context.Context, orgID uuid.UUID) ([]models.Project, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+projectColumns+" FROM projects WHERE org_id = ? AND deleted_at IS NULL ORDER BY created_at DESC", orgID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProjects(rows)
+}
+
+// ProjectRole implements storage.OrganizationStore.
+func (s *Store) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (models.Role, error) {
+	var role sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT m.role
+		FROM projects p
+		LEFT JOIN memberships m ON m.org_id = p.org_id AND m.user_id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL`,
+		userID.String(), projectID.String()).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", database.ErrProjectNotFound
+		}
+		return "", fmt.Errorf("failed to get project role: %w", err)
+	}
+	if !role.Valid {
+		return "", database.ErrMemberNotFound
+	}
+	return models.Role(role.String), nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func getMembership(ctx context.Context, db queryer, orgID, userID uuid.UUID) (*models.Membership, error) {
+	row := db.QueryRowContext(ctx, `
+		SELECT `+membershipColumns+`
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = ? AND m.user_id = ?`,
+		orgID.String(), userID.String())
+
+	member, err := scanMembership(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return member, nil
+}
+
+// checkNotLastOwner returns database.ErrLastOwner if member is their organization's only owner.
+func checkNotLastOwner(ctx context.Context, db queryer, member *models.Membership) error {
+	if member.Role != models.RoleOwner {
+		return nil
+	}
+
+	var owners int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM memberships WHERE org_id = ? AND role = ?",
+		member.OrgID.String(), models.RoleOwner).Scan(&owners); err != nil {
+		return fmt.Errorf("failed to count owners: %w", err)
+	}
+	if owners <= 1 {
+		return database.ErrLastOwner
+	}
+	return nil
+}
+
+// scanUser scans userColumns followed by any extra columns into extra.
+func scanUser(row rowScanner, extra ...any) (*models.User, error) {
+	var (
+		user      models.User
+		id        string
+		createdAt int64
+	)
+
+	dest := append([]any{&id, &user.Email, &user.Name, &createdAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if user.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+	user.CreatedAt = time.UnixMicro(createdAt).UTC()
+
+	return &user, nil
+}
+
+func scanMembership(row rowScanner) (*models.Membership, error) {
+	var (
+		member        models.Membership
+		orgID, userID string
+		createdAt     int64
+	)
+
+	if err := row.Scan(&orgID, &userID, &member.Email, &member.Name, &member.Role, &createdAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if member.OrgID, err = uuid.Parse(orgID); err != nil {
+		return nil, err
+	}
+	if member.UserID, err = uuid.Parse(userID); err != nil {
+		return nil, err
+	}
+	member.CreatedAt = time.UnixMicro(createdAt).UTC()
+
+	return &member, nil
+}
+
+// nullableID converts an optional ID to a nullable TEXT column value.
+func nullableID(id *uuid.UUID) any {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
@@ -0,0 +1,282 @@
+// Package storage defines the persistence interfaces the HTTP layer depends on.
+//
+// LogStore, ProjectStore, APIKeyStore, AdminStore, UserStore, OrganizationStore,
+// ProjectGroupStore, DeletedProjectStore, AuditStore and RateLimitStore cover everything
+// a backend must support.
+// Features that only some backends provide (facets, export, log context, retention)
+// are separate optional interfaces: the server registers their routes only when the
+// configured store implements them.
+//
+// Implementations report errors with the sentinel values and types from the database
+// package (database.ErrLogNotFound, database.ErrProjectNotFound, *database.QueryError, ...)
+// so handlers map them to status codes the same way for every backend.
+package storage
+
+import (
+	"context"
+	"jazz/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogStore stores and queries log entries. Every read is scoped to a single project.
+type LogStore interface {
+	// InsertLogsBatch stores logs atomically - either all or none are stored.
+	InsertLogsBatch(ctx context.Context, logs []models.LogEntry) error
+
+	// QueryLogs returns a page of a project's logs matching params and the total
+	// number of matches. Delegates to SearchLogs when params.Search is set.
+	QueryLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams) ([]models.LogEntry, int64, error)
+
+	// SearchLogs returns a page of full-text matches with Rank set and the total number of matches.
+	SearchLogs(ctx context.Context, projectID uuid.UUID, req models.SearchRequest) ([]models.LogEntry, int64, error)
+
+	// GetLog returns a single entry, or database.ErrLogNotFound.
+	GetLog(ctx context.Context, projectID, logID uuid.UUID) (*models.LogEntry, error)
+}
+
+// ProjectStore manages projects.
+type ProjectStore interface {
+	// CreateProject creates a project together with its first API key, named "default",
+	// which has every scope.
+	// The returned project is the only one with APIKey set.
+	CreateProject(ctx context.Context, name string) (*models.Project, error)
+
+	// ListProjects returns a page of live projects matching params, each with Stats set,
+	// and the number of matching projects. Returns *database.QueryError for an invalid sort.
+	ListProjects(ctx context.Context, params models.ProjectListParams) ([]models.Project, int, error)
+
+	// GetProject returns a project, or database.ErrProjectNotFound.
+	GetProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error)
+
+	// UpdateProject applies the fields req sets and bumps UpdatedAt, or returns
+	// database.ErrProjectNotFound.
+	UpdateProject(ctx context.Context, projectID uuid.UUID, req models.UpdateProjectRequest) (*models.Project, error)
+
+	// DeleteProject marks a project deleted, or returns database.ErrProjectNotFound.
+	// Deleted projects are hidden like unknown ones and their keys, sessions and groups
+	// stop authenticating, until RestoreProject or the reaper's purge (see DeletedProjectStore).
+	DeleteProject(ctx context.Context, projectID uuid.UUID) error
+
+	// GetDeletedProject returns a deleted project as it was when deleted. Returns
+	// database.ErrProjectNotDeleted for a live project and database.ErrProjectNotFound
+	// for unknown (or purged) projects.
+	GetDeletedProject(ctx context.Context, projectID uuid.UUID) (*models.Project, error)
+
+	// RestoreProject undeletes a project deleted at or after deletedSince, with its keys
+	// and logs. Returns database.ErrProjectNotDeleted for a live project and
+	// database.ErrProjectNotFound for unknown projects and older deletions.
+	RestoreProject(ctx context.Context, projectID uuid.UUID, deletedSince time.Time) (*models.Project, error)
+}
+
+// APIKeyStore manages projects' API keys and resolves requests' keys to them.
+// Methods taking a project ID return database.ErrProjectNotFound for an unknown
+// project and database.ErrAPIKeyNotFound for a key of another project.
+type APIKeyStore interface {
+	// CreateAPIKey issues a new key, with models.DefaultScopes unless req has scopes.
+	// The returned key is the only one with Key set.
+	CreateAPIKey(ctx context.Context, projectID uuid.UUID, req models.CreateAPIKeyRequest) (*models.APIKey, error)
+
+	// ListAPIKeys returns a project's keys, including revoked and expired ones, newest first.
+	ListAPIKeys(ctx context.Context, projectID uuid.UUID) ([]models.APIKey, error)
+
+	// RevokeAPIKey stops a key from authenticating immediately. Revoking a revoked key is a no-op.
+	RevokeAPIKey(ctx context.Context, projectID, keyID uuid.UUID) (*models.APIKey, error)
+
+	// RotateAPIKey issues a replacement for a key, with the same name and scopes, and makes
+	// the old key expire after gracePeriod unless it expires sooner. Returns the new key
+	// (with Key set) and the old one, or database.ErrAPIKeyInactive if the old key is no
+	// longer active.
+	RotateAPIKey(ctx context.Context, projectID, keyID uuid.UUID, gracePeriod time.Duration) (*models.APIKey, *models.APIKey, error)
+
+	// AuthenticateAPIKey returns the active key matching apiKey and records that it was used,
+	// or returns database.ErrInvalidAPIKey.
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (*models.APIKey, error)
+}
+
+// AdminStore manages admin users, who may manage every project.
+type AdminStore interface {
+	// CreateAdminUser creates an admin user with a generated token, or returns
+	// database.ErrAdminUserExists if the name is taken. The returned user is the only
+	// one with Token set.
+	CreateAdminUser(ctx context.Context, name string) (*models.AdminUser, error)
+
+	// ListAdminUsers returns every admin user, oldest first.
+	ListAdminUsers(ctx context.Context) ([]models.AdminUser, error)
+
+	// DeleteAdminUser removes an admin user, revoking their token, or returns database.ErrAdminUserNotFound.
+	DeleteAdminUser(ctx context.Context, userID uuid.UUID) error
+
+	// AuthenticateAdmin returns the admin user a token belongs to and records that it was
+	// used, or returns database.ErrInvalidAdminToken.
+	AuthenticateAdmin(ctx context.Context, token string) (*models.AdminUser, error)
+}
+
+// UserStore manages user accounts and their sessions.
+// Emails are compared case-insensitively (see models.NormalizeEmail).
+type UserStore interface {
+	// CreateUser creates a user with a bcrypt-hashed password, or returns
+	// database.ErrUserExists if the email is taken.
+	CreateUser(ctx context.Context, email, name, password string) (*models.User, error)
+
+	// AuthenticateUser checks an email and password, returning database.ErrInvalidCredentials
+	// if there is no such user or the password is wrong.
+	AuthenticateUser(ctx context.Context, email, password string) (*models.User, error)
+
+	// GetUserByEmail returns the user with an email, or database.ErrUserNotFound.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// CreateSession signs a user in for ttl. The returned session is the only one with Token set.
+	CreateSession(ctx context.Context, userID uuid.UUID, ttl time.Duration) (*models.Session, error)
+
+	// AuthenticateSession returns the unexpired session a token belongs to, or
+	// database.ErrInvalidSession.
+	AuthenticateSession(ctx context.Context, token string) (*models.Session, error)
+
+	// DeleteSession signs a session out. Deleting a missing session is not an error.
+	DeleteSession(ctx context.Context, sessionID uuid.UUID) error
+}
+
+// OrganizationStore manages organizations, their members' roles and the projects they own.
+// Organizations always keep at least one owner.
+type OrganizationStore interface {
+	// CreateOrganization creates an organization with ownerID as its first owner.
+	// The returned organization has Role set to models.RoleOwner.
+	CreateOrganization(ctx context.Context, name string, ownerID uuid.UUID) (*models.Organization, error)
+
+	// ListOrganizations returns the organizations userID belongs to, with their role, oldest first.
+	ListOrganizations(ctx context.Context, userID uuid.UUID) ([]models.Organization, error)
+
+	// GetMembership returns userID's membership of orgID, or database.ErrMemberNotFound
+	// (also when the organization doesn't exist).
+	GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*models.Membership, error)
+
+	// ListMembers returns an organization's members, oldest first, or database.ErrOrganizationNotFound.
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.Membership, error)
+
+	// AddMember gives the user with email a role in orgID. Returns database.ErrOrganizationNotFound,
+	// database.ErrUserNotFound, or database.ErrMemberExists if they already belong to it.
+	AddMember(ctx context.Context, orgID uuid.UUID, email string, role models.Role) (*models.Membership, error)
+
+	// UpdateMemberRole changes a member's role. Returns database.ErrMemberNotFound, or
+	// database.ErrLastOwner if it would leave the organization without an owner.
+	UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.Role) (*models.Membership, error)
+
+	// RemoveMember removes a user from an organization. Returns database.ErrMemberNotFound,
+	// or database.ErrLastOwner for the organization's only owner.
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+
+	// CreateOrganizationProject creates a project owned by orgID, with a "default" key like
+	// ProjectStore.CreateProject. Returns database.ErrOrganizationNotFound.
+	CreateOrganizationProject(ctx context.Context, orgID uuid.UUID, name string) (*models.Project, error)
+
+	// ListOrganizationProjects returns an organization's projects, newest first.
+	ListOrganizationProjects(ctx context.Context, orgID uuid.UUID) ([]models.Project, error)
+
+	// ProjectRole returns userID's role in the organization that owns projectID.
+	// Returns database.ErrProjectNotFound, or database.ErrMemberNotFound if the user
+	// isn't a member or the project belongs to no organization.
+	ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (models.Role, error)
+}
+
+// ProjectGroupStore manages which identity provider groups may read a project's logs.
+// Group names are compared exactly, as the provider spells them.
+type ProjectGroupStore interface {
+	// GrantProjectGroup lets members of group read the project's logs.
+	// Returns database.ErrProjectNotFound or database.ErrProjectGroupExists.
+	GrantProjectGroup(ctx context.Context, projectID uuid.UUID, group string) (*models.ProjectGroup, error)
+
+	// ListProjectGroups returns a project's groups in name order, or database.ErrProjectNotFound.
+	ListProjectGroups(ctx context.Context, projectID uuid.UUID) ([]models.ProjectGroup, error)
+
+	// RevokeProjectGroup removes a group's access, or returns database.ErrProjectGroupNotFound.
+	RevokeProjectGroup(ctx context.Context, projectID uuid.UUID, group string) error
+
+	// HasProjectGroup reports whether any of groups may read the project. Unknown projects
+	// have no groups.
+	HasProjectGroup(ctx context.Context, projectID uuid.UUID, groups []string) (bool, error)
+}
+
+// DeletedProjectStore permanently removes deleted projects once they can no longer be
+// restored. Logs go first, in batches, so no single transaction has to cascade over
+// millions of rows. Every method only touches projects deleted before deletedBefore.
+type DeletedProjectStore interface {
+	// ListDeletedProjects returns the projects deleted before deletedBefore, oldest deletion first.
+	ListDeletedProjects(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+
+	// PurgeDeletedProjectLogs deletes up to batchSize of a deleted project's logs and
+	// returns how many it deleted.
+	PurgeDeletedProjectLogs(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time, batchSize int) (int64, error)
+
+	// PurgeDeletedProject removes a deleted project with whatever it still owns (keys,
+	// groups, remaining logs), or returns database.ErrProjectNotFound.
+	PurgeDeletedProject(ctx context.Context, projectID uuid.UUID, deletedBefore time.Time) error
+}
+
+// AuditStore keeps the append-only trail of administrative actions (GET /audit).
+// Events outlive the projects, keys and users they mention.
+type AuditStore interface {
+	// RecordAuditEvent appends event, setting its ID and OccurredAt.
+	RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error
+
+	// ListAuditEvents returns a page of the events matching params, newest first, and
+	// the number of matching events. Returns *database.QueryError for invalid filters.
+	ListAuditEvents(ctx context.Context, params models.AuditQueryParams) ([]models.AuditEvent, int, error)
+}
+
+// RateLimitStore keeps the counters behind per-project ingestion limits
+// (middleware.RateLimit). PostgreSQL keeps them in the database, so every API instance
+// enforces the same limits.
+type RateLimitStore interface {
+	// TakeTokens spends cost tokens from a project's bucket (models.BucketRequests or
+	// models.BucketEntries), which refills at rate tokens per second up to one second's
+	// worth. A bucket that isn't empty admits any cost, going into debt that later calls
+	// wait out. Returns zero if admitted, otherwise how long until the bucket refills.
+	TakeTokens(ctx context.Context, projectID uuid.UUID, bucket string, rate, cost float64) (time.Duration, error)
+
+	// ChargeIngestQuota adds usage to a project's usage for the UTC day containing day,
+	// unless the total would exceed quota (zero fields are unlimited).
+	// Reports whether usage was charged.
+	ChargeIngestQuota(ctx context.Context, projectID uuid.UUID, day time.Time, usage, quota models.IngestUsage) (bool, error)
+
+	// PurgeIngestUsage deletes the usage of UTC days before the one containing day,
+	// which quotas no longer look at, and returns how many project-days it deleted.
+	PurgeIngestUsage(ctx context.Context, day time.Time) (int64, error)
+}
+
+// Store is a complete storage backend.
+type Store interface {
+	LogStore
+	ProjectStore
+	APIKeyStore
+	AdminStore
+	UserStore
+	OrganizationStore
+	ProjectGroupStore
+	DeletedProjectStore
+	AuditStore
+	RateLimitStore
+}
+
+// ContextStore loads the entries surrounding a log entry (GET /logs/:id/context).
+type ContextStore interface {
+	GetLogContext(ctx context.Context, projectID, logID uuid.UUID, params models.LogContextParams) (*models.LogContextResponse, error)
+}
+
+// FacetStore computes top values of log fields (GET /logs/facets, POST /search/facets).
+type FacetStore interface {
+	FacetLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams, size int) (*models.FacetsResponse, error)
+	FacetSearch(ctx context.Context, projectID uuid.UUID, req models.SearchRequest, size int) (*models.FacetsResponse, error)
+}
+
+// ExportStore streams every matching log without pagination (GET /logs/export).
+type ExportStore interface {
+	ExportLogs(ctx context.Context, projectID uuid.UUID, params models.QueryParams, fn func(models.LogEntry) error) (int64, error)
+}
+
+// RetentionStore reads and updates project retention policies (/projects/:id/retention).
+type RetentionStore interface {
+	GetRetentionStatus(ctx context.Context, projectID uuid.UUID, now time.Time) (*models.RetentionStatus, error)
+	SetProjectRetention(ctx context.Context, projectID uuid.UUID, days *int) (*models.Project, error)
+}
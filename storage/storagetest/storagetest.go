@@ -0,0 +1,1786 @@
+// Package storagetest is a conformance suite for storage.Store implementations.
+//
+// Every backend runs the same suite from its own tests, so the API behaves the
+// same whichever store is configured:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Store {
+//			return New()
+//		})
+//	}
+//
+// Search assertions stick to single-word, unstemmed terms and relative rank order,
+// since each backend tokenizes and scores matches differently.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"jazz/storage"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the conformance suite. newStore is called once per subtest and must
+// return a store with no projects, logs or admin users. Tests of the optional
+// interfaces (storage.ContextStore, ...) are skipped for stores without them.
+func Run(t *testing.T, newStore func(t *testing.T) storage.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store storage.Store)
+	}{
+		{"InsertLogsBatch", testInsertLogsBatch},
+		{"InsertLogsBatch_Empty", testInsertLogsBatchEmpty},
+		{"InsertLogsBatch_Atomic", testInsertLogsBatchAtomic},
+		{"InsertLogsBatch_MixedOffsets", testInsertLogsBatchMixedOffsets},
+		{"QueryLogs_Filtering", testQueryLogsFiltering},
+		{"QueryLogs_Pagination", testQueryLogsPagination},
+		{"QueryLogs_ProjectIsolation", testQueryLogsProjectIsolation},
+		{"QueryLogs_Sort", testQueryLogsSort},
+		{"QueryLogs_InvalidInput", testQueryLogsInvalidInput},
+		{"QueryLogs_Search", testQueryLogsSearch},
+		{"GetLog", testGetLog},
+		{"SearchLogs_Basic", testSearchLogsBasic},
+		{"SearchLogs_MultipleWords", testSearchLogsMultipleWords},
+		{"SearchLogs_WithFilters", testSearchLogsWithFilters},
+		{"SearchLogs_Ranking", testSearchLogsRanking},
+		{"SearchLogs_SortByTime", testSearchLogsSortByTime},
+		{"SearchLogs_InvalidQuery", testSearchLogsInvalidQuery},
+		{"Projects", testProjects},
+		{"Projects_NotFound", testProjectsNotFound},
+		{"ListProjects", testListProjects},
+		{"UpdateProject", testUpdateProject},
+		{"DeleteProject_RestoreAndPurge", testDeleteProjectRestoreAndPurge},
+		{"APIKeys", testAPIKeys},
+		{"APIKeys_Expiry", testAPIKeysExpiry},
+		{"APIKeys_Scopes", testAPIKeyScopes},
+		{"APIKeys_NotFound", testAPIKeysNotFound},
+		{"RevokeAPIKey", testRevokeAPIKey},
+		{"RotateAPIKey", testRotateAPIKey},
+		{"AdminUsers", testAdminUsers},
+		{"Users", testUsers},
+		{"Sessions", testSessions},
+		{"Organizations", testOrganizations},
+		{"Organizations_LastOwner", testOrganizationsLastOwner},
+		{"OrganizationProjects", testOrganizationProjects},
+		{"ProjectGroups", testProjectGroups},
+		{"AuditEvents", testAuditEvents},
+		{"TakeTokens", testTakeTokens},
+		{"ChargeIngestQuota", testChargeIngestQuota},
+		{"PurgeIngestUsage", testPurgeIngestUsage},
+		{"GetLogContext", testGetLogContext},
+		{"FacetLogs", testFacetLogs},
+		{"ExportLogs", testExportLogs},
+		{"Retention", testRetention},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testInsertLogsBatch(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Test error 1", Source: "backend", Timestamp: time.Now()},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Test info 1", Source: "frontend", Timestamp: time.Now()},
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	results, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int64(2), total)
+
+	for _, result := range results {
+		assert.Nil(t, result.Rank, "rank is only set for search results")
+	}
+}
+
+func testInsertLogsBatchEmpty(t *testing.T, store storage.Store) {
+	assert.NoError(t, store.InsertLogsBatch(context.Background(), []models.LogEntry{}))
+}
+
+func testInsertLogsBatchAtomic(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	err := store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "ok", Timestamp: time.Now()},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "a-level-that-is-far-too-long", Message: "bad", Timestamp: time.Now()},
+	})
+	var batchErr *database.BatchInsertError
+	require.True(t, errors.As(err, &batchErr), "expected *database.BatchInsertError, got %v", err)
+	assert.Equal(t, 1, batchErr.FailedIndex)
+	assert.Equal(t, 2, batchErr.TotalLogs)
+
+	_, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Zero(t, total, "a failed batch stores nothing")
+
+	err = store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: uuid.New(), Level: "info", Message: "orphan", Timestamp: time.Now()},
+	})
+	assert.True(t, errors.As(err, &batchErr), "unknown project: expected *database.BatchInsertError, got %v", err)
+}
+
+func testInsertLogsBatchMixedOffsets(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	// The same instant, and one 30 minutes later, written with different offsets
+	instant := time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC)
+	india := time.FixedZone("IST", 5*3600+1800)
+	pacific := time.FixedZone("PST", -8*3600)
+
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "UTC", Timestamp: instant},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "IST", Timestamp: instant.In(india)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "PST later", Timestamp: instant.Add(30 * time.Minute).In(pacific)},
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	for _, entry := range logs {
+		retrieved, err := store.GetLog(ctx, project.ID, entry.ID)
+		require.NoError(t, err)
+		assert.True(t, entry.Timestamp.Equal(retrieved.Timestamp),
+			"%s: expected %v, got %v", entry.Message, entry.Timestamp, retrieved.Timestamp)
+		assert.Equal(t, time.UTC, retrieved.Timestamp.Location())
+	}
+
+	// A range expressed with an offset selects by instant, not wall-clock time
+	results, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{
+		StartTime: "2024-11-22T15:30:00+05:30",
+		EndTime:   "2024-11-22T15:30:00+05:30",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.ElementsMatch(t, []string{"UTC", "IST"}, messages(results))
+
+	// Local times without an offset are resolved in tz
+	results, _, err = store.QueryLogs(ctx, project.ID, models.QueryParams{
+		StartTime: "2024-11-22T15:45:00",
+		TZ:        "Asia/Kolkata",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PST later"}, messages(results))
+}
+
+func testQueryLogsFiltering(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	now := time.Now()
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Error 1", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Error 2", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Info 1", Source: "frontend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "warning", Message: "Warning 1", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Old", Source: "backend", Timestamp: now.Add(-2 * time.Hour)},
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	tests := []struct {
+		name     string
+		params   models.QueryParams
+		expected int64
+	}{
+		{name: "no filters", params: models.QueryParams{}, expected: 5},
+		{name: "level", params: models.QueryParams{Level: "error"}, expected: 2},
+		{name: "source", params: models.QueryParams{Source: "backend"}, expected: 4},
+		{name: "level and source", params: models.QueryParams{Level: "error", Source: "backend"}, expected: 2},
+		{name: "relative start", params: models.QueryParams{StartTime: "now-1h"}, expected: 4},
+		{name: "relative end", params: models.QueryParams{EndTime: "now-1h"}, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, total, err := store.QueryLogs(ctx, project.ID, tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, total)
+			assert.Len(t, results, int(tt.expected))
+		})
+	}
+}
+
+func testQueryLogsPagination(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	now := time.Now()
+	logs := make([]models.LogEntry, 25)
+	for i := range logs {
+		logs[i] = models.LogEntry{
+			ID:        uuid.New(),
+			ProjectID: project.ID,
+			Level:     "info",
+			Message:   "Test log",
+			Timestamp: now.Add(time.Duration(i) * time.Second),
+		}
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	seen := map[uuid.UUID]bool{}
+	for offset, expected := range map[int]int{0: 10, 10: 10, 20: 5} {
+		page, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{Limit: 10, Offset: offset})
+		require.NoError(t, err)
+		assert.Len(t, page, expected, "offset %d", offset)
+		assert.Equal(t, int64(25), total)
+
+		for _, entry := range page {
+			assert.False(t, seen[entry.ID], "%s returned on more than one page", entry.ID)
+			seen[entry.ID] = true
+		}
+	}
+	assert.Len(t, seen, 25)
+
+	// Missing limit uses the default page size
+	page, _, err := store.QueryLogs(ctx, project.ID, models.QueryParams{})
+	require.NoError(t, err)
+	assert.Len(t, page, 25)
+}
+
+func testQueryLogsProjectIsolation(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project1 := createProject(t, store, "Project 1")
+	project2 := createProject(t, store, "Project 2")
+
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project1.ID, Level: "error", Message: "P1 Log", Timestamp: time.Now()},
+		{ID: uuid.New(), ProjectID: project2.ID, Level: "error", Message: "P2 Log", Timestamp: time.Now()},
+	}))
+
+	results, total, err := store.QueryLogs(ctx, project1.ID, models.QueryParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"P1 Log"}, messages(results))
+
+	results, total, err = store.QueryLogs(ctx, project2.ID, models.QueryParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"P2 Log"}, messages(results))
+
+	results, total, err = store.QueryLogs(ctx, uuid.New(), models.QueryParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.NotNil(t, results, "no matches is an empty slice, not nil")
+	assert.Empty(t, results)
+}
+
+func testQueryLogsSort(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	base := time.Now().Add(-time.Hour)
+	logs := []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "warning", Message: "Log 0", Timestamp: base},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Log 1", Timestamp: base.Add(time.Second)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Log 2", Timestamp: base.Add(2 * time.Second)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Log 3", Timestamp: base.Add(3 * time.Second)},
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	tests := []struct {
+		name     string
+		sort     string
+		expected []string
+	}{
+		{name: "default newest first", sort: "", expected: []string{"Log 3", "Log 2", "Log 1", "Log 0"}},
+		{name: "chronological", sort: "timestamp:asc", expected: []string{"Log 0", "Log 1", "Log 2", "Log 3"}},
+		{name: "secondary key", sort: "level:asc,timestamp:desc", expected: []string{"Log 3", "Log 1", "Log 2", "Log 0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, _, err := store.QueryLogs(ctx, project.ID, models.QueryParams{Sort: tt.sort, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, messages(results))
+		})
+	}
+}
+
+func testQueryLogsInvalidInput(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	for name, params := range map[string]models.QueryParams{
+		"rank outside search": {Sort: "rank:desc"},
+		"unknown sort column": {Sort: "message"},
+		"invalid start time":  {StartTime: "not-a-date"},
+		"invalid end time":    {EndTime: "not-a-date"},
+		"invalid time zone":   {StartTime: "now-1h", TZ: "Mars/Olympus"},
+		"invalid search":      {Search: "ab"},
+	} {
+		_, _, err := store.QueryLogs(ctx, project.ID, params)
+		var queryErr *database.QueryError
+		assert.ErrorAs(t, err, &queryErr, name)
+	}
+}
+
+func testQueryLogsSearch(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database connection failed", Timestamp: time.Now()},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "User logged in", Timestamp: time.Now()},
+	}))
+
+	// GET /logs?search= delegates to SearchLogs
+	results, total, err := store.QueryLogs(ctx, project.ID, models.QueryParams{Search: "database"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Database connection failed", results[0].Message)
+	assert.NotNil(t, results[0].Rank)
+}
+
+func testGetLog(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project1 := createProject(t, store, "Project 1")
+	project2 := createProject(t, store, "Project 2")
+
+	entry := models.LogEntry{
+		ID: uuid.New(), ProjectID: project1.ID, Level: "error", Message: "Target", Source: "backend",
+		Timestamp: time.Date(2024, 11, 22, 10, 0, 0, 123456400, time.UTC),
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{entry}))
+
+	retrieved, err := store.GetLog(ctx, project1.ID, entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entry.ID, retrieved.ID)
+	assert.Equal(t, entry.ProjectID, retrieved.ProjectID)
+	assert.Equal(t, "error", retrieved.Level)
+	assert.Equal(t, "Target", retrieved.Message)
+	assert.Equal(t, "backend", retrieved.Source)
+	assert.True(t, entry.Timestamp.Truncate(time.Microsecond).Equal(retrieved.Timestamp),
+		"timestamps keep microsecond precision: got %v", retrieved.Timestamp)
+
+	_, err = store.GetLog(ctx, project2.ID, entry.ID)
+	assert.ErrorIs(t, err, database.ErrLogNotFound)
+
+	_, err = store.GetLog(ctx, project1.ID, uuid.New())
+	assert.ErrorIs(t, err, database.ErrLogNotFound)
+}
+
+func testSearchLogsBasic(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	now := time.Now()
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database connection failed", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Payment gateway timeout", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "User logged in successfully", Timestamp: now},
+	}))
+
+	results, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Database connection failed", results[0].Message)
+	assert.NotNil(t, results[0].Rank)
+
+	// Other projects' logs never match
+	results, total, err = store.SearchLogs(ctx, uuid.New(), models.SearchRequest{Query: "database", Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, results)
+}
+
+func testSearchLogsMultipleWords(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	now := time.Now()
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database connection timeout", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database query failed", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Payment timeout", Timestamp: now},
+	}))
+
+	// Every word must match
+	results, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database timeout", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Database connection timeout"}, messages(results))
+}
+
+func testSearchLogsWithFilters(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	now := time.Now()
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database error", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Database connected", Source: "backend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database error", Source: "frontend", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "Database error", Source: "backend", Timestamp: now.Add(-2 * time.Hour)},
+	}))
+
+	results, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{
+		Query:     "database",
+		Level:     "error",
+		Source:    "backend",
+		StartTime: "now-1h",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, results, 1)
+	assert.Equal(t, "error", results[0].Level)
+	assert.Equal(t, "backend", results[0].Source)
+}
+
+func testSearchLogsRanking(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	now := time.Now()
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "payment declined for order", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "payment payment payment", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "order shipped", Timestamp: now},
+	}))
+
+	results, total, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "payment", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, results, 2)
+
+	// Denser matches rank higher, and results come back best first
+	assert.Equal(t, "payment payment payment", results[0].Message)
+	require.NotNil(t, results[0].Rank)
+	require.NotNil(t, results[1].Rank)
+	assert.Greater(t, *results[0].Rank, *results[1].Rank)
+}
+
+func testSearchLogsSortByTime(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	base := time.Now().Add(-time.Hour)
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database database database down", Timestamp: base.Add(2 * time.Second)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database slow", Timestamp: base},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database retry", Timestamp: base.Add(time.Second)},
+	}))
+
+	results, _, err := store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database", Sort: "timestamp:asc", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"database slow", "database retry", "database database database down"}, messages(results))
+
+	results, _, err = store.SearchLogs(ctx, project.ID, models.SearchRequest{Query: "database", Limit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "database database database down", results[0].Message, "default sort is by rank")
+}
+
+func testSearchLogsInvalidQuery(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	for name, req := range map[string]models.SearchRequest{
+		"empty query":         {},
+		"only short words":    {Query: "a b c"},
+		"unknown sort column": {Query: "database", Sort: "message"},
+		"invalid start time":  {Query: "database", StartTime: "yesterday"},
+	} {
+		_, _, err := store.SearchLogs(ctx, project.ID, req)
+		var queryErr *database.QueryError
+		assert.ErrorAs(t, err, &queryErr, name)
+	}
+}
+
+func testProjects(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	projects, total, err := store.ListProjects(ctx, models.ProjectListParams{})
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+	assert.Zero(t, total)
+
+	created := createProject(t, store, "Test Project")
+	assert.NotEqual(t, uuid.Nil, created.ID)
+	assert.Equal(t, "Test Project", created.Name)
+	assert.Greater(t, len(created.APIKey), 10, "API key should be generated")
+	assert.False(t, created.CreatedAt.IsZero())
+	assert.False(t, created.UpdatedAt.IsZero())
+
+	retrieved, err := store.GetProject(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, retrieved.ID)
+	assert.Equal(t, created.Name, retrieved.Name)
+	assert.True(t, created.CreatedAt.Equal(retrieved.CreatedAt))
+	assert.Empty(t, retrieved.APIKey, "the full key is only returned on creation")
+
+	// The project's key is its "default" API key
+	key, err := store.AuthenticateAPIKey(ctx, created.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, key.ProjectID)
+	assert.Equal(t, database.DefaultAPIKeyName, key.Name)
+	assert.Equal(t, created.APIKey[:len(key.Prefix)], key.Prefix)
+	assert.Empty(t, key.Key)
+
+	_, err = store.AuthenticateAPIKey(ctx, "invalid_key")
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+	assert.Contains(t, err.Error(), "invalid API key")
+
+	// Same prefix, different secret
+	last := "0"
+	if created.APIKey[len(created.APIKey)-1] == '0' {
+		last = "1"
+	}
+	wrongSecret := created.APIKey[:len(created.APIKey)-1] + last
+	_, err = store.AuthenticateAPIKey(ctx, wrongSecret)
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+
+	second := createProject(t, store, "Project 2")
+	assert.NotEqual(t, created.APIKey, second.APIKey)
+
+	projects, total, err = store.ListProjects(ctx, models.ProjectListParams{})
+	require.NoError(t, err)
+	assert.Len(t, projects, 2)
+	assert.Equal(t, 2, total)
+	for _, project := range projects {
+		assert.Empty(t, project.APIKey)
+	}
+}
+
+func testListProjects(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	billing := createProject(t, store, "Billing API")
+	time.Sleep(time.Millisecond) // distinct creation times
+	checkout := createProject(t, store, "Checkout")
+	time.Sleep(time.Millisecond)
+	empty := createProject(t, store, "Billing Worker")
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: billing.ID, Level: "info", Message: "invoice sent", Timestamp: base},
+		{ID: uuid.New(), ProjectID: checkout.ID, Level: "info", Message: "cart created", Timestamp: base},
+		{ID: uuid.New(), ProjectID: checkout.ID, Level: "error", Message: "payment declined", Timestamp: base.Add(time.Hour)},
+	}))
+
+	ids := func(projects []models.Project) []uuid.UUID {
+		result := make([]uuid.UUID, len(projects))
+		for i, project := range projects {
+			result[i] = project.ID
+		}
+		return result
+	}
+
+	// Newest first by default, each with its stats
+	projects, total, err := store.ListProjects(ctx, models.ProjectListParams{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []uuid.UUID{empty.ID, checkout.ID, billing.ID}, ids(projects))
+
+	stats := projects[1].Stats
+	require.NotNil(t, stats)
+	assert.Equal(t, int64(2), stats.LogCount)
+	require.NotNil(t, stats.LastLogAt)
+	assert.True(t, base.Add(time.Hour).Equal(*stats.LastLogAt))
+	assert.Positive(t, stats.StorageBytes)
+	assert.Greater(t, stats.StorageBytes, projects[2].Stats.StorageBytes)
+	assert.Equal(t, models.ProjectStats{}, *projects[0].Stats)
+
+	// Search is a case-insensitive substring of the name
+	projects, total, err = store.ListProjects(ctx, models.ProjectListParams{Search: "billing", Sort: "name"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []uuid.UUID{billing.ID, empty.ID}, ids(projects))
+
+	for sort, expected := range map[string][]uuid.UUID{
+		"name:desc":  {checkout.ID, empty.ID, billing.ID},
+		"name":       {billing.ID, empty.ID, checkout.ID},
+		"created_at": {billing.ID, checkout.ID, empty.ID},
+	} {
+		projects, _, err := store.ListProjects(ctx, models.ProjectListParams{Sort: sort})
+		require.NoError(t, err, sort)
+		assert.Equal(t, expected, ids(projects), sort)
+	}
+
+	// Pages keep the total of every match
+	projects, total, err = store.ListProjects(ctx, models.ProjectListParams{Sort: "created_at", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []uuid.UUID{checkout.ID}, ids(projects))
+	assert.Equal(t, int64(2), projects[0].Stats.LogCount)
+
+	projects, _, err = store.ListProjects(ctx, models.ProjectListParams{Sort: "name", Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{checkout.ID}, ids(projects))
+
+	// Stats are only aggregated for the page, so they can't order it
+	for _, sort := range []string{"api_key", "log_count:desc", "last_log_at"} {
+		_, _, err = store.ListProjects(ctx, models.ProjectListParams{Sort: sort})
+		var queryErr *database.QueryError
+		assert.ErrorAs(t, err, &queryErr, sort)
+	}
+}
+
+func testUpdateProject(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	created := createProject(t, store, "Test Project")
+
+	name := "Renamed"
+	days := 14
+	updated, err := store.UpdateProject(ctx, created.ID, models.UpdateProjectRequest{
+		Name:          &name,
+		RetentionDays: &days,
+		SetRetention:  true,
+		Settings: &models.ProjectSettings{
+			AllowedLevels:    []string{"info", "error"},
+			SearchLanguage:   "french",
+			MaxBatchSize:     100,
+			MaxMessageLength: 4096,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+	require.NotNil(t, updated.RetentionDays)
+	assert.Equal(t, 14, *updated.RetentionDays)
+	assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt))
+	assert.True(t, created.CreatedAt.Equal(updated.CreatedAt))
+
+	retrieved, err := store.GetProject(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", retrieved.Name)
+	assert.Equal(t, []string{"info", "error"}, retrieved.Settings.AllowedLevels)
+	assert.Equal(t, "french", retrieved.Settings.SearchLanguage)
+	assert.Equal(t, 100, retrieved.Settings.MaxBatchSize)
+	assert.Equal(t, 4096, retrieved.Settings.MaxMessageLength)
+	assert.True(t, updated.UpdatedAt.Equal(retrieved.UpdatedAt))
+
+	// Unset fields are left alone
+	updated, err = store.UpdateProject(ctx, created.ID, models.UpdateProjectRequest{SetRetention: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+	assert.Nil(t, updated.RetentionDays)
+	assert.Equal(t, "french", updated.Settings.SearchLanguage)
+
+	updated, err = store.UpdateProject(ctx, created.ID, models.UpdateProjectRequest{Settings: &models.ProjectSettings{}})
+	require.NoError(t, err)
+	assert.Equal(t, models.ProjectSettings{}, updated.Settings)
+
+	_, err = store.UpdateProject(ctx, uuid.New(), models.UpdateProjectRequest{Name: &name})
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+}
+
+func testProjectsNotFound(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	_, err := store.GetProject(ctx, uuid.New())
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+
+	err = store.DeleteProject(ctx, uuid.New())
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+}
+
+func testDeleteProjectRestoreAndPurge(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+
+	entry := models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database down", Timestamp: time.Now()}
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		entry,
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "database up", Timestamp: time.Now()},
+		{ID: uuid.New(), ProjectID: other.ID, Level: "error", Message: "database down", Timestamp: time.Now()},
+	}))
+
+	_, err := store.RestoreProject(ctx, project.ID, time.Time{})
+	assert.ErrorIs(t, err, database.ErrProjectNotDeleted)
+	_, err = store.GetDeletedProject(ctx, project.ID)
+	assert.ErrorIs(t, err, database.ErrProjectNotDeleted)
+	_, err = store.GetDeletedProject(ctx, uuid.New())
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+
+	// Deleted projects are hidden and their API key stops working
+	require.NoError(t, store.DeleteProject(ctx, project.ID))
+	_, err = store.GetProject(ctx, project.ID)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	_, err = store.AuthenticateAPIKey(ctx, project.APIKey)
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+	assert.ErrorIs(t, store.DeleteProject(ctx, project.ID), database.ErrProjectNotFound)
+	deleted, err := store.GetDeletedProject(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, project.Name, deleted.Name)
+	projects, _, err := store.ListProjects(ctx, models.ProjectListParams{})
+	require.NoError(t, err)
+	for _, p := range projects {
+		assert.NotEqual(t, project.ID, p.ID)
+	}
+
+	// Restoring inside the window brings everything back
+	restored, err := store.RestoreProject(ctx, project.ID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, project.ID, restored.ID)
+	_, err = store.AuthenticateAPIKey(ctx, project.APIKey)
+	require.NoError(t, err)
+	_, err = store.GetLog(ctx, project.ID, entry.ID)
+	require.NoError(t, err)
+	_, err = store.RestoreProject(ctx, project.ID, time.Time{})
+	assert.ErrorIs(t, err, database.ErrProjectNotDeleted)
+
+	// Nothing is purged or restored past the cutoff
+	require.NoError(t, store.DeleteProject(ctx, project.ID))
+	_, err = store.RestoreProject(ctx, project.ID, time.Now().Add(time.Minute))
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	ids, err := store.ListDeletedProjects(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	n, err := store.PurgeDeletedProjectLogs(ctx, project.ID, time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.ErrorIs(t, store.PurgeDeletedProject(ctx, project.ID, time.Now().Add(-time.Minute)), database.ErrProjectNotFound)
+
+	// Live projects are never purged
+	cutoff := time.Now().Add(time.Minute)
+	n, err = store.PurgeDeletedProjectLogs(ctx, other.ID, cutoff, 10)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.ErrorIs(t, store.PurgeDeletedProject(ctx, other.ID, cutoff), database.ErrProjectNotFound)
+
+	ids, err = store.ListDeletedProjects(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{project.ID}, ids)
+
+	// Logs go in batches, then the project itself
+	n, err = store.PurgeDeletedProjectLogs(ctx, project.ID, cutoff, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = store.PurgeDeletedProjectLogs(ctx, project.ID, cutoff, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	require.NoError(t, store.PurgeDeletedProject(ctx, project.ID, cutoff))
+
+	_, err = store.GetLog(ctx, project.ID, entry.ID)
+	assert.ErrorIs(t, err, database.ErrLogNotFound)
+	_, err = store.RestoreProject(ctx, project.ID, time.Time{})
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	ids, err = store.ListDeletedProjects(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, total, err := store.SearchLogs(ctx, other.ID, models.SearchRequest{Query: "database"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func testAPIKeys(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	time.Sleep(time.Millisecond) // distinct creation times
+
+	key, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "billing"})
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, key.ID)
+	assert.Equal(t, project.ID, key.ProjectID)
+	assert.Equal(t, "billing", key.Name)
+	assert.Greater(t, len(key.Key), 10, "API key should be generated")
+	assert.Equal(t, key.Key[:len(key.Prefix)], key.Prefix)
+	assert.False(t, key.CreatedAt.IsZero())
+	assert.Nil(t, key.LastUsedAt)
+	assert.Nil(t, key.ExpiresAt)
+	assert.Nil(t, key.RevokedAt)
+
+	// Every key authenticates as the project
+	for _, plaintext := range []string{project.APIKey, key.Key} {
+		authenticated, err := store.AuthenticateAPIKey(ctx, plaintext)
+		require.NoError(t, err)
+		assert.Equal(t, project.ID, authenticated.ProjectID)
+		require.NotNil(t, authenticated.LastUsedAt, "use is recorded")
+	}
+
+	keys, err := store.ListAPIKeys(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, []string{"billing", database.DefaultAPIKeyName}, []string{keys[0].Name, keys[1].Name}, "newest first")
+	for _, listed := range keys {
+		assert.Empty(t, listed.Key, "keys are only returned on creation")
+		assert.NotNil(t, listed.LastUsedAt)
+	}
+
+	// Keys belong to one project
+	other := createProject(t, store, "Other Project")
+	otherKeys, err := store.ListAPIKeys(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Len(t, otherKeys, 1)
+}
+
+func testAPIKeyScopes(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	// The project's first key can do everything
+	key, err := store.AuthenticateAPIKey(ctx, project.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, models.AllScopes, key.Scopes)
+
+	defaulted, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "service"})
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultScopes, defaulted.Scopes)
+
+	// Scopes are deduplicated and stored in canonical order
+	ingest, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{
+		Name:   "browser",
+		Scopes: []models.Scope{models.ScopeLogsWrite, models.ScopeLogsWrite},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Scope{models.ScopeLogsWrite}, ingest.Scopes)
+
+	readOnly, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{
+		Name:   "dashboard",
+		Scopes: []models.Scope{models.ScopeLogsRead},
+	})
+	require.NoError(t, err)
+
+	authenticated, err := store.AuthenticateAPIKey(ctx, readOnly.Key)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Scope{models.ScopeLogsRead}, authenticated.Scopes)
+	assert.True(t, authenticated.HasScope(models.ScopeLogsRead))
+	assert.False(t, authenticated.HasScope(models.ScopeLogsWrite))
+
+	keys, err := store.ListAPIKeys(ctx, project.ID)
+	require.NoError(t, err)
+	scopes := map[string][]models.Scope{}
+	for _, listed := range keys {
+		scopes[listed.Name] = listed.Scopes
+	}
+	assert.Equal(t, map[string][]models.Scope{
+		database.DefaultAPIKeyName: models.AllScopes,
+		"service":                  models.DefaultScopes,
+		"browser":                  {models.ScopeLogsWrite},
+		"dashboard":                {models.ScopeLogsRead},
+	}, scopes)
+
+	// Rotation keeps the scopes
+	rotated, _, err := store.RotateAPIKey(ctx, project.ID, ingest.ID, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Scope{models.ScopeLogsWrite}, rotated.Scopes)
+}
+
+func testAPIKeysExpiry(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	future := time.Now().Add(time.Hour)
+	key, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "temporary", ExpiresAt: &future})
+	require.NoError(t, err)
+	require.NotNil(t, key.ExpiresAt)
+	assert.WithinDuration(t, future, *key.ExpiresAt, time.Millisecond)
+	_, err = store.AuthenticateAPIKey(ctx, key.Key)
+	assert.NoError(t, err)
+
+	past := time.Now().Add(-time.Second)
+	expired, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "expired", ExpiresAt: &past})
+	require.NoError(t, err)
+	_, err = store.AuthenticateAPIKey(ctx, expired.Key)
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+
+	_, _, err = store.RotateAPIKey(ctx, project.ID, expired.ID, time.Hour)
+	assert.ErrorIs(t, err, database.ErrAPIKeyInactive)
+}
+
+func testRevokeAPIKey(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	key, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "leaked"})
+	require.NoError(t, err)
+
+	revoked, err := store.RevokeAPIKey(ctx, project.ID, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	assert.Empty(t, revoked.Key)
+
+	_, err = store.AuthenticateAPIKey(ctx, key.Key)
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+
+	// Other keys keep working
+	_, err = store.AuthenticateAPIKey(ctx, project.APIKey)
+	assert.NoError(t, err)
+
+	// Revoking again keeps the original time
+	again, err := store.RevokeAPIKey(ctx, project.ID, key.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked.RevokedAt.Equal(*again.RevokedAt))
+
+	_, _, err = store.RotateAPIKey(ctx, project.ID, key.ID, time.Hour)
+	assert.ErrorIs(t, err, database.ErrAPIKeyInactive)
+
+	keys, err := store.ListAPIKeys(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2, "revoked keys stay listed")
+}
+
+func testRotateAPIKey(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	keys, err := store.ListAPIKeys(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	oldID := keys[0].ID
+
+	key, old, err := store.RotateAPIKey(ctx, project.ID, oldID, time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldID, key.ID)
+	assert.Equal(t, database.DefaultAPIKeyName, key.Name, "the replacement keeps the name")
+	assert.NotEmpty(t, key.Key)
+	assert.Nil(t, key.ExpiresAt)
+	assert.Equal(t, oldID, old.ID)
+	assert.Empty(t, old.Key)
+	require.NotNil(t, old.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *old.ExpiresAt, time.Minute)
+
+	// Both keys work during the grace period
+	for _, plaintext := range []string{project.APIKey, key.Key} {
+		_, err := store.AuthenticateAPIKey(ctx, plaintext)
+		assert.NoError(t, err)
+	}
+
+	// A shorter grace period brings the old key's expiry forward; zero ends it now
+	_, old, err = store.RotateAPIKey(ctx, project.ID, key.ID, 0)
+	require.NoError(t, err)
+	require.NotNil(t, old.ExpiresAt)
+	_, err = store.AuthenticateAPIKey(ctx, key.Key)
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+
+	// Rotating never extends an earlier expiry
+	soon := time.Now().Add(time.Minute)
+	expiring, err := store.CreateAPIKey(ctx, project.ID, models.CreateAPIKeyRequest{Name: "expiring", ExpiresAt: &soon})
+	require.NoError(t, err)
+	_, old, err = store.RotateAPIKey(ctx, project.ID, expiring.ID, time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, soon, *old.ExpiresAt, time.Millisecond)
+
+	keys, err = store.ListAPIKeys(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 5)
+}
+
+func testAPIKeysNotFound(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+	otherKeys, err := store.ListAPIKeys(ctx, other.ID)
+	require.NoError(t, err)
+	otherKeyID := otherKeys[0].ID
+
+	_, err = store.CreateAPIKey(ctx, uuid.New(), models.CreateAPIKeyRequest{Name: "key"})
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	_, err = store.ListAPIKeys(ctx, uuid.New())
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	_, err = store.RevokeAPIKey(ctx, uuid.New(), otherKeyID)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	_, _, err = store.RotateAPIKey(ctx, uuid.New(), otherKeyID, time.Hour)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+
+	// Another project's key can't be managed through this one
+	_, err = store.RevokeAPIKey(ctx, project.ID, otherKeyID)
+	assert.ErrorIs(t, err, database.ErrAPIKeyNotFound)
+	_, _, err = store.RotateAPIKey(ctx, project.ID, otherKeyID, time.Hour)
+	assert.ErrorIs(t, err, database.ErrAPIKeyNotFound)
+	_, err = store.RevokeAPIKey(ctx, project.ID, uuid.New())
+	assert.ErrorIs(t, err, database.ErrAPIKeyNotFound)
+
+	_, err = store.AuthenticateAPIKey(ctx, other.APIKey)
+	assert.NoError(t, err, "the other project's key is untouched")
+}
+
+func createProject(t *testing.T, store storage.Store, name string) *models.Project {
+	t.Helper()
+
+	project, err := store.CreateProject(context.Background(), name)
+	require.NoError(t, err)
+	return project
+}
+
+func messages(logs []models.LogEntry) []string {
+	result := make([]string, len(logs))
+	for i, entry := range logs {
+		result[i] = entry.Message
+	}
+	return result
+}
+
+func testAdminUsers(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	alice, err := store.CreateAdminUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, alice.ID)
+	assert.NotEmpty(t, alice.Token)
+	assert.Equal(t, alice.Token[:len(alice.TokenPrefix)], alice.TokenPrefix)
+	assert.Nil(t, alice.LastUsedAt)
+
+	_, err = store.CreateAdminUser(ctx, "alice")
+	assert.ErrorIs(t, err, database.ErrAdminUserExists)
+
+	time.Sleep(time.Millisecond)
+	bob, err := store.CreateAdminUser(ctx, "bob")
+	require.NoError(t, err)
+
+	user, err := store.AuthenticateAdmin(ctx, alice.Token)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+	assert.Empty(t, user.Token)
+	assert.NotNil(t, user.LastUsedAt)
+
+	for _, token := range []string{"", "jazz_invalid", alice.Token + "x", alice.TokenPrefix} {
+		_, err = store.AuthenticateAdmin(ctx, token)
+		assert.ErrorIs(t, err, database.ErrInvalidAdminToken, "token %q", token)
+	}
+
+	users, err := store.ListAdminUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, alice.ID, users[0].ID, "oldest first")
+	assert.Equal(t, bob.ID, users[1].ID)
+	assert.Empty(t, users[0].Token)
+
+	require.NoError(t, store.DeleteAdminUser(ctx, alice.ID))
+	_, err = store.AuthenticateAdmin(ctx, alice.Token)
+	assert.ErrorIs(t, err, database.ErrInvalidAdminToken)
+	assert.ErrorIs(t, store.DeleteAdminUser(ctx, alice.ID), database.ErrAdminUserNotFound)
+
+	// API keys and admin tokens are separate credentials
+	project := createProject(t, store, "Test Project")
+	_, err = store.AuthenticateAdmin(ctx, project.APIKey)
+	assert.ErrorIs(t, err, database.ErrInvalidAdminToken)
+	_, err = store.AuthenticateAPIKey(ctx, bob.Token)
+	assert.ErrorIs(t, err, database.ErrInvalidAPIKey)
+}
+
+func testUsers(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	alice, err := store.CreateUser(ctx, " Alice@Example.com ", "Alice", "correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, alice.ID)
+	assert.Equal(t, "alice@example.com", alice.Email, "emails are normalized")
+
+	_, err = store.CreateUser(ctx, "ALICE@example.com", "Alice Again", "another password")
+	assert.ErrorIs(t, err, database.ErrUserExists)
+
+	user, err := store.AuthenticateUser(ctx, "alice@EXAMPLE.com", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+	assert.Equal(t, "Alice", user.Name)
+
+	_, err = store.AuthenticateUser(ctx, "alice@example.com", "wrong password")
+	assert.ErrorIs(t, err, database.ErrInvalidCredentials)
+	_, err = store.AuthenticateUser(ctx, "nobody@example.com", "correct horse")
+	assert.ErrorIs(t, err, database.ErrInvalidCredentials)
+
+	user, err = store.GetUserByEmail(ctx, "ALICE@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+	_, err = store.GetUserByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, database.ErrUserNotFound)
+}
+
+func testSessions(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	alice, err := store.CreateUser(ctx, "alice@example.com", "Alice", "correct horse")
+	require.NoError(t, err)
+
+	session, err := store.CreateSession(ctx, alice.ID, time.Hour)
+	require.NoError(t, err)
+	assert.NotEmpty(t, session.Token)
+	assert.Equal(t, alice.ID, session.User.ID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), session.ExpiresAt, time.Minute)
+
+	got, err := store.AuthenticateSession(ctx, session.Token)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, got.ID)
+	assert.Equal(t, alice.Email, got.User.Email)
+	assert.Empty(t, got.Token)
+
+	for _, token := range []string{"", "jazz_invalid", session.Token + "x"} {
+		_, err = store.AuthenticateSession(ctx, token)
+		assert.ErrorIs(t, err, database.ErrInvalidSession, "token %q", token)
+	}
+
+	expired, err := store.CreateSession(ctx, alice.ID, -time.Second)
+	require.NoError(t, err)
+	_, err = store.AuthenticateSession(ctx, expired.Token)
+	assert.ErrorIs(t, err, database.ErrInvalidSession, "expired session")
+
+	_, err = store.CreateSession(ctx, uuid.New(), time.Hour)
+	assert.ErrorIs(t, err, database.ErrUserNotFound)
+
+	require.NoError(t, store.DeleteSession(ctx, session.ID))
+	_, err = store.AuthenticateSession(ctx, session.Token)
+	assert.ErrorIs(t, err, database.ErrInvalidSession)
+	assert.NoError(t, store.DeleteSession(ctx, session.ID), "deleting twice is not an error")
+
+	// Session tokens are separate from API keys
+	project := createProject(t, store, "Test Project")
+	_, err = store.AuthenticateSession(ctx, project.APIKey)
+	assert.ErrorIs(t, err, database.ErrInvalidSession)
+}
+
+func testOrganizations(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	alice, err := store.CreateUser(ctx, "alice@example.com", "Alice", "correct horse")
+	require.NoError(t, err)
+	bob, err := store.CreateUser(ctx, "bob@example.com", "Bob", "battery staple")
+	require.NoError(t, err)
+
+	acme, err := store.CreateOrganization(ctx, "Acme", alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleOwner, acme.Role)
+
+	_, err = store.CreateOrganization(ctx, "Nobody's", uuid.New())
+	assert.ErrorIs(t, err, database.ErrUserNotFound)
+
+	time.Sleep(time.Millisecond)
+	globex, err := store.CreateOrganization(ctx, "Globex", bob.ID)
+	require.NoError(t, err)
+
+	member, err := store.AddMember(ctx, globex.ID, "ALICE@example.com", models.RoleViewer)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, member.UserID)
+	assert.Equal(t, alice.Email, member.Email)
+	assert.Equal(t, models.RoleViewer, member.Role)
+
+	_, err = store.AddMember(ctx, globex.ID, "alice@example.com", models.RoleMember)
+	assert.ErrorIs(t, err, database.ErrMemberExists)
+	_, err = store.AddMember(ctx, globex.ID, "nobody@example.com", models.RoleMember)
+	assert.ErrorIs(t, err, database.ErrUserNotFound)
+	_, err = store.AddMember(ctx, uuid.New(), "bob@example.com", models.RoleMember)
+	assert.ErrorIs(t, err, database.ErrOrganizationNotFound)
+
+	orgs, err := store.ListOrganizations(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, orgs, 2)
+	assert.Equal(t, acme.ID, orgs[0].ID, "oldest first")
+	assert.Equal(t, models.RoleOwner, orgs[0].Role)
+	assert.Equal(t, globex.ID, orgs[1].ID)
+	assert.Equal(t, models.RoleViewer, orgs[1].Role)
+
+	members, err := store.ListMembers(ctx, globex.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, bob.ID, members[0].UserID)
+	assert.Equal(t, models.RoleOwner, members[0].Role)
+
+	_, err = store.ListMembers(ctx, uuid.New())
+	assert.ErrorIs(t, err, database.ErrOrganizationNotFound)
+
+	member, err = store.UpdateMemberRole(ctx, globex.ID, alice.ID, models.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, member.Role)
+
+	got, err := store.GetMembership(ctx, globex.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, got.Role)
+
+	_, err = store.GetMembership(ctx, acme.ID, bob.ID)
+	assert.ErrorIs(t, err, database.ErrMemberNotFound)
+	_, err = store.UpdateMemberRole(ctx, acme.ID, bob.ID, models.RoleAdmin)
+	assert.ErrorIs(t, err, database.ErrMemberNotFound)
+
+	require.NoError(t, store.RemoveMember(ctx, globex.ID, alice.ID))
+	assert.ErrorIs(t, store.RemoveMember(ctx, globex.ID, alice.ID), database.ErrMemberNotFound)
+
+	orgs, err = store.ListOrganizations(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Len(t, orgs, 1)
+}
+
+func testOrganizationsLastOwner(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	alice, err := store.CreateUser(ctx, "alice@example.com", "Alice", "correct horse")
+	require.NoError(t, err)
+	bob, err := store.CreateUser(ctx, "bob@example.com", "Bob", "battery staple")
+	require.NoError(t, err)
+
+	org, err := store.CreateOrganization(ctx, "Acme", alice.ID)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, store.RemoveMember(ctx, org.ID, alice.ID), database.ErrLastOwner)
+	_, err = store.UpdateMemberRole(ctx, org.ID, alice.ID, models.RoleAdmin)
+	assert.ErrorIs(t, err, database.ErrLastOwner)
+
+	_, err = store.UpdateMemberRole(ctx, org.ID, alice.ID, models.RoleOwner)
+	assert.NoError(t, err, "an owner stays an owner")
+
+	// With a second owner, either can step down
+	_, err = store.AddMember(ctx, org.ID, bob.Email, models.RoleOwner)
+	require.NoError(t, err)
+	_, err = store.UpdateMemberRole(ctx, org.ID, alice.ID, models.RoleMember)
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.RemoveMember(ctx, org.ID, bob.ID), database.ErrLastOwner)
+}
+
+func testOrganizationProjects(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+
+	alice, err := store.CreateUser(ctx, "alice@example.com", "Alice", "correct horse")
+	require.NoError(t, err)
+	bob, err := store.CreateUser(ctx, "bob@example.com", "Bob", "battery staple")
+	require.NoError(t, err)
+	org, err := store.CreateOrganization(ctx, "Acme", alice.ID)
+	require.NoError(t, err)
+	_, err = store.AddMember(ctx, org.ID, bob.Email, models.RoleViewer)
+	require.NoError(t, err)
+
+	first, err := store.CreateOrganizationProject(ctx, org.ID, "First")
+	require.NoError(t, err)
+	require.NotNil(t, first.OrgID)
+	assert.Equal(t, org.ID, *first.OrgID)
+	assert.NotEmpty(t, first.APIKey)
+
+	time.Sleep(time.Millisecond)
+	second, err := store.CreateOrganizationProject(ctx, org.ID, "Second")
+	require.NoError(t, err)
+
+	unowned := createProject(t, store, "Unowned")
+	assert.Nil(t, unowned.OrgID)
+
+	_, err = store.CreateOrganizationProject(ctx, uuid.New(), "Orphan")
+	assert.ErrorIs(t, err, database.ErrOrganizationNotFound)
+
+	projects, err := store.ListOrganizationProjects(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	assert.Equal(t, second.ID, projects[0].ID, "newest first")
+	assert.Equal(t, first.ID, projects[1].ID)
+
+	got, err := store.GetProject(ctx, first.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.OrgID)
+	assert.Equal(t, org.ID, *got.OrgID)
+
+	role, err := store.ProjectRole(ctx, first.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleOwner, role)
+	role, err = store.ProjectRole(ctx, first.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleViewer, role)
+
+	_, err = store.ProjectRole(ctx, unowned.ID, alice.ID)
+	assert.ErrorIs(t, err, database.ErrMemberNotFound)
+	_, err = store.ProjectRole(ctx, first.ID, uuid.New())
+	assert.ErrorIs(t, err, database.ErrMemberNotFound)
+	_, err = store.ProjectRole(ctx, uuid.New(), alice.ID)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+}
+
+func testProjectGroups(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+
+	grant, err := store.GrantProjectGroup(ctx, project.ID, "sre")
+	require.NoError(t, err)
+	assert.Equal(t, project.ID, grant.ProjectID)
+	assert.Equal(t, "sre", grant.Group)
+	_, err = store.GrantProjectGroup(ctx, project.ID, "eng")
+	require.NoError(t, err)
+
+	_, err = store.GrantProjectGroup(ctx, project.ID, "sre")
+	assert.ErrorIs(t, err, database.ErrProjectGroupExists)
+	_, err = store.GrantProjectGroup(ctx, uuid.New(), "sre")
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+
+	groups, err := store.ListProjectGroups(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "eng", groups[0].Group, "name order")
+	assert.Equal(t, "sre", groups[1].Group)
+
+	groups, err = store.ListProjectGroups(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+	_, err = store.ListProjectGroups(ctx, uuid.New())
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+
+	ok, err := store.HasProjectGroup(ctx, project.ID, []string{"marketing", "sre"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	for _, tt := range []struct {
+		projectID uuid.UUID
+		groups    []string
+	}{
+		{project.ID, []string{"marketing"}},
+		{project.ID, []string{"SRE"}},
+		{project.ID, nil},
+		{other.ID, []string{"sre"}},
+		{uuid.New(), []string{"sre"}},
+	} {
+		ok, err := store.HasProjectGroup(ctx, tt.projectID, tt.groups)
+		require.NoError(t, err)
+		assert.False(t, ok, "%v", tt.groups)
+	}
+
+	require.NoError(t, store.RevokeProjectGroup(ctx, project.ID, "sre"))
+	assert.ErrorIs(t, store.RevokeProjectGroup(ctx, project.ID, "sre"), database.ErrProjectGroupNotFound)
+	ok, err = store.HasProjectGroup(ctx, project.ID, []string{"sre"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Deleting the project deletes its grants
+	require.NoError(t, store.DeleteProject(ctx, project.ID))
+	ok, err = store.HasProjectGroup(ctx, project.ID, []string{"eng"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testAuditEvents(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	actorID := uuid.New()
+
+	created := &models.AuditEvent{
+		ActorType:  models.AuditActorAdmin,
+		ActorName:  models.BootstrapAdminName,
+		Action:     models.AuditProjectCreate,
+		TargetType: models.AuditTargetProject,
+		TargetID:   project.ID.String(),
+		ProjectID:  &project.ID,
+		RequestID:  "req-1",
+		SourceIP:   "192.0.2.1",
+		After:      []byte(`{"name":"Test Project"}`),
+	}
+	require.NoError(t, store.RecordAuditEvent(ctx, created))
+	assert.NotEqual(t, uuid.Nil, created.ID)
+	assert.WithinDuration(t, time.Now(), created.OccurredAt, time.Minute)
+
+	revoked := &models.AuditEvent{
+		ActorType:  models.AuditActorUser,
+		ActorID:    &actorID,
+		ActorName:  "alice@example.com",
+		Action:     models.AuditAPIKeyRevoke,
+		TargetType: models.AuditTargetAPIKey,
+		TargetID:   uuid.NewString(),
+		ProjectID:  &project.ID,
+		RequestID:  "req-2",
+	}
+	require.NoError(t, store.RecordAuditEvent(ctx, revoked))
+	adminDeleted := &models.AuditEvent{
+		ActorType:  models.AuditActorAdmin,
+		ActorName:  models.BootstrapAdminName,
+		Action:     models.AuditAdminUserDelete,
+		TargetType: models.AuditTargetAdminUser,
+		TargetID:   uuid.NewString(),
+	}
+	require.NoError(t, store.RecordAuditEvent(ctx, adminDeleted))
+
+	events, total, err := store.ListAuditEvents(ctx, models.AuditQueryParams{Limit: 50})
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, events, 3)
+	assert.Equal(t, adminDeleted.ID, events[0].ID, "newest first")
+	assert.Equal(t, created.ID, events[2].ID)
+	assert.Nil(t, events[0].ProjectID)
+	assert.Nil(t, events[0].Before)
+
+	got := events[2]
+	assert.Equal(t, models.AuditActorAdmin, got.ActorType)
+	assert.Nil(t, got.ActorID)
+	assert.Equal(t, models.AuditProjectCreate, got.Action)
+	assert.Equal(t, project.ID.String(), got.TargetID)
+	require.NotNil(t, got.ProjectID)
+	assert.Equal(t, project.ID, *got.ProjectID)
+	assert.Equal(t, "req-1", got.RequestID)
+	assert.Equal(t, "192.0.2.1", got.SourceIP)
+	assert.Nil(t, got.Before)
+	assert.JSONEq(t, `{"name":"Test Project"}`, string(got.After))
+	assert.True(t, created.OccurredAt.Equal(got.OccurredAt))
+
+	for _, tt := range []struct {
+		name   string
+		params models.AuditQueryParams
+		want   []uuid.UUID
+	}{
+		{"project", models.AuditQueryParams{ProjectID: project.ID.String()}, []uuid.UUID{revoked.ID, created.ID}},
+		{"actor", models.AuditQueryParams{ActorID: actorID.String()}, []uuid.UUID{revoked.ID}},
+		{"action", models.AuditQueryParams{Action: models.AuditAdminUserDelete}, []uuid.UUID{adminDeleted.ID}},
+		{"since", models.AuditQueryParams{StartTime: "now-1h"}, []uuid.UUID{adminDeleted.ID, revoked.ID, created.ID}},
+		{"until", models.AuditQueryParams{EndTime: "now-1h"}, nil},
+		{"page", models.AuditQueryParams{Limit: 1, Offset: 1}, []uuid.UUID{revoked.ID}},
+	} {
+		events, _, err := store.ListAuditEvents(ctx, tt.params)
+		require.NoError(t, err, tt.name)
+		ids := make([]uuid.UUID, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		assert.ElementsMatch(t, tt.want, ids, tt.name)
+		if len(tt.want) > 1 {
+			assert.Equal(t, tt.want, ids, "%s: newest first", tt.name)
+		}
+	}
+
+	for _, params := range []models.AuditQueryParams{
+		{ProjectID: "not-a-uuid"},
+		{ActorID: "not-a-uuid"},
+		{StartTime: "yesterday"},
+		{StartTime: "now", EndTime: "now-1h"},
+	} {
+		_, _, err := store.ListAuditEvents(ctx, params)
+		var queryErr *database.QueryError
+		assert.True(t, errors.As(err, &queryErr), "%+v: %v", params, err)
+	}
+
+	// Events outlive the projects they mention
+	require.NoError(t, store.DeleteProject(ctx, project.ID))
+	_, total, err = store.ListAuditEvents(ctx, models.AuditQueryParams{ProjectID: project.ID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func testTakeTokens(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+
+	// A new bucket is full, and a full one admits more than it holds
+	wait, err := store.TakeTokens(ctx, project.ID, models.BucketEntries, 10, 25)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	// ...leaving 15 tokens of debt, 1.5s at 10 per second
+	wait, err = store.TakeTokens(ctx, project.ID, models.BucketEntries, 10, 1)
+	require.NoError(t, err)
+	assert.Greater(t, wait, time.Second)
+	assert.LessOrEqual(t, wait, 1500*time.Millisecond)
+
+	// Buckets are per project and per kind
+	wait, err = store.TakeTokens(ctx, project.ID, models.BucketRequests, 10, 1)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+	wait, err = store.TakeTokens(ctx, other.ID, models.BucketEntries, 10, 1)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
+
+func testChargeIngestQuota(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+	day := time.Date(2024, 11, 20, 9, 30, 0, 0, time.UTC)
+	quota := models.IngestUsage{Entries: 10, Bytes: 1000}
+
+	for _, tt := range []struct {
+		usage models.IngestUsage
+		want  bool
+	}{
+		{models.IngestUsage{Entries: 6, Bytes: 100}, true},
+		{models.IngestUsage{Entries: 5, Bytes: 100}, false},
+		{models.IngestUsage{Entries: 4, Bytes: 100}, true},
+		{models.IngestUsage{Entries: 1, Bytes: 1}, false},
+	} {
+		charged, err := store.ChargeIngestQuota(ctx, project.ID, day, tt.usage, quota)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, charged, "%+v", tt.usage)
+	}
+
+	// Bytes are limited separately, and a zero quota is unlimited
+	charged, err := store.ChargeIngestQuota(ctx, other.ID, day, models.IngestUsage{Entries: 1, Bytes: 1001}, quota)
+	require.NoError(t, err)
+	assert.False(t, charged, "over the byte quota")
+	charged, err = store.ChargeIngestQuota(ctx, other.ID, day, models.IngestUsage{Entries: 1000, Bytes: 900},
+		models.IngestUsage{Bytes: 1000})
+	require.NoError(t, err)
+	assert.True(t, charged, "no entry quota")
+
+	// Usage is per UTC day
+	charged, err = store.ChargeIngestQuota(ctx, project.ID, day.Add(15*time.Hour), models.IngestUsage{Entries: 10}, quota)
+	require.NoError(t, err)
+	assert.True(t, charged, "next day")
+	charged, err = store.ChargeIngestQuota(ctx, project.ID, day.Add(16*time.Hour), models.IngestUsage{Entries: 1}, quota)
+	require.NoError(t, err)
+	assert.False(t, charged)
+
+	// A batch bigger than the quota never fits, even on a fresh day
+	charged, err = store.ChargeIngestQuota(ctx, project.ID, day.AddDate(0, 0, 7), models.IngestUsage{Entries: 11}, quota)
+	require.NoError(t, err)
+	assert.False(t, charged)
+}
+
+func testPurgeIngestUsage(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	yesterday := createProject(t, store, "Yesterday")
+	today := createProject(t, store, "Today")
+	now := time.Date(2024, 11, 21, 8, 0, 0, 0, time.UTC)
+	quota := models.IngestUsage{Entries: 10}
+
+	charged, err := store.ChargeIngestQuota(ctx, yesterday.ID, now.AddDate(0, 0, -1), models.IngestUsage{Entries: 10}, quota)
+	require.NoError(t, err)
+	require.True(t, charged)
+	charged, err = store.ChargeIngestQuota(ctx, today.ID, now.Add(-time.Hour), models.IngestUsage{Entries: 10}, quota)
+	require.NoError(t, err)
+	require.True(t, charged)
+
+	purged, err := store.PurgeIngestUsage(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged, "only past days are purged")
+
+	// Today's usage still counts against the quota
+	charged, err = store.ChargeIngestQuota(ctx, today.ID, now, models.IngestUsage{Entries: 1}, quota)
+	require.NoError(t, err)
+	assert.False(t, charged)
+
+	purged, err = store.PurgeIngestUsage(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
+
+func testGetLogContext(t *testing.T, store storage.Store) {
+	contextStore, ok := store.(storage.ContextStore)
+	if !ok {
+		t.Skip("store doesn't implement storage.ContextStore")
+	}
+
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	logs := make([]models.LogEntry, 9)
+	for i := range logs {
+		source := "backend"
+		if i%2 == 1 {
+			source = "worker"
+		}
+		logs[i] = models.LogEntry{
+			ID:        uuid.New(),
+			ProjectID: project.ID,
+			Level:     "info",
+			Message:   fmt.Sprintf("Log %d", i),
+			Source:    source,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		}
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+	target := logs[4]
+	two, three, zero, five := 2, 3, 0, 5
+
+	response, err := contextStore.GetLogContext(ctx, project.ID, target.ID, models.LogContextParams{Before: &two, After: &three})
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, response.Log.ID)
+	assert.Equal(t, []string{"Log 2", "Log 3"}, messages(response.Before))
+	assert.Equal(t, []string{"Log 5", "Log 6", "Log 7"}, messages(response.After))
+
+	response, err = contextStore.GetLogContext(ctx, project.ID, target.ID, models.LogContextParams{SameSource: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Log 0", "Log 2"}, messages(response.Before))
+	assert.Equal(t, []string{"Log 6", "Log 8"}, messages(response.After))
+
+	response, err = contextStore.GetLogContext(ctx, project.ID, target.ID, models.LogContextParams{Before: &zero, After: &five})
+	require.NoError(t, err)
+	assert.Empty(t, response.Before)
+	assert.Equal(t, []string{"Log 5", "Log 6", "Log 7", "Log 8"}, messages(response.After))
+
+	// Entries logged at the same time are ordered by ID
+	same := make([]models.LogEntry, 5)
+	for i := range same {
+		same[i] = models.LogEntry{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Same time", Timestamp: base.Add(time.Minute)}
+	}
+	require.NoError(t, store.InsertLogsBatch(ctx, same))
+	sort.Slice(same, func(i, j int) bool { return same[i].ID.String() < same[j].ID.String() })
+
+	response, err = contextStore.GetLogContext(ctx, project.ID, same[2].ID, models.LogContextParams{Before: &two, After: &two})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{same[0].ID, same[1].ID}, ids(response.Before))
+	assert.Equal(t, []uuid.UUID{same[3].ID, same[4].ID}, ids(response.After))
+
+	other := createProject(t, store, "Other Project")
+	_, err = contextStore.GetLogContext(ctx, other.ID, target.ID, models.LogContextParams{})
+	assert.ErrorIs(t, err, database.ErrLogNotFound)
+	_, err = contextStore.GetLogContext(ctx, project.ID, uuid.New(), models.LogContextParams{})
+	assert.ErrorIs(t, err, database.ErrLogNotFound)
+}
+
+func testFacetLogs(t *testing.T, store storage.Store) {
+	facetStore, ok := store.(storage.FacetStore)
+	if !ok {
+		t.Skip("store doesn't implement storage.FacetStore")
+	}
+
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+
+	now := time.Now()
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database down", Source: "api", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "error", Message: "database down", Source: "worker", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "request served", Source: "api", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "warning", Message: "database slow", Source: "api", Timestamp: now},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "old request", Source: "api", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: uuid.New(), ProjectID: other.ID, Level: "debug", Message: "database elsewhere", Source: "cron", Timestamp: now},
+	}))
+
+	facets, err := facetStore.FacetLogs(ctx, project.ID, models.QueryParams{Limit: 1, Offset: 3}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), facets.Total, "limit and offset are ignored")
+	assert.Equal(t, []models.FacetValue{{Value: "api", Count: 4}, {Value: "worker", Count: 1}}, facets.Facets["source"])
+	assert.Equal(t, []models.FacetValue{{Value: "error", Count: 2}, {Value: "info", Count: 2}, {Value: "warning", Count: 1}}, facets.Facets["level"],
+		"ties are ordered by value")
+
+	facets, err = facetStore.FacetLogs(ctx, project.ID, models.QueryParams{StartTime: "now-1h", Search: "database"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), facets.Total)
+	assert.Equal(t, []models.FacetValue{{Value: "error", Count: 2}}, facets.Facets["level"])
+	assert.Equal(t, []models.FacetValue{{Value: "api", Count: 2}}, facets.Facets["source"])
+
+	facets, err = facetStore.FacetSearch(ctx, project.ID, models.SearchRequest{Query: "database", Level: "error"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), facets.Total)
+	assert.Equal(t, []models.FacetValue{{Value: "api", Count: 1}, {Value: "worker", Count: 1}}, facets.Facets["source"])
+
+	facets, err = facetStore.FacetLogs(ctx, project.ID, models.QueryParams{Level: "fatal"}, 0)
+	require.NoError(t, err)
+	assert.Zero(t, facets.Total)
+	assert.Empty(t, facets.Facets["level"])
+	assert.NotNil(t, facets.Facets["source"], "facets without values are empty, not missing")
+
+	var queryErr *database.QueryError
+	_, err = facetStore.FacetSearch(ctx, project.ID, models.SearchRequest{}, 0)
+	assert.True(t, errors.As(err, &queryErr), "empty query: expected *database.QueryError, got %v", err)
+	_, err = facetStore.FacetLogs(ctx, project.ID, models.QueryParams{StartTime: "yesterday-ish"}, 0)
+	assert.True(t, errors.As(err, &queryErr), "bad time: expected *database.QueryError, got %v", err)
+}
+
+func testExportLogs(t *testing.T, store storage.Store) {
+	exportStore, ok := store.(storage.ExportStore)
+	if !ok {
+		t.Skip("store doesn't implement storage.ExportStore")
+	}
+
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	other := createProject(t, store, "Other Project")
+
+	base := time.Now().Add(-time.Hour)
+	logs := make([]models.LogEntry, 0, 120)
+	for i := 0; i < 120; i++ {
+		level := "info"
+		if i%3 == 0 {
+			level = "error"
+		}
+		logs = append(logs, models.LogEntry{
+			ID:        uuid.New(),
+			ProjectID: project.ID,
+			Level:     level,
+			Message:   fmt.Sprintf("Entry %03d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	logs = append(logs, models.LogEntry{ID: uuid.New(), ProjectID: other.ID, Level: "error", Message: "Elsewhere", Timestamp: base})
+	require.NoError(t, store.InsertLogsBatch(ctx, logs))
+
+	var exported []models.LogEntry
+	collect := func(entry models.LogEntry) error {
+		exported = append(exported, entry)
+		return nil
+	}
+
+	// Every match, past any page limit, in the requested order
+	count, err := exportStore.ExportLogs(ctx, project.ID, models.QueryParams{Level: "error", Sort: "timestamp:asc", Limit: 10}, collect)
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), count)
+	require.Len(t, exported, 40)
+	assert.Equal(t, "Entry 000", exported[0].Message)
+	assert.Equal(t, "Entry 117", exported[39].Message)
+
+	// fn's error stops the export
+	stop := errors.New("client went away")
+	exported = nil
+	count, err = exportStore.ExportLogs(ctx, project.ID, models.QueryParams{}, func(entry models.LogEntry) error {
+		if len(exported) == 5 {
+			return stop
+		}
+		return collect(entry)
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, int64(5), count)
+	assert.Equal(t, "Entry 119", exported[0].Message, "newest first by default")
+
+	var queryErr *database.QueryError
+	_, err = exportStore.ExportLogs(ctx, project.ID, models.QueryParams{Sort: "nope"}, collect)
+	assert.True(t, errors.As(err, &queryErr), "bad sort: expected *database.QueryError, got %v", err)
+}
+
+func testRetention(t *testing.T, store storage.Store) {
+	retentionStore, ok := store.(storage.RetentionStore)
+	if !ok {
+		t.Skip("store doesn't implement storage.RetentionStore")
+	}
+
+	ctx := context.Background()
+	project := createProject(t, store, "Test Project")
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	oldest := now.AddDate(0, 0, -10)
+	require.NoError(t, store.InsertLogsBatch(ctx, []models.LogEntry{
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Ten days old", Timestamp: oldest},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "Five days old", Timestamp: now.AddDate(0, 0, -5)},
+		{ID: uuid.New(), ProjectID: project.ID, Level: "info", Message: "New", Timestamp: now},
+	}))
+
+	// Without a policy nothing is expired
+	status, err := retentionStore.GetRetentionStatus(ctx, project.ID, now)
+	require.NoError(t, err)
+	assert.Nil(t, status.RetentionDays)
+	assert.Nil(t, status.Cutoff)
+	require.NotNil(t, status.OldestLog)
+	assert.True(t, oldest.Equal(*status.OldestLog))
+	assert.Zero(t, status.ExpiredLogs)
+	assert.Nil(t, status.LastRun)
+
+	days := 7
+	updated, err := retentionStore.SetProjectRetention(ctx, project.ID, &days)
+	require.NoError(t, err)
+	require.NotNil(t, updated.RetentionDays)
+	assert.Equal(t, 7, *updated.RetentionDays)
+
+	status, err = retentionStore.GetRetentionStatus(ctx, project.ID, now)
+	require.NoError(t, err)
+	require.NotNil(t, status.Cutoff)
+	assert.True(t, database.RetentionCutoff(now, 7).Equal(*status.Cutoff))
+	assert.Equal(t, int64(1), status.ExpiredLogs)
+
+	updated, err = retentionStore.SetProjectRetention(ctx, project.ID, nil)
+	require.NoError(t, err)
+	assert.Nil(t, updated.RetentionDays)
+
+	_, err = retentionStore.SetProjectRetention(ctx, uuid.New(), &days)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+	_, err = retentionStore.GetRetentionStatus(ctx, uuid.New(), now)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound)
+
+	require.NoError(t, store.DeleteProject(ctx, project.ID))
+	_, err = retentionStore.SetProjectRetention(ctx, project.ID, &days)
+	assert.ErrorIs(t, err, database.ErrProjectNotFound, "deleted projects can't be changed")
+}
+
+func ids(logs []models.LogEntry) []uuid.UUID {
+	result := make([]uuid.UUID, len(logs))
+	for i, entry := range logs {
+		result[i] = entry.ID
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package tail
+
+import (
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"strings"
+	"time"
+)
+
+// Filter selects which entries a subscriber receives.
+// Built from the same QueryParams as GET /logs; zero value matches everything.
+type Filter struct {
+	Level     string
+	Source    string
+	StartTime time.Time
+	EndTime   time.Time
+	Terms     []string
+}
+
+// NewFilter builds a Filter from GET /logs query parameters.
+// Limit and Offset are ignored. Relative times such as "now-5m" are resolved once,
+// when the filter is built. Search is parsed with the same rules as full-text
+// search, but matched in-process: every term must appear in the message (case-insensitive).
+// Returns error if the search query or time range is invalid.
+func NewFilter(params models.QueryParams) (Filter, error) {
+	filter := Filter{
+		Level:  params.Level,
+		Source: params.Source,
+	}
+
+	if params.Search != "" {
+		tsQuery, err := database.NewSearchQueryParser().Parse(params.Search)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid search query: %w", err)
+		}
+		filter.Terms = strings.Split(tsQuery, " & ")
+	}
+
+	loc, err := database.LoadTimezone(params.TZ)
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid tz: %w", err)
+	}
+	parser := database.NewTimeParser(time.Now(), loc)
+
+	if params.StartTime != "" {
+		startTime, err := parser.Parse(params.StartTime)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid start_time: %w", err)
+		}
+		filter.StartTime = startTime
+	}
+	if params.EndTime != "" {
+		endTime, err := parser.Parse(params.EndTime)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid end_time: %w", err)
+		}
+		filter.EndTime = endTime
+	}
+
+	return filter, nil
+}
+
+// Match reports whether entry passes every filter condition.
+func (f Filter) Match(entry models.LogEntry) bool {
+	if f.Level != "" && entry.Level != f.Level {
+		return false
+	}
+	if f.Source != "" && entry.Source != f.Source {
+		return false
+	}
+	if !f.StartTime.IsZero() && entry.Timestamp.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && entry.Timestamp.After(f.EndTime) {
+		return false
+	}
+	if len(f.Terms) > 0 {
+		message := strings.ToLower(entry.Message)
+		for _, term := range f.Terms {
+			if !strings.Contains(message, term) {
+				return false
+			}
+		}
+	}
+	return true
+}
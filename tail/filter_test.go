@@ -0,0 +1,83 @@
+package tail
+
+import (
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  models.QueryParams
+		wantErr string
+	}{
+		{name: "empty", params: models.QueryParams{}},
+		{name: "all filters", params: models.QueryParams{
+			Level: "error", Source: "backend", Search: "database error",
+			StartTime: "2024-11-01T00:00:00Z", EndTime: "2024-11-22T23:59:59Z",
+		}},
+		{name: "invalid search", params: models.QueryParams{Search: "ab"}, wantErr: "invalid search query"},
+		{name: "invalid start time", params: models.QueryParams{StartTime: "yesterday"}, wantErr: "invalid start_time"},
+		{name: "invalid end time", params: models.QueryParams{EndTime: "tomorrow"}, wantErr: "invalid end_time"},
+		{name: "relative time", params: models.QueryParams{StartTime: "now-5m", TZ: "Asia/Tokyo"}},
+		{name: "invalid tz", params: models.QueryParams{StartTime: "now-5m", TZ: "Nowhere/Land"}, wantErr: "invalid tz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFilter(tt.params)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	filter, err := NewFilter(models.QueryParams{
+		Level:     "error",
+		Source:    "backend",
+		Search:    "Database Timeout",
+		StartTime: "2024-11-01T00:00:00Z",
+		EndTime:   "2024-11-30T00:00:00Z",
+	})
+	require.NoError(t, err)
+
+	match := models.LogEntry{
+		Level:     "error",
+		Source:    "backend",
+		Message:   "database query timeout after 30s",
+		Timestamp: time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e *models.LogEntry)
+		want   bool
+	}{
+		{name: "all conditions match", modify: func(*models.LogEntry) {}, want: true},
+		{name: "wrong level", modify: func(e *models.LogEntry) { e.Level = "info" }, want: false},
+		{name: "wrong source", modify: func(e *models.LogEntry) { e.Source = "frontend" }, want: false},
+		{name: "missing term", modify: func(e *models.LogEntry) { e.Message = "database ok" }, want: false},
+		{name: "terms are case-insensitive", modify: func(e *models.LogEntry) { e.Message = "DATABASE TIMEOUT" }, want: true},
+		{name: "before start", modify: func(e *models.LogEntry) { e.Timestamp = time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC) }, want: false},
+		{name: "after end", modify: func(e *models.LogEntry) { e.Timestamp = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC) }, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := match
+			tt.modify(&entry)
+			assert.Equal(t, tt.want, filter.Match(entry))
+		})
+	}
+
+	assert.True(t, Filter{}.Match(models.LogEntry{Level: "debug"}), "zero filter matches everything")
+}
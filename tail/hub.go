@@ -0,0 +1,152 @@
+// Package tail fans out newly ingested logs to live subscribers.
+// A Hub delivers entries published on this instance; a Relay forwards them
+// through PostgreSQL LISTEN/NOTIFY so subscribers on every API instance see them.
+package tail
+
+import (
+	"context"
+	"jazz/models"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBufferSize is the number of entries buffered per subscriber.
+// When a subscriber falls further behind, new entries are dropped for it.
+const DefaultBufferSize = 256
+
+// Forwarder sends published entries to other API instances.
+// Implemented by Relay.
+type Forwarder interface {
+	Forward(ctx context.Context, entries []models.LogEntry) error
+}
+
+// Hub fans out log entries to subscribers in this process.
+// Publishing never blocks on slow subscribers: each has a bounded buffer,
+// and entries that don't fit are dropped and counted.
+// Safe for concurrent use.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+	forwarder   Forwarder
+	dropped     atomic.Uint64
+}
+
+// NewHub creates a Hub with the given per-subscriber buffer size.
+// Zero or negative uses DefaultBufferSize.
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Hub{
+		subscribers: map[*Subscription]struct{}{},
+		bufferSize:  bufferSize,
+	}
+}
+
+// SetForwarder configures where published entries are forwarded for other instances.
+// Must be called before the hub is shared between goroutines.
+func (h *Hub) SetForwarder(f Forwarder) {
+	h.forwarder = f
+}
+
+// Subscribe registers a subscriber for a project's entries matching filter.
+// The caller must Close the subscription when done to release it.
+func (h *Hub) Subscribe(projectID uuid.UUID, filter Filter) *Subscription {
+	sub := &Subscription{
+		ProjectID: projectID,
+		filter:    filter,
+		ch:        make(chan models.LogEntry, h.bufferSize),
+		hub:       h,
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers entries to local subscribers and forwards them to other instances.
+// Called after entries are stored. Forwarding errors are logged, not returned -
+// a failed notification must not fail ingestion.
+func (h *Hub) Publish(ctx context.Context, entries []models.LogEntry) {
+	h.Broadcast(entries)
+
+	if h.forwarder != nil {
+		if err := h.forwarder.Forward(ctx, entries); err != nil {
+			log.Printf("tail: failed to forward %d logs: %v", len(entries), err)
+		}
+	}
+}
+
+// Broadcast delivers entries to local subscribers only.
+// Used for entries received from other instances, which must not be forwarded again.
+func (h *Hub) Broadcast(entries []models.LogEntry) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		for _, entry := range entries {
+			if entry.ProjectID != sub.ProjectID || !sub.filter.Match(entry) {
+				continue
+			}
+			select {
+			case sub.ch <- entry:
+			default:
+				sub.dropped.Add(1)
+				h.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Dropped returns the total number of entries dropped across all subscribers.
+func (h *Hub) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+}
+
+// Subscription receives a project's newly ingested entries matching its filter.
+type Subscription struct {
+	ProjectID uuid.UUID
+
+	filter    Filter
+	ch        chan models.LogEntry
+	hub       *Hub
+	dropped   atomic.Uint64
+	closeOnce sync.Once
+}
+
+// C returns the channel entries are delivered on.
+// The channel is never closed; stop reading once Close is called.
+func (s *Subscription) C() <-chan models.LogEntry {
+	return s.ch
+}
+
+// Dropped returns how many entries were dropped because this subscriber's buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unregisters the subscription. Safe to call multiple times.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.hub.unsubscribe(s)
+	})
+}
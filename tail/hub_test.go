@@ -0,0 +1,165 @@
+package tail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"jazz/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingForwarder struct {
+	forwarded []models.LogEntry
+	err       error
+}
+
+func (f *recordingForwarder) Forward(_ context.Context, entries []models.LogEntry) error {
+	f.forwarded = append(f.forwarded, entries...)
+	return f.err
+}
+
+func receive(t *testing.T, sub *Subscription) models.LogEntry {
+	t.Helper()
+	select {
+	case entry := <-sub.C():
+		return entry
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for entry")
+		return models.LogEntry{}
+	}
+}
+
+func assertEmpty(t *testing.T, sub *Subscription) {
+	t.Helper()
+	select {
+	case entry := <-sub.C():
+		t.Fatalf("unexpected entry: %+v", entry)
+	default:
+	}
+}
+
+func TestHub_PublishFansOutByProject(t *testing.T) {
+	hub := NewHub(10)
+	project1, project2 := uuid.New(), uuid.New()
+
+	sub1 := hub.Subscribe(project1, Filter{})
+	defer sub1.Close()
+	sub2 := hub.Subscribe(project1, Filter{})
+	defer sub2.Close()
+	other := hub.Subscribe(project2, Filter{})
+	defer other.Close()
+
+	entry := models.LogEntry{ID: uuid.New(), ProjectID: project1, Level: "error", Message: "boom"}
+	hub.Publish(context.Background(), []models.LogEntry{entry})
+
+	assert.Equal(t, entry.ID, receive(t, sub1).ID)
+	assert.Equal(t, entry.ID, receive(t, sub2).ID)
+	assertEmpty(t, other)
+}
+
+func TestHub_PublishAppliesFilter(t *testing.T) {
+	hub := NewHub(10)
+	projectID := uuid.New()
+
+	sub := hub.Subscribe(projectID, Filter{Level: "error"})
+	defer sub.Close()
+
+	hub.Publish(context.Background(), []models.LogEntry{
+		{ID: uuid.New(), ProjectID: projectID, Level: "info", Message: "skip"},
+		{ID: uuid.New(), ProjectID: projectID, Level: "error", Message: "keep"},
+	})
+
+	assert.Equal(t, "keep", receive(t, sub).Message)
+	assertEmpty(t, sub)
+}
+
+func TestHub_DropsWhenSubscriberFallsBehind(t *testing.T) {
+	hub := NewHub(2)
+	projectID := uuid.New()
+
+	slow := hub.Subscribe(projectID, Filter{})
+	defer slow.Close()
+
+	entries := make([]models.LogEntry, 5)
+	for i := range entries {
+		entries[i] = models.LogEntry{ID: uuid.New(), ProjectID: projectID, Level: "info"}
+	}
+	hub.Publish(context.Background(), entries)
+
+	assert.Equal(t, uint64(3), slow.Dropped())
+	assert.Equal(t, uint64(3), hub.Dropped())
+	assert.Equal(t, entries[0].ID, receive(t, slow).ID)
+	assert.Equal(t, entries[1].ID, receive(t, slow).ID)
+	assertEmpty(t, slow)
+}
+
+func TestHub_CloseUnsubscribes(t *testing.T) {
+	hub := NewHub(10)
+	projectID := uuid.New()
+
+	sub := hub.Subscribe(projectID, Filter{})
+	assert.Equal(t, 1, hub.Subscribers())
+
+	sub.Close()
+	sub.Close()
+	assert.Equal(t, 0, hub.Subscribers())
+
+	hub.Publish(context.Background(), []models.LogEntry{{ID: uuid.New(), ProjectID: projectID}})
+	assertEmpty(t, sub)
+}
+
+func TestHub_ForwardsPublishedButNotBroadcast(t *testing.T) {
+	hub := NewHub(10)
+	forwarder := &recordingForwarder{err: errors.New("notify failed")}
+	hub.SetForwarder(forwarder)
+
+	projectID := uuid.New()
+	sub := hub.Subscribe(projectID, Filter{})
+	defer sub.Close()
+
+	published := models.LogEntry{ID: uuid.New(), ProjectID: projectID}
+	hub.Publish(context.Background(), []models.LogEntry{published})
+	relayed := models.LogEntry{ID: uuid.New(), ProjectID: projectID}
+	hub.Broadcast([]models.LogEntry{relayed})
+
+	// Forwarding errors don't prevent local delivery
+	assert.Equal(t, published.ID, receive(t, sub).ID)
+	assert.Equal(t, relayed.ID, receive(t, sub).ID)
+	require.Len(t, forwarder.forwarded, 1)
+	assert.Equal(t, published.ID, forwarder.forwarded[0].ID)
+}
+
+func TestEncodeNotifications(t *testing.T) {
+	project1, project2 := uuid.New(), uuid.New()
+
+	var entries []models.LogEntry
+	for i := 0; i < maxIDsPerNotification+1; i++ {
+		entries = append(entries, models.LogEntry{ID: uuid.New(), ProjectID: project1})
+	}
+	entries = append(entries, models.LogEntry{ID: uuid.New(), ProjectID: project2})
+
+	payloads, err := encodeNotifications("origin-1", entries)
+	require.NoError(t, err)
+	require.Len(t, payloads, 3)
+
+	var decoded []notification
+	for _, payload := range payloads {
+		assert.Less(t, len(payload), 8000, "payload must fit in a NOTIFY")
+		var n notification
+		require.NoError(t, json.Unmarshal([]byte(payload), &n))
+		assert.Equal(t, "origin-1", n.Origin)
+		decoded = append(decoded, n)
+	}
+
+	assert.Equal(t, project1, decoded[0].ProjectID)
+	assert.Len(t, decoded[0].IDs, maxIDsPerNotification)
+	assert.Equal(t, project1, decoded[1].ProjectID)
+	assert.Equal(t, []uuid.UUID{entries[maxIDsPerNotification].ID}, decoded[1].IDs)
+	assert.Equal(t, project2, decoded[2].ProjectID)
+	assert.Len(t, decoded[2].IDs, 1)
+}
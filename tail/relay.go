@@ -0,0 +1,137 @@
+package tail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jazz/database"
+	"jazz/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Channel is the PostgreSQL NOTIFY channel ingested logs are relayed on.
+	Channel = "jazz_logs"
+
+	// maxIDsPerNotification keeps payloads well under PostgreSQL's 8000-byte limit
+	// (a JSON-encoded UUID is 39 bytes).
+	maxIDsPerNotification = 150
+
+	maxRelayBackoff = 30 * time.Second
+)
+
+// notification is the NOTIFY payload. Only IDs are sent - receivers load the
+// entries themselves, so message size never hits the payload limit.
+type notification struct {
+	Origin    string      `json:"origin"`
+	ProjectID uuid.UUID   `json:"project_id"`
+	IDs       []uuid.UUID `json:"ids"`
+}
+
+// Relay forwards published entries to other API instances through PostgreSQL LISTEN/NOTIFY.
+// Each instance runs one Relay: Forward notifies the others after ingestion, and Run
+// receives their notifications and broadcasts the entries to this instance's Hub.
+// Notifications from this instance are ignored, since Hub.Publish already delivered them.
+type Relay struct {
+	db     *database.DB
+	hub    *Hub
+	origin string
+}
+
+// NewRelay creates a Relay for hub and registers it as the hub's forwarder.
+// Call Run in a goroutine to receive entries from other instances.
+func NewRelay(db *database.DB, hub *Hub) *Relay {
+	relay := &Relay{
+		db:     db,
+		hub:    hub,
+		origin: uuid.New().String(),
+	}
+	hub.SetForwarder(relay)
+	return relay
+}
+
+// Forward notifies other instances about newly stored entries.
+// Entries are grouped by project and split into payloads of at most 150 IDs.
+func (r *Relay) Forward(ctx context.Context, entries []models.LogEntry) error {
+	payloads, err := encodeNotifications(r.origin, entries)
+	if err != nil {
+		return err
+	}
+	return r.db.Notify(ctx, Channel, payloads)
+}
+
+// Run listens for notifications from other instances until ctx is cancelled.
+// Reconnects with exponential backoff (max 30s) if the listening connection fails.
+func (r *Relay) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := r.db.Listen(ctx, Channel, func(payload string) {
+			r.handle(ctx, payload)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("tail: relay listener stopped: %v (retrying in %v)", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRelayBackoff)
+	}
+}
+
+func (r *Relay) handle(ctx context.Context, payload string) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.Printf("tail: ignoring malformed notification: %v", err)
+		return
+	}
+
+	// Skip our own notifications and avoid the query when nobody is listening
+	if n.Origin == r.origin || r.hub.Subscribers() == 0 {
+		return
+	}
+
+	entries, err := r.db.GetLogsByIDs(ctx, n.ProjectID, n.IDs)
+	if err != nil {
+		log.Printf("tail: failed to load relayed logs: %v", err)
+		return
+	}
+
+	r.hub.Broadcast(entries)
+}
+
+func encodeNotifications(origin string, entries []models.LogEntry) ([]string, error) {
+	idsByProject := map[uuid.UUID][]uuid.UUID{}
+	var projects []uuid.UUID
+	for _, entry := range entries {
+		if _, seen := idsByProject[entry.ProjectID]; !seen {
+			projects = append(projects, entry.ProjectID)
+		}
+		idsByProject[entry.ProjectID] = append(idsByProject[entry.ProjectID], entry.ID)
+	}
+
+	var payloads []string
+	for _, projectID := range projects {
+		ids := idsByProject[projectID]
+		for start := 0; start < len(ids); start += maxIDsPerNotification {
+			end := min(start+maxIDsPerNotification, len(ids))
+			payload, err := json.Marshal(notification{
+				Origin:    origin,
+				ProjectID: projectID,
+				IDs:       ids[start:end],
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode notification: %w", err)
+			}
+			payloads = append(payloads, string(payload))
+		}
+	}
+
+	return payloads, nil
+}